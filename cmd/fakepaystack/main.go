@@ -0,0 +1,52 @@
+// Command fakepaystack runs the fake Paystack API on a local port. Point the
+// wallet service at it with PAYSTACK_BASE_URL.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/brainox/paystack_wallet_service/pkg/fakepaystack"
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Share the secret key with the wallet service through its .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+
+	addr := flag.String("addr", getEnv("FAKE_PAYSTACK_ADDR", ":9090"), "address to listen on")
+	publicURL := flag.String("public-url", getEnv("FAKE_PAYSTACK_PUBLIC_URL", "http://localhost:9090"), "URL clients reach the fake server on")
+	webhookURL := flag.String("webhook-url", getEnv("FAKE_PAYSTACK_WEBHOOK_URL", "http://localhost:8080/webhooks/paystack"), "URL webhooks are sent to")
+	autoComplete := flag.Bool("auto-complete", true, "settle transfers and refunds automatically")
+	flag.Parse()
+
+	secretKey := os.Getenv("PAYSTACK_SECRET_KEY")
+	if secretKey == "" {
+		log.Fatal("PAYSTACK_SECRET_KEY is required")
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	server := fakepaystack.NewServer(fakepaystack.Config{
+		SecretKey:    secretKey,
+		PublicURL:    *publicURL,
+		WebhookURL:   *webhookURL,
+		AutoComplete: *autoComplete,
+	})
+
+	log.Printf("Fake Paystack listening on %s, sending webhooks to %s", *addr, *webhookURL)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatalf("Failed to start fake Paystack: %v", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,8 @@
+COMMENT ON COLUMN transactions.amount IS NULL;
+COMMENT ON COLUMN wallets.balance IS NULL;
+
+ALTER TABLE transactions ALTER COLUMN amount TYPE DECIMAL(20, 2) USING (amount / 100.0)::DECIMAL(20, 2);
+
+ALTER TABLE wallets ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE wallets ALTER COLUMN balance TYPE DECIMAL(20, 2) USING (balance / 100.0)::DECIMAL(20, 2);
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0.00;
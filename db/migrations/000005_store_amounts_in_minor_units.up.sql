@@ -0,0 +1,9 @@
+-- Amounts are stored as integer minor units (kobo for NGN) to avoid rounding drift
+ALTER TABLE wallets ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE wallets ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 100)::BIGINT;
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0;
+
+ALTER TABLE transactions ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+COMMENT ON COLUMN wallets.balance IS 'Balance in minor units (kobo)';
+COMMENT ON COLUMN transactions.amount IS 'Amount in minor units (kobo)';
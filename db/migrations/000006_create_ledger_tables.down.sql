@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS ledger_postings_balanced ON ledger_postings;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+DROP INDEX IF EXISTS idx_transactions_journal_entry_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS journal_entry_id;
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP TYPE IF EXISTS ledger_account_type;
+DROP TYPE IF EXISTS ledger_direction;
@@ -0,0 +1,92 @@
+CREATE TYPE ledger_direction AS ENUM ('debit', 'credit');
+CREATE TYPE ledger_account_type AS ENUM ('wallet', 'system');
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(100) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type ledger_account_type NOT NULL,
+    normal_balance ledger_direction NOT NULL,
+    wallet_id UUID UNIQUE REFERENCES wallets(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT wallet_account_has_wallet CHECK ((type = 'wallet') = (wallet_id IS NOT NULL))
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference VARCHAR(255) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    journal_entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    direction ledger_direction NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_ledger_postings_journal_entry_id ON ledger_postings(journal_entry_id);
+CREATE INDEX idx_ledger_postings_account_id ON ledger_postings(account_id);
+
+ALTER TABLE transactions ADD COLUMN journal_entry_id UUID REFERENCES journal_entries(id);
+CREATE INDEX idx_transactions_journal_entry_id ON transactions(journal_entry_id);
+
+-- Every journal entry must balance once the surrounding transaction commits
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+DECLARE
+    difference BIGINT;
+BEGIN
+    SELECT COALESCE(SUM(CASE WHEN direction = 'debit' THEN amount ELSE -amount END), 0)
+    INTO difference
+    FROM ledger_postings
+    WHERE journal_entry_id = NEW.journal_entry_id;
+
+    IF difference <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced (difference %)', NEW.journal_entry_id, difference;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT OR UPDATE ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- System accounts
+INSERT INTO ledger_accounts (code, name, type, normal_balance) VALUES
+    ('paystack_clearing', 'Paystack clearing', 'system', 'debit'),
+    ('fees', 'Fee income', 'system', 'credit'),
+    ('opening_balances', 'Opening balances', 'system', 'debit');
+
+-- Wallet accounts for existing wallets
+INSERT INTO ledger_accounts (code, name, type, normal_balance, wallet_id)
+SELECT 'wallet:' || id, 'Wallet ' || wallet_number, 'wallet', 'credit', id FROM wallets;
+
+-- Carry balances that existed before the ledger into an opening entry
+DO $$
+DECLARE
+    entry_id UUID;
+    total BIGINT;
+BEGIN
+    SELECT COALESCE(SUM(balance), 0) INTO total FROM wallets WHERE balance > 0;
+    IF total > 0 THEN
+        INSERT INTO journal_entries (reference, description)
+        VALUES ('OPENING_BALANCES', 'Wallet balances carried over when the ledger was introduced')
+        RETURNING id INTO entry_id;
+
+        INSERT INTO ledger_postings (journal_entry_id, account_id, direction, amount)
+        SELECT entry_id, la.id, 'credit', w.balance
+        FROM wallets w
+        JOIN ledger_accounts la ON la.wallet_id = w.id
+        WHERE w.balance > 0;
+
+        INSERT INTO ledger_postings (journal_entry_id, account_id, direction, amount)
+        SELECT entry_id, id, 'debit', total FROM ledger_accounts WHERE code = 'opening_balances';
+    END IF;
+END;
+$$;
@@ -0,0 +1,4 @@
+-- Enum values cannot be dropped in PostgreSQL; 'withdrawal' and 'reversed' are left in place
+DELETE FROM ledger_accounts
+WHERE code = 'pending_payouts'
+  AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = ledger_accounts.id);
@@ -0,0 +1,6 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'withdrawal';
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'reversed';
+
+INSERT INTO ledger_accounts (code, name, type, normal_balance) VALUES
+    ('pending_payouts', 'Withdrawals pending payout', 'system', 'credit')
+ON CONFLICT (code) DO NOTHING;
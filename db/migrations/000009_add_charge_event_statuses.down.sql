@@ -0,0 +1,2 @@
+-- Enum values cannot be dropped in PostgreSQL; 'disputed' is left in place
+DROP TABLE IF EXISTS unhandled_webhook_events;
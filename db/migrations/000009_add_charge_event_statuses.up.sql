@@ -0,0 +1,13 @@
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'reversed';
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'disputed';
+
+-- Webhook events the service does not act on are kept instead of being dropped
+CREATE TABLE IF NOT EXISTS unhandled_webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event VARCHAR(100) NOT NULL,
+    reference VARCHAR(255),
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_unhandled_webhook_events_event ON unhandled_webhook_events(event);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS unhandled_webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event VARCHAR(100) NOT NULL,
+    reference VARCHAR(255),
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_unhandled_webhook_events_event ON unhandled_webhook_events(event);
+
+INSERT INTO unhandled_webhook_events (id, event, reference, payload, created_at)
+SELECT id, event, reference, raw_body::JSONB, created_at
+FROM webhook_events
+WHERE status = 'ignored';
+
+DROP TABLE IF EXISTS webhook_events;
+DROP TYPE IF EXISTS webhook_event_status;
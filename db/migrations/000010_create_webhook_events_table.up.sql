@@ -0,0 +1,29 @@
+CREATE TYPE webhook_event_status AS ENUM ('received', 'processed', 'failed', 'ignored', 'rejected');
+
+-- Every inbound webhook delivery is stored before it is processed
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event VARCHAR(100),
+    reference VARCHAR(255),
+    raw_body TEXT NOT NULL,
+    signature TEXT,
+    signature_valid BOOLEAN NOT NULL DEFAULT FALSE,
+    status webhook_event_status NOT NULL DEFAULT 'received',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_events_event ON webhook_events(event);
+CREATE INDEX idx_webhook_events_reference ON webhook_events(reference);
+CREATE INDEX idx_webhook_events_retry ON webhook_events(next_attempt_at) WHERE status = 'failed';
+
+-- Unhandled events recorded so far become ignored webhook events
+INSERT INTO webhook_events (id, event, reference, raw_body, signature_valid, status, created_at, updated_at)
+SELECT id, event, reference, payload::TEXT, TRUE, 'ignored', created_at, created_at
+FROM unhandled_webhook_events;
+
+DROP TABLE IF EXISTS unhandled_webhook_events;
@@ -0,0 +1,2 @@
+-- Enum values cannot be dropped in PostgreSQL; 'under_review' is left in place
+UPDATE transactions SET status = 'pending' WHERE status = 'under_review';
@@ -0,0 +1,2 @@
+-- Deposits whose verified amount or currency differs from what was initialized
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'under_review';
@@ -0,0 +1,3 @@
+-- Enum values cannot be dropped in PostgreSQL; 'expired' is left in place
+DROP INDEX IF EXISTS idx_transactions_pending_deposits;
+UPDATE transactions SET status = 'failed' WHERE status = 'expired';
@@ -0,0 +1,5 @@
+-- Deposits whose checkout was abandoned past the reconciliation TTL
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'expired';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_deposits ON transactions(created_at)
+    WHERE type = 'deposit' AND status = 'pending';
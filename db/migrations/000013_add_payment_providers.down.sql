@@ -0,0 +1,7 @@
+DELETE FROM ledger_accounts
+WHERE code = 'flutterwave_clearing'
+  AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = ledger_accounts.id);
+
+DROP INDEX IF EXISTS idx_webhook_events_provider;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS provider;
+ALTER TABLE transactions DROP COLUMN IF EXISTS provider;
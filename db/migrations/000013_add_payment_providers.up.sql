@@ -0,0 +1,13 @@
+-- The payment provider that handled a deposit or payout
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS provider VARCHAR(20);
+
+UPDATE transactions SET provider = 'paystack' WHERE type IN ('deposit', 'withdrawal');
+
+-- Webhooks are received per provider
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS provider VARCHAR(20) NOT NULL DEFAULT 'paystack';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_provider ON webhook_events(provider);
+
+INSERT INTO ledger_accounts (code, name, type, normal_balance) VALUES
+    ('flutterwave_clearing', 'Flutterwave clearing', 'system', 'debit')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,8 @@
+-- Enum values cannot be dropped in PostgreSQL; 'refund' is left in place
+DELETE FROM ledger_accounts
+WHERE code = 'pending_refunds'
+  AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = ledger_accounts.id);
+
+DROP INDEX IF EXISTS idx_transactions_related_transaction_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS related_transaction_id;
@@ -0,0 +1,11 @@
+-- Deposits refunded to the payer through the Paystack Refund API
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'refund';
+
+-- The transaction a refund or reversal was made against
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS related_transaction_id UUID REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_related_transaction_id ON transactions(related_transaction_id);
+
+INSERT INTO ledger_accounts (code, name, type, normal_balance) VALUES
+    ('pending_refunds', 'Refunds pending at Paystack', 'system', 'credit')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_holds;
+DROP TYPE IF EXISTS hold_status;
@@ -0,0 +1,20 @@
+CREATE TYPE hold_status AS ENUM ('active', 'captured', 'released', 'expired');
+
+-- Funds reserved on a wallet until they are captured, released or expire
+CREATE TABLE IF NOT EXISTS wallet_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    status hold_status NOT NULL DEFAULT 'active',
+    reference VARCHAR(255),
+    description TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_wallet_holds_wallet_id ON wallet_holds(wallet_id, created_at DESC);
+CREATE INDEX idx_wallet_holds_active ON wallet_holds(wallet_id, expires_at) WHERE status = 'active';
+CREATE INDEX idx_wallet_holds_expiry ON wallet_holds(expires_at) WHERE status = 'active';
@@ -0,0 +1,2 @@
+-- Fails while any wallet is still negative; settle those balances first
+ALTER TABLE wallets ADD CONSTRAINT wallets_balance_check CHECK (balance >= 0);
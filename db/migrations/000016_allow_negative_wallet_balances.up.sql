@@ -0,0 +1,3 @@
+-- Admin-forced transfer reversals can take a wallet below zero. Ordinary
+-- postings are still refused by the application when funds are insufficient.
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_check;
@@ -0,0 +1,45 @@
+CREATE TYPE scheduled_transfer_type AS ENUM ('once', 'cron', 'monthly');
+CREATE TYPE scheduled_transfer_status AS ENUM ('active', 'paused', 'completed', 'cancelled', 'failed');
+CREATE TYPE scheduled_transfer_run_status AS ENUM ('success', 'failed');
+
+-- Transfers to another wallet made automatically by the scheduled transfer worker
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    recipient_wallet_number VARCHAR(13) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    description TEXT,
+    schedule_type scheduled_transfer_type NOT NULL,
+    cron_expression VARCHAR(100),
+    day_of_month INTEGER CHECK (day_of_month BETWEEN 1 AND 31),
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE,
+    status scheduled_transfer_status NOT NULL DEFAULT 'active',
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (schedule_type <> 'cron' OR cron_expression IS NOT NULL),
+    CHECK (schedule_type <> 'monthly' OR day_of_month IS NOT NULL)
+);
+
+CREATE INDEX idx_scheduled_transfers_user_id ON scheduled_transfers(user_id, created_at DESC);
+CREATE INDEX idx_scheduled_transfers_due ON scheduled_transfers(next_run_at) WHERE status = 'active';
+
+-- One row per attempt to run a scheduled transfer
+CREATE TABLE IF NOT EXISTS scheduled_transfer_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scheduled_transfer_id UUID NOT NULL REFERENCES scheduled_transfers(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status scheduled_transfer_run_status NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    error TEXT,
+    will_retry BOOLEAN NOT NULL DEFAULT FALSE,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_scheduled_transfer_runs_schedule ON scheduled_transfer_runs(scheduled_transfer_id, created_at DESC);
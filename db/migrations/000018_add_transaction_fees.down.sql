@@ -0,0 +1,2 @@
+-- Enum values cannot be dropped in PostgreSQL; 'fee' is left in place
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee;
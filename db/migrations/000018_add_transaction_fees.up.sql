@@ -0,0 +1,5 @@
+-- Fees charged on top of deposits, transfers and withdrawals
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'fee';
+
+-- The fee quoted for the transaction; it is recorded as its own fee transaction when charged
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee BIGINT NOT NULL DEFAULT 0 CHECK (fee >= 0);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_api_key_id;
+DROP INDEX IF EXISTS idx_transactions_outflows;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS api_key_id;
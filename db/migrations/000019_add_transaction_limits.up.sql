@@ -0,0 +1,8 @@
+-- The API key that initiated a transfer or withdrawal, for per-key spend limits
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
+
+-- Outflow totals for daily, monthly and hourly limits
+CREATE INDEX IF NOT EXISTS idx_transactions_outflows ON transactions(wallet_id, created_at)
+    WHERE type IN ('debit', 'withdrawal');
+CREATE INDEX IF NOT EXISTS idx_transactions_api_key_id ON transactions(api_key_id, created_at)
+    WHERE api_key_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS kyc_submissions;
+DROP TYPE IF EXISTS kyc_submission_status;
+
+ALTER TABLE users DROP COLUMN IF EXISTS kyc_tier;
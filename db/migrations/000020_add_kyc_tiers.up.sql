@@ -0,0 +1,22 @@
+-- 0: email only, 1: BVN verified, 2: identity document verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_tier SMALLINT NOT NULL DEFAULT 0 CHECK (kyc_tier BETWEEN 0 AND 2);
+
+CREATE TYPE kyc_submission_status AS ENUM ('pending', 'approved', 'rejected', 'failed');
+
+-- Verification data submitted to move a user up a KYC tier. Only the last
+-- digits of the ID number are kept.
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tier SMALLINT NOT NULL CHECK (tier BETWEEN 1 AND 2),
+    id_type VARCHAR(30) NOT NULL,
+    id_number_masked VARCHAR(30) NOT NULL,
+    status kyc_submission_status NOT NULL DEFAULT 'pending',
+    verifier VARCHAR(50) NOT NULL,
+    verifier_reference VARCHAR(255),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_kyc_submissions_user_id ON kyc_submissions(user_id, created_at DESC);
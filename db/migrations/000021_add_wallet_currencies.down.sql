@@ -0,0 +1,6 @@
+-- Fails if any user holds more than one wallet
+ALTER TABLE transactions DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS unique_user_wallet_currency;
+ALTER TABLE wallets ADD CONSTRAINT unique_user_wallet UNIQUE (user_id);
+ALTER TABLE wallets DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,9 @@
+-- A user can hold one wallet per currency
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'NGN'
+    CHECK (currency IN ('NGN', 'USD', 'GHS', 'ZAR', 'KES'));
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS unique_user_wallet;
+ALTER TABLE wallets ADD CONSTRAINT unique_user_wallet_currency UNIQUE (user_id, currency);
+
+-- Transactions are in the currency of their wallet
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'NGN';
+UPDATE transactions t SET currency = w.currency FROM wallets w WHERE w.id = t.wallet_id;
@@ -0,0 +1,12 @@
+-- Enum values cannot be dropped in PostgreSQL; 'fx_debit' and 'fx_credit' are left in place
+DELETE FROM ledger_accounts
+WHERE (code IN ('fx_position', 'fx_spread') OR code LIKE 'fx\_position:%' OR code LIKE 'fx\_spread:%')
+  AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = ledger_accounts.id);
+
+DROP INDEX IF EXISTS idx_transactions_fx_quote_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS fx_quote_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fx_rate;
+
+DROP TABLE IF EXISTS fx_quotes;
+DROP TYPE IF EXISTS fx_quote_status;
@@ -0,0 +1,39 @@
+-- Conversions between a user's own wallets in different currencies
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'fx_debit';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'fx_credit';
+
+CREATE TYPE fx_quote_status AS ENUM ('open', 'executed');
+
+-- Rates are fixed-point with eight decimal places
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL CHECK (to_currency <> from_currency),
+    sell_amount BIGINT NOT NULL CHECK (sell_amount > 0),
+    buy_amount BIGINT NOT NULL CHECK (buy_amount > 0),
+    mid_rate NUMERIC(20, 8) NOT NULL CHECK (mid_rate > 0),
+    rate NUMERIC(20, 8) NOT NULL CHECK (rate > 0),
+    spread_bps BIGINT NOT NULL CHECK (spread_bps >= 0),
+    spread BIGINT NOT NULL CHECK (spread >= 0),
+    provider VARCHAR(50) NOT NULL,
+    status fx_quote_status NOT NULL DEFAULT 'open',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    executed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_fx_quotes_user_id ON fx_quotes(user_id, created_at DESC);
+
+-- The rate and quote a conversion was made at, on both of its transactions
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(20, 8);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_quote_id UUID REFERENCES fx_quotes(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_fx_quote_id ON transactions(fx_quote_id) WHERE fx_quote_id IS NOT NULL;
+
+-- Accounts in other currencies are created from these on first use, e.g. fx_position:USD
+INSERT INTO ledger_accounts (code, name, type, normal_balance) VALUES
+    ('fx_position', 'FX position', 'system', 'debit'),
+    ('fx_spread', 'FX spread income', 'system', 'credit')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_requests;
+DROP TYPE IF EXISTS payment_request_status;
@@ -0,0 +1,26 @@
+CREATE TYPE payment_request_status AS ENUM ('pending', 'accepted', 'declined', 'expired', 'cancelled');
+
+-- Requests from one user for another to pay into their wallet
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    requester_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requester_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    requester_wallet_number VARCHAR(13) NOT NULL,
+    payer_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payer_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    payer_wallet_number VARCHAR(13) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    memo VARCHAR(140),
+    status payment_request_status NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (requester_user_id <> payer_user_id)
+);
+
+CREATE INDEX idx_payment_requests_requester ON payment_requests(requester_user_id, created_at DESC);
+CREATE INDEX idx_payment_requests_payer ON payment_requests(payer_user_id, created_at DESC);
+CREATE INDEX idx_payment_requests_expiry ON payment_requests(expires_at) WHERE status = 'pending';
@@ -0,0 +1,11 @@
+-- Enum values cannot be dropped in PostgreSQL; the escrow transaction types are left in place
+DELETE FROM ledger_accounts
+WHERE (code = 'escrow' OR code LIKE 'escrow:%')
+  AND NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.account_id = ledger_accounts.id);
+
+DROP INDEX IF EXISTS idx_transactions_escrow_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS escrow_id;
+
+DROP TABLE IF EXISTS escrows;
+DROP TYPE IF EXISTS escrow_status;
@@ -0,0 +1,44 @@
+-- Buyer payments held until they are released to the seller or refunded
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'escrow_debit';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'escrow_credit';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'escrow_refund';
+
+CREATE TYPE escrow_status AS ENUM ('funded', 'disputed', 'released', 'refunded');
+
+CREATE TABLE IF NOT EXISTS escrows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    buyer_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    buyer_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    buyer_wallet_number VARCHAR(13) NOT NULL,
+    seller_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seller_wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    seller_wallet_number VARCHAR(13) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    status escrow_status NOT NULL DEFAULT 'funded',
+    reference VARCHAR(255),
+    description TEXT,
+    auto_release_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    disputed_by UUID REFERENCES users(id),
+    dispute_reason TEXT,
+    disputed_at TIMESTAMP WITH TIME ZONE,
+    settled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (buyer_user_id <> seller_user_id)
+);
+
+CREATE INDEX idx_escrows_buyer ON escrows(buyer_user_id, created_at DESC);
+CREATE INDEX idx_escrows_seller ON escrows(seller_user_id, created_at DESC);
+CREATE INDEX idx_escrows_auto_release ON escrows(auto_release_at) WHERE status = 'funded';
+
+-- The escrow a funding, release or refund transaction belongs to
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS escrow_id UUID REFERENCES escrows(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_escrow_id ON transactions(escrow_id) WHERE escrow_id IS NOT NULL;
+
+-- Accounts in other currencies are created from this on first use, e.g. escrow:USD
+INSERT INTO ledger_accounts (code, name, type, normal_balance) VALUES
+    ('escrow', 'Escrow', 'system', 'credit')
+ON CONFLICT (code) DO NOTHING;
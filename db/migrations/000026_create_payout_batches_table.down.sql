@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payout_items;
+DROP TABLE IF EXISTS payout_batches;
+DROP TYPE IF EXISTS payout_item_status;
+DROP TYPE IF EXISTS payout_batch_status;
+DROP TYPE IF EXISTS payout_batch_mode;
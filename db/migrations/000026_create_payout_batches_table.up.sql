@@ -0,0 +1,49 @@
+-- Bulk payouts: a list of transfers from one wallet, made in the background
+CREATE TYPE payout_batch_mode AS ENUM ('all_or_nothing', 'best_effort');
+CREATE TYPE payout_batch_status AS ENUM ('pending', 'processing', 'completed', 'partially_completed', 'failed');
+CREATE TYPE payout_item_status AS ENUM ('pending', 'success', 'failed', 'skipped');
+
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    wallet_number VARCHAR(13) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    mode payout_batch_mode NOT NULL,
+    status payout_batch_status NOT NULL DEFAULT 'pending',
+    reference VARCHAR(255),
+    item_count INTEGER NOT NULL CHECK (item_count > 0),
+    total_amount BIGINT NOT NULL CHECK (total_amount > 0),
+    total_fee BIGINT NOT NULL DEFAULT 0,
+    succeeded_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    succeeded_amount BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_payout_batches_user ON payout_batches(user_id, created_at DESC);
+CREATE INDEX idx_payout_batches_runnable ON payout_batches(created_at) WHERE status IN ('pending', 'processing');
+
+CREATE TABLE IF NOT EXISTS payout_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id UUID NOT NULL REFERENCES payout_batches(id) ON DELETE CASCADE,
+    row_number INTEGER NOT NULL,
+    wallet_number VARCHAR(13) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    fee BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    narration VARCHAR(100),
+    status payout_item_status NOT NULL DEFAULT 'pending',
+    transaction_id UUID REFERENCES transactions(id),
+    error TEXT,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (batch_id, row_number)
+);
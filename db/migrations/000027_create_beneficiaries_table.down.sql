@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS beneficiaries;
+DROP TYPE IF EXISTS beneficiary_type;
@@ -0,0 +1,23 @@
+-- Saved recipients that transfers and withdrawals can refer to by ID
+CREATE TYPE beneficiary_type AS ENUM ('wallet', 'bank_account');
+
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type beneficiary_type NOT NULL,
+    nickname VARCHAR(50) NOT NULL,
+    wallet_number VARCHAR(13),
+    bank_code VARCHAR(20),
+    account_number VARCHAR(10),
+    account_name VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (
+        (type = 'wallet' AND wallet_number IS NOT NULL AND bank_code IS NULL AND account_number IS NULL)
+        OR (type = 'bank_account' AND wallet_number IS NULL AND bank_code IS NOT NULL AND account_number IS NOT NULL)
+    )
+);
+
+CREATE UNIQUE INDEX idx_beneficiaries_nickname ON beneficiaries(user_id, LOWER(nickname));
+CREATE UNIQUE INDEX idx_beneficiaries_wallet ON beneficiaries(user_id, wallet_number) WHERE type = 'wallet';
+CREATE UNIQUE INDEX idx_beneficiaries_bank_account ON beneficiaries(user_id, bank_code, account_number) WHERE type = 'bank_account';
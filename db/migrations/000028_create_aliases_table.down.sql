@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS alias_verifications;
+DROP TABLE IF EXISTS aliases;
+DROP TYPE IF EXISTS alias_status;
+DROP TYPE IF EXISTS alias_type;
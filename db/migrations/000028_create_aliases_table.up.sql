@@ -0,0 +1,39 @@
+-- Handles and phone numbers that transfers can use in place of a wallet number.
+-- Released aliases are kept as history so they cannot be re-claimed by
+-- someone else straight away.
+CREATE TYPE alias_type AS ENUM ('handle', 'phone');
+CREATE TYPE alias_status AS ENUM ('active', 'released');
+
+CREATE TABLE IF NOT EXISTS aliases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type alias_type NOT NULL,
+    -- Handles are stored lower case without the @; phones in E.164 form
+    value VARCHAR(20) NOT NULL,
+    status alias_status NOT NULL DEFAULT 'active',
+    claimed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    released_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((status = 'released') = (released_at IS NOT NULL))
+);
+
+-- An alias has one owner at a time, and a user one alias of each type
+CREATE UNIQUE INDEX idx_aliases_active_value ON aliases(value) WHERE status = 'active';
+CREATE UNIQUE INDEX idx_aliases_active_user_type ON aliases(user_id, type) WHERE status = 'active';
+CREATE INDEX idx_aliases_value_released_at ON aliases(value, released_at DESC) WHERE status = 'released';
+CREATE INDEX idx_aliases_user_id ON aliases(user_id, created_at DESC);
+
+-- One-time codes sent to prove ownership of a phone number before it becomes an alias
+CREATE TABLE IF NOT EXISTS alias_verifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phone VARCHAR(20) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_alias_verifications_user_id ON alias_verifications(user_id, created_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE ledger_accounts DROP COLUMN IF EXISTS currency;
+ALTER TABLE wallet_holds DROP COLUMN IF EXISTS currency;
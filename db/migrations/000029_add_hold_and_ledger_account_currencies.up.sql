@@ -0,0 +1,10 @@
+-- Holds are in the currency of their wallet
+ALTER TABLE wallet_holds ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'NGN';
+UPDATE wallet_holds h SET currency = w.currency FROM wallets w WHERE w.id = h.wallet_id;
+
+-- Ledger accounts hold a single currency: a wallet's currency, or the
+-- suffix of a system account code such as fees:USD
+ALTER TABLE ledger_accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'NGN';
+UPDATE ledger_accounts la SET currency = w.currency FROM wallets w WHERE w.id = la.wallet_id;
+UPDATE ledger_accounts SET currency = SPLIT_PART(code, ':', 2)
+    WHERE type = 'system' AND code LIKE '%:%';
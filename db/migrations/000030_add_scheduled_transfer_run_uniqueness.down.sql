@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_scheduled_transfer_runs_paid;
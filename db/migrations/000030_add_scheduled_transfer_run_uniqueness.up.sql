@@ -0,0 +1,4 @@
+-- Each occurrence of a scheduled transfer is paid at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_transfer_runs_paid
+    ON scheduled_transfer_runs(scheduled_transfer_id, scheduled_for)
+    WHERE status = 'success';
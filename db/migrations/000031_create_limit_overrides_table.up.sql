@@ -0,0 +1,19 @@
+-- Limits set for one user or one API key in place of the configured
+-- defaults. A NULL column falls back to the default; zero lifts the limit.
+CREATE TABLE IF NOT EXISTS limit_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+    max_transfer_amount BIGINT CHECK (max_transfer_amount >= 0),
+    daily_outflow BIGINT CHECK (daily_outflow >= 0),
+    monthly_outflow BIGINT CHECK (monthly_outflow >= 0),
+    api_key_daily_spend BIGINT CHECK (api_key_daily_spend >= 0),
+    max_transfers_per_hour INTEGER CHECK (max_transfers_per_hour >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (api_key_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_limit_overrides_user ON limit_overrides(user_id, currency) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_limit_overrides_api_key ON limit_overrides(api_key_id, currency) WHERE api_key_id IS NOT NULL;
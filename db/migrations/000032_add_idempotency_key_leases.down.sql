@@ -0,0 +1,9 @@
+-- Only JSON responses can be moved back into the JSONB column
+DELETE FROM idempotency_keys
+WHERE response_body IS NOT NULL AND response_content_type NOT LIKE 'application/json%';
+
+ALTER TABLE idempotency_keys
+    ALTER COLUMN response_body TYPE JSONB USING convert_from(response_body, 'UTF8')::JSONB,
+    DROP COLUMN response_content_type,
+    DROP COLUMN locked_until,
+    DROP COLUMN lock_token;
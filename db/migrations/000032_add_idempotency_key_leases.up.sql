@@ -0,0 +1,9 @@
+-- A request holds its key only until locked_until, so a retry can take over a
+-- key whose request died before storing a response. lock_token identifies the
+-- request currently holding the key. Responses are stored as the exact bytes
+-- sent to the client so that replays are identical.
+ALTER TABLE idempotency_keys
+    ADD COLUMN lock_token UUID,
+    ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN response_content_type TEXT,
+    ALTER COLUMN response_body TYPE BYTEA USING convert_to(response_body::TEXT, 'UTF8');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_payout_batches_user_reference;
@@ -0,0 +1,3 @@
+-- A user cannot reuse a payout batch reference
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payout_batches_user_reference
+    ON payout_batches(user_id, reference) WHERE reference IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The API key that scheduled a transfer, so its runs count towards the key's limits
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
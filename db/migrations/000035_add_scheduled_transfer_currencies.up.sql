@@ -0,0 +1,3 @@
+-- Scheduled transfers are paid from the sender's wallet in their currency
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'NGN';
+UPDATE scheduled_transfers st SET currency = w.currency FROM wallets w WHERE w.id = st.wallet_id;
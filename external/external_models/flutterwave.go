@@ -0,0 +1,53 @@
+package external_models
+
+import "encoding/json"
+
+// Flutterwave API Models (v3). Flutterwave amounts are in major units, e.g.
+// 150.50 Naira, so they are kept as json.Number to avoid float rounding.
+
+// FlutterwaveCustomer identifies the paying customer
+type FlutterwaveCustomer struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// FlutterwavePaymentRequest represents the request to create a Flutterwave Standard checkout
+type FlutterwavePaymentRequest struct {
+	TxRef       string              `json:"tx_ref"`                 // Unique transaction reference
+	Amount      json.Number         `json:"amount"`                 // Amount in major units
+	Currency    string              `json:"currency"`               // Currency (NGN, USD, etc.)
+	RedirectURL string              `json:"redirect_url,omitempty"` // Where the customer returns after paying
+	Customer    FlutterwaveCustomer `json:"customer"`
+}
+
+// FlutterwavePaymentResponse represents the response from Flutterwave checkout creation
+type FlutterwavePaymentResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Link string `json:"link"`
+	} `json:"data"`
+}
+
+// FlutterwaveTransaction is a transaction as returned by verification and webhooks
+type FlutterwaveTransaction struct {
+	ID       int64       `json:"id"`
+	TxRef    string      `json:"tx_ref"`
+	FlwRef   string      `json:"flw_ref"`
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+	Status   string      `json:"status"` // successful, failed or pending
+}
+
+// FlutterwaveVerifyResponse represents the response from Flutterwave transaction verification
+type FlutterwaveVerifyResponse struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message"`
+	Data    FlutterwaveTransaction `json:"data"`
+}
+
+// FlutterwaveWebhookEvent represents a Flutterwave webhook event
+type FlutterwaveWebhookEvent struct {
+	Event string                 `json:"event"`
+	Data  FlutterwaveTransaction `json:"data"`
+}
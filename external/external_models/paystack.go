@@ -6,7 +6,7 @@ import "time"
 
 // InitializeTransactionRequest represents the request to initialize a Paystack transaction
 type InitializeTransactionRequest struct {
-	Amount      int                    `json:"amount"`                 // Amount in kobo
+	Amount      int64                  `json:"amount"`                 // Amount in kobo
 	Email       string                 `json:"email"`                  // Customer email
 	Reference   string                 `json:"reference"`              // Unique transaction reference
 	CallbackURL string                 `json:"callback_url,omitempty"` // Optional callback URL
@@ -34,7 +34,7 @@ type VerifyTransactionResponse struct {
 		Domain          string    `json:"domain"`
 		Status          string    `json:"status"`
 		Reference       string    `json:"reference"`
-		Amount          int       `json:"amount"`
+		Amount          int64     `json:"amount"`
 		Message         string    `json:"message"`
 		GatewayResponse string    `json:"gateway_response"`
 		PaidAt          time.Time `json:"paid_at"`
@@ -75,7 +75,7 @@ type WebhookTransactionData struct {
 	Domain          string    `json:"domain"`
 	Status          string    `json:"status"`
 	Reference       string    `json:"reference"`
-	Amount          int       `json:"amount"`
+	Amount          int64     `json:"amount"`
 	Message         string    `json:"message"`
 	GatewayResponse string    `json:"gateway_response"`
 	PaidAt          time.Time `json:"paid_at"`
@@ -84,9 +84,155 @@ type WebhookTransactionData struct {
 	Currency        string    `json:"currency"`
 	IPAddress       string    `json:"ip_address"`
 	Metadata        string    `json:"metadata"`
+	TransferCode    string    `json:"transfer_code"` // Set on transfer.* events
 	Customer        struct {
 		ID           int    `json:"id"`
 		Email        string `json:"email"`
 		CustomerCode string `json:"customer_code"`
 	} `json:"customer"`
+
+	// Set on refund.* events
+	TransactionReference string `json:"transaction_reference"`
+
+	// Set on charge.dispute.* events
+	Resolution  string                     `json:"resolution"`
+	Transaction *WebhookDisputeTransaction `json:"transaction"`
+}
+
+// WebhookDisputeTransaction is the disputed charge in a charge.dispute.* event
+type WebhookDisputeTransaction struct {
+	ID        int64  `json:"id"`
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+// ChargeReference returns the reference of the charge an event refers to.
+// Refund and dispute events carry it in a different field than charge events.
+func (d *WebhookTransactionData) ChargeReference() string {
+	if d.TransactionReference != "" {
+		return d.TransactionReference
+	}
+	if d.Transaction != nil && d.Transaction.Reference != "" {
+		return d.Transaction.Reference
+	}
+	return d.Reference
+}
+
+// Bank represents a bank returned by the Paystack bank list endpoint
+type Bank struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Code     string `json:"code"`
+	LongCode string `json:"longcode"`
+	Active   bool   `json:"active"`
+	Country  string `json:"country"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+}
+
+// ListBanksResponse represents the response from the Paystack bank list endpoint
+type ListBanksResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    []Bank `json:"data"`
+}
+
+// ResolveAccountResponse represents the response from Paystack account resolution
+type ResolveAccountResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		AccountNumber string `json:"account_number"`
+		AccountName   string `json:"account_name"`
+		BankID        int64  `json:"bank_id"`
+	} `json:"data"`
+}
+
+// CreateTransferRecipientRequest represents the request to create a Paystack transfer recipient
+type CreateTransferRecipientRequest struct {
+	Type          string `json:"type"` // "nuban" for Nigerian bank accounts
+	Name          string `json:"name"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+	Currency      string `json:"currency"`
+}
+
+// CreateTransferRecipientResponse represents the response from Paystack transfer recipient creation
+type CreateTransferRecipientResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		RecipientCode string `json:"recipient_code"`
+		Name          string `json:"name"`
+		Details       struct {
+			AccountNumber string `json:"account_number"`
+			AccountName   string `json:"account_name"`
+			BankCode      string `json:"bank_code"`
+			BankName      string `json:"bank_name"`
+		} `json:"details"`
+	} `json:"data"`
+}
+
+// InitiateTransferRequest represents the request to initiate a Paystack transfer (payout)
+type InitiateTransferRequest struct {
+	Source    string `json:"source"` // "balance"
+	Amount    int64  `json:"amount"` // Amount in kobo
+	Recipient string `json:"recipient"`
+	Reference string `json:"reference"`
+	Reason    string `json:"reason,omitempty"`
+	Currency  string `json:"currency"`
+}
+
+// InitiateTransferResponse represents the response from Paystack transfer initiation
+type InitiateTransferResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Reference    string `json:"reference"`
+		TransferCode string `json:"transfer_code"`
+		Status       string `json:"status"`
+		Amount       int64  `json:"amount"`
+		Currency     string `json:"currency"`
+	} `json:"data"`
+}
+
+// CreateRefundRequest represents the request to refund a Paystack transaction
+type CreateRefundRequest struct {
+	Transaction  string `json:"transaction"`      // Transaction reference or ID
+	Amount       int64  `json:"amount,omitempty"` // Amount in kobo; the full amount when omitted
+	Currency     string `json:"currency,omitempty"`
+	MerchantNote string `json:"merchant_note,omitempty"`
+}
+
+// CreateRefundResponse represents the response from Paystack refund creation
+type CreateRefundResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID          int64  `json:"id"`
+		Amount      int64  `json:"amount"`
+		Currency    string `json:"currency"`
+		Status      string `json:"status"` // pending, processing, processed or failed
+		Transaction struct {
+			ID        int64  `json:"id"`
+			Reference string `json:"reference"`
+		} `json:"transaction"`
+	} `json:"data"`
+}
+
+// Refund is a refund as returned by the Paystack refund list endpoint
+type Refund struct {
+	ID       int64  `json:"id"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Status   string `json:"status"` // pending, processing, processed or failed
+}
+
+// ListRefundsResponse represents the response from the Paystack refund list endpoint
+type ListRefundsResponse struct {
+	Status  bool     `json:"status"`
+	Message string   `json:"message"`
+	Data    []Refund `json:"data"`
 }
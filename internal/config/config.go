@@ -3,20 +3,47 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Google   GoogleOAuthConfig
-	Paystack PaystackConfig
+	Server             ServerConfig
+	Database           DatabaseConfig
+	JWT                JWTConfig
+	Google             GoogleOAuthConfig
+	Paystack           PaystackConfig
+	Flutterwave        FlutterwaveConfig
+	Payment            PaymentConfig
+	Fees               FeesConfig
+	Limits             LimitsConfig
+	KYC                KYCConfig
+	FX                 FXConfig
+	Idempotency        IdempotencyConfig
+	Webhook            WebhookConfig
+	Reconciliation     ReconciliationConfig
+	Holds              HoldsConfig
+	Escrow             EscrowConfig
+	Payouts            PayoutsConfig
+	ScheduledTransfers ScheduledTransfersConfig
+	PaymentRequests    PaymentRequestsConfig
+	Aliases            AliasesConfig
+	Admin              AdminConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Host string
+	// Env is "production", "development" or "test". Stand-ins for outside
+	// providers, such as the stub KYC verifier, only run outside production.
+	Env string
+}
+
+// AllowsStubs reports whether stand-ins for outside providers may be used
+func (c *ServerConfig) AllowsStubs() bool {
+	return c.Env == "development" || c.Env == "test"
 }
 
 type DatabaseConfig struct {
@@ -42,16 +69,164 @@ type GoogleOAuthConfig struct {
 type PaystackConfig struct {
 	SecretKey string
 	PublicKey string
+	// API base URL; point it at a fake server for offline development
+	BaseURL string
+}
+
+type FlutterwaveConfig struct {
+	SecretKey string
+	// Secret hash set on the Flutterwave dashboard, sent back in the verif-hash header
+	SecretHash  string
+	RedirectURL string
+}
+
+// Enabled reports whether Flutterwave credentials are configured
+func (c *FlutterwaveConfig) Enabled() bool {
+	return c.SecretKey != ""
+}
+
+type PaymentConfig struct {
+	// Provider used for deposits that do not name one
+	DefaultProvider string
+}
+
+type FeesConfig struct {
+	// JSON file with the fee rule for each operation; no fees are charged when empty
+	ScheduleFile string
+}
+
+// LimitsConfig caps outflows from each wallet; zero disables a limit
+type LimitsConfig struct {
+	MaxTransferAmount   models.Money
+	DailyOutflow        models.Money
+	MonthlyOutflow      models.Money
+	APIKeyDailySpend    models.Money
+	MaxTransfersPerHour int
+}
+
+type KYCConfig struct {
+	// Verifier checks submitted identity details; only "stub" is available,
+	// and only outside production
+	Verifier string
+	// Limits for each tier, indexed by tier; zero disables a limit
+	Tiers []KYCTierConfig
+}
+
+type KYCTierConfig struct {
+	MaxBalance models.Money
+	DailyLimit models.Money
+}
+
+type FXConfig struct {
+	// RateProvider supplies exchange rates; only "static" is available
+	RateProvider string
+	// JSON file of rates keyed by pair, e.g. {"USD/NGN": "1550.00"}
+	RatesFile string
+	// Kept by the platform on each conversion, in basis points of the amount sold
+	SpreadBPS int
+	// How long a quote can be executed for
+	QuoteTTL time.Duration
+}
+
+type IdempotencyConfig struct {
+	KeyTTL          time.Duration
+	CleanupInterval time.Duration
+	// How long a request holds its key before a retry can take it over.
+	// Must be longer than any request takes.
+	LockLease time.Duration
+}
+
+type WebhookConfig struct {
+	MaxAttempts    int
+	RetryInterval  time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+type ReconciliationConfig struct {
+	Interval time.Duration
+	// Pending deposits younger than this are left for the webhook
+	MinAge time.Duration
+	// Pending deposits older than this are expired if still unpaid
+	DepositTTL time.Duration
+}
+
+type HoldsConfig struct {
+	// How often holds past their expiry are marked expired
+	ExpiryInterval time.Duration
+}
+
+type EscrowConfig struct {
+	// How often escrows past their auto-release time are released
+	ReleaseInterval time.Duration
+}
+
+type PayoutsConfig struct {
+	// How often queued bulk payouts are picked up and paid
+	Interval time.Duration
+}
+
+type ScheduledTransfersConfig struct {
+	// How often due scheduled transfers are run
+	Interval time.Duration
+	// Attempts per run, including the first
+	MaxAttempts int
+	// Wait before retrying a failed run
+	RetryDelay time.Duration
+}
+
+type PaymentRequestsConfig struct {
+	// Lifetime of a request created without an expiry
+	TTL time.Duration
+	// Longest lifetime a requester can ask for
+	MaxTTL time.Duration
+	// How often requests past their expiry are marked expired
+	ExpiryInterval time.Duration
+}
+
+type AliasesConfig struct {
+	// OTPSender delivers phone verification codes; phone aliases are
+	// disabled when it is empty. Only "log" is available, and only outside
+	// production.
+	OTPSender string
+	// Key for the HMAC that phone verification codes are stored under;
+	// required when OTPSender is set
+	OTPSecret string
+	// Comma-separated words that cannot be claimed as handles, on top of the built-in list
+	ReservedWords string
+	// How long a released alias is held back from other users
+	ReleaseCooldown time.Duration
+	// Shortest time between two handle changes
+	HandleChangeInterval time.Duration
+	// How long a phone verification code can be used for
+	OTPTTL time.Duration
+	// Shortest time between two codes sent to the same user
+	OTPResendInterval time.Duration
+	// Wrong codes allowed before a code stops working
+	MaxOTPAttempts int
+}
+
+// PhoneVerificationEnabled reports whether users can claim phone aliases
+func (c *AliasesConfig) PhoneVerificationEnabled() bool {
+	return c.OTPSender != ""
+}
+
+type AdminConfig struct {
+	// Admin endpoints are disabled when no key is configured
+	APIKey string
+	// Lets admins force a transfer reversal that takes the recipient below zero
+	AllowForceNegativeReversals bool
 }
 
 func Load() (*Config, error) {
 	// Heroku provides PORT environment variable
 	port := getEnv("PORT", getEnv("SERVER_PORT", "8080"))
-	
+
 	config := &Config{
 		Server: ServerConfig{
 			Port: port,
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Env:  getEnv("APP_ENV", "production"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -73,6 +248,98 @@ func Load() (*Config, error) {
 		Paystack: PaystackConfig{
 			SecretKey: getEnv("PAYSTACK_SECRET_KEY", ""),
 			PublicKey: getEnv("PAYSTACK_PUBLIC_KEY", ""),
+			BaseURL:   getEnv("PAYSTACK_BASE_URL", "https://api.paystack.co"),
+		},
+		Flutterwave: FlutterwaveConfig{
+			SecretKey:   getEnv("FLUTTERWAVE_SECRET_KEY", ""),
+			SecretHash:  getEnv("FLUTTERWAVE_SECRET_HASH", ""),
+			RedirectURL: getEnv("FLUTTERWAVE_REDIRECT_URL", ""),
+		},
+		Payment: PaymentConfig{
+			DefaultProvider: getEnv("DEFAULT_PAYMENT_PROVIDER", "paystack"),
+		},
+		Fees: FeesConfig{
+			ScheduleFile: getEnv("FEE_SCHEDULE_FILE", ""),
+		},
+		Limits: LimitsConfig{
+			MaxTransferAmount:   getEnvMoney("LIMIT_MAX_TRANSFER_AMOUNT", "0"),
+			DailyOutflow:        getEnvMoney("LIMIT_DAILY_OUTFLOW", "0"),
+			MonthlyOutflow:      getEnvMoney("LIMIT_MONTHLY_OUTFLOW", "0"),
+			APIKeyDailySpend:    getEnvMoney("LIMIT_API_KEY_DAILY_SPEND", "0"),
+			MaxTransfersPerHour: getEnvInt("LIMIT_MAX_TRANSFERS_PER_HOUR", 0),
+		},
+		// Defaults follow the CBN tiered KYC limits
+		KYC: KYCConfig{
+			Verifier: getEnv("KYC_VERIFIER", ""),
+			Tiers: []KYCTierConfig{
+				{
+					MaxBalance: getEnvMoney("KYC_TIER0_MAX_BALANCE", "300000.00"),
+					DailyLimit: getEnvMoney("KYC_TIER0_DAILY_LIMIT", "50000.00"),
+				},
+				{
+					MaxBalance: getEnvMoney("KYC_TIER1_MAX_BALANCE", "500000.00"),
+					DailyLimit: getEnvMoney("KYC_TIER1_DAILY_LIMIT", "200000.00"),
+				},
+				{
+					MaxBalance: getEnvMoney("KYC_TIER2_MAX_BALANCE", "0"),
+					DailyLimit: getEnvMoney("KYC_TIER2_DAILY_LIMIT", "5000000.00"),
+				},
+			},
+		},
+		FX: FXConfig{
+			RateProvider: getEnv("FX_RATE_PROVIDER", "static"),
+			RatesFile:    getEnv("FX_RATES_FILE", ""),
+			SpreadBPS:    getEnvInt("FX_SPREAD_BPS", 100),
+			QuoteTTL:     getEnvDuration("FX_QUOTE_TTL", 30*time.Second),
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL:          getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+			CleanupInterval: getEnvDuration("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+			LockLease:       getEnvDuration("IDEMPOTENCY_LOCK_LEASE", 2*time.Minute),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryInterval:  getEnvDuration("WEBHOOK_RETRY_INTERVAL", time.Minute),
+			RetryBaseDelay: getEnvDuration("WEBHOOK_RETRY_BASE_DELAY", 30*time.Second),
+			RetryMaxDelay:  getEnvDuration("WEBHOOK_RETRY_MAX_DELAY", 6*time.Hour),
+		},
+		Reconciliation: ReconciliationConfig{
+			Interval:   getEnvDuration("RECONCILIATION_INTERVAL", 5*time.Minute),
+			MinAge:     getEnvDuration("RECONCILIATION_MIN_AGE", 15*time.Minute),
+			DepositTTL: getEnvDuration("DEPOSIT_TTL", 24*time.Hour),
+		},
+		Holds: HoldsConfig{
+			ExpiryInterval: getEnvDuration("HOLD_EXPIRY_INTERVAL", time.Minute),
+		},
+		Escrow: EscrowConfig{
+			ReleaseInterval: getEnvDuration("ESCROW_RELEASE_INTERVAL", 5*time.Minute),
+		},
+		Payouts: PayoutsConfig{
+			Interval: getEnvDuration("PAYOUT_INTERVAL", 10*time.Second),
+		},
+		ScheduledTransfers: ScheduledTransfersConfig{
+			Interval:    getEnvDuration("SCHEDULED_TRANSFER_INTERVAL", time.Minute),
+			MaxAttempts: getEnvInt("SCHEDULED_TRANSFER_MAX_ATTEMPTS", 3),
+			RetryDelay:  getEnvDuration("SCHEDULED_TRANSFER_RETRY_DELAY", time.Hour),
+		},
+		PaymentRequests: PaymentRequestsConfig{
+			TTL:            getEnvDuration("PAYMENT_REQUEST_TTL", 7*24*time.Hour),
+			MaxTTL:         getEnvDuration("PAYMENT_REQUEST_MAX_TTL", 30*24*time.Hour),
+			ExpiryInterval: getEnvDuration("PAYMENT_REQUEST_EXPIRY_INTERVAL", time.Minute),
+		},
+		Aliases: AliasesConfig{
+			OTPSender:            getEnv("ALIAS_OTP_SENDER", ""),
+			OTPSecret:            getEnv("ALIAS_OTP_SECRET", ""),
+			ReservedWords:        getEnv("ALIAS_RESERVED_WORDS", ""),
+			ReleaseCooldown:      getEnvDuration("ALIAS_RELEASE_COOLDOWN", 90*24*time.Hour),
+			HandleChangeInterval: getEnvDuration("ALIAS_HANDLE_CHANGE_INTERVAL", 30*24*time.Hour),
+			OTPTTL:               getEnvDuration("ALIAS_OTP_TTL", 10*time.Minute),
+			OTPResendInterval:    getEnvDuration("ALIAS_OTP_RESEND_INTERVAL", time.Minute),
+			MaxOTPAttempts:       getEnvInt("ALIAS_OTP_MAX_ATTEMPTS", 5),
+		},
+		Admin: AdminConfig{
+			APIKey:                      getEnv("ADMIN_API_KEY", ""),
+			AllowForceNegativeReversals: getEnvBool("ALLOW_FORCE_NEGATIVE_REVERSALS", false),
 		},
 	}
 
@@ -84,6 +351,11 @@ func Load() (*Config, error) {
 }
 
 func (c *Config) Validate() error {
+	switch c.Server.Env {
+	case "production", "development", "test":
+	default:
+		return fmt.Errorf("APP_ENV must be production, development or test")
+	}
 	if c.JWT.Secret == "" {
 		return fmt.Errorf("JWT_SECRET is required")
 	}
@@ -96,6 +368,21 @@ func (c *Config) Validate() error {
 	if c.Paystack.SecretKey == "" {
 		return fmt.Errorf("PAYSTACK_SECRET_KEY is required")
 	}
+	if c.KYC.Verifier == "" {
+		return fmt.Errorf("KYC_VERIFIER is required")
+	}
+	if c.KYC.Verifier == "stub" && !c.Server.AllowsStubs() {
+		return fmt.Errorf("KYC_VERIFIER=stub approves every submission and can only be used when APP_ENV is development or test")
+	}
+	if c.Aliases.PhoneVerificationEnabled() && c.Aliases.OTPSecret == "" {
+		return fmt.Errorf("ALIAS_OTP_SECRET is required when ALIAS_OTP_SENDER is set")
+	}
+	if c.Aliases.OTPSender == "log" && !c.Server.AllowsStubs() {
+		return fmt.Errorf("ALIAS_OTP_SENDER=log writes codes to the log and can only be used when APP_ENV is development or test")
+	}
+	if c.Flutterwave.Enabled() && c.Flutterwave.SecretHash == "" {
+		return fmt.Errorf("FLUTTERWAVE_SECRET_HASH is required when FLUTTERWAVE_SECRET_KEY is set")
+	}
 	// DB_PASSWORD only required if DATABASE_URL is not set
 	if c.Database.Password == "" && os.Getenv("DATABASE_URL") == "" {
 		return fmt.Errorf("DB_PASSWORD or DATABASE_URL is required")
@@ -108,7 +395,7 @@ func (c *DatabaseConfig) GetDSN() string {
 	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
 		return databaseURL
 	}
-	
+
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode,
@@ -121,3 +408,44 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration parses a Go duration such as "30m" or "24h"
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool parses values such as "true", "1" or "false"
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvMoney parses an amount in the default currency such as "50000.00";
+// invalid values fall back to the default
+func getEnvMoney(key, defaultValue string) models.Money {
+	if value := os.Getenv(key); value != "" {
+		if amount, err := models.ParseMoney(value, models.DefaultCurrency); err == nil {
+			return amount
+		}
+	}
+	amount, _ := models.ParseMoney(defaultValue, models.DefaultCurrency)
+	return amount
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig returns a config that passes Validate
+func validConfig() *Config {
+	return &Config{
+		Server:   ServerConfig{Env: "production"},
+		Database: DatabaseConfig{Password: "postgres"},
+		JWT:      JWTConfig{Secret: "secret"},
+		Google:   GoogleOAuthConfig{ClientID: "client", ClientSecret: "client-secret"},
+		Paystack: PaystackConfig{SecretKey: "sk_test"},
+		KYC:      KYCConfig{Verifier: "provider"},
+	}
+}
+
+func TestValidateKYCVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		verifier string
+		wantErr  string
+	}{
+		{name: "real verifier in production", env: "production", verifier: "provider"},
+		{name: "no verifier", env: "production", verifier: "", wantErr: "KYC_VERIFIER is required"},
+		{name: "stub in production", env: "production", verifier: "stub", wantErr: "KYC_VERIFIER=stub"},
+		{name: "stub in development", env: "development", verifier: "stub"},
+		{name: "stub in test", env: "test", verifier: "stub"},
+		{name: "unknown environment", env: "staging", verifier: "stub", wantErr: "APP_ENV must be"},
+	}
+
+	for _, tt := range tests {
+		c := validConfig()
+		c.Server.Env = tt.env
+		c.KYC.Verifier = tt.verifier
+
+		err := c.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Validate() = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateAliasOTP(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		sender    string
+		secret    string
+		wantErr   string
+		wantPhone bool
+	}{
+		{name: "phone aliases disabled without a secret", env: "production"},
+		{name: "sender without a secret", env: "development", sender: "log", wantErr: "ALIAS_OTP_SECRET is required", wantPhone: true},
+		{name: "log sender in development", env: "development", sender: "log", secret: "otp-secret", wantPhone: true},
+		{name: "log sender in production", env: "production", sender: "log", secret: "otp-secret", wantErr: "ALIAS_OTP_SENDER=log", wantPhone: true},
+		{name: "real sender in production", env: "production", sender: "sms", secret: "otp-secret", wantPhone: true},
+	}
+
+	for _, tt := range tests {
+		c := validConfig()
+		c.Server.Env = tt.env
+		c.Aliases = AliasesConfig{OTPSender: tt.sender, OTPSecret: tt.secret}
+
+		err := c.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Validate() = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+		if got := c.Aliases.PhoneVerificationEnabled(); got != tt.wantPhone {
+			t.Errorf("%s: PhoneVerificationEnabled() = %v, want %v", tt.name, got, tt.wantPhone)
+		}
+	}
+}
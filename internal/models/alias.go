@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AliasType is the kind of name a user can claim in place of their wallet number
+type AliasType string
+
+const (
+	// A @handle chosen by the user
+	AliasTypeHandle AliasType = "handle"
+	// A phone number the user has verified with a one-time code
+	AliasTypePhone AliasType = "phone"
+)
+
+func (t *AliasType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = AliasType(string(v))
+	case string:
+		*t = AliasType(v)
+	}
+	return nil
+}
+
+func (t AliasType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// AliasStatus is whether an alias is still held by its user
+type AliasStatus string
+
+const (
+	AliasStatusActive AliasStatus = "active"
+	// Given up or replaced; kept so that the alias cannot be re-claimed
+	// by someone else straight away
+	AliasStatusReleased AliasStatus = "released"
+)
+
+func (s *AliasStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = AliasStatus(string(v))
+	case string:
+		*s = AliasStatus(v)
+	}
+	return nil
+}
+
+func (s AliasStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Alias is a handle or phone number a user has claimed. Handles are stored
+// lower case without the @ and phone numbers in E.164 form.
+type Alias struct {
+	ID         uuid.UUID   `json:"id" db:"id"`
+	UserID     uuid.UUID   `json:"user_id" db:"user_id"`
+	Type       AliasType   `json:"type" db:"type"`
+	Value      string      `json:"value" db:"value"`
+	Status     AliasStatus `json:"status" db:"status"`
+	ClaimedAt  time.Time   `json:"claimed_at" db:"claimed_at"`
+	ReleasedAt *time.Time  `json:"released_at,omitempty" db:"released_at"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// String is the alias as a sender would type it, e.g. @ada or +2348012345678
+func (a *Alias) String() string {
+	if a.Type == AliasTypeHandle {
+		return "@" + a.Value
+	}
+	return a.Value
+}
+
+// AliasVerification is a one-time code sent to a phone number that the user
+// must send back before the number becomes their alias
+type AliasVerification struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Phone      string     `json:"phone" db:"phone"`
+	CodeHash   string     `json:"-" db:"code_hash"`
+	Attempts   int        `json:"attempts" db:"attempts"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BeneficiaryType is where a saved beneficiary is paid
+type BeneficiaryType string
+
+const (
+	// Another wallet, paid by transfer
+	BeneficiaryTypeWallet BeneficiaryType = "wallet"
+	// A bank account, paid by withdrawal
+	BeneficiaryTypeBankAccount BeneficiaryType = "bank_account"
+)
+
+func (t *BeneficiaryType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = BeneficiaryType(string(v))
+	case string:
+		*t = BeneficiaryType(v)
+	}
+	return nil
+}
+
+func (t BeneficiaryType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// Beneficiary is a recipient a user has saved under a nickname so that
+// transfers and withdrawals can refer to it by ID. Wallet beneficiaries have
+// a WalletNumber; bank account beneficiaries a BankCode and AccountNumber.
+type Beneficiary struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	UserID        uuid.UUID       `json:"user_id" db:"user_id"`
+	Type          BeneficiaryType `json:"type" db:"type"`
+	Nickname      string          `json:"nickname" db:"nickname"`
+	WalletNumber  *string         `json:"wallet_number,omitempty" db:"wallet_number"`
+	BankCode      *string         `json:"bank_code,omitempty" db:"bank_code"`
+	AccountNumber *string         `json:"account_number,omitempty" db:"account_number"`
+	// The owner's name when the beneficiary was saved: masked for wallets,
+	// as the bank has it for bank accounts
+	AccountName string    `json:"account_name" db:"account_name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
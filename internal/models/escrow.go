@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EscrowStatus tracks the lifecycle of an escrow
+type EscrowStatus string
+
+const (
+	// The buyer has paid in; the money is held until it is released or refunded
+	EscrowStatusFunded EscrowStatus = "funded"
+	// A party has opened a dispute; nothing moves until an admin resolves it
+	EscrowStatusDisputed EscrowStatus = "disputed"
+	// Paid out to the seller
+	EscrowStatusReleased EscrowStatus = "released"
+	// Paid back to the buyer
+	EscrowStatusRefunded EscrowStatus = "refunded"
+)
+
+func (s *EscrowStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = EscrowStatus(string(v))
+	case string:
+		*s = EscrowStatus(v)
+	}
+	return nil
+}
+
+func (s EscrowStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Escrow holds a buyer's payment to a seller in the escrow system account
+// until the buyer releases it, the seller cancels it or it is released
+// automatically at AutoReleaseAt. A dispute freezes it until an admin decides.
+type Escrow struct {
+	ID                 uuid.UUID    `json:"id" db:"id"`
+	BuyerUserID        uuid.UUID    `json:"buyer_user_id" db:"buyer_user_id"`
+	BuyerWalletID      uuid.UUID    `json:"-" db:"buyer_wallet_id"`
+	BuyerWalletNumber  string       `json:"buyer_wallet_number" db:"buyer_wallet_number"`
+	SellerUserID       uuid.UUID    `json:"seller_user_id" db:"seller_user_id"`
+	SellerWalletID     uuid.UUID    `json:"-" db:"seller_wallet_id"`
+	SellerWalletNumber string       `json:"seller_wallet_number" db:"seller_wallet_number"`
+	Amount             Money        `json:"amount" db:"amount"`
+	Currency           Currency     `json:"currency" db:"currency"`
+	Status             EscrowStatus `json:"status" db:"status"`
+	// The caller's own reference, e.g. a marketplace order number
+	Reference     *string   `json:"reference,omitempty" db:"reference"`
+	Description   *string   `json:"description,omitempty" db:"description"`
+	AutoReleaseAt time.Time `json:"auto_release_at" db:"auto_release_at"`
+	// The API key that funded the escrow, if any
+	APIKeyID      *uuid.UUID `json:"api_key_id,omitempty" db:"api_key_id"`
+	DisputedBy    *uuid.UUID `json:"disputed_by,omitempty" db:"disputed_by"`
+	DisputeReason *string    `json:"dispute_reason,omitempty" db:"dispute_reason"`
+	DisputedAt    *time.Time `json:"disputed_at,omitempty" db:"disputed_at"`
+	// When the escrow was released or refunded
+	SettledAt *time.Time `json:"settled_at,omitempty" db:"settled_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the escrow's currency on its amount after it has been
+// read from the database
+func (e *Escrow) ApplyCurrency() {
+	e.Amount.Currency = e.Currency
+}
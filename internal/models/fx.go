@@ -0,0 +1,207 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rateDigits is the number of decimal places an exchange rate is held to
+const rateDigits = 8
+
+// rateFactor is the number of rate units in 1
+const rateFactor = 100000000
+
+// Rate is an exchange rate: how much of one currency a single unit of another
+// buys. It is held as a fixed-point number with eight decimal places so that
+// rates, like amounts, are never floats.
+type Rate int64
+
+// ParseRate parses a decimal string such as "1550.25" into a Rate
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || (hasFraction && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("invalid rate: %q", s)
+	}
+	if len(fraction) > rateDigits {
+		return 0, fmt.Errorf("invalid rate: %q has more than %d decimal places", s, rateDigits)
+	}
+	fraction += strings.Repeat("0", rateDigits-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate: %q is out of range", s)
+	}
+	return Rate(units), nil
+}
+
+// String formats the rate as a decimal string without trailing zeros, e.g. "1550.25"
+func (r Rate) String() string {
+	s := fmt.Sprintf("%d.%0*d", r/rateFactor, rateDigits, r%rateFactor)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// IsPositive reports whether the rate is greater than zero
+func (r Rate) IsPositive() bool {
+	return r > 0
+}
+
+// Invert returns the rate in the other direction, rounded half up
+func (r Rate) Invert() Rate {
+	if r <= 0 {
+		return 0
+	}
+	return Rate((int64(rateFactor)*rateFactor + int64(r)/2) / int64(r))
+}
+
+// Mul chains two rates, e.g. USD to NGN then NGN to GHS gives USD to GHS.
+// The result is rounded down. It fails if the result is too large to hold.
+func (r Rate) Mul(other Rate) (Rate, error) {
+	product := new(big.Int).Mul(big.NewInt(int64(r)), big.NewInt(int64(other)))
+	product.Quo(product, big.NewInt(rateFactor))
+	if !product.IsInt64() {
+		return 0, fmt.Errorf("rate %s x %s is out of range", r, other)
+	}
+	return Rate(product.Int64()), nil
+}
+
+// Convert converts an amount into another currency at the rate, rounding down
+// to the nearest minor unit so that conversions never create money. It fails
+// if the converted amount is too large to hold.
+func (r Rate) Convert(m Money, to Currency) (Money, error) {
+	converted := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(r)))
+	converted.Quo(converted, big.NewInt(rateFactor))
+	if !converted.IsInt64() {
+		return Money{}, fmt.Errorf("%s %s is too large to convert to %s", m, m.Currency, to)
+	}
+	return NewMoney(converted.Int64(), to), nil
+}
+
+// RateOf returns the rate that converts from into to, rounded down
+func RateOf(from, to Money) Rate {
+	if !from.IsPositive() {
+		return 0
+	}
+	rate := new(big.Int).Mul(big.NewInt(to.Amount), big.NewInt(rateFactor))
+	rate.Quo(rate, big.NewInt(from.Amount))
+	return Rate(rate.Int64())
+}
+
+// MarshalJSON encodes the rate as a decimal string
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts either a decimal string or a JSON number
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("invalid rate: %w", err)
+		}
+	}
+
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Scan reads a rate stored as NUMERIC
+func (r *Rate) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case nil:
+		*r = 0
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Rate", value)
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Rate: %w", s, err)
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores the rate as a decimal string
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// FXQuoteStatus tracks whether a quote has been used
+type FXQuoteStatus string
+
+const (
+	// The quote can be executed until it expires
+	FXQuoteStatusOpen FXQuoteStatus = "open"
+	// The conversion has been made
+	FXQuoteStatusExecuted FXQuoteStatus = "executed"
+)
+
+func (s *FXQuoteStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = FXQuoteStatus(string(v))
+	case string:
+		*s = FXQuoteStatus(v)
+	}
+	return nil
+}
+
+func (s FXQuoteStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// FXQuote is a price for converting between two of a user's wallets. The
+// user sells SellAmount and receives BuyAmount; the spread is kept by the
+// platform. A quote can be executed once, before it expires.
+type FXQuote struct {
+	ID           uuid.UUID     `json:"id" db:"id"`
+	UserID       uuid.UUID     `json:"user_id" db:"user_id"`
+	FromCurrency Currency      `json:"from_currency" db:"from_currency"`
+	ToCurrency   Currency      `json:"to_currency" db:"to_currency"`
+	SellAmount   Money         `json:"sell_amount" db:"sell_amount"`
+	BuyAmount    Money         `json:"buy_amount" db:"buy_amount"`
+	MidRate      Rate          `json:"mid_rate" db:"mid_rate"` // From the rate provider
+	Rate         Rate          `json:"rate" db:"rate"`         // What the user gets, after the spread
+	SpreadBPS    int64         `json:"spread_bps" db:"spread_bps"`
+	Spread       Money         `json:"spread" db:"spread"` // In the sell currency
+	Provider     string        `json:"provider" db:"provider"`
+	Status       FXQuoteStatus `json:"status" db:"status"`
+	ExpiresAt    time.Time     `json:"expires_at" db:"expires_at"`
+	ExecutedAt   *time.Time    `json:"executed_at,omitempty" db:"executed_at"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the quote's currencies on its amounts after it has been
+// read from the database
+func (q *FXQuote) ApplyCurrency() {
+	q.SellAmount.Currency = q.FromCurrency
+	q.Spread.Currency = q.FromCurrency
+	q.BuyAmount.Currency = q.ToCurrency
+}
+
+// IsExecutable reports whether the quote can still be executed
+func (q *FXQuote) IsExecutable(now time.Time) bool {
+	return q.Status == FXQuoteStatusOpen && now.Before(q.ExpiresAt)
+}
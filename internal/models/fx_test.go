@@ -0,0 +1,99 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func mustParseRate(t *testing.T, s string) Rate {
+	t.Helper()
+	rate, err := ParseRate(s)
+	if err != nil {
+		t.Fatalf("ParseRate(%q): %v", s, err)
+	}
+	return rate
+}
+
+func TestRateInvert(t *testing.T) {
+	tests := []struct {
+		rate Rate
+		want string
+	}{
+		{rate: mustParseRate(t, "2"), want: "0.5"},
+		// 1/1500 = 0.000666666... rounds half up
+		{rate: mustParseRate(t, "1500"), want: "0.00066667"},
+		// 1/40000000 = 0.000000025 is exactly half way and rounds up
+		{rate: mustParseRate(t, "40000000"), want: "0.00000003"},
+		{rate: 0, want: "0"},
+		{rate: -100, want: "0"},
+	}
+	for _, tt := range tests {
+		if got := tt.rate.Invert().String(); got != tt.want {
+			t.Errorf("%s.Invert() = %s, want %s", tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestRateMul(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{a: "1500", b: "0.0125", want: "18.75"},
+		{a: "1", b: "1", want: "1"},
+		// 0.99999999 exactly; the product is rounded down, never up
+		{a: "0.33333333", b: "3", want: "0.99999999"},
+		{a: "0.00000001", b: "0.5", want: "0"},
+	}
+	for _, tt := range tests {
+		got, err := mustParseRate(t, tt.a).Mul(mustParseRate(t, tt.b))
+		if err != nil {
+			t.Errorf("%s.Mul(%s) failed: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s.Mul(%s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRateMulOverflow(t *testing.T) {
+	if got, err := Rate(math.MaxInt64).Mul(mustParseRate(t, "2")); err == nil {
+		t.Errorf("Mul overflowing int64 = %s, want an error", got)
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		rate   string
+		to     Currency
+		want   Money
+	}{
+		{name: "whole result", amount: NewMoney(1000, CurrencyUSD), rate: "1500", to: CurrencyNGN, want: NewMoney(1500000, CurrencyNGN)},
+		// 150000 x 0.00066667 = 100.0005 minor units
+		{name: "rounds down", amount: NewMoney(150000, CurrencyNGN), rate: "0.00066667", to: CurrencyUSD, want: NewMoney(100, CurrencyUSD)},
+		// Half a cent is not paid out
+		{name: "half a minor unit", amount: NewMoney(1, CurrencyUSD), rate: "0.5", to: CurrencyGHS, want: NewMoney(0, CurrencyGHS)},
+		{name: "zero", amount: NewMoney(0, CurrencyUSD), rate: "1500", to: CurrencyNGN, want: NewMoney(0, CurrencyNGN)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mustParseRate(t, tt.rate).Convert(tt.amount, tt.to)
+			if err != nil {
+				t.Fatalf("Convert failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%s %s) at %s = %s %s, want %s %s", tt.amount, tt.amount.Currency, tt.rate, got, got.Currency, tt.want, tt.want.Currency)
+			}
+		})
+	}
+}
+
+func TestRateConvertOverflow(t *testing.T) {
+	amount := NewMoney(math.MaxInt64/2, CurrencyUSD)
+	if got, err := mustParseRate(t, "1500").Convert(amount, CurrencyNGN); err == nil {
+		t.Errorf("Convert overflowing int64 = %s, want an error", got)
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HoldStatus tracks the lifecycle of a hold on wallet funds
+type HoldStatus string
+
+const (
+	// Funds are reserved and can be captured until the hold expires
+	HoldStatusActive HoldStatus = "active"
+	// The full amount has been captured
+	HoldStatusCaptured HoldStatus = "captured"
+	// The uncaptured remainder was released by the caller
+	HoldStatusReleased HoldStatus = "released"
+	// The uncaptured remainder was released when the hold expired
+	HoldStatusExpired HoldStatus = "expired"
+)
+
+func (s *HoldStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = HoldStatus(string(v))
+	case string:
+		*s = HoldStatus(v)
+	}
+	return nil
+}
+
+func (s HoldStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Hold reserves part of a wallet's balance, e.g. for an order that has not
+// been fulfilled yet. Held funds stay in the wallet's ledger balance but are
+// not available to spend until the hold is captured, released or expires.
+type Hold struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	WalletID       uuid.UUID  `json:"wallet_id" db:"wallet_id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	Amount         Money      `json:"amount" db:"amount"`
+	CapturedAmount Money      `json:"captured_amount" db:"captured_amount"`
+	Currency       Currency   `json:"currency" db:"currency"`
+	Status         HoldStatus `json:"status" db:"status"`
+	Reference      *string    `json:"reference,omitempty" db:"reference"` // Caller's reference, e.g. an order ID
+	Description    *string    `json:"description,omitempty" db:"description"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the hold's currency on its amounts after it has been
+// read from the database
+func (h *Hold) ApplyCurrency() {
+	h.Amount.Currency = h.Currency
+	h.CapturedAmount.Currency = h.Currency
+}
+
+// Remaining returns the part of the hold that has not been captured
+func (h *Hold) Remaining() Money {
+	return h.Amount.Sub(h.CapturedAmount)
+}
+
+// IsCapturable reports whether funds can still be captured from the hold
+func (h *Hold) IsCapturable(now time.Time) bool {
+	return h.Status == HoldStatusActive && now.Before(h.ExpiresAt)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey records a client-supplied Idempotency-Key together with the
+// request it was first used for and the response that was returned. Until a
+// response is stored, the request holding the key is identified by LockToken
+// and keeps the key until LockedUntil.
+type IdempotencyKey struct {
+	ID                  uuid.UUID  `json:"id" db:"id"`
+	UserID              uuid.UUID  `json:"user_id" db:"user_id"`
+	Key                 string     `json:"key" db:"key"`
+	RequestMethod       string     `json:"request_method" db:"request_method"`
+	RequestPath         string     `json:"request_path" db:"request_path"`
+	RequestHash         string     `json:"request_hash" db:"request_hash"`
+	LockToken           uuid.UUID  `json:"-" db:"lock_token"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+	ResponseStatus      *int       `json:"response_status,omitempty" db:"response_status"`
+	ResponseContentType *string    `json:"response_content_type,omitempty" db:"response_content_type"`
+	// The exact bytes sent to the client
+	ResponseBody []byte    `json:"-" db:"response_body"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// IsCompleted reports whether a response has been stored for the key
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.ResponseStatus != nil
+}
+
+// IsExpired checks if the idempotency key is expired
+func (k *IdempotencyKey) IsExpired() bool {
+	return time.Now().After(k.ExpiresAt)
+}
+
+// IsLocked reports whether the request holding the key may still be running
+func (k *IdempotencyKey) IsLocked(now time.Time) bool {
+	return k.LockedUntil != nil && now.Before(*k.LockedUntil)
+}
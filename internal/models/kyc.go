@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KYCTier is how much is known about a user. Higher tiers allow larger
+// balances and daily limits.
+type KYCTier int
+
+const (
+	// Signed up with an email address only
+	KYCTierEmail KYCTier = 0
+	// BVN verified
+	KYCTierBVN KYCTier = 1
+	// Government-issued identity document verified
+	KYCTierDocument KYCTier = 2
+
+	MaxKYCTier = KYCTierDocument
+)
+
+// KYCIDType is the kind of identity number submitted for verification
+type KYCIDType string
+
+const (
+	KYCIDTypeBVN            KYCIDType = "bvn"
+	KYCIDTypeNIN            KYCIDType = "nin"
+	KYCIDTypeDriversLicense KYCIDType = "drivers_license"
+	KYCIDTypePassport       KYCIDType = "passport"
+	KYCIDTypeVotersCard     KYCIDType = "voters_card"
+)
+
+// KYCSubmissionStatus is the outcome of a verification attempt
+type KYCSubmissionStatus string
+
+const (
+	KYCSubmissionStatusPending  KYCSubmissionStatus = "pending"
+	KYCSubmissionStatusApproved KYCSubmissionStatus = "approved"
+	KYCSubmissionStatusRejected KYCSubmissionStatus = "rejected"
+	// The verifier could not be reached; the user can submit again
+	KYCSubmissionStatusFailed KYCSubmissionStatus = "failed"
+)
+
+func (s *KYCSubmissionStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = KYCSubmissionStatus(string(v))
+	case string:
+		*s = KYCSubmissionStatus(v)
+	}
+	return nil
+}
+
+func (s KYCSubmissionStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// KYCSubmission is one attempt to verify a user for a KYC tier. The full ID
+// number is passed to the verifier but never stored.
+type KYCSubmission struct {
+	ID                uuid.UUID           `json:"id" db:"id"`
+	UserID            uuid.UUID           `json:"user_id" db:"user_id"`
+	Tier              KYCTier             `json:"tier" db:"tier"`
+	IDType            KYCIDType           `json:"id_type" db:"id_type"`
+	IDNumberMasked    string              `json:"id_number" db:"id_number_masked"`
+	Status            KYCSubmissionStatus `json:"status" db:"status"`
+	Verifier          string              `json:"verifier" db:"verifier"`
+	VerifierReference *string             `json:"verifier_reference,omitempty" db:"verifier_reference"`
+	// Why the submission was rejected or failed
+	Reason    *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerDirection is the side of a posting (debit or credit)
+type LedgerDirection string
+
+const (
+	LedgerDebit  LedgerDirection = "debit"
+	LedgerCredit LedgerDirection = "credit"
+)
+
+func (d *LedgerDirection) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*d = LedgerDirection(string(v))
+	case string:
+		*d = LedgerDirection(v)
+	}
+	return nil
+}
+
+func (d LedgerDirection) Value() (driver.Value, error) {
+	return string(d), nil
+}
+
+// LedgerAccountType distinguishes wallet accounts from system accounts
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeWallet LedgerAccountType = "wallet"
+	LedgerAccountTypeSystem LedgerAccountType = "system"
+)
+
+func (t *LedgerAccountType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = LedgerAccountType(string(v))
+	case string:
+		*t = LedgerAccountType(v)
+	}
+	return nil
+}
+
+func (t LedgerAccountType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// System ledger account codes
+const (
+	// LedgerAccountPaystackClearing holds funds collected through Paystack
+	LedgerAccountPaystackClearing = "paystack_clearing"
+	// LedgerAccountFlutterwaveClearing holds funds collected through Flutterwave
+	LedgerAccountFlutterwaveClearing = "flutterwave_clearing"
+	// LedgerAccountFees holds fee income earned by the platform
+	LedgerAccountFees = "fees"
+	// LedgerAccountOpeningBalances offsets balances that existed before the ledger
+	LedgerAccountOpeningBalances = "opening_balances"
+	// LedgerAccountPendingPayouts holds withdrawals awaiting a Paystack transfer result
+	LedgerAccountPendingPayouts = "pending_payouts"
+	// LedgerAccountPendingRefunds holds deposit refunds awaiting a Paystack refund result
+	LedgerAccountPendingRefunds = "pending_refunds"
+	// LedgerAccountFXPosition is the platform's side of currency conversions:
+	// it takes in the currency users sell and pays out the currency they buy
+	LedgerAccountFXPosition = "fx_position"
+	// LedgerAccountFXSpread holds the spread earned on currency conversions
+	LedgerAccountFXSpread = "fx_spread"
+	// LedgerAccountEscrow holds buyers' payments until escrows are released or refunded
+	LedgerAccountEscrow = "escrow"
+)
+
+// LedgerAccount is an account that postings are made against. Every wallet
+// has exactly one wallet account; system accounts are identified by code.
+type LedgerAccount struct {
+	ID            uuid.UUID         `json:"id" db:"id"`
+	Code          string            `json:"code" db:"code"`
+	Name          string            `json:"name" db:"name"`
+	Type          LedgerAccountType `json:"type" db:"type"`
+	NormalBalance LedgerDirection   `json:"normal_balance" db:"normal_balance"`
+	WalletID      *uuid.UUID        `json:"wallet_id,omitempty" db:"wallet_id"`
+	Currency      Currency          `json:"currency" db:"currency"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// JournalEntry groups postings whose debits and credits must balance
+type JournalEntry struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Reference   string    `json:"reference" db:"reference"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Postings    []Posting `json:"postings" db:"-"`
+}
+
+// Posting is a single debit or credit against a ledger account
+type Posting struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	JournalEntryID uuid.UUID       `json:"journal_entry_id" db:"journal_entry_id"`
+	AccountID      uuid.UUID       `json:"account_id" db:"account_id"`
+	Direction      LedgerDirection `json:"direction" db:"direction"`
+	Amount         Money           `json:"amount" db:"amount"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// TrialBalance summarises all postings in the ledger
+type TrialBalance struct {
+	TotalDebits  Money `json:"total_debits" db:"total_debits"`
+	TotalCredits Money `json:"total_credits" db:"total_credits"`
+}
+
+// IsBalanced reports whether total debits equal total credits
+func (t *TrialBalance) IsBalanced() bool {
+	return t.TotalDebits.Amount == t.TotalCredits.Amount
+}
+
+// BalanceMismatch is a wallet whose stored balance disagrees with its postings
+type BalanceMismatch struct {
+	WalletID      uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	StoredBalance Money     `json:"stored_balance" db:"stored_balance"`
+	LedgerBalance Money     `json:"ledger_balance" db:"ledger_balance"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LimitOverride replaces the configured transfer limits for one user or one
+// API key in one currency. Exactly one of UserID and APIKeyID is set. A nil
+// limit falls back to the configured default; zero lifts the limit.
+type LimitOverride struct {
+	ID                  uuid.UUID  `json:"id" db:"id"`
+	UserID              *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	APIKeyID            *uuid.UUID `json:"api_key_id,omitempty" db:"api_key_id"`
+	Currency            Currency   `json:"currency" db:"currency"`
+	MaxTransferAmount   *Money     `json:"max_transfer_amount" db:"max_transfer_amount"`
+	DailyOutflow        *Money     `json:"daily_outflow" db:"daily_outflow"`
+	MonthlyOutflow      *Money     `json:"monthly_outflow" db:"monthly_outflow"`
+	APIKeyDailySpend    *Money     `json:"api_key_daily_spend" db:"api_key_daily_spend"`
+	MaxTransfersPerHour *int       `json:"max_transfers_per_hour" db:"max_transfers_per_hour"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the override's currency on its amounts after it has
+// been read from the database
+func (o *LimitOverride) ApplyCurrency() {
+	for _, amount := range []*Money{o.MaxTransferAmount, o.DailyOutflow, o.MonthlyOutflow, o.APIKeyDailySpend} {
+		if amount != nil {
+			amount.Currency = o.Currency
+		}
+	}
+}
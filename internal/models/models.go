@@ -14,6 +14,7 @@ type User struct {
 	Email     string    `json:"email" db:"email"`
 	GoogleID  *string   `json:"google_id,omitempty" db:"google_id"`
 	Name      string    `json:"name" db:"name"`
+	KYCTier   KYCTier   `json:"kyc_tier" db:"kyc_tier"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -23,19 +24,37 @@ type Wallet struct {
 	ID           uuid.UUID `json:"id" db:"id"`
 	UserID       uuid.UUID `json:"user_id" db:"user_id"`
 	WalletNumber string    `json:"wallet_number" db:"wallet_number"`
-	Balance      float64   `json:"balance" db:"balance"`
+	Balance      Money     `json:"balance" db:"balance"`
+	Currency     Currency  `json:"currency" db:"currency"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ApplyCurrency sets the wallet's currency on its balance after it has been
+// read from the database
+func (w *Wallet) ApplyCurrency() {
+	w.Balance.Currency = w.Currency
+}
+
 // TransactionType represents the type of transaction
 type TransactionType string
 
 const (
-	TransactionTypeDeposit  TransactionType = "deposit"
-	TransactionTypeTransfer TransactionType = "transfer"
-	TransactionTypeCredit   TransactionType = "credit"
-	TransactionTypeDebit    TransactionType = "debit"
+	TransactionTypeDeposit    TransactionType = "deposit"
+	TransactionTypeTransfer   TransactionType = "transfer"
+	TransactionTypeCredit     TransactionType = "credit"
+	TransactionTypeDebit      TransactionType = "debit"
+	TransactionTypeWithdrawal TransactionType = "withdrawal"
+	TransactionTypeRefund     TransactionType = "refund"
+	// A fee charged on another transaction, linked to it by related_transaction_id
+	TransactionTypeFee TransactionType = "fee"
+	// The two sides of a currency conversion between a user's own wallets
+	TransactionTypeFXDebit  TransactionType = "fx_debit"
+	TransactionTypeFXCredit TransactionType = "fx_credit"
+	// Escrow funding, release to the seller and refund to the buyer
+	TransactionTypeEscrowDebit  TransactionType = "escrow_debit"
+	TransactionTypeEscrowCredit TransactionType = "escrow_credit"
+	TransactionTypeEscrowRefund TransactionType = "escrow_refund"
 )
 
 func (t *TransactionType) Scan(value interface{}) error {
@@ -56,9 +75,15 @@ func (t TransactionType) Value() (driver.Value, error) {
 type TransactionStatus string
 
 const (
-	TransactionStatusPending TransactionStatus = "pending"
-	TransactionStatusSuccess TransactionStatus = "success"
-	TransactionStatusFailed  TransactionStatus = "failed"
+	TransactionStatusPending  TransactionStatus = "pending"
+	TransactionStatusSuccess  TransactionStatus = "success"
+	TransactionStatusFailed   TransactionStatus = "failed"
+	TransactionStatusReversed TransactionStatus = "reversed"
+	TransactionStatusDisputed TransactionStatus = "disputed"
+	// Held for manual review instead of being credited
+	TransactionStatusUnderReview TransactionStatus = "under_review"
+	// A deposit whose checkout was abandoned
+	TransactionStatusExpired TransactionStatus = "expired"
 )
 
 func (s *TransactionStatus) Scan(value interface{}) error {
@@ -81,16 +106,36 @@ type Transaction struct {
 	UserID            uuid.UUID         `json:"user_id" db:"user_id"`
 	WalletID          uuid.UUID         `json:"wallet_id" db:"wallet_id"`
 	Type              TransactionType   `json:"type" db:"type"`
-	Amount            float64           `json:"amount" db:"amount"`
+	Amount            Money             `json:"amount" db:"amount"`
+	Fee               Money             `json:"fee" db:"fee"` // Charged on top of the amount
+	Currency          Currency          `json:"currency" db:"currency"`
 	Status            TransactionStatus `json:"status" db:"status"`
 	Reference         *string           `json:"reference,omitempty" db:"reference"`
-	PaystackReference *string           `json:"paystack_reference,omitempty" db:"paystack_reference"`
+	PaystackReference *string           `json:"paystack_reference,omitempty" db:"paystack_reference"` // Reference at the payment provider
+	Provider          *PaymentProvider  `json:"provider,omitempty" db:"provider"`
 	RecipientWalletID *uuid.UUID        `json:"recipient_wallet_id,omitempty" db:"recipient_wallet_id"`
 	RecipientUserID   *uuid.UUID        `json:"recipient_user_id,omitempty" db:"recipient_user_id"`
 	Description       *string           `json:"description,omitempty" db:"description"`
 	Metadata          *string           `json:"metadata,omitempty" db:"metadata"`
-	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+	JournalEntryID    *uuid.UUID        `json:"journal_entry_id,omitempty" db:"journal_entry_id"`
+	// The API key that initiated a transfer or withdrawal
+	APIKeyID *uuid.UUID `json:"api_key_id,omitempty" db:"api_key_id"`
+	// The transaction a refund, reversal or fee was made against
+	RelatedTransactionID *uuid.UUID `json:"related_transaction_id,omitempty" db:"related_transaction_id"`
+	// The rate and quote a currency conversion was made at
+	FXRate    *Rate      `json:"fx_rate,omitempty" db:"fx_rate"`
+	FXQuoteID *uuid.UUID `json:"fx_quote_id,omitempty" db:"fx_quote_id"`
+	// The escrow a funding, release or refund belongs to
+	EscrowID  *uuid.UUID `json:"escrow_id,omitempty" db:"escrow_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the transaction's currency on its amounts after it has
+// been read from the database
+func (t *Transaction) ApplyCurrency() {
+	t.Amount.Currency = t.Currency
+	t.Fee.Currency = t.Currency
 }
 
 // APIKey represents an API key for service-to-service access
@@ -114,14 +159,19 @@ const (
 	PermissionDeposit  = "deposit"
 	PermissionTransfer = "transfer"
 	PermissionRead     = "read"
+	PermissionWithdraw = "withdraw"
+	// Lets a key release the escrows its owner has funded
+	PermissionEscrowRelease = "escrow_release"
 )
 
 // IsValidPermission checks if a permission is valid
 func IsValidPermission(permission string) bool {
 	validPermissions := map[string]bool{
-		PermissionDeposit:  true,
-		PermissionTransfer: true,
-		PermissionRead:     true,
+		PermissionDeposit:       true,
+		PermissionTransfer:      true,
+		PermissionRead:          true,
+		PermissionWithdraw:      true,
+		PermissionEscrowRelease: true,
 	}
 	return validPermissions[permission]
 }
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	CurrencyNGN Currency = "NGN"
+	CurrencyUSD Currency = "USD"
+	CurrencyGHS Currency = "GHS"
+	CurrencyZAR Currency = "ZAR"
+	CurrencyKES Currency = "KES"
+
+	// DefaultCurrency is the currency used when none is specified
+	DefaultCurrency = CurrencyNGN
+)
+
+// SupportedCurrencies are the currencies wallets can be held in. All of them
+// have two decimal places.
+var SupportedCurrencies = []Currency{CurrencyNGN, CurrencyUSD, CurrencyGHS, CurrencyZAR, CurrencyKES}
+
+// IsSupported reports whether wallets can be held in the currency
+func (c Currency) IsSupported() bool {
+	for _, supported := range SupportedCurrencies {
+		if c == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCurrency parses a currency code such as "usd". An empty code is the
+// default currency.
+func ParseCurrency(s string) (Currency, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DefaultCurrency, nil
+	}
+	currency := Currency(strings.ToUpper(s))
+	if !currency.IsSupported() {
+		return "", fmt.Errorf("unsupported currency %q", s)
+	}
+	return currency, nil
+}
+
+// minorUnitDigits is the number of decimal places in a currency's minor unit
+const minorUnitDigits = 2
+
+// minorUnitFactor is the number of minor units in one major unit
+const minorUnitFactor = 100
+
+// Money is an exact monetary amount held in the currency's minor unit
+// (kobo for NGN). Amounts are never represented as floats.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// NewMoney creates a Money value from an amount in minor units
+func NewMoney(minorUnits int64, currency Currency) Money {
+	return Money{Amount: minorUnits, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "19.99" into Money.
+// Values with more precision than the currency's minor unit are rejected.
+func ParseMoney(s string, currency Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, fmt.Errorf("invalid amount: empty value")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || (hasFraction && fraction == "") {
+		return Money{}, fmt.Errorf("invalid amount: %q", s)
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount: %q", s)
+	}
+	if len(fraction) > minorUnitDigits {
+		return Money{}, fmt.Errorf("invalid amount: %q has more than %d decimal places", s, minorUnitDigits)
+	}
+	fraction += strings.Repeat("0", minorUnitDigits-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount: %q is out of range", s)
+	}
+	if negative {
+		units = -units
+	}
+
+	return Money{Amount: units, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal string in major units, e.g. "19.99"
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/minorUnitFactor, minorUnitDigits, amount%minorUnitFactor)
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// SameCurrency reports whether both amounts are in the same currency
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
+
+// Add returns m + other. Callers must ensure both amounts share a currency.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub returns m - other. Callers must ensure both amounts share a currency.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// LessThan reports whether m is smaller than other
+func (m Money) LessThan(other Money) bool {
+	return m.Amount < other.Amount
+}
+
+// MarshalJSON encodes the amount as a decimal string so that clients never
+// see a binary floating point value
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts either a decimal string ("19.99") or a JSON number
+// (19.99). Numbers are parsed from their literal text, never through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var text string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("invalid amount: %w", err)
+		}
+	} else {
+		text = string(data)
+	}
+
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(text, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads an amount in minor units from the database. The currency is not
+// stored alongside the amount and defaults to DefaultCurrency; models with a
+// currency column set it once the row has been read.
+func (m *Money) Scan(value interface{}) error {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+
+	switch v := value.(type) {
+	case int64:
+		m.Amount = v
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case nil:
+		m.Amount = 0
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", s, err)
+	}
+	m.Amount = amount
+	return nil
+}
+
+// Value stores the amount in minor units
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
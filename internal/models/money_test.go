@@ -0,0 +1,183 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "19.99", want: 1999},
+		{input: "19.9", want: 1990},
+		{input: "19", want: 1900},
+		{input: "0.01", want: 1},
+		{input: "0", want: 0},
+		{input: "  250.50 ", want: 25050},
+		{input: "+5.00", want: 500},
+		{input: "007.10", want: 710},
+		// Negatives parse; callers that need a positive amount check for it
+		{input: "-5.25", want: -525},
+		{input: "-0.01", want: -1},
+		{input: "92233720368547758.07", want: 9223372036854775807},
+		// Too many decimal places
+		{input: "19.999", wantErr: true},
+		{input: "0.001", wantErr: true},
+		{input: "1.000", wantErr: true},
+		// Out of range for int64 minor units
+		{input: "92233720368547758.08", wantErr: true},
+		{input: "-92233720368547758.08", wantErr: true},
+		{input: "100000000000000000000", wantErr: true},
+		// Malformed
+		{input: "", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: "--5", wantErr: true},
+		{input: "+-5", wantErr: true},
+		{input: ".50", wantErr: true},
+		{input: "5.", wantErr: true},
+		{input: "1,000.00", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "1.2.3", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "NaN", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input, CurrencyUSD)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %d, want an error", tt.input, got.Amount)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got.Amount != tt.want || got.Currency != CurrencyUSD {
+			t.Errorf("ParseMoney(%q) = %d %s, want %d USD", tt.input, got.Amount, got.Currency, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: `"19.99"`, want: 1999},
+		{input: `19.99`, want: 1999},
+		{input: `19`, want: 1900},
+		{input: ` "0.10" `, want: 10},
+		{input: `-3.50`, want: -350},
+		{input: `"-3.50"`, want: -350},
+		// 0.1 + 0.2 style float errors cannot creep in: the literal is parsed
+		{input: `0.30`, want: 30},
+		{input: `19.999`, wantErr: true},
+		{input: `"19.999"`, wantErr: true},
+		{input: `92233720368547758.08`, wantErr: true},
+		{input: `1e2`, wantErr: true},
+		{input: `"1e2"`, wantErr: true},
+		{input: `""`, wantErr: true},
+		{input: `true`, wantErr: true},
+		{input: `{"amount": 1}`, wantErr: true},
+		{input: `"19.99`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var m Money
+		err := json.Unmarshal([]byte(tt.input), &m)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %d, want an error", tt.input, m.Amount)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) failed: %v", tt.input, err)
+			continue
+		}
+		if m.Amount != tt.want || m.Currency != DefaultCurrency {
+			t.Errorf("Unmarshal(%s) = %d %s, want %d %s", tt.input, m.Amount, m.Currency, tt.want, DefaultCurrency)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSONKeepsCurrencyAndNull(t *testing.T) {
+	m := NewMoney(500, CurrencyUSD)
+	if err := json.Unmarshal([]byte(`null`), &m); err != nil {
+		t.Fatalf("Unmarshal(null) failed: %v", err)
+	}
+	if m != NewMoney(500, CurrencyUSD) {
+		t.Errorf("Unmarshal(null) changed the amount to %d %s", m.Amount, m.Currency)
+	}
+
+	if err := json.Unmarshal([]byte(`"12.34"`), &m); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if m != NewMoney(1234, CurrencyUSD) {
+		t.Errorf("Unmarshal = %d %s, want 1234 USD", m.Amount, m.Currency)
+	}
+
+	var req struct {
+		Amount *Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": null}`), &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if req.Amount != nil {
+		t.Errorf("null amount = %s, want nil", req.Amount)
+	}
+}
+
+func TestMoneyMarshalJSONRoundTrip(t *testing.T) {
+	for _, amount := range []int64{0, 1, 10, 1999, -525, 9223372036854775807} {
+		data, err := json.Marshal(NewMoney(amount, DefaultCurrency))
+		if err != nil {
+			t.Fatalf("Marshal(%d) failed: %v", amount, err)
+		}
+		var m Money
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+		}
+		if m.Amount != amount {
+			t.Errorf("%d round-tripped through %s to %d", amount, data, m.Amount)
+		}
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Currency
+		wantErr bool
+	}{
+		{input: "", want: DefaultCurrency},
+		{input: "  ", want: DefaultCurrency},
+		{input: "NGN", want: CurrencyNGN},
+		{input: "usd", want: CurrencyUSD},
+		{input: " Ghs ", want: CurrencyGHS},
+		{input: "EUR", wantErr: true},
+		{input: "XYZ", wantErr: true},
+		{input: "NG", wantErr: true},
+		{input: "NGNN", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCurrency(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseCurrency(%q) = %s, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCurrency(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCurrency(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql/driver"
+)
+
+// PaymentProvider identifies the payment gateway that collected a deposit
+type PaymentProvider string
+
+const (
+	PaymentProviderPaystack    PaymentProvider = "paystack"
+	PaymentProviderFlutterwave PaymentProvider = "flutterwave"
+)
+
+func (p *PaymentProvider) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*p = PaymentProvider(string(v))
+	case string:
+		*p = PaymentProvider(v)
+	}
+	return nil
+}
+
+func (p PaymentProvider) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+// ClearingAccount returns the code of the system ledger account that holds
+// the funds collected through the provider
+func (p PaymentProvider) ClearingAccount() string {
+	switch p {
+	case PaymentProviderFlutterwave:
+		return LedgerAccountFlutterwaveClearing
+	default:
+		return LedgerAccountPaystackClearing
+	}
+}
+
+// DisplayName returns the provider's name as shown to users
+func (p PaymentProvider) DisplayName() string {
+	switch p {
+	case PaymentProviderPaystack:
+		return "Paystack"
+	case PaymentProviderFlutterwave:
+		return "Flutterwave"
+	default:
+		return string(p)
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentRequestStatus tracks the lifecycle of a payment request
+type PaymentRequestStatus string
+
+const (
+	// Waiting for the payer to accept or decline
+	PaymentRequestStatusPending PaymentRequestStatus = "pending"
+	// The payer paid the request
+	PaymentRequestStatusAccepted PaymentRequestStatus = "accepted"
+	// The payer refused the request
+	PaymentRequestStatusDeclined PaymentRequestStatus = "declined"
+	// Nobody answered before expires_at
+	PaymentRequestStatusExpired PaymentRequestStatus = "expired"
+	// The requester withdrew the request
+	PaymentRequestStatusCancelled PaymentRequestStatus = "cancelled"
+)
+
+func (s *PaymentRequestStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = PaymentRequestStatus(string(v))
+	case string:
+		*s = PaymentRequestStatus(v)
+	}
+	return nil
+}
+
+func (s PaymentRequestStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// PaymentRequest asks the owner of a wallet to pay an amount into the
+// requester's wallet in the same currency. Accepting it makes an ordinary
+// transfer from the payer to the requester.
+type PaymentRequest struct {
+	ID                    uuid.UUID            `json:"id" db:"id"`
+	RequesterUserID       uuid.UUID            `json:"requester_user_id" db:"requester_user_id"`
+	RequesterWalletID     uuid.UUID            `json:"-" db:"requester_wallet_id"`
+	RequesterWalletNumber string               `json:"requester_wallet_number" db:"requester_wallet_number"`
+	PayerUserID           uuid.UUID            `json:"payer_user_id" db:"payer_user_id"`
+	PayerWalletID         uuid.UUID            `json:"-" db:"payer_wallet_id"`
+	PayerWalletNumber     string               `json:"payer_wallet_number" db:"payer_wallet_number"`
+	Amount                Money                `json:"amount" db:"amount"`
+	Currency              Currency             `json:"currency" db:"currency"`
+	Memo                  *string              `json:"memo,omitempty" db:"memo"`
+	Status                PaymentRequestStatus `json:"status" db:"status"`
+	ExpiresAt             time.Time            `json:"expires_at" db:"expires_at"`
+	RespondedAt           *time.Time           `json:"responded_at,omitempty" db:"responded_at"`
+	// The payer's debit, once the request has been accepted
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the request's currency on its amount after it has been
+// read from the database
+func (r *PaymentRequest) ApplyCurrency() {
+	r.Amount.Currency = r.Currency
+}
+
+// IsPayable reports whether the request can still be accepted
+func (r *PaymentRequest) IsPayable(now time.Time) bool {
+	return r.Status == PaymentRequestStatusPending && now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PayoutBatchMode decides what happens to a bulk payout when one row fails
+type PayoutBatchMode string
+
+const (
+	// Every row is paid or none is
+	PayoutBatchModeAllOrNothing PayoutBatchMode = "all_or_nothing"
+	// Rows are paid one at a time and a failed row does not stop the rest
+	PayoutBatchModeBestEffort PayoutBatchMode = "best_effort"
+)
+
+func (m *PayoutBatchMode) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*m = PayoutBatchMode(string(v))
+	case string:
+		*m = PayoutBatchMode(v)
+	}
+	return nil
+}
+
+func (m PayoutBatchMode) Value() (driver.Value, error) {
+	return string(m), nil
+}
+
+// IsValid reports whether m is a known mode
+func (m PayoutBatchMode) IsValid() bool {
+	return m == PayoutBatchModeAllOrNothing || m == PayoutBatchModeBestEffort
+}
+
+// PayoutBatchStatus tracks a bulk payout through processing
+type PayoutBatchStatus string
+
+const (
+	// Accepted and waiting to be processed
+	PayoutBatchStatusPending PayoutBatchStatus = "pending"
+	// Rows are being paid
+	PayoutBatchStatusProcessing PayoutBatchStatus = "processing"
+	// Every row was paid
+	PayoutBatchStatusCompleted PayoutBatchStatus = "completed"
+	// Some rows of a best-effort batch were paid and some failed
+	PayoutBatchStatusPartiallyCompleted PayoutBatchStatus = "partially_completed"
+	// No row was paid
+	PayoutBatchStatusFailed PayoutBatchStatus = "failed"
+)
+
+func (s *PayoutBatchStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = PayoutBatchStatus(string(v))
+	case string:
+		*s = PayoutBatchStatus(v)
+	}
+	return nil
+}
+
+func (s PayoutBatchStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// PayoutItemStatus is the outcome of one row of a bulk payout
+type PayoutItemStatus string
+
+const (
+	PayoutItemStatusPending PayoutItemStatus = "pending"
+	PayoutItemStatusSuccess PayoutItemStatus = "success"
+	PayoutItemStatusFailed  PayoutItemStatus = "failed"
+	// Not paid because another row of an all-or-nothing batch failed
+	PayoutItemStatusSkipped PayoutItemStatus = "skipped"
+)
+
+func (s *PayoutItemStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = PayoutItemStatus(string(v))
+	case string:
+		*s = PayoutItemStatus(v)
+	}
+	return nil
+}
+
+func (s PayoutItemStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// PayoutBatch is a list of transfers from one wallet submitted together.
+// Each row is paid as its own transfer; the batch tracks how far it has got.
+type PayoutBatch struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	UserID       uuid.UUID         `json:"user_id" db:"user_id"`
+	WalletID     uuid.UUID         `json:"-" db:"wallet_id"`
+	WalletNumber string            `json:"wallet_number" db:"wallet_number"`
+	Currency     Currency          `json:"currency" db:"currency"`
+	Mode         PayoutBatchMode   `json:"mode" db:"mode"`
+	Status       PayoutBatchStatus `json:"status" db:"status"`
+	// The caller's own reference, e.g. a payroll run
+	Reference *string `json:"reference,omitempty" db:"reference"`
+	ItemCount int     `json:"item_count" db:"item_count"`
+	// Sum of the row amounts and of the fees quoted for them
+	TotalAmount     Money `json:"total_amount" db:"total_amount"`
+	TotalFee        Money `json:"total_fee" db:"total_fee"`
+	SucceededCount  int   `json:"succeeded_count" db:"succeeded_count"`
+	FailedCount     int   `json:"failed_count" db:"failed_count"`
+	SucceededAmount Money `json:"succeeded_amount" db:"succeeded_amount"`
+	// Why an all-or-nothing batch failed
+	Error *string `json:"error,omitempty" db:"error"`
+	// The API key that submitted the batch, if any
+	APIKeyID    *uuid.UUID `json:"api_key_id,omitempty" db:"api_key_id"`
+	LockedUntil *time.Time `json:"-" db:"locked_until"`
+	StartedAt   *time.Time `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the batch's currency on its amounts after it has been
+// read from the database
+func (b *PayoutBatch) ApplyCurrency() {
+	b.TotalAmount.Currency = b.Currency
+	b.TotalFee.Currency = b.Currency
+	b.SucceededAmount.Currency = b.Currency
+}
+
+// IsFinished reports whether every row of the batch has an outcome
+func (b *PayoutBatch) IsFinished() bool {
+	switch b.Status {
+	case PayoutBatchStatusCompleted, PayoutBatchStatusPartiallyCompleted, PayoutBatchStatusFailed:
+		return true
+	}
+	return false
+}
+
+// PayoutItem is one row of a bulk payout
+type PayoutItem struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	BatchID uuid.UUID `json:"batch_id" db:"batch_id"`
+	// Position of the row in the submitted list or CSV, starting at 1
+	RowNumber    int              `json:"row_number" db:"row_number"`
+	WalletNumber string           `json:"wallet_number" db:"wallet_number"`
+	Amount       Money            `json:"amount" db:"amount"`
+	Fee          Money            `json:"fee" db:"fee"`
+	Currency     Currency         `json:"currency" db:"currency"`
+	Narration    *string          `json:"narration,omitempty" db:"narration"`
+	Status       PayoutItemStatus `json:"status" db:"status"`
+	// The sender's debit transaction, once the row is paid
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	Error         *string    `json:"error,omitempty" db:"error"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty" db:"processed_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the item's currency on its amounts after it has been
+// read from the database
+func (i *PayoutItem) ApplyCurrency() {
+	i.Amount.Currency = i.Currency
+	i.Fee.Currency = i.Currency
+}
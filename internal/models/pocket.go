@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pocket is a named part of a wallet's balance set aside for something, e.g.
+// rent. Money in a pocket stays in the wallet's ledger balance but cannot be
+// spent until it is moved back to the main balance. A locked pocket cannot
+// be emptied before LockedUntil.
+type Pocket struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	WalletID     uuid.UUID  `json:"wallet_id" db:"wallet_id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Name         string     `json:"name" db:"name"`
+	Balance      Money      `json:"balance" db:"balance"`
+	Currency     Currency   `json:"currency" db:"currency"`
+	TargetAmount *Money     `json:"target_amount,omitempty" db:"target_amount"`
+	TargetDate   *time.Time `json:"target_date,omitempty" db:"target_date"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the pocket's currency on its amounts after it has been
+// read from the database
+func (p *Pocket) ApplyCurrency() {
+	p.Balance.Currency = p.Currency
+	if p.TargetAmount != nil {
+		p.TargetAmount.Currency = p.Currency
+	}
+}
+
+// IsLocked reports whether money cannot be taken out of the pocket yet
+func (p *Pocket) IsLocked(now time.Time) bool {
+	return p.LockedUntil != nil && now.Before(*p.LockedUntil)
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleType says when a scheduled transfer runs
+type ScheduleType string
+
+const (
+	// Runs once at start_at
+	ScheduleTypeOnce ScheduleType = "once"
+	// Runs whenever a five-field cron expression matches, in UTC
+	ScheduleTypeCron ScheduleType = "cron"
+	// Runs on the same day every month at the time of day of start_at. Days
+	// past the end of a short month run on its last day.
+	ScheduleTypeMonthly ScheduleType = "monthly"
+)
+
+func (t *ScheduleType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = ScheduleType(string(v))
+	case string:
+		*t = ScheduleType(v)
+	}
+	return nil
+}
+
+func (t ScheduleType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// ScheduledTransferStatus tracks the lifecycle of a scheduled transfer
+type ScheduledTransferStatus string
+
+const (
+	// Runs at next_run_at
+	ScheduledTransferStatusActive ScheduledTransferStatus = "active"
+	// Skipped by the worker until it is resumed
+	ScheduledTransferStatusPaused ScheduledTransferStatus = "paused"
+	// A one-off transfer that ran, or a recurring one past its end
+	ScheduledTransferStatusCompleted ScheduledTransferStatus = "completed"
+	ScheduledTransferStatusCancelled ScheduledTransferStatus = "cancelled"
+	// A one-off transfer that used up its attempts
+	ScheduledTransferStatusFailed ScheduledTransferStatus = "failed"
+)
+
+func (s *ScheduledTransferStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = ScheduledTransferStatus(string(v))
+	case string:
+		*s = ScheduledTransferStatus(v)
+	}
+	return nil
+}
+
+func (s ScheduledTransferStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// ScheduledTransferRunStatus is the outcome of one attempt to run a scheduled transfer
+type ScheduledTransferRunStatus string
+
+const (
+	ScheduledTransferRunStatusSuccess ScheduledTransferRunStatus = "success"
+	ScheduledTransferRunStatusFailed  ScheduledTransferRunStatus = "failed"
+)
+
+func (s *ScheduledTransferRunStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = ScheduledTransferRunStatus(string(v))
+	case string:
+		*s = ScheduledTransferRunStatus(v)
+	}
+	return nil
+}
+
+func (s ScheduledTransferRunStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// ScheduledTransfer is a transfer to another wallet that the service makes
+// on the user's behalf, once or on a recurring schedule
+type ScheduledTransfer struct {
+	ID                    uuid.UUID               `json:"id" db:"id"`
+	UserID                uuid.UUID               `json:"user_id" db:"user_id"`
+	WalletID              uuid.UUID               `json:"wallet_id" db:"wallet_id"`
+	RecipientWalletNumber string                  `json:"recipient_wallet_number" db:"recipient_wallet_number"`
+	Amount                Money                   `json:"amount" db:"amount"`
+	Currency              Currency                `json:"currency" db:"currency"`
+	Description           *string                 `json:"description,omitempty" db:"description"`
+	ScheduleType          ScheduleType            `json:"schedule_type" db:"schedule_type"`
+	CronExpression        *string                 `json:"cron_expression,omitempty" db:"cron_expression"`
+	DayOfMonth            *int                    `json:"day_of_month,omitempty" db:"day_of_month"`
+	StartAt               time.Time               `json:"start_at" db:"start_at"`
+	EndAt                 *time.Time              `json:"end_at,omitempty" db:"end_at"`
+	Status                ScheduledTransferStatus `json:"status" db:"status"`
+	NextRunAt             *time.Time              `json:"next_run_at,omitempty" db:"next_run_at"`
+	LastRunAt             *time.Time              `json:"last_run_at,omitempty" db:"last_run_at"`
+	Attempts              int                     `json:"attempts" db:"attempts"` // Failed attempts at the current run
+	// The API key that scheduled the transfer, if any
+	APIKeyID    *uuid.UUID `json:"api_key_id,omitempty" db:"api_key_id"`
+	LockedUntil *time.Time `json:"-" db:"locked_until"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyCurrency sets the scheduled transfer's currency on its amount after it
+// has been read from the database
+func (st *ScheduledTransfer) ApplyCurrency() {
+	st.Amount.Currency = st.Currency
+}
+
+// ScheduledTransferRun records one attempt to run a scheduled transfer
+type ScheduledTransferRun struct {
+	ID                  uuid.UUID                  `json:"id" db:"id"`
+	ScheduledTransferID uuid.UUID                  `json:"scheduled_transfer_id" db:"scheduled_transfer_id"`
+	Attempt             int                        `json:"attempt" db:"attempt"`
+	Status              ScheduledTransferRunStatus `json:"status" db:"status"`
+	TransactionID       *uuid.UUID                 `json:"transaction_id,omitempty" db:"transaction_id"`
+	Error               *string                    `json:"error,omitempty" db:"error"`
+	WillRetry           bool                       `json:"will_retry" db:"will_retry"`
+	ScheduledFor        time.Time                  `json:"scheduled_for" db:"scheduled_for"`
+	CreatedAt           time.Time                  `json:"created_at" db:"created_at"`
+}
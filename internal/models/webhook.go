@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventStatus tracks how far a stored webhook delivery has been processed
+type WebhookEventStatus string
+
+const (
+	// Stored but not processed yet
+	WebhookEventStatusReceived  WebhookEventStatus = "received"
+	WebhookEventStatusProcessed WebhookEventStatus = "processed"
+	// Processing failed; the retry worker picks it up at next_attempt_at
+	WebhookEventStatusFailed WebhookEventStatus = "failed"
+	// An event type the service does not act on
+	WebhookEventStatusIgnored WebhookEventStatus = "ignored"
+	// Bad signature or unreadable payload; never processed
+	WebhookEventStatusRejected WebhookEventStatus = "rejected"
+)
+
+func (s *WebhookEventStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = WebhookEventStatus(string(v))
+	case string:
+		*s = WebhookEventStatus(v)
+	}
+	return nil
+}
+
+func (s WebhookEventStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// WebhookEvent is an inbound webhook delivery exactly as it was received
+type WebhookEvent struct {
+	ID             uuid.UUID          `json:"id" db:"id"`
+	Provider       PaymentProvider    `json:"provider" db:"provider"`
+	Event          *string            `json:"event,omitempty" db:"event"`
+	Reference      *string            `json:"reference,omitempty" db:"reference"`
+	RawBody        string             `json:"raw_body" db:"raw_body"`
+	Signature      *string            `json:"-" db:"signature"`
+	SignatureValid bool               `json:"signature_valid" db:"signature_valid"`
+	Status         WebhookEventStatus `json:"status" db:"status"`
+	Attempts       int                `json:"attempts" db:"attempts"`
+	LastError      *string            `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  *time.Time         `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	ProcessedAt    *time.Time         `json:"processed_at,omitempty" db:"processed_at"`
+	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
+}
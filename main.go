@@ -1,16 +1,32 @@
 package main
 
 import (
+	"context"
 	"log"
+	"strings"
 
 	"github.com/brainox/paystack_wallet_service/internal/config"
+	"github.com/brainox/paystack_wallet_service/internal/models"
 	"github.com/brainox/paystack_wallet_service/pkg/handlers"
 	"github.com/brainox/paystack_wallet_service/pkg/router"
+	"github.com/brainox/paystack_wallet_service/services/alias"
 	"github.com/brainox/paystack_wallet_service/services/auth"
 	"github.com/brainox/paystack_wallet_service/services/database"
+	"github.com/brainox/paystack_wallet_service/services/fees"
+	"github.com/brainox/paystack_wallet_service/services/flutterwave"
+	"github.com/brainox/paystack_wallet_service/services/fx"
+	"github.com/brainox/paystack_wallet_service/services/idempotency"
+	"github.com/brainox/paystack_wallet_service/services/kyc"
+	"github.com/brainox/paystack_wallet_service/services/ledger"
+	"github.com/brainox/paystack_wallet_service/services/notification"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+	"github.com/brainox/paystack_wallet_service/services/paymentrequest"
 	"github.com/brainox/paystack_wallet_service/services/paystack"
 	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/brainox/paystack_wallet_service/services/scheduledtransfer"
+	"github.com/brainox/paystack_wallet_service/services/scheduler"
 	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/brainox/paystack_wallet_service/services/webhook"
 	"github.com/joho/godotenv"
 )
 
@@ -39,11 +55,72 @@ func main() {
 	walletRepo := repository.NewWalletRepository(database.DB)
 	transactionRepo := repository.NewTransactionRepository(database.DB)
 	apiKeyRepo := repository.NewAPIKeyRepository(database.DB)
+	ledgerRepo := repository.NewLedgerRepository(database.DB)
+	idempotencyRepo := repository.NewIdempotencyRepository(database.DB)
+	webhookEventRepo := repository.NewWebhookEventRepository(database.DB)
+	holdRepo := repository.NewHoldRepository(database.DB)
+	scheduledTransferRepo := repository.NewScheduledTransferRepository(database.DB)
+	kycRepo := repository.NewKYCRepository(database.DB)
+	fxQuoteRepo := repository.NewFXQuoteRepository(database.DB)
+	pocketRepo := repository.NewPocketRepository(database.DB)
+	paymentRequestRepo := repository.NewPaymentRequestRepository(database.DB)
+	escrowRepo := repository.NewEscrowRepository(database.DB)
+	payoutRepo := repository.NewPayoutRepository(database.DB)
+	beneficiaryRepo := repository.NewBeneficiaryRepository(database.DB)
+	aliasRepo := repository.NewAliasRepository(database.DB)
+	limitOverrideRepo := repository.NewLimitOverrideRepository(database.DB)
 
 	// Initialize services
 	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.ExpiryDuration)
 	apiKeyService := auth.NewAPIKeyService(apiKeyRepo)
-	paystackService := paystack.NewPaystackService(cfg.Paystack.SecretKey)
+	paystackService := paystack.NewPaystackService(cfg.Paystack.SecretKey, cfg.Paystack.BaseURL)
+
+	// Payment providers for deposits; Paystack is always available
+	providers := payment.NewRegistry(paystackService)
+	if cfg.Flutterwave.Enabled() {
+		providers.Register(flutterwave.NewFlutterwaveService(
+			cfg.Flutterwave.SecretKey,
+			cfg.Flutterwave.SecretHash,
+			cfg.Flutterwave.RedirectURL,
+		))
+	}
+	if err := providers.SetDefault(models.PaymentProvider(cfg.Payment.DefaultProvider)); err != nil {
+		log.Fatalf("Invalid DEFAULT_PAYMENT_PROVIDER: %v", err)
+	}
+	feeSchedule, err := fees.LoadSchedule(cfg.Fees.ScheduleFile)
+	if err != nil {
+		log.Fatalf("Invalid FEE_SCHEDULE_FILE: %v", err)
+	}
+	kycVerifier, err := kyc.NewVerifier(cfg.KYC.Verifier)
+	if err != nil {
+		log.Fatalf("Invalid KYC_VERIFIER: %v", err)
+	}
+	kycTiers := kyc.Tiers{}
+	for tier, limits := range cfg.KYC.Tiers {
+		kycTiers[models.KYCTier(tier)] = kyc.TierLimits{
+			MaxBalance:   limits.MaxBalance,
+			DailyOutflow: limits.DailyLimit,
+		}
+	}
+	var otpSender alias.OTPSender
+	if cfg.Aliases.PhoneVerificationEnabled() {
+		otpSender, err = alias.NewOTPSender(cfg.Aliases.OTPSender)
+		if err != nil {
+			log.Fatalf("Invalid ALIAS_OTP_SENDER: %v", err)
+		}
+	} else {
+		log.Println("ALIAS_OTP_SENDER is not set; phone aliases are disabled")
+	}
+	fxRates, err := fx.NewRateProvider(cfg.FX.RateProvider, cfg.FX.RatesFile)
+	if err != nil {
+		log.Fatalf("Invalid FX rate configuration: %v", err)
+	}
+	fxQuoter, err := fx.NewQuoter(fxRates, int64(cfg.FX.SpreadBPS), cfg.FX.QuoteTTL)
+	if err != nil {
+		log.Fatalf("Invalid FX configuration: %v", err)
+	}
+	ledgerService := ledger.NewLedgerService(database.DB, ledgerRepo, walletRepo)
+	idempotencyService := idempotency.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.KeyTTL, cfg.Idempotency.LockLease)
 
 	googleAuthService := auth.NewGoogleAuthService(
 		&cfg.Google,
@@ -56,26 +133,207 @@ func main() {
 		database.DB,
 		walletRepo,
 		transactionRepo,
+		holdRepo,
+		pocketRepo,
+		fxQuoteRepo,
+		escrowRepo,
+		payoutRepo,
+		beneficiaryRepo,
 		userRepo,
+		limitOverrideRepo,
+		ledgerService,
 		paystackService,
+		providers,
+		feeSchedule,
+		wallet.Limits{
+			MaxTransferAmount:   cfg.Limits.MaxTransferAmount,
+			DailyOutflow:        cfg.Limits.DailyOutflow,
+			MonthlyOutflow:      cfg.Limits.MonthlyOutflow,
+			APIKeyDailySpend:    cfg.Limits.APIKeyDailySpend,
+			MaxTransfersPerHour: cfg.Limits.MaxTransfersPerHour,
+		},
+		kycTiers,
+		fxQuoter,
+		wallet.ReversalPolicy{AllowForceNegative: cfg.Admin.AllowForceNegativeReversals},
+	)
+
+	scheduledTransferService := scheduledtransfer.NewScheduledTransferService(
+		database.DB,
+		scheduledTransferRepo,
+		walletRepo,
+		userRepo,
+		walletService,
+		notification.NewLogNotifier(),
+		scheduledtransfer.RetryPolicy{
+			MaxAttempts: cfg.ScheduledTransfers.MaxAttempts,
+			RetryDelay:  cfg.ScheduledTransfers.RetryDelay,
+		},
+	)
+
+	paymentRequestService := paymentrequest.NewPaymentRequestService(
+		paymentRequestRepo,
+		walletRepo,
+		userRepo,
+		walletService,
+		notification.NewLogNotifier(),
+		paymentrequest.Policy{
+			DefaultTTL: cfg.PaymentRequests.TTL,
+			MaxTTL:     cfg.PaymentRequests.MaxTTL,
+		},
+	)
+
+	kycService := kyc.NewKYCService(
+		database.DB,
+		kycRepo,
+		userRepo,
+		kycVerifier,
+		kycTiers,
+	)
+
+	aliasService := alias.NewAliasService(
+		database.DB,
+		aliasRepo,
+		walletRepo,
+		otpSender,
+		[]byte(cfg.Aliases.OTPSecret),
+		alias.NewBlocklist(strings.Split(cfg.Aliases.ReservedWords, ",")),
+		alias.Policy{
+			ReleaseCooldown:      cfg.Aliases.ReleaseCooldown,
+			HandleChangeInterval: cfg.Aliases.HandleChangeInterval,
+			OTPTTL:               cfg.Aliases.OTPTTL,
+			OTPResendInterval:    cfg.Aliases.OTPResendInterval,
+			MaxOTPAttempts:       cfg.Aliases.MaxOTPAttempts,
+		},
+	)
+
+	webhookService := webhook.NewWebhookService(
+		webhookEventRepo,
+		walletService,
+		providers,
+		webhook.RetryPolicy{
+			MaxAttempts: cfg.Webhook.MaxAttempts,
+			BaseDelay:   cfg.Webhook.RetryBaseDelay,
+			MaxDelay:    cfg.Webhook.RetryMaxDelay,
+		},
 	)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(googleAuthService)
 	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
-	walletHandler := handlers.NewWalletHandler(walletService, paystackService)
+	walletHandler := handlers.NewWalletHandler(walletService, aliasService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	scheduledTransferHandler := handlers.NewScheduledTransferHandler(scheduledTransferService)
+	kycHandler := handlers.NewKYCHandler(kycService)
+	paymentRequestHandler := handlers.NewPaymentRequestHandler(paymentRequestService)
+	aliasHandler := handlers.NewAliasHandler(aliasService)
 
 	// Setup router
 	walletRouter := router.NewWalletRouter(
 		authHandler,
 		apiKeyHandler,
 		walletHandler,
+		webhookHandler,
+		scheduledTransferHandler,
+		kycHandler,
+		paymentRequestHandler,
+		aliasHandler,
 		jwtService,
 		apiKeyService,
+		idempotencyService,
+		cfg.Admin.APIKey,
 	)
 
 	r := walletRouter.Setup()
 
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := scheduler.NewScheduler()
+	jobs.Every("purge-idempotency-keys", cfg.Idempotency.CleanupInterval, func(ctx context.Context) error {
+		purged, err := idempotencyService.PurgeExpired()
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("Purged %d expired idempotency keys", purged)
+		}
+		return nil
+	})
+	jobs.Every("retry-webhook-events", cfg.Webhook.RetryInterval, webhookService.RetryDue)
+	jobs.Every("reconcile-deposits", cfg.Reconciliation.Interval, scheduler.Exclusive(database.DB, "reconcile-deposits", func(ctx context.Context) error {
+		checked, err := walletService.ReconcilePendingDeposits(ctx, cfg.Reconciliation.MinAge, cfg.Reconciliation.DepositTTL)
+		if err != nil {
+			return err
+		}
+		if checked > 0 {
+			log.Printf("Reconciled %d pending deposits", checked)
+		}
+		return nil
+	}))
+	jobs.Every("reconcile-withdrawals", cfg.Reconciliation.Interval, scheduler.Exclusive(database.DB, "reconcile-withdrawals", func(ctx context.Context) error {
+		checked, err := walletService.ReconcilePendingWithdrawals(ctx, cfg.Reconciliation.MinAge)
+		if err != nil {
+			return err
+		}
+		if checked > 0 {
+			log.Printf("Reconciled %d pending withdrawals", checked)
+		}
+		return nil
+	}))
+	jobs.Every("reconcile-refunds", cfg.Reconciliation.Interval, scheduler.Exclusive(database.DB, "reconcile-refunds", func(ctx context.Context) error {
+		checked, err := walletService.ReconcilePendingRefunds(ctx, cfg.Reconciliation.MinAge)
+		if err != nil {
+			return err
+		}
+		if checked > 0 {
+			log.Printf("Reconciled %d pending refunds", checked)
+		}
+		return nil
+	}))
+	jobs.Every("expire-holds", cfg.Holds.ExpiryInterval, func(ctx context.Context) error {
+		expired, err := walletService.ExpireHolds()
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			log.Printf("Expired %d holds", expired)
+		}
+		return nil
+	})
+	jobs.Every("release-escrows", cfg.Escrow.ReleaseInterval, func(ctx context.Context) error {
+		released, err := walletService.ReleaseDueEscrows(ctx)
+		if err != nil {
+			return err
+		}
+		if released > 0 {
+			log.Printf("Auto-released %d escrows", released)
+		}
+		return nil
+	})
+	jobs.Every("process-payouts", cfg.Payouts.Interval, func(ctx context.Context) error {
+		finished, err := walletService.ProcessPayouts(ctx)
+		if err != nil {
+			return err
+		}
+		if finished > 0 {
+			log.Printf("Finished %d payout batches", finished)
+		}
+		return nil
+	})
+	jobs.Every("run-scheduled-transfers", cfg.ScheduledTransfers.Interval, scheduledTransferService.RunDue)
+	jobs.Every("expire-payment-requests", cfg.PaymentRequests.ExpiryInterval, func(ctx context.Context) error {
+		expired, err := paymentRequestService.ExpireRequests()
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			log.Printf("Expired %d payment requests", expired)
+		}
+		return nil
+	})
+	jobs.Start(ctx)
+
 	// Start server
 	addr := cfg.Server.Host + ":" + cfg.Server.Port
 	log.Printf("Starting server on %s", addr)
@@ -0,0 +1,21 @@
+package fakepaystack
+
+import "github.com/brainox/paystack_wallet_service/external/external_models"
+
+// Banks returned by GET /bank. Codes match the real Paystack codes so that
+// test fixtures work against either.
+var fakeBanks = []external_models.Bank{
+	{ID: 1, Name: "Access Bank", Slug: "access-bank", Code: "044", LongCode: "044150149", Active: true, Country: "Nigeria", Currency: "NGN", Type: "nuban"},
+	{ID: 9, Name: "First Bank of Nigeria", Slug: "first-bank-of-nigeria", Code: "011", LongCode: "011151003", Active: true, Country: "Nigeria", Currency: "NGN", Type: "nuban"},
+	{ID: 7, Name: "Guaranty Trust Bank", Slug: "guaranty-trust-bank", Code: "058", LongCode: "058152036", Active: true, Country: "Nigeria", Currency: "NGN", Type: "nuban"},
+	{ID: 21, Name: "Zenith Bank", Slug: "zenith-bank", Code: "057", LongCode: "057150013", Active: true, Country: "Nigeria", Currency: "NGN", Type: "nuban"},
+}
+
+func findBank(code string) (external_models.Bank, bool) {
+	for _, bank := range fakeBanks {
+		if bank.Code == code {
+			return bank, true
+		}
+	}
+	return external_models.Bank{}, false
+}
@@ -0,0 +1,713 @@
+// Package fakepaystack is an in-memory stand-in for the Paystack API. It
+// implements the endpoints the wallet service calls and signs its webhooks
+// the way Paystack does, so the deposit, withdrawal and refund flows can be
+// run end to end without network access.
+package fakepaystack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/external/external_models"
+	"github.com/gin-gonic/gin"
+)
+
+// Delay before an automatic webhook is sent, so that the caller has stored
+// the result of the request that triggered it
+const autoCompleteDelay = 500 * time.Millisecond
+
+type Config struct {
+	// Secret key clients must authenticate with; webhooks are signed with it
+	SecretKey string
+	// URL of the fake server as seen by clients, used in authorization URLs
+	PublicURL string
+	// URL webhooks are sent to, e.g. http://localhost:8080/webhooks/paystack
+	WebhookURL string
+	// AutoComplete settles transfers and refunds with a webhook shortly after
+	// they are created. Without it they stay pending until completed through
+	// the /_fake endpoints.
+	AutoComplete bool
+}
+
+// Charge is a transaction created through /transaction/initialize
+type Charge struct {
+	ID        int64      `json:"id"`
+	Reference string     `json:"reference"`
+	Email     string     `json:"email"`
+	Amount    int64      `json:"amount"`
+	Currency  string     `json:"currency"`
+	Status    string     `json:"status"`
+	Refunded  int64      `json:"refunded"`
+	PaidAt    *time.Time `json:"paid_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Recipient is a bank account created through /transferrecipient
+type Recipient struct {
+	RecipientCode string `json:"recipient_code"`
+	Name          string `json:"name"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+	Currency      string `json:"currency"`
+}
+
+// Transfer is a payout created through /transfer
+type Transfer struct {
+	ID           int64     `json:"id"`
+	Reference    string    `json:"reference"`
+	TransferCode string    `json:"transfer_code"`
+	Recipient    string    `json:"recipient"`
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	Reason       string    `json:"reason"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Refund is a refund created through /refund
+type Refund struct {
+	ID                   int64     `json:"id"`
+	TransactionReference string    `json:"transaction_reference"`
+	Amount               int64     `json:"amount"`
+	Currency             string    `json:"currency"`
+	Status               string    `json:"status"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// refundRequest is the body of POST /refund. Paystack accepts either the
+// transaction ID or its reference.
+type refundRequest struct {
+	Transaction json.RawMessage `json:"transaction"`
+	Amount      int64           `json:"amount"`
+	Currency    string          `json:"currency"`
+}
+
+type Server struct {
+	cfg    Config
+	router *gin.Engine
+	client *http.Client
+
+	mu         sync.Mutex
+	nextID     int64
+	charges    map[string]*Charge
+	recipients map[string]*Recipient
+	transfers  map[string]*Transfer
+	refunds    map[int64]*Refund
+}
+
+func NewServer(cfg Config) *Server {
+	s := &Server{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		nextID:     1000,
+		charges:    make(map[string]*Charge),
+		recipients: make(map[string]*Recipient),
+		transfers:  make(map[string]*Transfer),
+		refunds:    make(map[int64]*Refund),
+	}
+	s.router = s.routes()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Paystack API
+	api := r.Group("/")
+	api.Use(s.authenticate)
+	{
+		api.POST("/transaction/initialize", s.initializeTransaction)
+		api.GET("/transaction/verify/:reference", s.verifyTransaction)
+		api.GET("/bank", s.listBanks)
+		api.GET("/bank/resolve", s.resolveAccount)
+		api.POST("/transferrecipient", s.createRecipient)
+		api.POST("/transfer", s.initiateTransfer)
+		api.GET("/transfer/verify/:reference", s.verifyTransfer)
+		api.POST("/refund", s.createRefund)
+		api.GET("/refund", s.listRefunds)
+	}
+
+	// Hosted checkout page that the authorization URL points at
+	r.GET("/checkout/:reference", s.checkout)
+
+	// Controls for driving the fake from tests
+	fake := r.Group("/_fake")
+	{
+		fake.POST("/charges/:reference", s.completeCharge)
+		fake.POST("/transfers/:reference", s.completeTransfer)
+		fake.POST("/refunds/:id", s.completeRefund)
+		fake.POST("/webhooks", s.sendCustomWebhook)
+	}
+
+	return r
+}
+
+// authenticate checks the bearer secret key like Paystack does
+func (s *Server) authenticate(c *gin.Context) {
+	if c.GetHeader("Authorization") != "Bearer "+s.cfg.SecretKey {
+		fail(c, http.StatusUnauthorized, "Invalid key")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func (s *Server) initializeTransaction(c *gin.Context) {
+	var req external_models.InitializeTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Email == "" {
+		fail(c, http.StatusBadRequest, "Email is required")
+		return
+	}
+	if req.Amount <= 0 {
+		fail(c, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Reference == "" {
+		req.Reference = fmt.Sprintf("FAKE_%d", s.newID())
+	}
+	if _, exists := s.charges[req.Reference]; exists {
+		fail(c, http.StatusBadRequest, "Duplicate Transaction Reference")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "NGN"
+	}
+
+	// Paystack reports a checkout nobody has paid as abandoned
+	charge := &Charge{
+		ID:        s.newID(),
+		Reference: req.Reference,
+		Email:     req.Email,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		Status:    "abandoned",
+		CreatedAt: time.Now().UTC(),
+	}
+	s.charges[charge.Reference] = charge
+
+	succeed(c, http.StatusOK, "Authorization URL created", gin.H{
+		"authorization_url": fmt.Sprintf("%s/checkout/%s", strings.TrimRight(s.cfg.PublicURL, "/"), charge.Reference),
+		"access_code":       fmt.Sprintf("ACS_%d", charge.ID),
+		"reference":         charge.Reference,
+	})
+}
+
+func (s *Server) verifyTransaction(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charge, ok := s.charges[c.Param("reference")]
+	if !ok {
+		fail(c, http.StatusBadRequest, "Transaction reference not found")
+		return
+	}
+
+	succeed(c, http.StatusOK, "Verification successful", chargeData(charge))
+}
+
+func (s *Server) listBanks(c *gin.Context) {
+	currency := c.DefaultQuery("currency", "NGN")
+	banks := []external_models.Bank{}
+	for _, bank := range fakeBanks {
+		if bank.Currency == currency {
+			banks = append(banks, bank)
+		}
+	}
+	succeed(c, http.StatusOK, "Banks retrieved", banks)
+}
+
+// resolveAccount resolves any ten-digit account number at a known bank
+func (s *Server) resolveAccount(c *gin.Context) {
+	accountNumber := c.Query("account_number")
+	bank, ok := findBank(c.Query("bank_code"))
+	if !ok || len(accountNumber) != 10 {
+		fail(c, http.StatusUnprocessableEntity, "Could not resolve account name. Check parameters or try again.")
+		return
+	}
+
+	succeed(c, http.StatusOK, "Account number resolved", gin.H{
+		"account_number": accountNumber,
+		"account_name":   "FAKE ACCOUNT " + accountNumber[6:],
+		"bank_id":        bank.ID,
+	})
+}
+
+func (s *Server) createRecipient(c *gin.Context) {
+	var req external_models.CreateTransferRecipientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	bank, ok := findBank(req.BankCode)
+	if !ok {
+		fail(c, http.StatusBadRequest, "Invalid bank code")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recipient := &Recipient{
+		RecipientCode: fmt.Sprintf("RCP_%d", s.newID()),
+		Name:          req.Name,
+		AccountNumber: req.AccountNumber,
+		BankCode:      req.BankCode,
+		Currency:      req.Currency,
+	}
+	s.recipients[recipient.RecipientCode] = recipient
+
+	succeed(c, http.StatusCreated, "Transfer recipient created successfully", gin.H{
+		"recipient_code": recipient.RecipientCode,
+		"name":           recipient.Name,
+		"details": gin.H{
+			"account_number": recipient.AccountNumber,
+			"account_name":   recipient.Name,
+			"bank_code":      recipient.BankCode,
+			"bank_name":      bank.Name,
+		},
+	})
+}
+
+func (s *Server) initiateTransfer(c *gin.Context) {
+	var req external_models.InitiateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Amount <= 0 {
+		fail(c, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recipients[req.Recipient]; !ok {
+		fail(c, http.StatusBadRequest, "Recipient specified is invalid")
+		return
+	}
+	if req.Reference == "" {
+		req.Reference = fmt.Sprintf("FAKE_TRF_%d", s.newID())
+	}
+	if _, exists := s.transfers[req.Reference]; exists {
+		fail(c, http.StatusBadRequest, "Duplicate Transfer Reference")
+		return
+	}
+
+	id := s.newID()
+	transfer := &Transfer{
+		ID:           id,
+		Reference:    req.Reference,
+		TransferCode: fmt.Sprintf("TRF_%d", id),
+		Recipient:    req.Recipient,
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+		Reason:       req.Reason,
+		Status:       "pending",
+		CreatedAt:    time.Now().UTC(),
+	}
+	s.transfers[transfer.Reference] = transfer
+
+	if s.cfg.AutoComplete {
+		s.later(func() error { return s.settleTransfer(transfer.Reference, "success") })
+	}
+
+	succeed(c, http.StatusOK, "Transfer has been queued", transferData(transfer))
+}
+
+func (s *Server) verifyTransfer(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, ok := s.transfers[c.Param("reference")]
+	if !ok {
+		fail(c, http.StatusNotFound, "Transfer not found")
+		return
+	}
+
+	succeed(c, http.StatusOK, "Transfer retrieved", transferData(transfer))
+}
+
+func (s *Server) createRefund(c *gin.Context) {
+	var req refundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charge := s.findCharge(req.Transaction)
+	if charge == nil {
+		fail(c, http.StatusNotFound, "Transaction not found")
+		return
+	}
+	if charge.Status != "success" {
+		fail(c, http.StatusBadRequest, "Transaction has not been paid")
+		return
+	}
+
+	remaining := charge.Amount - charge.Refunded
+	amount := req.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		fail(c, http.StatusBadRequest, "Refund amount cannot be greater than the unrefunded transaction amount")
+		return
+	}
+	if req.Currency != "" && req.Currency != charge.Currency {
+		fail(c, http.StatusBadRequest, "Currency does not match the transaction currency")
+		return
+	}
+
+	// The amount is reserved as soon as the refund is created
+	charge.Refunded += amount
+	refund := &Refund{
+		ID:                   s.newID(),
+		TransactionReference: charge.Reference,
+		Amount:               amount,
+		Currency:             charge.Currency,
+		Status:               "pending",
+		CreatedAt:            time.Now().UTC(),
+	}
+	s.refunds[refund.ID] = refund
+
+	if s.cfg.AutoComplete {
+		s.later(func() error { return s.settleRefund(refund.ID, "processed") })
+	}
+
+	succeed(c, http.StatusOK, "Refund has been queued for processing", refundData(refund, charge))
+}
+
+// listRefunds lists the refunds against the charge named by ?transaction=
+func (s *Server) listRefunds(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charge := s.findCharge(json.RawMessage(strconv.Quote(c.Query("transaction"))))
+	if charge == nil {
+		fail(c, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	refunds := []gin.H{}
+	for _, refund := range s.refunds {
+		if refund.TransactionReference == charge.Reference {
+			refunds = append(refunds, refundData(refund, charge))
+		}
+	}
+	succeed(c, http.StatusOK, "Refunds retrieved", refunds)
+}
+
+// checkout stands in for the hosted payment page: opening the authorization
+// URL pays the charge, or fails it with ?outcome=failed
+func (s *Server) checkout(c *gin.Context) {
+	outcome := c.DefaultQuery("outcome", "success")
+	if err := s.settleCharge(c.Param("reference"), outcome); err != nil {
+		c.String(http.StatusBadRequest, "Payment not completed: %v\n", err)
+		return
+	}
+	c.String(http.StatusOK, "Payment %s. You can close this page.\n", outcome)
+}
+
+// completeCharge settles a charge with ?outcome=success (default) or failed
+func (s *Server) completeCharge(c *gin.Context) {
+	s.respond(c, s.settleCharge(c.Param("reference"), c.DefaultQuery("outcome", "success")))
+}
+
+// completeTransfer settles a transfer with ?outcome=success (default), failed or reversed
+func (s *Server) completeTransfer(c *gin.Context) {
+	s.respond(c, s.settleTransfer(c.Param("reference"), c.DefaultQuery("outcome", "success")))
+}
+
+// completeRefund settles a refund with ?outcome=processed (default) or failed
+func (s *Server) completeRefund(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		fail(c, http.StatusBadRequest, "Invalid refund ID")
+		return
+	}
+	s.respond(c, s.settleRefund(id, c.DefaultQuery("outcome", "processed")))
+}
+
+// sendCustomWebhook signs and sends an arbitrary {"event", "data"} body
+func (s *Server) sendCustomWebhook(c *gin.Context) {
+	var req struct {
+		Event string          `json:"event" binding:"required"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(c, http.StatusBadRequest, "event is required")
+		return
+	}
+	s.respond(c, s.SendWebhook(req.Event, req.Data))
+}
+
+func (s *Server) respond(c *gin.Context, err error) {
+	if err != nil {
+		fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	succeed(c, http.StatusOK, "Webhook sent", nil)
+}
+
+// settleCharge marks a charge paid or failed and sends the matching webhook
+func (s *Server) settleCharge(reference, outcome string) error {
+	var event string
+	switch outcome {
+	case "success":
+		event = "charge.success"
+	case "failed":
+		event = "charge.failed"
+	default:
+		return fmt.Errorf("unknown charge outcome %q", outcome)
+	}
+
+	s.mu.Lock()
+	charge, ok := s.charges[reference]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("charge %s not found", reference)
+	}
+	if charge.Status == "success" {
+		s.mu.Unlock()
+		return fmt.Errorf("charge %s is already paid", reference)
+	}
+	charge.Status = outcome
+	if outcome == "success" {
+		paidAt := time.Now().UTC()
+		charge.PaidAt = &paidAt
+	}
+	data := chargeData(charge)
+	s.mu.Unlock()
+
+	return s.SendWebhook(event, data)
+}
+
+// settleTransfer moves a transfer to its final status and sends the matching webhook
+func (s *Server) settleTransfer(reference, outcome string) error {
+	switch outcome {
+	case "success", "failed", "reversed":
+	default:
+		return fmt.Errorf("unknown transfer outcome %q", outcome)
+	}
+
+	s.mu.Lock()
+	transfer, ok := s.transfers[reference]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("transfer %s not found", reference)
+	}
+	transfer.Status = outcome
+	data := transferData(transfer)
+	s.mu.Unlock()
+
+	return s.SendWebhook("transfer."+outcome, data)
+}
+
+// settleRefund moves a refund to its final status and sends the matching
+// webhook. A failed refund frees the amount to be refunded again.
+func (s *Server) settleRefund(id int64, outcome string) error {
+	switch outcome {
+	case "processed", "failed":
+	default:
+		return fmt.Errorf("unknown refund outcome %q", outcome)
+	}
+
+	s.mu.Lock()
+	refund, ok := s.refunds[id]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("refund %d not found", id)
+	}
+	if refund.Status != "pending" {
+		s.mu.Unlock()
+		return fmt.Errorf("refund %d is already %s", id, refund.Status)
+	}
+	refund.Status = outcome
+	charge := s.charges[refund.TransactionReference]
+	if outcome == "failed" {
+		charge.Refunded -= refund.Amount
+	}
+	data := refundData(refund, charge)
+	s.mu.Unlock()
+
+	return s.SendWebhook("refund."+outcome, data)
+}
+
+// SendWebhook posts an event to the configured webhook URL, signed with the
+// secret key in the x-paystack-signature header
+func (s *Server) SendWebhook(event string, data interface{}) error {
+	if s.cfg.WebhookURL == "" {
+		return fmt.Errorf("no webhook URL configured")
+	}
+
+	body, err := json.Marshal(gin.H{"event": event, "data": data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-paystack-signature", Sign(s.cfg.SecretKey, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook %s was answered with status %d", event, resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA512 signature Paystack sends with a webhook body
+func Sign(secretKey string, body []byte) string {
+	hash := hmac.New(sha512.New, []byte(secretKey))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Charges returns a snapshot of the charges created so far
+func (s *Server) Charges() []Charge {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charges := make([]Charge, 0, len(s.charges))
+	for _, charge := range s.charges {
+		charges = append(charges, *charge)
+	}
+	return charges
+}
+
+// later runs fn after autoCompleteDelay, logging any error
+func (s *Server) later(fn func() error) {
+	time.AfterFunc(autoCompleteDelay, func() {
+		if err := fn(); err != nil {
+			log.Printf("fakepaystack: %v", err)
+		}
+	})
+}
+
+// newID returns the next ID. The caller must hold s.mu.
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// findCharge looks a charge up by ID or reference. The caller must hold s.mu.
+func (s *Server) findCharge(raw json.RawMessage) *Charge {
+	var reference string
+	if err := json.Unmarshal(raw, &reference); err == nil {
+		if charge, ok := s.charges[reference]; ok {
+			return charge
+		}
+	}
+
+	var id int64
+	if err := json.Unmarshal(raw, &id); err != nil {
+		id, _ = strconv.ParseInt(reference, 10, 64)
+	}
+	for _, charge := range s.charges {
+		if charge.ID == id {
+			return charge
+		}
+	}
+	return nil
+}
+
+func chargeData(charge *Charge) gin.H {
+	return gin.H{
+		"id":               charge.ID,
+		"domain":           "test",
+		"status":           charge.Status,
+		"reference":        charge.Reference,
+		"amount":           charge.Amount,
+		"gateway_response": gatewayResponse(charge.Status),
+		"paid_at":          charge.PaidAt,
+		"created_at":       charge.CreatedAt,
+		"channel":          "card",
+		"currency":         charge.Currency,
+		"customer": gin.H{
+			"id":            charge.ID,
+			"email":         charge.Email,
+			"customer_code": fmt.Sprintf("CUS_%d", charge.ID),
+		},
+	}
+}
+
+func transferData(transfer *Transfer) gin.H {
+	return gin.H{
+		"id":            transfer.ID,
+		"domain":        "test",
+		"status":        transfer.Status,
+		"reference":     transfer.Reference,
+		"transfer_code": transfer.TransferCode,
+		"amount":        transfer.Amount,
+		"currency":      transfer.Currency,
+		"reason":        transfer.Reason,
+		"created_at":    transfer.CreatedAt,
+	}
+}
+
+func refundData(refund *Refund, charge *Charge) gin.H {
+	return gin.H{
+		"id":                    refund.ID,
+		"domain":                "test",
+		"status":                refund.Status,
+		"transaction_reference": refund.TransactionReference,
+		"amount":                refund.Amount,
+		"currency":              refund.Currency,
+		"created_at":            refund.CreatedAt,
+		"transaction":           chargeData(charge),
+	}
+}
+
+func gatewayResponse(status string) string {
+	switch status {
+	case "success":
+		return "Successful"
+	case "failed":
+		return "Declined"
+	default:
+		return ""
+	}
+}
+
+func succeed(c *gin.Context, status int, message string, data interface{}) {
+	c.JSON(status, gin.H{"status": true, "message": message, "data": data})
+}
+
+func fail(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{"status": false, "message": message})
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/alias"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AliasHandler struct {
+	aliasService *alias.AliasService
+}
+
+func NewAliasHandler(aliasService *alias.AliasService) *AliasHandler {
+	return &AliasHandler{
+		aliasService: aliasService,
+	}
+}
+
+type ClaimHandleRequest struct {
+	// With or without the leading @
+	Handle string `json:"handle" binding:"required"`
+}
+
+type StartPhoneVerificationRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+type VerifyPhoneRequest struct {
+	VerificationID uuid.UUID `json:"verification_id" binding:"required"`
+	Code           string    `json:"code" binding:"required"`
+}
+
+// GetAliases returns the caller's handle, phone number and released aliases
+func (h *AliasHandler) GetAliases(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	aliases, err := h.aliasService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aliases)
+}
+
+// CheckHandle reports whether the caller could claim a handle, and why not
+func (h *AliasHandler) CheckHandle(c *gin.Context) {
+	handle := c.Query("handle")
+	if handle == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "handle is required"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	availability, err := h.aliasService.CheckHandle(userID, handle)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// ClaimHandle makes a handle the caller's, in place of their current one
+func (h *AliasHandler) ClaimHandle(c *gin.Context) {
+	var req ClaimHandleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	claimed, err := h.aliasService.ClaimHandle(userID, req.Handle)
+	if err != nil {
+		respondAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claimed)
+}
+
+// ReleaseHandle gives up the caller's handle
+func (h *AliasHandler) ReleaseHandle(c *gin.Context) {
+	h.release(c, models.AliasTypeHandle)
+}
+
+// StartPhoneVerification sends a one-time code to a phone number the caller
+// wants as their alias
+func (h *AliasHandler) StartPhoneVerification(c *gin.Context) {
+	var req StartPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	verification, err := h.aliasService.StartPhoneVerification(userID, req.Phone)
+	if err != nil {
+		respondAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, verification)
+}
+
+// VerifyPhone checks the code sent to a phone number and, if it is right,
+// makes the number the caller's alias
+func (h *AliasHandler) VerifyPhone(c *gin.Context) {
+	var req VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	claimed, err := h.aliasService.VerifyPhone(userID, req.VerificationID, req.Code)
+	if err != nil {
+		respondAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claimed)
+}
+
+// ReleasePhone gives up the caller's phone alias
+func (h *AliasHandler) ReleasePhone(c *gin.Context) {
+	h.release(c, models.AliasTypePhone)
+}
+
+func (h *AliasHandler) release(c *gin.Context, aliasType models.AliasType) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.aliasService.Release(userID, aliasType); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alias released"})
+}
+
+// respondAliasError answers 409 for an alias someone else holds or that is
+// reserved, 403 when phone aliases are disabled, and 400 for everything else
+func respondAliasError(c *gin.Context, err error) {
+	if errors.Is(err, alias.ErrPhoneVerificationDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	var unavailableErr *alias.UnavailableError
+	if errors.As(err, &unavailableErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateBeneficiaryRequest struct {
+	// "wallet" or "bank_account"
+	Type     string `json:"type" binding:"required"`
+	Nickname string `json:"nickname" binding:"required"`
+	// For wallet beneficiaries
+	WalletNumber string `json:"wallet_number"`
+	// For bank account beneficiaries
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number" binding:"omitempty,len=10,numeric"`
+}
+
+type UpdateBeneficiaryRequest struct {
+	Nickname string `json:"nickname" binding:"required"`
+}
+
+// ResolveWalletNumber returns the masked name of a wallet's owner so the
+// caller can check who they are about to pay. The wallet is given by its
+// number, or by an alias and currency.
+func (h *WalletHandler) ResolveWalletNumber(c *gin.Context) {
+	walletNumber, aliasValue := c.Query("wallet_number"), c.Query("alias")
+	switch {
+	case walletNumber != "" && aliasValue != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide either wallet_number or alias, not both"})
+		return
+	case aliasValue != "":
+		currency, err := models.ParseCurrency(c.Query("currency"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		walletNumber, err = h.aliasService.ResolveWalletNumber(aliasValue, currency)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	case walletNumber == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "wallet_number or alias is required"})
+		return
+	}
+
+	owner, err := h.walletService.ResolveWalletNumber(walletNumber)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, owner)
+}
+
+// CreateBeneficiary saves a wallet or bank account for the caller under a nickname
+func (h *WalletHandler) CreateBeneficiary(c *gin.Context) {
+	var req CreateBeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	beneficiary := &models.Beneficiary{
+		Type:     models.BeneficiaryType(req.Type),
+		Nickname: req.Nickname,
+	}
+	if req.WalletNumber != "" {
+		beneficiary.WalletNumber = &req.WalletNumber
+	}
+	if req.BankCode != "" {
+		beneficiary.BankCode = &req.BankCode
+	}
+	if req.AccountNumber != "" {
+		beneficiary.AccountNumber = &req.AccountNumber
+	}
+
+	beneficiary, err = h.walletService.CreateBeneficiary(userID, beneficiary)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, beneficiary)
+}
+
+// ListBeneficiaries lists the caller's beneficiaries, optionally only those of one type
+func (h *WalletHandler) ListBeneficiaries(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	beneficiaries, err := h.walletService.ListBeneficiaries(userID, c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, beneficiaries)
+}
+
+// GetBeneficiary returns one of the caller's beneficiaries
+func (h *WalletHandler) GetBeneficiary(c *gin.Context) {
+	userID, beneficiaryID, ok := beneficiaryParams(c)
+	if !ok {
+		return
+	}
+
+	beneficiary, err := h.walletService.GetBeneficiary(userID, beneficiaryID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, beneficiary)
+}
+
+// UpdateBeneficiary renames one of the caller's beneficiaries
+func (h *WalletHandler) UpdateBeneficiary(c *gin.Context) {
+	var req UpdateBeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, beneficiaryID, ok := beneficiaryParams(c)
+	if !ok {
+		return
+	}
+
+	beneficiary, err := h.walletService.RenameBeneficiary(userID, beneficiaryID, req.Nickname)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, beneficiary)
+}
+
+// DeleteBeneficiary removes one of the caller's beneficiaries
+func (h *WalletHandler) DeleteBeneficiary(c *gin.Context) {
+	userID, beneficiaryID, ok := beneficiaryParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.walletService.DeleteBeneficiary(userID, beneficiaryID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Beneficiary deleted"})
+}
+
+// beneficiaryParams reads the caller and the beneficiary ID from the path.
+// It writes an error response and returns false if either is missing.
+func beneficiaryParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	beneficiaryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid beneficiary ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, beneficiaryID, true
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateWalletRequest struct {
+	Currency string `json:"currency" binding:"required"`
+}
+
+// CreateWallet opens a wallet in another currency
+func (h *WalletHandler) CreateWallet(c *gin.Context) {
+	var req CreateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := models.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	wallet, err := h.walletService.CreateWallet(userID, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, walletResponse(wallet))
+}
+
+// ListWallets lists the user's wallets, one per currency
+func (h *WalletHandler) ListWallets(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	wallets, err := h.walletService.ListWallets(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(wallets))
+	for i := range wallets {
+		response = append(response, walletResponse(&wallets[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wallets": response})
+}
+
+func walletResponse(wallet *models.Wallet) gin.H {
+	return gin.H{
+		"wallet_number": wallet.WalletNumber,
+		"currency":      wallet.Currency,
+		"balance":       wallet.Balance,
+		"created_at":    wallet.CreatedAt,
+	}
+}
+
+// bindCurrency sets amount's currency from an optional currency code in a
+// request body. It writes a 400 and returns false if the code is not supported.
+func bindCurrency(c *gin.Context, code string, amount *models.Money) bool {
+	currency, err := models.ParseCurrency(code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	amount.Currency = currency
+	return true
+}
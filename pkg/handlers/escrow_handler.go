@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateEscrowRequest struct {
+	// The seller's wallet, paid when the escrow is released
+	WalletNumber string       `json:"wallet_number" binding:"required"`
+	Amount       models.Money `json:"amount"`
+	Currency     string       `json:"currency"`
+	Reference    string       `json:"reference" binding:"max=255"`
+	Description  string       `json:"description"`
+	// Defaults to 14 days from now
+	AutoReleaseAt *time.Time `json:"auto_release_at"`
+}
+
+type DisputeEscrowRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ResolveEscrowRequest struct {
+	// "release" pays the seller, "refund" pays the buyer back
+	Outcome string `json:"outcome" binding:"required"`
+}
+
+// CreateEscrow pays into escrow from the caller's wallet for a seller
+func (h *WalletHandler) CreateEscrow(c *gin.Context) {
+	var req CreateEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !bindCurrency(c, req.Currency, &req.Amount) {
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var autoReleaseAt time.Time
+	if req.AutoReleaseAt != nil {
+		autoReleaseAt = *req.AutoReleaseAt
+	}
+
+	escrow, err := h.walletService.CreateEscrow(
+		userID,
+		middleware.GetAPIKeyID(c),
+		req.WalletNumber,
+		req.Amount,
+		req.Reference,
+		req.Description,
+		autoReleaseAt,
+	)
+	if err != nil {
+		if respondLimitError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, escrow)
+}
+
+// ListEscrows lists the escrows the caller is the buyer or seller in, optionally filtered by status
+func (h *WalletHandler) ListEscrows(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	escrows, err := h.walletService.ListEscrows(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrows)
+}
+
+// GetEscrow returns an escrow the caller is a party to, with its transactions
+func (h *WalletHandler) GetEscrow(c *gin.Context) {
+	userID, escrowID, ok := escrowParams(c)
+	if !ok {
+		return
+	}
+
+	escrow, err := h.walletService.GetEscrow(userID, escrowID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, escrow)
+}
+
+// ReleaseEscrow pays an escrow the caller funded out to the seller
+func (h *WalletHandler) ReleaseEscrow(c *gin.Context) {
+	userID, escrowID, ok := escrowParams(c)
+	if !ok {
+		return
+	}
+
+	escrow, err := h.walletService.ReleaseEscrow(userID, middleware.GetAPIKeyID(c), escrowID)
+	if err != nil {
+		respondEscrowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, escrow)
+}
+
+// CancelEscrow refunds an escrow the caller is the seller in to the buyer
+func (h *WalletHandler) CancelEscrow(c *gin.Context) {
+	userID, escrowID, ok := escrowParams(c)
+	if !ok {
+		return
+	}
+
+	escrow, err := h.walletService.CancelEscrow(userID, escrowID)
+	if err != nil {
+		respondEscrowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, escrow)
+}
+
+// DisputeEscrow freezes an escrow the caller is a party to until an admin resolves it
+func (h *WalletHandler) DisputeEscrow(c *gin.Context) {
+	var req DisputeEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, escrowID, ok := escrowParams(c)
+	if !ok {
+		return
+	}
+
+	escrow, err := h.walletService.DisputeEscrow(userID, escrowID, req.Reason)
+	if err != nil {
+		respondEscrowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, escrow)
+}
+
+// ResolveEscrowDispute releases or refunds a disputed escrow (admin only)
+func (h *WalletHandler) ResolveEscrowDispute(c *gin.Context) {
+	var req ResolveEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	escrowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid escrow ID"})
+		return
+	}
+
+	escrow, err := h.walletService.ResolveEscrowDispute(escrowID, req.Outcome)
+	if err != nil {
+		respondEscrowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, escrow)
+}
+
+// escrowParams reads the caller and the escrow ID from the path. It writes
+// an error response and returns false if either is missing.
+func escrowParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	escrowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid escrow ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, escrowID, true
+}
+
+// respondEscrowError maps disputed escrows to 409 and everything else to 400
+func respondEscrowError(c *gin.Context, err error) {
+	if errors.Is(err, wallet.ErrEscrowDisputed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/fees"
+	"github.com/gin-gonic/gin"
+)
+
+// QuoteFee returns the fee for a deposit, transfer or withdrawal of the given amount
+func (h *WalletHandler) QuoteFee(c *gin.Context) {
+	kind := fees.Kind(c.Query("type"))
+	switch kind {
+	case fees.KindDeposit, fees.KindTransfer, fees.KindWithdrawal:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be deposit, transfer or withdrawal"})
+		return
+	}
+
+	amount, err := models.ParseMoney(c.Query("amount"), models.DefaultCurrency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.walletService.QuoteFee(kind, amount))
+}
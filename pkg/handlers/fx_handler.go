@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/fx"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateFXQuoteRequest struct {
+	Amount       models.Money `json:"amount"` // In from_currency
+	FromCurrency string       `json:"from_currency" binding:"required"`
+	ToCurrency   string       `json:"to_currency" binding:"required"`
+}
+
+// CreateFXQuote prices a conversion between two of the caller's wallets
+func (h *WalletHandler) CreateFXQuote(c *gin.Context) {
+	var req CreateFXQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !bindCurrency(c, req.FromCurrency, &req.Amount) {
+		return
+	}
+	to, err := models.ParseCurrency(req.ToCurrency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	quote, err := h.walletService.QuoteConversion(userID, req.Amount, to)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateUnavailable) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, quote)
+}
+
+// GetFXQuote gets one of the caller's quotes
+func (h *WalletHandler) GetFXQuote(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	quoteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+
+	quote, err := h.walletService.GetQuote(userID, quoteID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// ExecuteFXQuote makes the conversion a quote priced
+func (h *WalletHandler) ExecuteFXQuote(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	quoteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+
+	conversion, err := h.walletService.ExecuteQuote(userID, quoteID)
+	if err != nil {
+		if respondLimitError(c, err) {
+			return
+		}
+		if errors.Is(err, wallet.ErrQuoteExpired) {
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
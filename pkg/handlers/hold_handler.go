@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateHoldRequest struct {
+	Amount   models.Money `json:"amount"`
+	Currency string       `json:"currency"` // Optional; defaults to NGN
+	// Defaults to seven days from now
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Reference   string     `json:"reference" binding:"max=255"`
+	Description string     `json:"description"`
+}
+
+// CreateHold reserves funds in the caller's wallet
+func (h *WalletHandler) CreateHold(c *gin.Context) {
+	var req CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !bindCurrency(c, req.Currency, &req.Amount) {
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	hold, err := h.walletService.CreateHold(userID, req.Amount, expiresAt, req.Reference, req.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ListHolds lists the holds on the caller's wallet in ?currency (default
+// NGN), optionally filtered by status
+func (h *WalletHandler) ListHolds(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	currency, err := models.ParseCurrency(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	holds, err := h.walletService.ListHolds(userID, currency, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
+
+// GetHold returns one of the caller's holds
+func (h *WalletHandler) GetHold(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	holdID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	hold, err := h.walletService.GetHold(userID, holdID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+type CaptureHoldRequest struct {
+	WalletNumber string `json:"wallet_number" binding:"required"`
+	// Omit the amount to capture everything left on the hold
+	Amount   *models.Money `json:"amount"`
+	Currency string        `json:"currency"` // Optional; defaults to NGN
+}
+
+// CaptureHold pays all or part of a hold to another wallet
+func (h *WalletHandler) CaptureHold(c *gin.Context) {
+	var req CaptureHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var amount models.Money
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+			return
+		}
+		amount = *req.Amount
+		if !bindCurrency(c, req.Currency, &amount) {
+			return
+		}
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	holdID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	hold, err := h.walletService.CaptureHold(userID, middleware.GetAPIKeyID(c), holdID, req.WalletNumber, amount)
+	if err != nil {
+		if respondLimitError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// ReleaseHold releases the uncaptured remainder of a hold
+func (h *WalletHandler) ReleaseHold(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	holdID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	hold, err := h.walletService.ReleaseHold(userID, holdID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
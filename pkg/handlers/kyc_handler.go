@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/kyc"
+	"github.com/gin-gonic/gin"
+)
+
+type KYCHandler struct {
+	kycService *kyc.KYCService
+}
+
+func NewKYCHandler(kycService *kyc.KYCService) *KYCHandler {
+	return &KYCHandler{
+		kycService: kycService,
+	}
+}
+
+type SubmitKYCRequest struct {
+	Tier      models.KYCTier   `json:"tier" binding:"required"`
+	IDType    models.KYCIDType `json:"id_type" binding:"required"`
+	IDNumber  string           `json:"id_number" binding:"required"`
+	FirstName string           `json:"first_name" binding:"required"`
+	LastName  string           `json:"last_name" binding:"required"`
+	// YYYY-MM-DD
+	DateOfBirth string `json:"date_of_birth" binding:"required"`
+}
+
+// GetKYCStatus returns the caller's KYC tier, its limits and what the next tier needs
+func (h *KYCHandler) GetKYCStatus(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := h.kycService.GetStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SubmitKYC verifies the caller's identity details for the next KYC tier
+func (h *KYCHandler) SubmitKYC(c *gin.Context) {
+	var req SubmitKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	submission, err := h.kycService.Submit(userID, kyc.VerificationRequest{
+		Tier:        req.Tier,
+		IDType:      req.IDType,
+		IDNumber:    req.IDNumber,
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		DateOfBirth: req.DateOfBirth,
+	})
+	if err != nil {
+		if errors.Is(err, kyc.ErrVerifierUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// ListKYCSubmissions lists the caller's KYC submissions
+func (h *KYCHandler) ListKYCSubmissions(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	submissions, err := h.kycService.ListSubmissions(userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SetLimitOverrideRequest sets a user's or API key's limits in one currency.
+// Limits left out or null fall back to the configured defaults; zero lifts
+// the limit.
+type SetLimitOverrideRequest struct {
+	Currency            string        `json:"currency"` // Optional; defaults to NGN
+	MaxTransferAmount   *models.Money `json:"max_transfer_amount"`
+	DailyOutflow        *models.Money `json:"daily_outflow"`
+	MonthlyOutflow      *models.Money `json:"monthly_outflow"`
+	APIKeyDailySpend    *models.Money `json:"api_key_daily_spend"`
+	MaxTransfersPerHour *int          `json:"max_transfers_per_hour"`
+}
+
+// GetLimits returns the transfer limits on each of the caller's wallets and
+// what is left of them
+func (h *WalletHandler) GetLimits(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limits, err := h.walletService.GetLimits(userID, middleware.GetAPIKeyID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// ListUserLimitOverrides returns a user's limit overrides (admin only)
+func (h *WalletHandler) ListUserLimitOverrides(c *gin.Context) {
+	h.listLimitOverrides(c, false)
+}
+
+// SetUserLimitOverride sets a user's limits in one currency (admin only)
+func (h *WalletHandler) SetUserLimitOverride(c *gin.Context) {
+	h.setLimitOverride(c, false)
+}
+
+// DeleteUserLimitOverride puts a user back on the default limits in
+// ?currency (admin only)
+func (h *WalletHandler) DeleteUserLimitOverride(c *gin.Context) {
+	h.deleteLimitOverride(c, false)
+}
+
+// ListAPIKeyLimitOverrides returns an API key's limit overrides (admin only)
+func (h *WalletHandler) ListAPIKeyLimitOverrides(c *gin.Context) {
+	h.listLimitOverrides(c, true)
+}
+
+// SetAPIKeyLimitOverride sets an API key's limits in one currency (admin only)
+func (h *WalletHandler) SetAPIKeyLimitOverride(c *gin.Context) {
+	h.setLimitOverride(c, true)
+}
+
+// DeleteAPIKeyLimitOverride puts an API key back on the default limits in
+// ?currency (admin only)
+func (h *WalletHandler) DeleteAPIKeyLimitOverride(c *gin.Context) {
+	h.deleteLimitOverride(c, true)
+}
+
+func (h *WalletHandler) listLimitOverrides(c *gin.Context, forAPIKey bool) {
+	ownerID, ok := limitOverrideOwner(c, forAPIKey)
+	if !ok {
+		return
+	}
+
+	var overrides []models.LimitOverride
+	var err error
+	if forAPIKey {
+		overrides, err = h.walletService.ListAPIKeyLimitOverrides(ownerID)
+	} else {
+		overrides, err = h.walletService.ListUserLimitOverrides(ownerID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+func (h *WalletHandler) setLimitOverride(c *gin.Context, forAPIKey bool) {
+	var req SetLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerID, ok := limitOverrideOwner(c, forAPIKey)
+	if !ok {
+		return
+	}
+
+	currency, err := models.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override := &models.LimitOverride{
+		Currency:            currency,
+		MaxTransferAmount:   req.MaxTransferAmount,
+		DailyOutflow:        req.DailyOutflow,
+		MonthlyOutflow:      req.MonthlyOutflow,
+		APIKeyDailySpend:    req.APIKeyDailySpend,
+		MaxTransfersPerHour: req.MaxTransfersPerHour,
+	}
+	if forAPIKey {
+		override.APIKeyID = &ownerID
+	} else {
+		override.UserID = &ownerID
+	}
+
+	override, err = h.walletService.SetLimitOverride(override)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, wallet.ErrLimitOwnerNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+func (h *WalletHandler) deleteLimitOverride(c *gin.Context, forAPIKey bool) {
+	ownerID, ok := limitOverrideOwner(c, forAPIKey)
+	if !ok {
+		return
+	}
+
+	currency, err := models.ParseCurrency(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if forAPIKey {
+		err = h.walletService.DeleteAPIKeyLimitOverride(ownerID, currency)
+	} else {
+		err = h.walletService.DeleteUserLimitOverride(ownerID, currency)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Limit override deleted"})
+}
+
+// limitOverrideOwner reads the user or API key ID from the path. It writes an
+// error response and returns false if it is not a valid ID.
+func limitOverrideOwner(c *gin.Context, forAPIKey bool) (uuid.UUID, bool) {
+	ownerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		message := "Invalid user ID"
+		if forAPIKey {
+			message = "Invalid API key ID"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return uuid.Nil, false
+	}
+	return ownerID, true
+}
+
+// respondLimitError writes a 403 with the limit's code if err is a
+// *wallet.LimitError, and reports whether it did
+func respondLimitError(c *gin.Context, err error) bool {
+	var limitErr *wallet.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": limitErr.Message, "code": limitErr.Code})
+	return true
+}
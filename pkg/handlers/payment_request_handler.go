@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/paymentrequest"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentRequestHandler struct {
+	paymentRequestService *paymentrequest.PaymentRequestService
+}
+
+func NewPaymentRequestHandler(paymentRequestService *paymentrequest.PaymentRequestService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		paymentRequestService: paymentRequestService,
+	}
+}
+
+type CreatePaymentRequestRequest struct {
+	// The wallet being asked to pay
+	WalletNumber string       `json:"wallet_number" binding:"required"`
+	Amount       models.Money `json:"amount"`
+	// Defaults to the currency of the payer's wallet
+	Currency string `json:"currency"`
+	Memo     string `json:"memo"`
+	// Defaults to PAYMENT_REQUEST_TTL from now
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatePaymentRequest asks the owner of a wallet to pay the caller
+func (h *PaymentRequestHandler) CreatePaymentRequest(c *gin.Context) {
+	var req CreatePaymentRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Currency == "" {
+		req.Amount.Currency = ""
+	} else if !bindCurrency(c, req.Currency, &req.Amount) {
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	pr, err := h.paymentRequestService.Create(userID, req.WalletNumber, req.Amount, req.Memo, req.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, pr)
+}
+
+// ListSentPaymentRequests lists the payment requests the caller has sent, optionally filtered by status
+func (h *PaymentRequestHandler) ListSentPaymentRequests(c *gin.Context) {
+	h.list(c, h.paymentRequestService.ListSent)
+}
+
+// ListReceivedPaymentRequests lists the payment requests the caller has been sent, optionally filtered by status
+func (h *PaymentRequestHandler) ListReceivedPaymentRequests(c *gin.Context) {
+	h.list(c, h.paymentRequestService.ListReceived)
+}
+
+func (h *PaymentRequestHandler) list(
+	c *gin.Context,
+	list func(userID uuid.UUID, status string, limit, offset int) ([]models.PaymentRequest, error),
+) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	requests, err := list(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetPaymentRequest returns a payment request the caller sent or received
+func (h *PaymentRequestHandler) GetPaymentRequest(c *gin.Context) {
+	userID, id, ok := paymentRequestParams(c)
+	if !ok {
+		return
+	}
+
+	pr, err := h.paymentRequestService.Get(userID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pr)
+}
+
+// AcceptPaymentRequest pays a payment request the caller received
+func (h *PaymentRequestHandler) AcceptPaymentRequest(c *gin.Context) {
+	userID, id, ok := paymentRequestParams(c)
+	if !ok {
+		return
+	}
+
+	pr, err := h.paymentRequestService.Accept(userID, middleware.GetAPIKeyID(c), id)
+	if err != nil {
+		if respondLimitError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pr)
+}
+
+// DeclinePaymentRequest refuses a payment request the caller received
+func (h *PaymentRequestHandler) DeclinePaymentRequest(c *gin.Context) {
+	h.changeStatus(c, h.paymentRequestService.Decline)
+}
+
+// CancelPaymentRequest withdraws a payment request the caller sent
+func (h *PaymentRequestHandler) CancelPaymentRequest(c *gin.Context) {
+	h.changeStatus(c, h.paymentRequestService.Cancel)
+}
+
+func (h *PaymentRequestHandler) changeStatus(
+	c *gin.Context,
+	change func(userID, id uuid.UUID) (*models.PaymentRequest, error),
+) {
+	userID, id, ok := paymentRequestParams(c)
+	if !ok {
+		return
+	}
+
+	pr, err := change(userID, id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pr)
+}
+
+// paymentRequestParams reads the caller and the payment request ID from the
+// request, writing an error response if either is missing
+func paymentRequestParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment request ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
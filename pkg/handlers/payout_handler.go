@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxPayoutCSVSize is the largest CSV file accepted for a bulk payout
+const maxPayoutCSVSize = 1 << 20
+
+type CreatePayoutBatchRequest struct {
+	// Defaults to the default currency
+	Currency string `json:"currency"`
+	// "all_or_nothing" (default) or "best_effort"
+	Mode      string             `json:"mode"`
+	Reference string             `json:"reference" binding:"max=255"`
+	Items     []wallet.PayoutRow `json:"items"`
+}
+
+// CreatePayoutBatch queues a bulk payout from the caller's wallet. The rows
+// come either as JSON items or, for multipart/form-data requests, as a CSV
+// file in the file field with currency, mode and reference as form fields.
+func (h *WalletHandler) CreatePayoutBatch(c *gin.Context) {
+	var req CreatePayoutBatchRequest
+	var rows []wallet.PayoutRow
+
+	isCSV := c.ContentType() == "multipart/form-data"
+	if isCSV {
+		req.Currency = c.PostForm("currency")
+		req.Mode = c.PostForm("mode")
+		req.Reference = c.PostForm("reference")
+		if len(req.Reference) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reference must be at most 255 characters"})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := models.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := models.PayoutBatchModeAllOrNothing
+	if req.Mode != "" {
+		mode = models.PayoutBatchMode(strings.ToLower(req.Mode))
+	}
+
+	if isCSV {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a CSV file is required in the file field"})
+			return
+		}
+		if file.Size > maxPayoutCSVSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("CSV file must be at most %d bytes", maxPayoutCSVSize)})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read CSV file"})
+			return
+		}
+		defer f.Close()
+
+		rows, err = wallet.ParsePayoutCSV(f, currency)
+		if err != nil {
+			respondPayoutError(c, err)
+			return
+		}
+	} else {
+		// Amounts are read in the default currency; the batch's applies to every row
+		rows = req.Items
+		for i := range rows {
+			rows[i].Amount.Currency = currency
+		}
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	batch, err := h.walletService.CreatePayoutBatch(userID, middleware.GetAPIKeyID(c), currency, mode, req.Reference, rows)
+	if err != nil {
+		respondPayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, batch)
+}
+
+// ListPayoutBatches lists the caller's bulk payouts, optionally filtered by status
+func (h *WalletHandler) ListPayoutBatches(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	batches, err := h.walletService.ListPayoutBatches(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetPayoutBatch returns the progress of one of the caller's bulk payouts and
+// the outcome of each row, optionally only the rows with item_status
+func (h *WalletHandler) GetPayoutBatch(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	batchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payout batch ID"})
+		return
+	}
+
+	batch, err := h.walletService.GetPayoutBatch(userID, batchID, c.Query("item_status"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// respondPayoutError lists the invalid rows of a rejected payout alongside
+// the error and answers 409 for a reused reference; everything else is a 400
+func respondPayoutError(c *gin.Context, err error) {
+	var validationErr *wallet.PayoutValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error(), "rows": validationErr.Rows})
+		return
+	}
+	if errors.Is(err, wallet.ErrDuplicatePayoutReference) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreatePocketRequest struct {
+	Name         string        `json:"name" binding:"required"`
+	Currency     string        `json:"currency"` // Optional; defaults to NGN
+	TargetAmount *models.Money `json:"target_amount"`
+	TargetDate   *time.Time    `json:"target_date"`
+	LockedUntil  *time.Time    `json:"locked_until"`
+}
+
+type UpdatePocketRequest struct {
+	Name         *string       `json:"name"`
+	TargetAmount *models.Money `json:"target_amount"`
+	TargetDate   *time.Time    `json:"target_date"`
+	LockedUntil  *time.Time    `json:"locked_until"`
+	// Removes the target amount and date
+	ClearTarget bool `json:"clear_target"`
+}
+
+type PocketMoveRequest struct {
+	Amount models.Money `json:"amount"`
+}
+
+// PocketResponse is a pocket with whether it is locked and how far it is
+// from its target
+type PocketResponse struct {
+	models.Pocket
+	Locked          bool          `json:"locked"`
+	TargetRemaining *models.Money `json:"target_remaining,omitempty"`
+}
+
+func newPocketResponse(pocket models.Pocket, now time.Time) PocketResponse {
+	response := PocketResponse{Pocket: pocket, Locked: pocket.IsLocked(now)}
+	if pocket.TargetAmount != nil {
+		remaining := pocket.TargetAmount.Sub(pocket.Balance)
+		if remaining.IsNegative() {
+			remaining = models.NewMoney(0, remaining.Currency)
+		}
+		response.TargetRemaining = &remaining
+	}
+	return response
+}
+
+func newPocketResponses(pockets []models.Pocket) []PocketResponse {
+	now := time.Now()
+	responses := make([]PocketResponse, 0, len(pockets))
+	for _, pocket := range pockets {
+		responses = append(responses, newPocketResponse(pocket, now))
+	}
+	return responses
+}
+
+// CreatePocket adds a named pocket to the caller's wallet
+func (h *WalletHandler) CreatePocket(c *gin.Context) {
+	var req CreatePocketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := models.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	pocket, err := h.walletService.CreatePocket(userID, currency, req.Name, req.TargetAmount, req.TargetDate, req.LockedUntil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newPocketResponse(*pocket, time.Now()))
+}
+
+// ListPockets lists the pockets on the caller's wallet
+func (h *WalletHandler) ListPockets(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	currency, err := models.ParseCurrency(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pockets, err := h.walletService.ListPockets(userID, currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pockets": newPocketResponses(pockets)})
+}
+
+// GetPocket gets one of the caller's pockets
+func (h *WalletHandler) GetPocket(c *gin.Context) {
+	userID, pocketID, ok := pocketParams(c)
+	if !ok {
+		return
+	}
+
+	pocket, err := h.walletService.GetPocket(userID, pocketID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPocketResponse(*pocket, time.Now()))
+}
+
+// UpdatePocket renames a pocket or changes its target or lock
+func (h *WalletHandler) UpdatePocket(c *gin.Context) {
+	var req UpdatePocketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, pocketID, ok := pocketParams(c)
+	if !ok {
+		return
+	}
+
+	update := wallet.PocketUpdate{
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+		TargetDate:   req.TargetDate,
+		LockedUntil:  req.LockedUntil,
+	}
+	if req.ClearTarget {
+		update.TargetAmount = &models.Money{}
+		update.TargetDate = &time.Time{}
+	}
+
+	pocket, err := h.walletService.UpdatePocket(userID, pocketID, update)
+	if err != nil {
+		respondPocketError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPocketResponse(*pocket, time.Now()))
+}
+
+// MoveToPocket moves money from the wallet's available balance into a pocket
+func (h *WalletHandler) MoveToPocket(c *gin.Context) {
+	var req PocketMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, pocketID, ok := pocketParams(c)
+	if !ok {
+		return
+	}
+
+	pocket, err := h.walletService.MoveToPocket(userID, pocketID, req.Amount)
+	if err != nil {
+		respondPocketError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPocketResponse(*pocket, time.Now()))
+}
+
+// MoveFromPocket moves money from a pocket back to the wallet's available
+// balance. An empty body or zero amount empties the pocket.
+func (h *WalletHandler) MoveFromPocket(c *gin.Context) {
+	var req PocketMoveRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID, pocketID, ok := pocketParams(c)
+	if !ok {
+		return
+	}
+
+	pocket, err := h.walletService.MoveFromPocket(userID, pocketID, req.Amount)
+	if err != nil {
+		respondPocketError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPocketResponse(*pocket, time.Now()))
+}
+
+// DeletePocket removes a pocket; anything left in it goes back to the wallet
+func (h *WalletHandler) DeletePocket(c *gin.Context) {
+	userID, pocketID, ok := pocketParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.walletService.DeletePocket(userID, pocketID); err != nil {
+		respondPocketError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pocket deleted"})
+}
+
+// pocketParams reads the caller and the pocket ID from the path. It writes
+// an error response and returns false if either is missing.
+func pocketParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	pocketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pocket ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, pocketID, true
+}
+
+// respondPocketError maps locked pockets to 403 and everything else to 400
+func respondPocketError(c *gin.Context, err error) {
+	if errors.Is(err, wallet.ErrPocketLocked) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
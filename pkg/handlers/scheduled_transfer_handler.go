@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/pkg/middleware"
+	"github.com/brainox/paystack_wallet_service/services/scheduledtransfer"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ScheduledTransferHandler struct {
+	scheduledTransferService *scheduledtransfer.ScheduledTransferService
+}
+
+func NewScheduledTransferHandler(scheduledTransferService *scheduledtransfer.ScheduledTransferService) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{
+		scheduledTransferService: scheduledTransferService,
+	}
+}
+
+type CreateScheduledTransferRequest struct {
+	WalletNumber string              `json:"wallet_number" binding:"required"`
+	Amount       models.Money        `json:"amount"`
+	Currency     string              `json:"currency"` // Optional; defaults to NGN
+	Description  string              `json:"description"`
+	ScheduleType models.ScheduleType `json:"schedule_type" binding:"required"`
+	// Required for cron schedules, e.g. "0 9 * * 1" for 09:00 UTC every Monday
+	CronExpression string `json:"cron_expression"`
+	// Required for monthly schedules
+	DayOfMonth int `json:"day_of_month"`
+	// When a one-off transfer runs, or when a recurring one starts. Defaults
+	// to now for recurring transfers.
+	StartAt *time.Time `json:"start_at"`
+	// Recurring transfers stop after this time
+	EndAt *time.Time `json:"end_at"`
+}
+
+// CreateScheduledTransfer schedules a one-off or recurring transfer from the caller's wallet
+func (h *ScheduledTransferHandler) CreateScheduledTransfer(c *gin.Context) {
+	var req CreateScheduledTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !bindCurrency(c, req.Currency, &req.Amount) {
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	st := &models.ScheduledTransfer{
+		RecipientWalletNumber: req.WalletNumber,
+		Amount:                req.Amount,
+		ScheduleType:          req.ScheduleType,
+		EndAt:                 req.EndAt,
+	}
+	if req.Description != "" {
+		st.Description = &req.Description
+	}
+	if req.CronExpression != "" {
+		st.CronExpression = &req.CronExpression
+	}
+	if req.DayOfMonth != 0 {
+		st.DayOfMonth = &req.DayOfMonth
+	}
+	if req.StartAt != nil {
+		st.StartAt = *req.StartAt
+	}
+
+	st, err = h.scheduledTransferService.Create(userID, middleware.GetAPIKeyID(c), st)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, st)
+}
+
+// ListScheduledTransfers lists the caller's scheduled transfers, optionally filtered by status
+func (h *ScheduledTransferHandler) ListScheduledTransfers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	transfers, err := h.scheduledTransferService.List(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+// GetScheduledTransfer returns one of the caller's scheduled transfers
+func (h *ScheduledTransferHandler) GetScheduledTransfer(c *gin.Context) {
+	userID, id, ok := scheduledTransferParams(c)
+	if !ok {
+		return
+	}
+
+	st, err := h.scheduledTransferService.Get(userID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, st)
+}
+
+// ListScheduledTransferRuns lists the outcome of each attempt to run a scheduled transfer
+func (h *ScheduledTransferHandler) ListScheduledTransferRuns(c *gin.Context) {
+	userID, id, ok := scheduledTransferParams(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := paginationParams(c)
+
+	runs, err := h.scheduledTransferService.ListRuns(userID, id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// PauseScheduledTransfer stops a scheduled transfer from running until it is resumed
+func (h *ScheduledTransferHandler) PauseScheduledTransfer(c *gin.Context) {
+	h.changeStatus(c, h.scheduledTransferService.Pause)
+}
+
+// ResumeScheduledTransfer reactivates a paused scheduled transfer
+func (h *ScheduledTransferHandler) ResumeScheduledTransfer(c *gin.Context) {
+	h.changeStatus(c, h.scheduledTransferService.Resume)
+}
+
+// CancelScheduledTransfer stops a scheduled transfer for good
+func (h *ScheduledTransferHandler) CancelScheduledTransfer(c *gin.Context) {
+	h.changeStatus(c, h.scheduledTransferService.Cancel)
+}
+
+func (h *ScheduledTransferHandler) changeStatus(
+	c *gin.Context,
+	change func(userID, id uuid.UUID) (*models.ScheduledTransfer, error),
+) {
+	userID, id, ok := scheduledTransferParams(c)
+	if !ok {
+		return
+	}
+
+	st, err := change(userID, id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, st)
+}
+
+// scheduledTransferParams reads the caller and the scheduled transfer ID from
+// the request, writing an error response if either is missing
+func scheduledTransferParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled transfer ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
@@ -1,41 +1,42 @@
 package handlers
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
-	"strconv"
 
-	"github.com/brainox/paystack_wallet_service/external/external_models"
 	"github.com/brainox/paystack_wallet_service/internal/models"
 	"github.com/brainox/paystack_wallet_service/pkg/middleware"
-	"github.com/brainox/paystack_wallet_service/services/paystack"
+	"github.com/brainox/paystack_wallet_service/services/alias"
 	"github.com/brainox/paystack_wallet_service/services/wallet"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type WalletHandler struct {
-	walletService   *wallet.WalletService
-	paystackService *paystack.PaystackService
+	walletService *wallet.WalletService
+	aliasService  *alias.AliasService
 }
 
-func NewWalletHandler(
-	walletService *wallet.WalletService,
-	paystackService *paystack.PaystackService,
-) *WalletHandler {
+func NewWalletHandler(walletService *wallet.WalletService, aliasService *alias.AliasService) *WalletHandler {
 	return &WalletHandler{
-		walletService:   walletService,
-		paystackService: paystackService,
+		walletService: walletService,
+		aliasService:  aliasService,
 	}
 }
 
 type DepositRequest struct {
-	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Amount   models.Money           `json:"amount"`
+	Currency string                 `json:"currency"` // Optional; defaults to NGN
+	Provider models.PaymentProvider `json:"provider"` // Optional; defaults to the configured provider
 }
 
 type DepositResponse struct {
-	Reference        string `json:"reference"`
-	AuthorizationURL string `json:"authorization_url"`
+	Reference        string                 `json:"reference"`
+	AuthorizationURL string                 `json:"authorization_url"`
+	Provider         models.PaymentProvider `json:"provider"`
+	Amount           models.Money           `json:"amount"`
+	Fee              models.Money           `json:"fee"`
+	Total            models.Money           `json:"total"` // Charged at checkout
 }
 
 // InitiateDeposit initiates a wallet deposit
@@ -46,63 +47,40 @@ func (h *WalletHandler) InitiateDeposit(c *gin.Context) {
 		return
 	}
 
+	if !bindCurrency(c, req.Currency, &req.Amount) {
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	reference, authURL, err := h.walletService.InitiateDeposit(userID, req.Amount)
+	transaction, authURL, err := h.walletService.InitiateDeposit(userID, req.Amount, req.Provider)
 	if err != nil {
+		if respondLimitError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, DepositResponse{
-		Reference:        reference,
+		Reference:        *transaction.Reference,
 		AuthorizationURL: authURL,
+		Provider:         *transaction.Provider,
+		Amount:           transaction.Amount,
+		Fee:              transaction.Fee,
+		Total:            transaction.Amount.Add(transaction.Fee),
 	})
 }
 
-// HandlePaystackWebhook handles Paystack webhook events
-func (h *WalletHandler) HandlePaystackWebhook(c *gin.Context) {
-	// Get the signature from headers
-	signature := c.GetHeader("x-paystack-signature")
-	if signature == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing signature"})
-		return
-	}
-
-	// Read the raw body
-	body, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
-		return
-	}
-
-	// Validate the signature
-	if !h.paystackService.ValidateWebhookSignature(body, signature) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
-		return
-	}
-
-	// Parse the webhook event
-	var event external_models.WebhookEvent
-	if err := json.Unmarshal(body, &event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
-		return
-	}
-
-	// Process the webhook
-	if err := h.walletService.ProcessWebhook(&event); err != nil {
-		// Log error but return 200 to prevent Paystack from retrying
-		c.JSON(http.StatusOK, gin.H{"status": false, "message": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"status": true})
-}
-
 // GetDepositStatus gets the status of a deposit
 func (h *WalletHandler) GetDepositStatus(c *gin.Context) {
 	reference := c.Param("reference")
@@ -132,16 +110,30 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 		return
 	}
 
-	balance, err := h.walletService.GetBalance(userID)
+	currency, err := models.ParseCurrency(c.Query("currency"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"balance": balance})
+	balance, err := h.walletService.GetBalance(userID, currency)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// balance is the available balance, which is what clients can spend
+	c.JSON(http.StatusOK, gin.H{
+		"balance":           balance.Available,
+		"available_balance": balance.Available,
+		"ledger_balance":    balance.Ledger,
+		"pockets_balance":   balance.Pockets,
+		"currency":          balance.Currency,
+	})
 }
 
-// GetWalletInfo gets the wallet information including wallet number
+// GetWalletInfo lists the caller's wallets with their wallet numbers,
+// balances and pockets
 func (h *WalletHandler) GetWalletInfo(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -149,22 +141,32 @@ func (h *WalletHandler) GetWalletInfo(c *gin.Context) {
 		return
 	}
 
-	wallet, err := h.walletService.GetWalletDetails(userID)
+	infos, err := h.walletService.GetWalletInfo(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"wallet_number": wallet.WalletNumber,
-		"balance":       wallet.Balance,
-		"created_at":    wallet.CreatedAt,
-	})
+	response := make([]gin.H, 0, len(infos))
+	for i := range infos {
+		wallet := walletResponse(&infos[i].Wallet)
+		wallet["available_balance"] = infos[i].Balance.Available
+		wallet["pockets_balance"] = infos[i].Balance.Pockets
+		wallet["pockets"] = newPocketResponses(infos[i].Pockets)
+		response = append(response, wallet)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wallets": response})
 }
 
 type TransferRequest struct {
-	WalletNumber string  `json:"wallet_number" binding:"required"`
-	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	// The recipient: a wallet number, a saved wallet beneficiary or an
+	// alias (@handle or phone number)
+	WalletNumber  string       `json:"wallet_number"`
+	BeneficiaryID *uuid.UUID   `json:"beneficiary_id"`
+	Alias         string       `json:"alias"`
+	Amount        models.Money `json:"amount"`
+	Currency      string       `json:"currency"` // Optional; defaults to NGN
 }
 
 // Transfer transfers money to another wallet
@@ -175,26 +177,83 @@ func (h *WalletHandler) Transfer(c *gin.Context) {
 		return
 	}
 
+	if !bindCurrency(c, req.Currency, &req.Amount) {
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	if err := h.walletService.Transfer(userID, req.WalletNumber, req.Amount); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	recipients := 0
+	for _, given := range []bool{req.WalletNumber != "", req.BeneficiaryID != nil, req.Alias != ""} {
+		if given {
+			recipients++
+		}
+	}
+	switch {
+	case recipients > 1:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide only one of wallet_number, beneficiary_id and alias"})
+		return
+	case req.BeneficiaryID != nil:
+		req.WalletNumber, err = h.walletService.BeneficiaryWalletNumber(userID, *req.BeneficiaryID)
+		if err != nil {
+			respondTransferError(c, err)
+			return
+		}
+	case req.Alias != "":
+		req.WalletNumber, err = h.aliasService.ResolveWalletNumber(req.Alias, req.Amount.Currency)
+		if err != nil {
+			respondTransferError(c, err)
+			return
+		}
+	case req.WalletNumber == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "wallet_number, beneficiary_id or alias is required"})
+		return
+	}
+
+	transaction, err := h.walletService.Transfer(userID, middleware.GetAPIKeyID(c), req.WalletNumber, req.Amount)
+	if err != nil {
+		respondTransferError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Transfer completed",
+		"status":    "success",
+		"message":   "Transfer completed",
+		"reference": transaction.Reference,
+		"fee":       transaction.Fee,
 	})
 }
 
+// respondTransferError answers 403 for limits, 400 for transfers the caller
+// can fix and 500 for internal failures. Internal failures must not be 4xx,
+// or a retry with the same Idempotency-Key would replay them.
+func respondTransferError(c *gin.Context, err error) {
+	if respondLimitError(c, err) {
+		return
+	}
+	var transferErr *wallet.TransferError
+	var aliasErr *alias.UnknownAliasError
+	switch {
+	case errors.As(err, &transferErr), errors.As(err, &aliasErr),
+		errors.Is(err, wallet.ErrInsufficientBalance), errors.Is(err, wallet.ErrCrossCurrencyTransfer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 type TransactionHistoryResponse struct {
 	Type   models.TransactionType   `json:"type"`
-	Amount float64                  `json:"amount"`
+	Amount models.Money             `json:"amount"`
 	Status models.TransactionStatus `json:"status"`
 }
 
@@ -207,20 +266,7 @@ func (h *WalletHandler) GetTransactionHistory(c *gin.Context) {
 	}
 
 	// Get pagination parameters
-	limit := 50
-	offset := 0
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
-	}
+	limit, offset := paginationParams(c)
 
 	transactions, err := h.walletService.GetTransactionHistory(userID, limit, offset)
 	if err != nil {
@@ -240,3 +286,162 @@ func (h *WalletHandler) GetTransactionHistory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ListBanks lists the banks that withdrawals can be paid out to
+func (h *WalletHandler) ListBanks(c *gin.Context) {
+	banks, err := h.walletService.ListBanks()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, banks)
+}
+
+// ResolveBankAccount returns the account name for a bank account
+func (h *WalletHandler) ResolveBankAccount(c *gin.Context) {
+	accountNumber := c.Query("account_number")
+	bankCode := c.Query("bank_code")
+	if accountNumber == "" || bankCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_number and bank_code are required"})
+		return
+	}
+
+	accountName, err := h.walletService.ResolveBankAccount(accountNumber, bankCode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account_number": accountNumber,
+		"bank_code":      bankCode,
+		"account_name":   accountName,
+	})
+}
+
+type WithdrawRequest struct {
+	Amount models.Money `json:"amount"`
+	// The bank account: either bank_code and account_number or a saved bank
+	// account beneficiary
+	BankCode      string     `json:"bank_code"`
+	AccountNumber string     `json:"account_number" binding:"omitempty,len=10,numeric"`
+	BeneficiaryID *uuid.UUID `json:"beneficiary_id"`
+	Reason        string     `json:"reason"`
+}
+
+// Withdraw pays wallet funds out to a bank account
+func (h *WalletHandler) Withdraw(c *gin.Context) {
+	var req WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	switch {
+	case req.BeneficiaryID != nil && (req.BankCode != "" || req.AccountNumber != ""):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide either bank_code and account_number or beneficiary_id, not both"})
+		return
+	case req.BeneficiaryID != nil:
+		req.BankCode, req.AccountNumber, err = h.walletService.BeneficiaryBankAccount(userID, *req.BeneficiaryID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case req.BankCode == "" || req.AccountNumber == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bank_code and account_number, or beneficiary_id, are required"})
+		return
+	}
+
+	transaction, err := h.walletService.InitiateWithdrawal(userID, middleware.GetAPIKeyID(c), req.Amount, req.BankCode, req.AccountNumber, req.Reason)
+	if err != nil {
+		if respondLimitError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reference": transaction.Reference,
+		"status":    transaction.Status,
+		"amount":    transaction.Amount,
+		"fee":       transaction.Fee,
+	})
+}
+
+type RefundRequest struct {
+	// Omit the amount to refund everything not yet refunded
+	Amount *models.Money `json:"amount"`
+	Reason string        `json:"reason"`
+}
+
+// RefundDeposit refunds all or part of a deposit to the payer's card
+func (h *WalletHandler) RefundDeposit(c *gin.Context) {
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var amount models.Money
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+			return
+		}
+		amount = *req.Amount
+	}
+
+	transaction, err := h.walletService.RefundDeposit(c.Param("reference"), amount, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reference":         transaction.Reference,
+		"deposit_reference": c.Param("reference"),
+		"status":            transaction.Status,
+		"amount":            transaction.Amount,
+	})
+}
+
+type ReverseTransferRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// Reverse even if the recipient has spent the money, leaving them below
+	// zero. Only honoured when ALLOW_FORCE_NEGATIVE_REVERSALS is set.
+	ForceNegative bool `json:"force_negative"`
+}
+
+// ReverseTransfer undoes a completed wallet-to-wallet transfer
+func (h *WalletHandler) ReverseTransfer(c *gin.Context) {
+	var req ReverseTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reversal, err := h.walletService.ReverseTransfer(c.Param("reference"), req.Reason, req.ForceNegative)
+	if err != nil {
+		if errors.Is(err, wallet.ErrRecipientSpentFunds) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reversal)
+}
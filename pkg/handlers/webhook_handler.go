@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService *webhook.WebhookService
+}
+
+func NewWebhookHandler(webhookService *webhook.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// HandlePaystackWebhook handles Paystack webhook events on the original
+// webhook URL
+func (h *WebhookHandler) HandlePaystackWebhook(c *gin.Context) {
+	h.receive(c, models.PaymentProviderPaystack)
+}
+
+// HandleProviderWebhook handles webhook events for the provider named in the URL
+func (h *WebhookHandler) HandleProviderWebhook(c *gin.Context) {
+	h.receive(c, models.PaymentProvider(c.Param("provider")))
+}
+
+// receive stores and processes a webhook event
+func (h *WebhookHandler) receive(c *gin.Context, provider models.PaymentProvider) {
+	// Read the raw body
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	// Store the event, then process it
+	event, err := h.webhookService.Receive(provider, body, c.Request.Header)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrUnknownProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown payment provider"})
+		case errors.Is(err, webhook.ErrMissingSignature):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing signature"})
+		case errors.Is(err, webhook.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		case errors.Is(err, webhook.ErrInvalidPayload):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+		default:
+			// Not stored, so let the provider deliver it again
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// The event is stored; failures are retried in the background, so
+	// the provider does not need to redeliver it
+	if event.Status == models.WebhookEventStatusFailed {
+		c.JSON(http.StatusOK, gin.H{"status": false, "message": *event.LastError})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true})
+}
+
+// ListWebhookEvents lists stored webhook events, optionally filtered by status
+func (h *WebhookHandler) ListWebhookEvents(c *gin.Context) {
+	limit, offset := paginationParams(c)
+
+	events, err := h.webhookService.ListEvents(c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetWebhookEvent returns a stored webhook event
+func (h *WebhookHandler) GetWebhookEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook event ID"})
+		return
+	}
+
+	event, err := h.webhookService.GetEvent(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// ReplayWebhookEvent processes a stored webhook event again
+func (h *WebhookHandler) ReplayWebhookEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook event ID"})
+		return
+	}
+
+	event, err := h.webhookService.Replay(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
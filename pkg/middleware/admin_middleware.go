@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const AdminKeyHeader = "x-admin-key"
+
+// AdminAuth protects operator endpoints with the shared admin key. When no key
+// is configured the endpoints are disabled.
+func AdminAuth(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminKey == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled"})
+			c.Abort()
+			return
+		}
+
+		key := c.GetHeader(AdminKeyHeader)
+		if subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin key"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -13,6 +13,7 @@ import (
 const (
 	UserIDKey            = "user_id"
 	UserEmailKey         = "user_email"
+	APIKeyIDKey          = "api_key_id"
 	APIKeyPermissionsKey = "api_key_permissions"
 	IsAPIKeyAuth         = "is_api_key_auth"
 )
@@ -33,6 +34,7 @@ func AuthMiddleware(jwtService *auth.JWTService, apiKeyService *auth.APIKeyServi
 
 			// Set user context
 			c.Set(UserIDKey, apiKeyModel.UserID)
+			c.Set(APIKeyIDKey, apiKeyModel.ID)
 			c.Set(APIKeyPermissionsKey, apiKeyModel.Permissions)
 			c.Set(IsAPIKeyAuth, true)
 			c.Next()
@@ -126,6 +128,19 @@ func RequirePermission(permission string) gin.HandlerFunc {
 	}
 }
 
+// RequireJWT rejects requests authenticated with an API key, for actions only
+// the user themselves may take
+func RequireJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAPIKey, _ := c.Get(IsAPIKeyAuth); isAPIKey == true {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint requires user authentication"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetUserID extracts the user ID from the context
 func GetUserID(c *gin.Context) (uuid.UUID, error) {
 	userID, exists := c.Get(UserIDKey)
@@ -134,3 +149,14 @@ func GetUserID(c *gin.Context) (uuid.UUID, error) {
 	}
 	return userID.(uuid.UUID), nil
 }
+
+// GetAPIKeyID returns the ID of the API key that authenticated the request,
+// or nil for JWT requests
+func GetAPIKeyID(c *gin.Context) *uuid.UUID {
+	apiKeyID, exists := c.Get(APIKeyIDKey)
+	if !exists {
+		return nil
+	}
+	id := apiKeyID.(uuid.UUID)
+	return &id
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/services/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyContentType   = "application/json; charset=utf-8"
+)
+
+// responseRecorder copies everything written to the client so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a request safe to retry when the client sends an
+// Idempotency-Key header. The first response for a key is stored byte for
+// byte and replayed for later requests with the same key and body; a
+// different body under the same key is rejected. Server errors are not
+// stored. Must run after AuthMiddleware.
+func Idempotency(idempotencyService *idempotency.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := idempotencyService.Begin(userID, key, c.Request.Method, c.FullPath(), body)
+		if err != nil {
+			switch {
+			case errors.Is(err, idempotency.ErrInvalidKey):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, idempotency.ErrKeyReused):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, idempotency.ErrRequestInProgress):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				// A storage failure; the client can retry with the same key
+				log.Printf("Failed to reserve idempotency key %s: %v", key, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process idempotency key"})
+			}
+			c.Abort()
+			return
+		}
+
+		if replay {
+			contentType := idempotencyContentType
+			if record.ResponseContentType != nil {
+				contentType = *record.ResponseContentType
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(*record.ResponseStatus, contentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+		c.Next()
+
+		contentType := recorder.Header().Get("Content-Type")
+		if err := idempotencyService.Complete(record, recorder.Status(), contentType, recorder.body.Bytes()); err != nil {
+			log.Printf("Failed to store response for idempotency key %s: %v", key, err)
+		}
+	}
+}
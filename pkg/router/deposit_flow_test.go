@@ -0,0 +1,338 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/config"
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/internal/testdb"
+	"github.com/brainox/paystack_wallet_service/pkg/fakepaystack"
+	"github.com/brainox/paystack_wallet_service/pkg/handlers"
+	"github.com/brainox/paystack_wallet_service/services/alias"
+	"github.com/brainox/paystack_wallet_service/services/auth"
+	"github.com/brainox/paystack_wallet_service/services/idempotency"
+	"github.com/brainox/paystack_wallet_service/services/kyc"
+	"github.com/brainox/paystack_wallet_service/services/ledger"
+	"github.com/brainox/paystack_wallet_service/services/notification"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+	"github.com/brainox/paystack_wallet_service/services/paymentrequest"
+	"github.com/brainox/paystack_wallet_service/services/paystack"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/brainox/paystack_wallet_service/services/scheduledtransfer"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/brainox/paystack_wallet_service/services/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const testPaystackSecret = "sk_test_fake"
+
+// testApp is the wallet service wired up as in main, talking to a fake
+// Paystack that sends its webhooks back to the service
+type testApp struct {
+	t             *testing.T
+	db            *sqlx.DB
+	server        *httptest.Server
+	paystack      *fakepaystack.Server
+	paystackURL   string
+	jwtService    *auth.JWTService
+	userRepo      *repository.UserRepository
+	walletRepo    *repository.WalletRepository
+	ledgerService *ledger.LedgerService
+}
+
+func newTestApp(t *testing.T) *testApp {
+	db := testdb.Open(t)
+	gin.SetMode(gin.TestMode)
+
+	// Both servers need each other's URL, so they are started once both are built
+	appServer := httptest.NewUnstartedServer(nil)
+	paystackServer := httptest.NewUnstartedServer(nil)
+	appURL := "http://" + appServer.Listener.Addr().String()
+	paystackURL := "http://" + paystackServer.Listener.Addr().String()
+
+	fake := fakepaystack.NewServer(fakepaystack.Config{
+		SecretKey:  testPaystackSecret,
+		PublicURL:  paystackURL,
+		WebhookURL: appURL + "/webhooks/paystack",
+	})
+
+	userRepo := repository.NewUserRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
+	ledgerService := ledger.NewLedgerService(db, repository.NewLedgerRepository(db), walletRepo)
+	jwtService := auth.NewJWTService("test-secret", time.Hour)
+	apiKeyService := auth.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+	paystackService := paystack.NewPaystackService(testPaystackSecret, paystackURL)
+	providers := payment.NewRegistry(paystackService)
+	kycTiers := kyc.Tiers{}
+
+	walletService := wallet.NewWalletService(
+		db,
+		walletRepo,
+		repository.NewTransactionRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewPocketRepository(db),
+		repository.NewFXQuoteRepository(db),
+		repository.NewEscrowRepository(db),
+		repository.NewPayoutRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		userRepo,
+		repository.NewLimitOverrideRepository(db),
+		ledgerService,
+		paystackService,
+		providers,
+		nil,
+		wallet.Limits{},
+		kycTiers,
+		nil,
+		wallet.ReversalPolicy{},
+	)
+	aliasService := alias.NewAliasService(
+		db,
+		repository.NewAliasRepository(db),
+		walletRepo,
+		alias.NewLogOTPSender(),
+		[]byte("test-otp-secret"),
+		alias.NewBlocklist(nil),
+		alias.Policy{},
+	)
+	webhookService := webhook.NewWebhookService(
+		repository.NewWebhookEventRepository(db),
+		walletService,
+		providers,
+		webhook.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	)
+
+	walletRouter := NewWalletRouter(
+		handlers.NewAuthHandler(auth.NewGoogleAuthService(&config.GoogleOAuthConfig{}, userRepo, walletRepo, jwtService)),
+		handlers.NewAPIKeyHandler(apiKeyService),
+		handlers.NewWalletHandler(walletService, aliasService),
+		handlers.NewWebhookHandler(webhookService),
+		handlers.NewScheduledTransferHandler(scheduledtransfer.NewScheduledTransferService(
+			db,
+			repository.NewScheduledTransferRepository(db),
+			walletRepo,
+			userRepo,
+			walletService,
+			notification.NewLogNotifier(),
+			scheduledtransfer.RetryPolicy{},
+		)),
+		handlers.NewKYCHandler(kyc.NewKYCService(db, repository.NewKYCRepository(db), userRepo, kyc.NewStubVerifier(), kycTiers)),
+		handlers.NewPaymentRequestHandler(paymentrequest.NewPaymentRequestService(
+			repository.NewPaymentRequestRepository(db),
+			walletRepo,
+			userRepo,
+			walletService,
+			notification.NewLogNotifier(),
+			paymentrequest.Policy{},
+		)),
+		handlers.NewAliasHandler(aliasService),
+		jwtService,
+		apiKeyService,
+		idempotency.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, time.Minute),
+		"test-admin-key",
+	)
+
+	appServer.Config.Handler = walletRouter.Setup()
+	paystackServer.Config.Handler = fake
+	appServer.Start()
+	paystackServer.Start()
+	t.Cleanup(appServer.Close)
+	t.Cleanup(paystackServer.Close)
+
+	return &testApp{
+		t:             t,
+		db:            db,
+		server:        appServer,
+		paystack:      fake,
+		paystackURL:   paystackURL,
+		jwtService:    jwtService,
+		userRepo:      userRepo,
+		walletRepo:    walletRepo,
+		ledgerService: ledgerService,
+	}
+}
+
+// newUser creates a user with an NGN wallet and returns a JWT for them
+func (a *testApp) newUser() (*models.Wallet, string) {
+	a.t.Helper()
+	user := &models.User{Email: "deposit-" + uuid.NewString() + "@example.com", Name: "Deposit Test"}
+	if err := a.userRepo.Create(user); err != nil {
+		a.t.Fatalf("failed to create user: %v", err)
+	}
+	wallet := &models.Wallet{UserID: user.ID, Balance: models.NewMoney(0, models.CurrencyNGN)}
+	if err := a.walletRepo.Create(wallet); err != nil {
+		a.t.Fatalf("failed to create wallet: %v", err)
+	}
+	token, err := a.jwtService.GenerateToken(user.ID, user.Email)
+	if err != nil {
+		a.t.Fatalf("failed to generate token: %v", err)
+	}
+	return wallet, token
+}
+
+// do sends a request and decodes a JSON response into out, if given
+func (a *testApp) do(method, url, token string, body interface{}, headers map[string]string, out interface{}) int {
+	a.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			a.t.Fatalf("failed to marshal request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		a.t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			a.t.Fatalf("failed to decode %s %s response: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func (a *testApp) balance(token string) models.Money {
+	a.t.Helper()
+	var resp struct {
+		Balance models.Money `json:"balance"`
+	}
+	if status := a.do(http.MethodGet, a.server.URL+"/wallet/balance", token, nil, nil, &resp); status != http.StatusOK {
+		a.t.Fatalf("GET /wallet/balance = %d", status)
+	}
+	return resp.Balance
+}
+
+func TestDepositThroughFakePaystack(t *testing.T) {
+	app := newTestApp(t)
+	wallet, token := app.newUser()
+
+	var deposit handlers.DepositResponse
+	status := app.do(http.MethodPost, app.server.URL+"/wallet/deposit", token, map[string]string{"amount": "5000.00"}, nil, &deposit)
+	if status != http.StatusOK {
+		t.Fatalf("POST /wallet/deposit = %d", status)
+	}
+	if !strings.HasPrefix(deposit.AuthorizationURL, app.paystackURL+"/checkout/") {
+		t.Fatalf("authorization URL = %q, want the fake Paystack checkout", deposit.AuthorizationURL)
+	}
+	if got := app.balance(token); got.Amount != 0 {
+		t.Errorf("balance before payment = %s, want 0.00", got)
+	}
+
+	charges := app.paystack.Charges()
+	if len(charges) != 1 || charges[0].Amount != 500000 {
+		t.Fatalf("fake Paystack charges = %+v, want one of 500000 kobo", charges)
+	}
+	charge := charges[0]
+
+	// A webhook that is not signed with the secret key is refused
+	forged := []byte(`{"event":"charge.success","data":{"reference":"` + charge.Reference + `","status":"success","amount":500000,"currency":"NGN"}}`)
+	status = app.do(http.MethodPost, app.server.URL+"/webhooks/paystack", "", forged, map[string]string{
+		"x-paystack-signature": fakepaystack.Sign("sk_test_wrong", forged),
+	}, nil)
+	if status != http.StatusUnauthorized {
+		t.Errorf("forged webhook = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if got := app.balance(token); got.Amount != 0 {
+		t.Errorf("balance after forged webhook = %s, want 0.00", got)
+	}
+
+	// Paying at checkout makes the fake send a signed charge.success
+	resp, err := http.Get(deposit.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("checkout = %d", resp.StatusCode)
+	}
+
+	if got := app.balance(token); got.Amount != 500000 {
+		t.Errorf("balance after payment = %s, want 5000.00", got)
+	}
+	var depositStatus struct {
+		Status models.TransactionStatus `json:"status"`
+	}
+	app.do(http.MethodGet, app.server.URL+"/wallet/deposit/"+deposit.Reference+"/status", token, nil, nil, &depositStatus)
+	if depositStatus.Status != models.TransactionStatusSuccess {
+		t.Errorf("deposit status = %s, want %s", depositStatus.Status, models.TransactionStatusSuccess)
+	}
+
+	// The deposit is posted from Paystack clearing to the wallet
+	entry, err := app.ledgerService.GetJournalEntry(deposit.Reference)
+	if err != nil {
+		t.Fatalf("GetJournalEntry failed: %v", err)
+	}
+	tx, err := app.db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	clearing, err := app.ledgerService.SystemAccount(tx, models.LedgerAccountPaystackClearing, models.CurrencyNGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	walletAccount, err := app.ledgerService.WalletAccount(tx, wallet.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uuid.UUID]models.LedgerDirection{clearing.ID: models.LedgerDebit, walletAccount.ID: models.LedgerCredit}
+	if len(entry.Postings) != len(want) {
+		t.Fatalf("entry has %d postings, want %d", len(entry.Postings), len(want))
+	}
+	for _, posting := range entry.Postings {
+		if posting.Direction != want[posting.AccountID] || posting.Amount.Amount != 500000 {
+			t.Errorf("posting %s %s of %d, want %s of 500000", posting.AccountID, posting.Direction, posting.Amount.Amount, want[posting.AccountID])
+		}
+	}
+
+	// Paystack delivering the webhook again does not credit the wallet twice
+	redelivery := map[string]interface{}{
+		"event": "charge.success",
+		"data":  map[string]interface{}{"reference": charge.Reference, "status": "success", "amount": charge.Amount, "currency": charge.Currency},
+	}
+	if status := app.do(http.MethodPost, app.paystackURL+"/_fake/webhooks", "", redelivery, nil, nil); status != http.StatusOK {
+		t.Errorf("redelivered webhook = %d", status)
+	}
+	if got := app.balance(token); got.Amount != 500000 {
+		t.Errorf("balance after redelivery = %s, want 5000.00", got)
+	}
+
+	mismatches, err := app.ledgerService.VerifyWalletBalances()
+	if err != nil {
+		t.Fatalf("VerifyWalletBalances failed: %v", err)
+	}
+	for _, mismatch := range mismatches {
+		if mismatch.WalletID == wallet.ID {
+			t.Errorf("wallet balance %s does not match its ledger balance %s", mismatch.StoredBalance, mismatch.LedgerBalance)
+		}
+	}
+}
@@ -5,30 +5,52 @@ import (
 	"github.com/brainox/paystack_wallet_service/pkg/handlers"
 	"github.com/brainox/paystack_wallet_service/pkg/middleware"
 	"github.com/brainox/paystack_wallet_service/services/auth"
+	"github.com/brainox/paystack_wallet_service/services/idempotency"
 	"github.com/gin-gonic/gin"
 )
 
 type WalletRouter struct {
-	authHandler   *handlers.AuthHandler
-	apiKeyHandler *handlers.APIKeyHandler
-	walletHandler *handlers.WalletHandler
-	jwtService    *auth.JWTService
-	apiKeyService *auth.APIKeyService
+	authHandler        *handlers.AuthHandler
+	apiKeyHandler      *handlers.APIKeyHandler
+	walletHandler      *handlers.WalletHandler
+	webhookHandler     *handlers.WebhookHandler
+	scheduledHandler   *handlers.ScheduledTransferHandler
+	kycHandler         *handlers.KYCHandler
+	paymentReqHandler  *handlers.PaymentRequestHandler
+	aliasHandler       *handlers.AliasHandler
+	jwtService         *auth.JWTService
+	apiKeyService      *auth.APIKeyService
+	idempotencyService *idempotency.IdempotencyService
+	adminAPIKey        string
 }
 
 func NewWalletRouter(
 	authHandler *handlers.AuthHandler,
 	apiKeyHandler *handlers.APIKeyHandler,
 	walletHandler *handlers.WalletHandler,
+	webhookHandler *handlers.WebhookHandler,
+	scheduledHandler *handlers.ScheduledTransferHandler,
+	kycHandler *handlers.KYCHandler,
+	paymentReqHandler *handlers.PaymentRequestHandler,
+	aliasHandler *handlers.AliasHandler,
 	jwtService *auth.JWTService,
 	apiKeyService *auth.APIKeyService,
+	idempotencyService *idempotency.IdempotencyService,
+	adminAPIKey string,
 ) *WalletRouter {
 	return &WalletRouter{
-		authHandler:   authHandler,
-		apiKeyHandler: apiKeyHandler,
-		walletHandler: walletHandler,
-		jwtService:    jwtService,
-		apiKeyService: apiKeyService,
+		authHandler:        authHandler,
+		apiKeyHandler:      apiKeyHandler,
+		walletHandler:      walletHandler,
+		webhookHandler:     webhookHandler,
+		scheduledHandler:   scheduledHandler,
+		kycHandler:         kycHandler,
+		paymentReqHandler:  paymentReqHandler,
+		aliasHandler:       aliasHandler,
+		jwtService:         jwtService,
+		apiKeyService:      apiKeyService,
+		idempotencyService: idempotencyService,
+		adminAPIKey:        adminAPIKey,
 	}
 }
 
@@ -53,12 +75,34 @@ func (r *WalletRouter) Setup() *gin.Engine {
 		auth.GET("/google/callback", r.authHandler.HandleGoogleCallback)
 	}
 
-	// Webhook route (no authentication required but signature validation)
-	router.POST("/wallet/paystack/webhook", r.walletHandler.HandlePaystackWebhook)
+	// Webhook routes (no authentication required but signature validation)
+	router.POST("/wallet/paystack/webhook", r.webhookHandler.HandlePaystackWebhook)
+	router.POST("/webhooks/:provider", r.webhookHandler.HandleProviderWebhook)
+
+	// Admin routes (admin key required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AdminAuth(r.adminAPIKey))
+	{
+		admin.GET("/webhooks", r.webhookHandler.ListWebhookEvents)
+		admin.GET("/webhooks/:id", r.webhookHandler.GetWebhookEvent)
+		admin.POST("/webhooks/:id/replay", r.webhookHandler.ReplayWebhookEvent)
+		admin.POST("/deposits/:reference/refund", r.walletHandler.RefundDeposit)
+		admin.POST("/transfers/:reference/reverse", r.walletHandler.ReverseTransfer)
+		admin.POST("/escrows/:id/resolve", r.walletHandler.ResolveEscrowDispute)
+		admin.GET("/users/:id/limits", r.walletHandler.ListUserLimitOverrides)
+		admin.PUT("/users/:id/limits", r.walletHandler.SetUserLimitOverride)
+		admin.DELETE("/users/:id/limits", r.walletHandler.DeleteUserLimitOverride)
+		admin.GET("/api-keys/:id/limits", r.walletHandler.ListAPIKeyLimitOverrides)
+		admin.PUT("/api-keys/:id/limits", r.walletHandler.SetAPIKeyLimitOverride)
+		admin.DELETE("/api-keys/:id/limits", r.walletHandler.DeleteAPIKeyLimitOverride)
+	}
 
 	// Authenticated routes
 	authMiddleware := middleware.AuthMiddleware(r.jwtService, r.apiKeyService)
 
+	// Replays the stored response when a client retries with the same Idempotency-Key
+	idempotencyMiddleware := middleware.Idempotency(r.idempotencyService)
+
 	// API Key management routes (JWT only)
 	keys := router.Group("/keys")
 	keys.Use(authMiddleware)
@@ -69,6 +113,28 @@ func (r *WalletRouter) Setup() *gin.Engine {
 		keys.DELETE("/:id", r.apiKeyHandler.DeleteAPIKey)
 	}
 
+	// KYC routes (JWT only; identity details cannot be submitted with an API key)
+	kycRoutes := router.Group("/kyc")
+	kycRoutes.Use(authMiddleware, middleware.RequireJWT())
+	{
+		kycRoutes.GET("", r.kycHandler.GetKYCStatus)
+		kycRoutes.POST("/submissions", r.kycHandler.SubmitKYC)
+		kycRoutes.GET("/submissions", r.kycHandler.ListKYCSubmissions)
+	}
+
+	// Alias routes (JWT only; a handle or phone number identifies the user to senders)
+	aliases := router.Group("/aliases")
+	aliases.Use(authMiddleware, middleware.RequireJWT())
+	{
+		aliases.GET("", r.aliasHandler.GetAliases)
+		aliases.GET("/handle/availability", r.aliasHandler.CheckHandle)
+		aliases.PUT("/handle", r.aliasHandler.ClaimHandle)
+		aliases.DELETE("/handle", r.aliasHandler.ReleaseHandle)
+		aliases.POST("/phone", r.aliasHandler.StartPhoneVerification)
+		aliases.POST("/phone/verify", r.aliasHandler.VerifyPhone)
+		aliases.DELETE("/phone", r.aliasHandler.ReleasePhone)
+	}
+
 	// Wallet routes
 	wallet := router.Group("/wallet")
 	wallet.Use(authMiddleware)
@@ -76,6 +142,7 @@ func (r *WalletRouter) Setup() *gin.Engine {
 		// Deposit (requires JWT or API key with deposit permission)
 		wallet.POST("/deposit",
 			middleware.RequirePermission(models.PermissionDeposit),
+			idempotencyMiddleware,
 			r.walletHandler.InitiateDeposit,
 		)
 
@@ -97,12 +164,262 @@ func (r *WalletRouter) Setup() *gin.Engine {
 			r.walletHandler.GetWalletInfo,
 		)
 
+		// Open a wallet in another currency (JWT only)
+		wallet.POST("/wallets",
+			middleware.RequireJWT(),
+			r.walletHandler.CreateWallet,
+		)
+
+		// List the user's wallets, one per currency (read permission)
+		wallet.GET("/wallets",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListWallets,
+		)
+
+		// Pockets set money aside from the available balance (transfer permission)
+		wallet.POST("/pockets",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.CreatePocket,
+		)
+		wallet.GET("/pockets",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListPockets,
+		)
+		wallet.GET("/pockets/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetPocket,
+		)
+		wallet.PATCH("/pockets/:id",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.UpdatePocket,
+		)
+		wallet.DELETE("/pockets/:id",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.DeletePocket,
+		)
+		wallet.POST("/pockets/:id/move-in",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.MoveToPocket,
+		)
+		wallet.POST("/pockets/:id/move-out",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.MoveFromPocket,
+		)
+
+		// Currency conversion between the user's own wallets (transfer permission)
+		wallet.POST("/fx/quotes",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.CreateFXQuote,
+		)
+		wallet.GET("/fx/quotes/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetFXQuote,
+		)
+		wallet.POST("/fx/quotes/:id/execute",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.walletHandler.ExecuteFXQuote,
+		)
+
 		// Transfer (transfer permission)
 		wallet.POST("/transfer",
 			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
 			r.walletHandler.Transfer,
 		)
 
+		// Bulk payouts: many transfers submitted together and paid in the background (transfer permission)
+		wallet.POST("/payouts",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.walletHandler.CreatePayoutBatch,
+		)
+		wallet.GET("/payouts",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListPayoutBatches,
+		)
+		wallet.GET("/payouts/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetPayoutBatch,
+		)
+
+		// Transfer limits and what is left of them (read permission)
+		wallet.GET("/limits",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetLimits,
+		)
+
+		// Fee for a deposit, transfer or withdrawal (read permission)
+		wallet.GET("/fees/quote",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.QuoteFee,
+		)
+
+		// Banks available for withdrawals (read permission)
+		wallet.GET("/banks",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListBanks,
+		)
+
+		// Resolve a bank account name (read permission)
+		wallet.GET("/banks/resolve",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ResolveBankAccount,
+		)
+
+		// Masked owner name for a wallet number or alias, to check a recipient before paying (read permission)
+		wallet.GET("/resolve",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ResolveWalletNumber,
+		)
+
+		// Saved wallets and bank accounts that transfers and withdrawals can refer to (transfer permission)
+		wallet.POST("/beneficiaries",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.CreateBeneficiary,
+		)
+		wallet.GET("/beneficiaries",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListBeneficiaries,
+		)
+		wallet.GET("/beneficiaries/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetBeneficiary,
+		)
+		wallet.PATCH("/beneficiaries/:id",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.UpdateBeneficiary,
+		)
+		wallet.DELETE("/beneficiaries/:id",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.DeleteBeneficiary,
+		)
+
+		// Withdraw to a bank account (withdraw permission)
+		wallet.POST("/withdraw",
+			middleware.RequirePermission(models.PermissionWithdraw),
+			idempotencyMiddleware,
+			r.walletHandler.Withdraw,
+		)
+
+		// Holds reserve funds until they are captured or released (transfer permission)
+		wallet.POST("/holds",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.walletHandler.CreateHold,
+		)
+		wallet.GET("/holds",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListHolds,
+		)
+		wallet.GET("/holds/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetHold,
+		)
+		wallet.POST("/holds/:id/capture",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.walletHandler.CaptureHold,
+		)
+		wallet.POST("/holds/:id/release",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.ReleaseHold,
+		)
+
+		// Escrow holds a buyer's payment until it is released to the seller
+		// (transfer permission to fund, cancel and dispute, read to view)
+		wallet.POST("/escrows",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.walletHandler.CreateEscrow,
+		)
+		wallet.GET("/escrows",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.ListEscrows,
+		)
+		wallet.GET("/escrows/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.walletHandler.GetEscrow,
+		)
+		// Releasing needs the escrow_release permission when done with an API key
+		wallet.POST("/escrows/:id/release",
+			middleware.RequirePermission(models.PermissionEscrowRelease),
+			idempotencyMiddleware,
+			r.walletHandler.ReleaseEscrow,
+		)
+		wallet.POST("/escrows/:id/cancel",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.walletHandler.CancelEscrow,
+		)
+		wallet.POST("/escrows/:id/dispute",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.walletHandler.DisputeEscrow,
+		)
+
+		// Scheduled and recurring transfers (transfer permission to manage, read to view)
+		wallet.POST("/scheduled-transfers",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.scheduledHandler.CreateScheduledTransfer,
+		)
+		wallet.GET("/scheduled-transfers",
+			middleware.RequirePermission(models.PermissionRead),
+			r.scheduledHandler.ListScheduledTransfers,
+		)
+		wallet.GET("/scheduled-transfers/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.scheduledHandler.GetScheduledTransfer,
+		)
+		wallet.GET("/scheduled-transfers/:id/runs",
+			middleware.RequirePermission(models.PermissionRead),
+			r.scheduledHandler.ListScheduledTransferRuns,
+		)
+		wallet.POST("/scheduled-transfers/:id/pause",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.scheduledHandler.PauseScheduledTransfer,
+		)
+		wallet.POST("/scheduled-transfers/:id/resume",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.scheduledHandler.ResumeScheduledTransfer,
+		)
+		wallet.POST("/scheduled-transfers/:id/cancel",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.scheduledHandler.CancelScheduledTransfer,
+		)
+
+		// Payment requests between users (transfer permission to send and answer, read to view)
+		wallet.POST("/payment-requests",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.paymentReqHandler.CreatePaymentRequest,
+		)
+		wallet.GET("/payment-requests/sent",
+			middleware.RequirePermission(models.PermissionRead),
+			r.paymentReqHandler.ListSentPaymentRequests,
+		)
+		wallet.GET("/payment-requests/received",
+			middleware.RequirePermission(models.PermissionRead),
+			r.paymentReqHandler.ListReceivedPaymentRequests,
+		)
+		wallet.GET("/payment-requests/:id",
+			middleware.RequirePermission(models.PermissionRead),
+			r.paymentReqHandler.GetPaymentRequest,
+		)
+		wallet.POST("/payment-requests/:id/accept",
+			middleware.RequirePermission(models.PermissionTransfer),
+			idempotencyMiddleware,
+			r.paymentReqHandler.AcceptPaymentRequest,
+		)
+		wallet.POST("/payment-requests/:id/decline",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.paymentReqHandler.DeclinePaymentRequest,
+		)
+		wallet.POST("/payment-requests/:id/cancel",
+			middleware.RequirePermission(models.PermissionTransfer),
+			r.paymentReqHandler.CancelPaymentRequest,
+		)
+
 		// Transaction history (read permission)
 		wallet.GET("/transactions",
 			middleware.RequirePermission(models.PermissionRead),
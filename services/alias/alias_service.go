@@ -0,0 +1,457 @@
+package alias
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// otpDigits is the length of the codes sent to phone numbers
+const otpDigits = 6
+
+// ErrPhoneVerificationDisabled is returned for phone verification when no
+// OTP sender is configured
+var ErrPhoneVerificationDisabled = errors.New("phone aliases are disabled")
+
+// Policy controls how aliases are claimed and verified
+type Policy struct {
+	// How long a released alias is held back from other users; its previous
+	// owner can claim it again at any time
+	ReleaseCooldown time.Duration
+	// Shortest time between two handle changes, so that nobody can hold back
+	// many handles by cycling through them
+	HandleChangeInterval time.Duration
+	// How long a one-time code can be used for
+	OTPTTL time.Duration
+	// Shortest time between two codes sent to the same user
+	OTPResendInterval time.Duration
+	// Wrong codes allowed before a code stops working
+	MaxOTPAttempts int
+}
+
+// reclaimableAt returns when userID can claim an alias that was last
+// released as released. Its previous holder can claim it back at any time.
+func (p Policy) reclaimableAt(released *models.Alias, userID uuid.UUID) time.Time {
+	if released == nil || released.ReleasedAt == nil || released.UserID == userID {
+		return time.Time{}
+	}
+	return released.ReleasedAt.Add(p.ReleaseCooldown)
+}
+
+// nextHandleChange returns when a user whose aliases, newest first, are
+// history can claim a new handle
+func (p Policy) nextHandleChange(history []models.Alias) time.Time {
+	for _, previous := range history {
+		if previous.Type == models.AliasTypeHandle {
+			return previous.ClaimedAt.Add(p.HandleChangeInterval)
+		}
+	}
+	return time.Time{}
+}
+
+// nextOTPAt returns when another code can be sent to a user whose last code
+// was latest
+func (p Policy) nextOTPAt(latest *models.AliasVerification) time.Time {
+	if latest == nil {
+		return time.Time{}
+	}
+	return latest.CreatedAt.Add(p.OTPResendInterval)
+}
+
+// UnavailableError is returned when an alias cannot be claimed
+type UnavailableError struct {
+	Reason string
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Reason
+}
+
+func unavailable(format string, args ...interface{}) error {
+	return &UnavailableError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// UnknownAliasError is returned when an alias does not lead to a wallet
+type UnknownAliasError struct {
+	Reason string
+}
+
+func (e *UnknownAliasError) Error() string {
+	return e.Reason
+}
+
+func unknownAlias(format string, args ...interface{}) error {
+	return &UnknownAliasError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// AliasService lets users claim a handle or phone number that senders can
+// use in place of their wallet number
+type AliasService struct {
+	db         *sqlx.DB
+	aliasRepo  *repository.AliasRepository
+	walletRepo *repository.WalletRepository
+	// Nil when phone verification is disabled
+	otpSender OTPSender
+	// Key for the HMAC that codes are stored under
+	otpSecret []byte
+	blocklist *Blocklist
+	policy    Policy
+}
+
+func NewAliasService(
+	db *sqlx.DB,
+	aliasRepo *repository.AliasRepository,
+	walletRepo *repository.WalletRepository,
+	otpSender OTPSender,
+	otpSecret []byte,
+	blocklist *Blocklist,
+	policy Policy,
+) *AliasService {
+	return &AliasService{
+		db:         db,
+		aliasRepo:  aliasRepo,
+		walletRepo: walletRepo,
+		otpSender:  otpSender,
+		otpSecret:  otpSecret,
+		blocklist:  blocklist,
+		policy:     policy,
+	}
+}
+
+// Aliases is a user's current handle and phone number and the aliases they
+// have given up
+type Aliases struct {
+	Handle  *models.Alias  `json:"handle"`
+	Phone   *models.Alias  `json:"phone"`
+	History []models.Alias `json:"history"`
+}
+
+// List returns a user's aliases
+func (s *AliasService) List(userID uuid.UUID) (*Aliases, error) {
+	all, err := s.aliasRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+
+	aliases := &Aliases{History: []models.Alias{}}
+	for i := range all {
+		alias := &all[i]
+		switch {
+		case alias.Status == models.AliasStatusReleased:
+			aliases.History = append(aliases.History, *alias)
+		case alias.Type == models.AliasTypeHandle:
+			aliases.Handle = alias
+		case alias.Type == models.AliasTypePhone:
+			aliases.Phone = alias
+		}
+	}
+	return aliases, nil
+}
+
+// Availability is whether a handle can be claimed, and why not
+type Availability struct {
+	Handle    string `json:"handle"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// CheckHandle reports whether a user could claim handle
+func (s *AliasService) CheckHandle(userID uuid.UUID, handle string) (*Availability, error) {
+	availability := &Availability{Handle: handle}
+	if _, err := NormalizeHandle(handle); err != nil {
+		availability.Reason = err.Error()
+		return availability, nil
+	}
+	normalized, err := s.validateHandle(userID, handle)
+	if err != nil {
+		var unavailableErr *UnavailableError
+		if !errors.As(err, &unavailableErr) {
+			return nil, err
+		}
+		availability.Reason = unavailableErr.Reason
+		return availability, nil
+	}
+	availability.Handle = "@" + normalized
+	availability.Available = true
+	return availability, nil
+}
+
+// ClaimHandle makes handle the user's handle, releasing their current one
+func (s *AliasService) ClaimHandle(userID uuid.UUID, handle string) (*models.Alias, error) {
+	handle, err := s.validateHandle(userID, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.aliasRepo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	if next := s.policy.nextHandleChange(history); time.Now().Before(next) {
+		return nil, fmt.Errorf("you can change your handle again after %s", next.Format(time.RFC3339))
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	alias, err := s.claim(tx, userID, models.AliasTypeHandle, handle)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return alias, nil
+}
+
+// validateHandle normalizes handle and checks that the user may claim it
+func (s *AliasService) validateHandle(userID uuid.UUID, handle string) (string, error) {
+	handle, err := NormalizeHandle(handle)
+	if err != nil {
+		return "", err
+	}
+	if s.blocklist.Blocks(handle) {
+		return "", unavailable("@%s is reserved", handle)
+	}
+	if err := s.checkAvailable(userID, "@"+handle, handle); err != nil {
+		return "", err
+	}
+	return handle, nil
+}
+
+// checkAvailable checks that nobody holds value and that it was not given up
+// by someone else too recently. display is value as shown to the user.
+func (s *AliasService) checkAvailable(userID uuid.UUID, display, value string) error {
+	active, err := s.aliasRepo.GetActive(value)
+	if err != nil {
+		return fmt.Errorf("failed to look up alias: %w", err)
+	}
+	if active != nil {
+		if active.UserID == userID {
+			return unavailable("%s is already yours", display)
+		}
+		return unavailable("%s is taken", display)
+	}
+
+	released, err := s.aliasRepo.GetLastReleased(value)
+	if err != nil {
+		return fmt.Errorf("failed to look up alias history: %w", err)
+	}
+	if until := s.policy.reclaimableAt(released, userID); time.Now().Before(until) {
+		return unavailable("%s was recently released and can be claimed after %s", display, until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// claim releases the user's current alias of aliasType and gives them value
+// instead, within tx
+func (s *AliasService) claim(tx *sqlx.Tx, userID uuid.UUID, aliasType models.AliasType, value string) (*models.Alias, error) {
+	if err := s.aliasRepo.Release(tx, userID, aliasType); err != nil {
+		return nil, fmt.Errorf("failed to release alias: %w", err)
+	}
+	alias := &models.Alias{
+		UserID: userID,
+		Type:   aliasType,
+		Value:  value,
+	}
+	if err := s.aliasRepo.Create(tx, alias); err != nil {
+		// Someone claimed it between the availability check and now
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, unavailable("%s is taken", alias)
+		}
+		return nil, fmt.Errorf("failed to create alias: %w", err)
+	}
+	return alias, nil
+}
+
+// Release gives up the user's alias of aliasType. It stays in their history
+// and cannot be claimed by anyone else until the cooldown has passed.
+func (s *AliasService) Release(userID uuid.UUID, aliasType models.AliasType) error {
+	aliases, err := s.List(userID)
+	if err != nil {
+		return err
+	}
+	if (aliasType == models.AliasTypeHandle && aliases.Handle == nil) ||
+		(aliasType == models.AliasTypePhone && aliases.Phone == nil) {
+		return fmt.Errorf("you have no %s alias", aliasType)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.aliasRepo.Release(tx, userID, aliasType); err != nil {
+		return fmt.Errorf("failed to release alias: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// StartPhoneVerification sends a one-time code to phone. The phone number
+// becomes the user's alias once the code is sent back to VerifyPhone.
+func (s *AliasService) StartPhoneVerification(userID uuid.UUID, phone string) (*models.AliasVerification, error) {
+	if s.otpSender == nil {
+		return nil, ErrPhoneVerificationDisabled
+	}
+	phone, err := NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAvailable(userID, phone, phone); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.aliasRepo.GetLatestVerification(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verification: %w", err)
+	}
+	if next := s.policy.nextOTPAt(latest); time.Now().Before(next) {
+		return nil, fmt.Errorf("a code was sent recently; you can request another after %s", next.Format(time.RFC3339))
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code: %w", err)
+	}
+	verification := &models.AliasVerification{
+		UserID:    userID,
+		Phone:     phone,
+		CodeHash:  s.hashOTP(userID, phone, code),
+		ExpiresAt: time.Now().Add(s.policy.OTPTTL),
+	}
+	if err := s.aliasRepo.CreateVerification(verification); err != nil {
+		return nil, fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := s.otpSender.Send(phone, code); err != nil {
+		return nil, fmt.Errorf("failed to send code with %s: %w", s.otpSender.Name(), err)
+	}
+	return verification, nil
+}
+
+// VerifyPhone checks a code sent by StartPhoneVerification and, if it is
+// right, makes the phone number the user's alias in place of their current one
+func (s *AliasService) VerifyPhone(userID, verificationID uuid.UUID, code string) (*models.Alias, error) {
+	if s.otpSender == nil {
+		return nil, ErrPhoneVerificationDisabled
+	}
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	verification, err := s.aliasRepo.GetVerificationForUpdate(tx, verificationID)
+	if err != nil {
+		return nil, err
+	}
+	if verification.UserID != userID {
+		return nil, fmt.Errorf("verification not found")
+	}
+	if verification.VerifiedAt != nil {
+		return nil, fmt.Errorf("this code has already been used")
+	}
+	if time.Now().After(verification.ExpiresAt) {
+		return nil, fmt.Errorf("this code has expired; request a new one")
+	}
+	if verification.Attempts >= s.policy.MaxOTPAttempts {
+		return nil, fmt.Errorf("too many wrong codes; request a new one")
+	}
+
+	expected, _ := hex.DecodeString(verification.CodeHash)
+	given, _ := hex.DecodeString(s.hashOTP(userID, verification.Phone, code))
+	if subtle.ConstantTimeCompare(expected, given) != 1 {
+		if err := s.aliasRepo.RecordAttempt(tx, verification.ID); err != nil {
+			return nil, fmt.Errorf("failed to record attempt: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, fmt.Errorf("incorrect code; %d attempts left", s.policy.MaxOTPAttempts-verification.Attempts-1)
+	}
+
+	// The number may have been claimed by someone else while the code was out
+	if err := s.checkAvailable(userID, verification.Phone, verification.Phone); err != nil {
+		return nil, err
+	}
+	if err := s.aliasRepo.MarkVerified(tx, verification.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark verification: %w", err)
+	}
+	alias, err := s.claim(tx, userID, models.AliasTypePhone, verification.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return alias, nil
+}
+
+// ResolveWalletNumber returns the number of the wallet in currency that
+// belongs to whoever holds alias. alias is a handle, with or without the @,
+// or a phone number. An alias that is malformed or does not lead to a wallet
+// fails with an *UnknownAliasError.
+func (s *AliasService) ResolveWalletNumber(alias string, currency models.Currency) (string, error) {
+	aliasType, value, err := Parse(alias)
+	if err != nil {
+		return "", unknownAlias("%s", err)
+	}
+	display := (&models.Alias{Type: aliasType, Value: value}).String()
+	holder, err := s.aliasRepo.GetActive(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up alias: %w", err)
+	}
+	if holder == nil {
+		return "", unknownAlias("no wallet has the alias %s", display)
+	}
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(holder.UserID, currency)
+	if errors.Is(err, repository.ErrWalletNotFound) {
+		return "", unknownAlias("%s has no %s wallet", display, currency)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return wallet.WalletNumber, nil
+}
+
+// generateOTP returns a random numeric code
+func generateOTP() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n), nil
+}
+
+// hashOTP hashes a code with who it was sent to under the server's OTP
+// secret, so that a stored hash cannot be matched against another user's
+// code, nor the six-digit code brute-forced from a leaked hash
+func (s *AliasService) hashOTP(userID uuid.UUID, phone, code string) string {
+	mac := hmac.New(sha256.New, s.otpSecret)
+	mac.Write([]byte(userID.String() + ":" + phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
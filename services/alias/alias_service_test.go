@@ -0,0 +1,108 @@
+package alias
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestPolicyReclaimableAt(t *testing.T) {
+	policy := Policy{ReleaseCooldown: 90 * 24 * time.Hour}
+	owner, other := uuid.New(), uuid.New()
+	releasedAt := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	released := &models.Alias{UserID: owner, Status: models.AliasStatusReleased, ReleasedAt: &releasedAt}
+
+	tests := []struct {
+		name     string
+		released *models.Alias
+		userID   uuid.UUID
+		want     time.Time
+	}{
+		{name: "never released", released: nil, userID: other},
+		{name: "previous holder reclaims", released: released, userID: owner},
+		{name: "someone else waits out the cooldown", released: released, userID: other, want: releasedAt.Add(90 * 24 * time.Hour)},
+		{name: "no release time", released: &models.Alias{UserID: owner}, userID: other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.reclaimableAt(tt.released, tt.userID); !got.Equal(tt.want) {
+				t.Errorf("reclaimableAt = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyNextHandleChange(t *testing.T) {
+	policy := Policy{HandleChangeInterval: 30 * 24 * time.Hour}
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Newest first, as the repository lists them
+	history := []models.Alias{
+		{Type: models.AliasTypePhone, ClaimedAt: base.Add(48 * time.Hour)},
+		{Type: models.AliasTypeHandle, ClaimedAt: base.Add(24 * time.Hour), Status: models.AliasStatusReleased},
+		{Type: models.AliasTypeHandle, ClaimedAt: base, Status: models.AliasStatusReleased},
+	}
+	if got, want := policy.nextHandleChange(history), base.Add(24*time.Hour+30*24*time.Hour); !got.Equal(want) {
+		t.Errorf("nextHandleChange = %s, want %s", got, want)
+	}
+
+	phoneOnly := []models.Alias{{Type: models.AliasTypePhone, ClaimedAt: base}}
+	for _, history := range [][]models.Alias{nil, phoneOnly} {
+		if got := policy.nextHandleChange(history); !got.IsZero() {
+			t.Errorf("nextHandleChange with no handle = %s, want zero", got)
+		}
+	}
+}
+
+func TestPolicyNextOTPAt(t *testing.T) {
+	policy := Policy{OTPResendInterval: time.Minute}
+	sentAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	if got := policy.nextOTPAt(nil); !got.IsZero() {
+		t.Errorf("nextOTPAt(nil) = %s, want zero", got)
+	}
+	if got, want := policy.nextOTPAt(&models.AliasVerification{CreatedAt: sentAt}), sentAt.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("nextOTPAt = %s, want %s", got, want)
+	}
+}
+
+func TestHashOTP(t *testing.T) {
+	service := &AliasService{otpSecret: []byte("secret-one")}
+	userID := uuid.New()
+	phone := "+2348012345678"
+
+	hash := service.hashOTP(userID, phone, "123456")
+	if hash != service.hashOTP(userID, phone, "123456") {
+		t.Fatal("hashOTP is not deterministic")
+	}
+
+	other := &AliasService{otpSecret: []byte("secret-two")}
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{name: "another code", got: service.hashOTP(userID, phone, "123457")},
+		{name: "another user", got: service.hashOTP(uuid.New(), phone, "123456")},
+		{name: "another phone", got: service.hashOTP(userID, "+2348012345679", "123456")},
+		// Without the secret, a leaked hash cannot be matched by trying every code
+		{name: "another secret", got: other.hashOTP(userID, phone, "123456")},
+	}
+	for _, tt := range tests {
+		if tt.got == hash {
+			t.Errorf("%s gives the same hash", tt.name)
+		}
+	}
+}
+
+func TestPhoneVerificationDisabledWithoutSender(t *testing.T) {
+	service := &AliasService{}
+	if _, err := service.StartPhoneVerification(uuid.New(), "08012345678"); !errors.Is(err, ErrPhoneVerificationDisabled) {
+		t.Errorf("StartPhoneVerification = %v, want ErrPhoneVerificationDisabled", err)
+	}
+	if _, err := service.VerifyPhone(uuid.New(), uuid.New(), "123456"); !errors.Is(err, ErrPhoneVerificationDisabled) {
+		t.Errorf("VerifyPhone = %v, want ErrPhoneVerificationDisabled", err)
+	}
+}
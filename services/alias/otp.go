@@ -0,0 +1,42 @@
+package alias
+
+import (
+	"fmt"
+	"log"
+)
+
+// OTPSender delivers one-time codes that prove a user owns a phone number
+type OTPSender interface {
+	// Name identifies the sender in logs
+	Name() string
+
+	// Send delivers code to phone, which is in E.164 form
+	Send(phone, code string) error
+}
+
+// NewOTPSender returns the OTP sender with the given name
+func NewOTPSender(name string) (OTPSender, error) {
+	switch name {
+	case "log":
+		return NewLogOTPSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown OTP sender %q", name)
+	}
+}
+
+// LogOTPSender writes codes to the service log instead of sending them. It
+// lets phone aliases be claimed locally and must not be used in production.
+type LogOTPSender struct{}
+
+func NewLogOTPSender() *LogOTPSender {
+	return &LogOTPSender{}
+}
+
+func (s *LogOTPSender) Name() string {
+	return "log"
+}
+
+func (s *LogOTPSender) Send(phone, code string) error {
+	log.Printf("OTP for %s: %s", phone, code)
+	return nil
+}
@@ -0,0 +1,123 @@
+package alias
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+const (
+	// MinHandleLength is the shortest a handle can be, without the @
+	MinHandleLength = 3
+	// MaxHandleLength is the longest a handle can be, without the @
+	MaxHandleLength = 20
+)
+
+var (
+	handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	e164Pattern   = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+)
+
+// reservedWords cannot be claimed as handles, on their own or with digits
+// and underscores added, e.g. admin, admin1 and the_admin
+var reservedWords = []string{
+	"account", "accounts", "admin", "administrator", "api", "app", "bank",
+	"billing", "cashier", "ceo", "compliance", "customercare", "customerservice",
+	"deposit", "deposits", "fees", "help", "helpdesk", "info", "mod",
+	"moderator", "null", "operator", "payment", "payments", "payout", "payouts",
+	"refund", "refunds", "root", "security", "settings", "staff", "support",
+	"system", "team", "test", "transfer", "transfers", "undefined", "verify",
+	"wallet", "wallets", "withdraw", "withdrawal",
+}
+
+// impersonationWords cannot appear anywhere in a handle, so that nobody can
+// pass themselves off as the platform, e.g. paystack_refunds
+var impersonationWords = []string{"admin", "paystack", "official", "support"}
+
+// Blocklist holds the words that cannot be claimed as handles
+type Blocklist struct {
+	words map[string]bool
+}
+
+// NewBlocklist returns the built-in reserved words plus extra
+func NewBlocklist(extra []string) *Blocklist {
+	b := &Blocklist{words: make(map[string]bool)}
+	for _, word := range reservedWords {
+		b.words[word] = true
+	}
+	for _, word := range extra {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			b.words[word] = true
+		}
+	}
+	return b
+}
+
+// Blocks reports whether handle is reserved
+func (b *Blocklist) Blocks(handle string) bool {
+	stem := strings.Map(func(r rune) rune {
+		if r == '_' || (r >= '0' && r <= '9') {
+			return -1
+		}
+		return r
+	}, handle)
+	if b.words[handle] || b.words[stem] {
+		return true
+	}
+	for _, word := range impersonationWords {
+		if strings.Contains(stem, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeHandle checks a handle's format and returns it lower case without
+// the leading @. Handles start with a letter and hold only letters, digits
+// and single underscores, so they cannot be mistaken for phone or wallet numbers.
+func NormalizeHandle(handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength {
+		return "", fmt.Errorf("handle must be %d to %d characters", MinHandleLength, MaxHandleLength)
+	}
+	if !handlePattern.MatchString(handle) {
+		return "", fmt.Errorf("handle must start with a letter and contain only letters, digits and underscores")
+	}
+	if strings.Contains(handle, "__") || strings.HasSuffix(handle, "_") {
+		return "", fmt.Errorf("handle cannot end with an underscore or contain two in a row")
+	}
+	return handle, nil
+}
+
+// NormalizePhone returns a phone number in E.164 form. Nigerian numbers may
+// also be given in local form (08012345678) or without the + (2348012345678).
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	switch {
+	case len(phone) == 11 && strings.HasPrefix(phone, "0"):
+		phone = "+234" + phone[1:]
+	case len(phone) == 13 && strings.HasPrefix(phone, "234"):
+		phone = "+" + phone
+	}
+	if !e164Pattern.MatchString(phone) {
+		return "", fmt.Errorf("phone must be in international form, e.g. +2348012345678")
+	}
+	return phone, nil
+}
+
+// Parse works out whether an alias a sender typed is a handle or a phone
+// number and normalizes it. Handles may be given with or without the @.
+func Parse(value string) (models.AliasType, string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", fmt.Errorf("alias is required")
+	}
+	if first := value[0]; first == '+' || (first >= '0' && first <= '9') {
+		phone, err := NormalizePhone(value)
+		return models.AliasTypePhone, phone, err
+	}
+	handle, err := NormalizeHandle(value)
+	return models.AliasTypeHandle, handle, err
+}
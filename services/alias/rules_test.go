@@ -0,0 +1,145 @@
+package alias
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantType  models.AliasType
+		wantValue string
+		wantErr   bool
+	}{
+		{input: "@Ada_99", wantType: models.AliasTypeHandle, wantValue: "ada_99"},
+		{input: "ada", wantType: models.AliasTypeHandle, wantValue: "ada"},
+		{input: "  @bob ", wantType: models.AliasTypeHandle, wantValue: "bob"},
+		{input: "08012345678", wantType: models.AliasTypePhone, wantValue: "+2348012345678"},
+		{input: "2348012345678", wantType: models.AliasTypePhone, wantValue: "+2348012345678"},
+		{input: "+234-801-234-5678", wantType: models.AliasTypePhone, wantValue: "+2348012345678"},
+		{input: "+44 20 7946 0958", wantType: models.AliasTypePhone, wantValue: "+442079460958"},
+		{input: "", wantErr: true},
+		{input: "   ", wantErr: true},
+		// Too short for a phone number, and handles cannot start with a digit
+		{input: "0801234", wantType: models.AliasTypePhone, wantErr: true},
+		{input: "1ada", wantType: models.AliasTypePhone, wantErr: true},
+		{input: "+0123456789", wantType: models.AliasTypePhone, wantErr: true},
+		{input: "+2348012345678901234", wantType: models.AliasTypePhone, wantErr: true},
+		{input: "@ab", wantType: models.AliasTypeHandle, wantErr: true},
+		{input: "_ada", wantType: models.AliasTypeHandle, wantErr: true},
+	}
+	for _, tt := range tests {
+		aliasType, value, err := Parse(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s %q, want an error", tt.input, aliasType, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if aliasType != tt.wantType || value != tt.wantValue {
+			t.Errorf("Parse(%q) = %s %q, want %s %q", tt.input, aliasType, value, tt.wantType, tt.wantValue)
+		}
+	}
+}
+
+func TestNormalizeHandle(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "abc", want: "abc"},
+		{input: "@Ada_Obi", want: "ada_obi"},
+		{input: "a1_b2_c3", want: "a1_b2_c3"},
+		{input: "abcdefghijklmnopqrst", want: "abcdefghijklmnopqrst"},
+		{input: "ab", wantErr: true},
+		{input: "abcdefghijklmnopqrstu", wantErr: true},
+		{input: "9lives", wantErr: true},
+		{input: "ada-obi", wantErr: true},
+		{input: "ada.obi", wantErr: true},
+		{input: "ada obi", wantErr: true},
+		{input: "ada__obi", wantErr: true},
+		{input: "ada_", wantErr: true},
+		{input: "@@ada", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeHandle(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeHandle(%q) = %q, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NormalizeHandle(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeHandle(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBlocklistBlocks(t *testing.T) {
+	blocklist := NewBlocklist([]string{" Promo ", ""})
+
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{handle: "admin", want: true},
+		{handle: "admin_2", want: true},
+		{handle: "refunds", want: true},
+		{handle: "the_admin", want: true},
+		{handle: "paystack_refunds", want: true},
+		{handle: "officialada", want: true},
+		{handle: "promo", want: true},
+		{handle: "promo1", want: true},
+		{handle: "ada_99", want: false},
+		// Reserved words only block a handle on their own
+		{handle: "testing", want: false},
+		{handle: "walletwatcher", want: false},
+	}
+	for _, tt := range tests {
+		if got := blocklist.Blocks(tt.handle); got != tt.want {
+			t.Errorf("Blocks(%q) = %v, want %v", tt.handle, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHandleRejectsBeforeLookingUpAliases(t *testing.T) {
+	// Neither malformed nor reserved handles reach the alias lookup, so the
+	// service needs no repository here
+	service := &AliasService{blocklist: NewBlocklist(nil)}
+
+	tests := []struct {
+		handle       string
+		wantReserved bool
+	}{
+		{handle: "ab"},
+		{handle: "ada__obi"},
+		{handle: "9lives"},
+		{handle: "@Support", wantReserved: true},
+		{handle: "admin_2", wantReserved: true},
+		{handle: "PayStackHQ", wantReserved: true},
+	}
+	for _, tt := range tests {
+		_, err := service.validateHandle(uuid.New(), tt.handle)
+		if err == nil {
+			t.Errorf("validateHandle(%q) succeeded, want an error", tt.handle)
+			continue
+		}
+		var unavailableErr *UnavailableError
+		if reserved := errors.As(err, &unavailableErr); reserved != tt.wantReserved {
+			t.Errorf("validateHandle(%q) = %v; reserved = %v, want %v", tt.handle, err, reserved, tt.wantReserved)
+		}
+	}
+}
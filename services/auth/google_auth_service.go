@@ -85,7 +85,7 @@ func (s *GoogleAuthService) HandleCallback(ctx context.Context, code string) (st
 		// Create wallet for new user
 		wallet := &models.Wallet{
 			UserID:  user.ID,
-			Balance: 0,
+			Balance: models.NewMoney(0, models.DefaultCurrency),
 		}
 		if err := s.walletRepo.Create(wallet); err != nil {
 			return "", fmt.Errorf("failed to create wallet: %w", err)
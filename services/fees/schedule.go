@@ -0,0 +1,165 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// Kind is an operation that can carry a fee
+type Kind string
+
+const (
+	KindDeposit    Kind = "deposit"
+	KindTransfer   Kind = "transfer"
+	KindWithdrawal Kind = "withdrawal"
+)
+
+// basisPointsPerUnit is the number of basis points in 100%
+const basisPointsPerUnit = 10000
+
+// Rule computes the fee for one kind of operation. The fee is Flat plus
+// RateBPS basis points of the amount, raised to Min and limited to Cap. When
+// Tiers are set the first tier covering the amount is used instead.
+type Rule struct {
+	Flat    models.Money  `json:"flat"`
+	RateBPS int64         `json:"rate_bps"` // 150 is 1.5%
+	Min     *models.Money `json:"min,omitempty"`
+	Cap     *models.Money `json:"cap,omitempty"`
+	Tiers   []Tier        `json:"tiers,omitempty"`
+}
+
+// Tier is a rule for amounts up to and including UpTo. The last tier has no
+// UpTo and covers everything above the previous one.
+type Tier struct {
+	UpTo *models.Money `json:"up_to,omitempty"`
+	Rule
+}
+
+// Quote is the fee for an operation, worked out before it is made
+type Quote struct {
+	Type   Kind         `json:"type"`
+	Amount models.Money `json:"amount"`
+	Fee    models.Money `json:"fee"`
+	// What the payer is charged: the amount plus the fee
+	Total models.Money `json:"total"`
+}
+
+// Schedule holds the fee rule for each kind of operation. Rule amounts are in
+// the default currency. Operations without a rule or in another currency are
+// free, as is everything on a nil schedule.
+type Schedule struct {
+	rules map[Kind]Rule
+}
+
+// NewSchedule builds a schedule from rules keyed by operation
+func NewSchedule(rules map[Kind]Rule) (*Schedule, error) {
+	for kind, rule := range rules {
+		switch kind {
+		case KindDeposit, KindTransfer, KindWithdrawal:
+		default:
+			return nil, fmt.Errorf("unknown fee type %q", kind)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s fee: %w", kind, err)
+		}
+	}
+	return &Schedule{rules: rules}, nil
+}
+
+// LoadSchedule reads a schedule from a JSON file of rules keyed by operation.
+// An empty path gives a schedule with no fees.
+func LoadSchedule(path string) (*Schedule, error) {
+	if path == "" {
+		return &Schedule{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee schedule: %w", err)
+	}
+
+	var rules map[Kind]Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse fee schedule: %w", err)
+	}
+	return NewSchedule(rules)
+}
+
+// Quote works out the fee for an operation of the given amount
+func (s *Schedule) Quote(kind Kind, amount models.Money) Quote {
+	fee := models.NewMoney(0, amount.Currency)
+	if s != nil && amount.Currency == models.DefaultCurrency {
+		if rule, ok := s.rules[kind]; ok {
+			fee = rule.fee(amount)
+		}
+	}
+
+	return Quote{
+		Type:   kind,
+		Amount: amount,
+		Fee:    fee,
+		Total:  amount.Add(fee),
+	}
+}
+
+func (r Rule) fee(amount models.Money) models.Money {
+	if len(r.Tiers) > 0 {
+		for _, tier := range r.Tiers {
+			if tier.UpTo == nil || !tier.UpTo.LessThan(amount) {
+				return tier.Rule.fee(amount)
+			}
+		}
+	}
+
+	// Percentages round half up to the nearest minor unit. The amount is
+	// split so that large amounts cannot overflow when multiplied by the rate.
+	whole, rest := amount.Amount/basisPointsPerUnit, amount.Amount%basisPointsPerUnit
+	fee := r.Flat.Amount + whole*r.RateBPS + (rest*r.RateBPS+basisPointsPerUnit/2)/basisPointsPerUnit
+	if r.Min != nil && fee < r.Min.Amount {
+		fee = r.Min.Amount
+	}
+	if r.Cap != nil && fee > r.Cap.Amount {
+		fee = r.Cap.Amount
+	}
+	return models.NewMoney(fee, amount.Currency)
+}
+
+func (r Rule) validate() error {
+	if r.Flat.IsNegative() {
+		return fmt.Errorf("flat must not be negative")
+	}
+	if r.RateBPS < 0 || r.RateBPS > basisPointsPerUnit {
+		return fmt.Errorf("rate_bps must be between 0 and %d", basisPointsPerUnit)
+	}
+	if r.Min != nil && r.Min.IsNegative() {
+		return fmt.Errorf("min must not be negative")
+	}
+	if r.Cap != nil && r.Cap.IsNegative() {
+		return fmt.Errorf("cap must not be negative")
+	}
+	if r.Min != nil && r.Cap != nil && r.Cap.LessThan(*r.Min) {
+		return fmt.Errorf("cap must not be less than min")
+	}
+
+	for i, tier := range r.Tiers {
+		if len(tier.Tiers) > 0 {
+			return fmt.Errorf("tiers cannot be nested")
+		}
+		if err := tier.Rule.validate(); err != nil {
+			return fmt.Errorf("tier %d: %w", i+1, err)
+		}
+		last := i == len(r.Tiers)-1
+		switch {
+		case tier.UpTo == nil && !last:
+			return fmt.Errorf("only the last tier can leave out up_to")
+		case tier.UpTo != nil && last:
+			return fmt.Errorf("the last tier must leave out up_to to cover larger amounts")
+		case tier.UpTo != nil && i > 0 && !r.Tiers[i-1].UpTo.LessThan(*tier.UpTo):
+			return fmt.Errorf("tier %d up_to must be greater than the tier before it", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package fees
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func ngn(amount int64) models.Money {
+	return models.NewMoney(amount, models.CurrencyNGN)
+}
+
+func ngnPtr(amount int64) *models.Money {
+	m := ngn(amount)
+	return &m
+}
+
+func TestRuleFee(t *testing.T) {
+	tiered := Rule{Tiers: []Tier{
+		{UpTo: ngnPtr(500000), Rule: Rule{Flat: ngn(1000)}},
+		{UpTo: ngnPtr(5000000), Rule: Rule{Flat: ngn(2500)}},
+		{Rule: Rule{Flat: ngn(5000), RateBPS: 1, Cap: ngnPtr(10000)}},
+	}}
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		amount int64
+		want   int64
+	}{
+		{name: "no fee", rule: Rule{}, amount: 500000, want: 0},
+		{name: "flat only", rule: Rule{Flat: ngn(10000)}, amount: 500000, want: 10000},
+		{name: "percentage", rule: Rule{RateBPS: 150}, amount: 500000, want: 7500},
+		{name: "flat plus percentage", rule: Rule{Flat: ngn(10000), RateBPS: 150}, amount: 500000, want: 17500},
+		// 1.5% of 0.33 is 0.495 of a kobo, which rounds to 0; of 0.34 it is 0.51, which rounds to 1
+		{name: "rounds down below half", rule: Rule{RateBPS: 150}, amount: 33, want: 0},
+		{name: "rounds half up", rule: Rule{RateBPS: 5000}, amount: 1, want: 1},
+		{name: "rounds up above half", rule: Rule{RateBPS: 150}, amount: 34, want: 1},
+		{name: "whole amount", rule: Rule{RateBPS: basisPointsPerUnit}, amount: 12345, want: 12345},
+
+		{name: "below min", rule: Rule{RateBPS: 50, Min: ngnPtr(1000)}, amount: 100000, want: 1000},
+		{name: "at min", rule: Rule{RateBPS: 50, Min: ngnPtr(1000)}, amount: 200000, want: 1000},
+		{name: "above min", rule: Rule{RateBPS: 50, Min: ngnPtr(1000)}, amount: 300000, want: 1500},
+		{name: "below cap", rule: Rule{RateBPS: 50, Cap: ngnPtr(10000)}, amount: 1999800, want: 9999},
+		{name: "at cap", rule: Rule{RateBPS: 50, Cap: ngnPtr(10000)}, amount: 2000000, want: 10000},
+		{name: "above cap", rule: Rule{RateBPS: 50, Cap: ngnPtr(10000)}, amount: 2000200, want: 10000},
+		{name: "cap includes the flat fee", rule: Rule{Flat: ngn(10000), RateBPS: 150, Cap: ngnPtr(200000)}, amount: 20000000, want: 200000},
+		{name: "min equals cap", rule: Rule{RateBPS: 50, Min: ngnPtr(1000), Cap: ngnPtr(1000)}, amount: 99999999, want: 1000},
+		{name: "zero cap makes it free", rule: Rule{Flat: ngn(1000), Cap: ngnPtr(0)}, amount: 500000, want: 0},
+		{name: "largest amount does not overflow", rule: Rule{RateBPS: 150}, amount: math.MaxInt64, want: 138350580552821637},
+		{name: "largest amount is capped", rule: Rule{RateBPS: 150, Cap: ngnPtr(200000)}, amount: math.MaxInt64, want: 200000},
+
+		{name: "first tier", rule: tiered, amount: 1, want: 1000},
+		{name: "first tier boundary", rule: tiered, amount: 500000, want: 1000},
+		{name: "just above first tier", rule: tiered, amount: 500001, want: 2500},
+		{name: "second tier boundary", rule: tiered, amount: 5000000, want: 2500},
+		{name: "last tier", rule: tiered, amount: 5000001, want: 5500},
+		{name: "last tier capped", rule: tiered, amount: 100000000, want: 10000},
+		{name: "tiers replace the top-level rule", rule: Rule{Flat: ngn(99999), Tiers: tiered.Tiers}, amount: 1, want: 1000},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.fee(ngn(tt.amount)); got.Amount != tt.want {
+			t.Errorf("%s: fee(%d) = %d, want %d", tt.name, tt.amount, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestScheduleQuote(t *testing.T) {
+	schedule, err := NewSchedule(map[Kind]Rule{
+		KindTransfer: {Flat: ngn(1000), RateBPS: 100},
+	})
+	if err != nil {
+		t.Fatalf("NewSchedule failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		schedule *Schedule
+		kind     Kind
+		amount   models.Money
+		wantFee  int64
+	}{
+		{name: "rule applies", schedule: schedule, kind: KindTransfer, amount: ngn(100000), wantFee: 2000},
+		{name: "kind without a rule is free", schedule: schedule, kind: KindWithdrawal, amount: ngn(100000), wantFee: 0},
+		{name: "other currencies are free", schedule: schedule, kind: KindTransfer, amount: models.NewMoney(100000, models.CurrencyUSD), wantFee: 0},
+		{name: "nil schedule is free", schedule: nil, kind: KindTransfer, amount: ngn(100000), wantFee: 0},
+		{name: "empty schedule is free", schedule: &Schedule{}, kind: KindTransfer, amount: ngn(100000), wantFee: 0},
+	}
+	for _, tt := range tests {
+		quote := tt.schedule.Quote(tt.kind, tt.amount)
+		if quote.Fee.Amount != tt.wantFee || quote.Fee.Currency != tt.amount.Currency {
+			t.Errorf("%s: fee = %d %s, want %d %s", tt.name, quote.Fee.Amount, quote.Fee.Currency, tt.wantFee, tt.amount.Currency)
+		}
+		if quote.Total != tt.amount.Add(quote.Fee) || quote.Amount != tt.amount || quote.Type != tt.kind {
+			t.Errorf("%s: quote = %+v, want total of amount and fee", tt.name, quote)
+		}
+	}
+}
+
+func TestNewScheduleValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   map[Kind]Rule
+		wantErr string
+	}{
+		{name: "unknown kind", rules: map[Kind]Rule{"refund": {}}, wantErr: "unknown fee type"},
+		{name: "negative flat", rules: map[Kind]Rule{KindDeposit: {Flat: ngn(-1)}}, wantErr: "flat must not be negative"},
+		{name: "negative rate", rules: map[Kind]Rule{KindDeposit: {RateBPS: -1}}, wantErr: "rate_bps must be between"},
+		{name: "rate above 100%", rules: map[Kind]Rule{KindDeposit: {RateBPS: basisPointsPerUnit + 1}}, wantErr: "rate_bps must be between"},
+		{name: "negative min", rules: map[Kind]Rule{KindDeposit: {Min: ngnPtr(-1)}}, wantErr: "min must not be negative"},
+		{name: "negative cap", rules: map[Kind]Rule{KindDeposit: {Cap: ngnPtr(-1)}}, wantErr: "cap must not be negative"},
+		{name: "cap below min", rules: map[Kind]Rule{KindDeposit: {Min: ngnPtr(1000), Cap: ngnPtr(999)}}, wantErr: "cap must not be less than min"},
+		{
+			name: "nested tiers",
+			rules: map[Kind]Rule{KindDeposit: {Tiers: []Tier{
+				{Rule: Rule{Tiers: []Tier{{Rule: Rule{Flat: ngn(1)}}}}},
+			}}},
+			wantErr: "tiers cannot be nested",
+		},
+		{
+			name: "invalid tier",
+			rules: map[Kind]Rule{KindDeposit: {Tiers: []Tier{
+				{UpTo: ngnPtr(1000), Rule: Rule{Flat: ngn(1)}},
+				{Rule: Rule{RateBPS: -5}},
+			}}},
+			wantErr: "tier 2: rate_bps",
+		},
+		{
+			name: "tier without up_to before the last",
+			rules: map[Kind]Rule{KindDeposit: {Tiers: []Tier{
+				{Rule: Rule{Flat: ngn(1)}},
+				{Rule: Rule{Flat: ngn(2)}},
+			}}},
+			wantErr: "only the last tier",
+		},
+		{
+			name: "last tier with up_to",
+			rules: map[Kind]Rule{KindDeposit: {Tiers: []Tier{
+				{UpTo: ngnPtr(1000), Rule: Rule{Flat: ngn(1)}},
+				{UpTo: ngnPtr(2000), Rule: Rule{Flat: ngn(2)}},
+			}}},
+			wantErr: "the last tier must leave out up_to",
+		},
+		{
+			name: "tiers out of order",
+			rules: map[Kind]Rule{KindDeposit: {Tiers: []Tier{
+				{UpTo: ngnPtr(2000), Rule: Rule{Flat: ngn(1)}},
+				{UpTo: ngnPtr(1000), Rule: Rule{Flat: ngn(2)}},
+				{Rule: Rule{Flat: ngn(3)}},
+			}}},
+			wantErr: "tier 2 up_to must be greater",
+		},
+		{
+			name: "tiers with the same up_to",
+			rules: map[Kind]Rule{KindDeposit: {Tiers: []Tier{
+				{UpTo: ngnPtr(1000), Rule: Rule{Flat: ngn(1)}},
+				{UpTo: ngnPtr(1000), Rule: Rule{Flat: ngn(2)}},
+				{Rule: Rule{Flat: ngn(3)}},
+			}}},
+			wantErr: "tier 2 up_to must be greater",
+		},
+		{
+			name:  "valid rules",
+			rules: map[Kind]Rule{KindDeposit: {Flat: ngn(100), RateBPS: 150, Min: ngnPtr(100), Cap: ngnPtr(200000)}},
+		},
+	}
+	for _, tt := range tests {
+		_, err := NewSchedule(tt.rules)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: NewSchedule failed: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: NewSchedule = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadScheduleExample(t *testing.T) {
+	schedule, err := LoadSchedule("../../fees.example.json")
+	if err != nil {
+		t.Fatalf("LoadSchedule failed: %v", err)
+	}
+
+	tests := []struct {
+		kind    Kind
+		amount  int64
+		wantFee int64
+	}{
+		{kind: KindDeposit, amount: 1000000, wantFee: 25000},
+		{kind: KindDeposit, amount: 100000000, wantFee: 200000},
+		{kind: KindTransfer, amount: 100000, wantFee: 1000},
+		{kind: KindTransfer, amount: 1000000, wantFee: 5000},
+		{kind: KindTransfer, amount: 100000000, wantFee: 10000},
+		{kind: KindWithdrawal, amount: 500000, wantFee: 1000},
+		{kind: KindWithdrawal, amount: 500001, wantFee: 2500},
+		{kind: KindWithdrawal, amount: 5000001, wantFee: 5000},
+	}
+	for _, tt := range tests {
+		if got := schedule.Quote(tt.kind, ngn(tt.amount)).Fee.Amount; got != tt.wantFee {
+			t.Errorf("%s fee on %d = %d, want %d", tt.kind, tt.amount, got, tt.wantFee)
+		}
+	}
+
+	if schedule, err := LoadSchedule(""); err != nil || schedule.Quote(KindDeposit, ngn(1000000)).Fee.Amount != 0 {
+		t.Errorf("LoadSchedule(\"\") = %v, %v, want a schedule with no fees", schedule, err)
+	}
+	if _, err := LoadSchedule("testdata/missing.json"); err == nil {
+		t.Error("LoadSchedule of a missing file succeeded")
+	}
+}
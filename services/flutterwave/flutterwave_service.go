@@ -0,0 +1,225 @@
+package flutterwave
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/brainox/paystack_wallet_service/external/external_models"
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+)
+
+const (
+	FlutterwaveBaseURL = "https://api.flutterwave.com/v3"
+
+	// Flutterwave sends the secret hash configured on the dashboard in this header
+	webhookSignatureHeader = "verif-hash"
+)
+
+// FlutterwaveService implements payment.PaymentProvider
+var _ payment.PaymentProvider = (*FlutterwaveService)(nil)
+
+type FlutterwaveService struct {
+	secretKey   string
+	secretHash  string
+	redirectURL string
+	client      *http.Client
+}
+
+func NewFlutterwaveService(secretKey, secretHash, redirectURL string) *FlutterwaveService {
+	return &FlutterwaveService{
+		secretKey:   secretKey,
+		secretHash:  secretHash,
+		redirectURL: redirectURL,
+		client:      &http.Client{},
+	}
+}
+
+func (s *FlutterwaveService) Name() models.PaymentProvider {
+	return models.PaymentProviderFlutterwave
+}
+
+// InitializePayment creates a Flutterwave Standard checkout
+func (s *FlutterwaveService) InitializePayment(email string, amount models.Money, reference string) (*payment.Checkout, error) {
+	endpoint := fmt.Sprintf("%s/payments", FlutterwaveBaseURL)
+
+	payload := external_models.FlutterwavePaymentRequest{
+		TxRef:       reference,
+		Amount:      json.Number(amount.String()), // Amount in major units
+		Currency:    string(amount.Currency),
+		RedirectURL: s.redirectURL,
+		Customer:    external_models.FlutterwaveCustomer{Email: email},
+	}
+
+	var result external_models.FlutterwavePaymentResponse
+	if err := s.do(http.MethodPost, endpoint, payload, &result); err != nil {
+		return nil, err
+	}
+
+	return &payment.Checkout{
+		AuthorizationURL: result.Data.Link,
+		Reference:        reference,
+	}, nil
+}
+
+// VerifyPayment looks up a Flutterwave transaction by our reference
+func (s *FlutterwaveService) VerifyPayment(reference string) (*payment.Verification, error) {
+	query := url.Values{}
+	query.Set("tx_ref", reference)
+	endpoint := fmt.Sprintf("%s/transactions/verify_by_reference?%s", FlutterwaveBaseURL, query.Encode())
+
+	var result external_models.FlutterwaveVerifyResponse
+	if err := s.do(http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+
+	amount, err := minorUnits(result.Data.Amount, result.Data.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment.Verification{
+		Reference: result.Data.TxRef,
+		Status:    paymentStatus(result.Data.Status),
+		Amount:    amount,
+		Currency:  result.Data.Currency,
+	}, nil
+}
+
+func (s *FlutterwaveService) WebhookSignatureHeader() string {
+	return webhookSignatureHeader
+}
+
+// ValidateWebhook compares the verif-hash header with the configured secret hash
+func (s *FlutterwaveService) ValidateWebhook(body []byte, signature string) bool {
+	if s.secretHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(signature), []byte(s.secretHash)) == 1
+}
+
+// ParseWebhook normalizes a Flutterwave webhook event
+func (s *FlutterwaveService) ParseWebhook(body []byte) (*payment.Event, error) {
+	var webhook external_models.FlutterwaveWebhookEvent
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+	if webhook.Event == "" {
+		return nil, fmt.Errorf("webhook has no event name")
+	}
+
+	event := &payment.Event{
+		Provider:  models.PaymentProviderFlutterwave,
+		Name:      webhook.Event,
+		ID:        strconv.FormatInt(webhook.Data.ID, 10),
+		Reference: webhook.Data.TxRef,
+		Currency:  webhook.Data.Currency,
+	}
+
+	switch {
+	case webhook.Event == "charge.completed" && webhook.Data.Status == "successful":
+		event.Type = payment.EventChargeSuccess
+	case webhook.Event == "charge.completed" && webhook.Data.Status == "failed":
+		event.Type = payment.EventChargeFailed
+	default:
+		event.Type = payment.EventUnhandled
+		return event, nil
+	}
+
+	amount, err := minorUnits(webhook.Data.Amount, webhook.Data.Currency)
+	if err != nil {
+		return nil, err
+	}
+	event.Amount = amount
+
+	return event, nil
+}
+
+// do sends an authenticated request to Flutterwave and decodes the response into result
+func (s *FlutterwaveService) do(method, url string, payload interface{}, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Every Flutterwave response shares the same status/message envelope
+	var envelope struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	decodeErr := json.Unmarshal(respBody, &envelope)
+
+	// An error without Flutterwave's envelope, e.g. from a proxy, is not a clear answer
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		if decodeErr != nil || envelope.Status != "error" {
+			return fmt.Errorf("flutterwave error (HTTP %d): %s", resp.StatusCode, string(respBody))
+		}
+		return &payment.APIError{Provider: models.PaymentProviderFlutterwave, StatusCode: resp.StatusCode, Message: envelope.Message}
+	}
+
+	if decodeErr != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", decodeErr)
+	}
+
+	if envelope.Status != "success" {
+		return &payment.APIError{Provider: models.PaymentProviderFlutterwave, StatusCode: resp.StatusCode, Message: envelope.Message}
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// minorUnits converts a Flutterwave major-unit amount into minor units
+func minorUnits(amount json.Number, currency string) (int64, error) {
+	money, err := models.ParseMoney(amount.String(), models.Currency(currency))
+	if err != nil {
+		return 0, fmt.Errorf("invalid flutterwave amount %q: %w", amount, err)
+	}
+	return money.Amount, nil
+}
+
+// paymentStatus maps a Flutterwave transaction status onto the common statuses
+func paymentStatus(status string) payment.PaymentStatus {
+	switch status {
+	case "successful":
+		return payment.PaymentStatusSuccess
+	case "failed":
+		return payment.PaymentStatusFailed
+	default:
+		return payment.PaymentStatusPending
+	}
+}
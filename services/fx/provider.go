@@ -0,0 +1,129 @@
+package fx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// ErrRateUnavailable is returned when a provider has no rate for a pair
+var ErrRateUnavailable = errors.New("no exchange rate is available for this currency pair")
+
+// RateProvider supplies mid-market exchange rates
+type RateProvider interface {
+	// Name identifies the provider on quotes
+	Name() string
+
+	// Rate returns how much of to one unit of from buys. It returns
+	// ErrRateUnavailable if the provider does not quote the pair.
+	Rate(from, to models.Currency) (models.Rate, error)
+}
+
+// NewRateProvider returns the rate provider with the given name. ratesFile
+// is read by the static provider.
+func NewRateProvider(name, ratesFile string) (RateProvider, error) {
+	switch name {
+	case "static":
+		return LoadStaticRates(ratesFile)
+	default:
+		return nil, fmt.Errorf("unknown FX rate provider %q", name)
+	}
+}
+
+// StaticRateProvider quotes fixed rates from configuration. A pair that is
+// not configured is worked out from its reverse, or through the default
+// currency.
+type StaticRateProvider struct {
+	rates map[pair]models.Rate
+}
+
+type pair struct {
+	from, to models.Currency
+}
+
+// NewStaticRateProvider builds a provider from rates keyed by pair, e.g. "USD/NGN"
+func NewStaticRateProvider(rates map[string]models.Rate) (*StaticRateProvider, error) {
+	provider := &StaticRateProvider{rates: make(map[pair]models.Rate, len(rates))}
+	for key, rate := range rates {
+		from, to, ok := strings.Cut(key, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid currency pair %q; use FROM/TO, e.g. USD/NGN", key)
+		}
+		fromCurrency, err := models.ParseCurrency(from)
+		if err != nil || from == "" {
+			return nil, fmt.Errorf("invalid currency pair %q", key)
+		}
+		toCurrency, err := models.ParseCurrency(to)
+		if err != nil || to == "" {
+			return nil, fmt.Errorf("invalid currency pair %q", key)
+		}
+		if fromCurrency == toCurrency {
+			return nil, fmt.Errorf("invalid currency pair %q: currencies must differ", key)
+		}
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("rate for %s must be greater than zero", key)
+		}
+		provider.rates[pair{fromCurrency, toCurrency}] = rate
+	}
+	return provider, nil
+}
+
+// LoadStaticRates reads rates from a JSON file of decimal strings keyed by
+// pair. An empty path gives a provider with no rates.
+func LoadStaticRates(path string) (*StaticRateProvider, error) {
+	if path == "" {
+		return &StaticRateProvider{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FX rates: %w", err)
+	}
+
+	var rates map[string]models.Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("failed to parse FX rates: %w", err)
+	}
+	return NewStaticRateProvider(rates)
+}
+
+func (p *StaticRateProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticRateProvider) Rate(from, to models.Currency) (models.Rate, error) {
+	if rate, ok := p.direct(from, to); ok {
+		return rate, nil
+	}
+
+	base := models.DefaultCurrency
+	if from != base && to != base {
+		toBase, ok := p.direct(from, base)
+		if ok {
+			if fromBase, ok := p.direct(base, to); ok {
+				if rate, err := toBase.Mul(fromBase); err == nil && rate.IsPositive() {
+					return rate, nil
+				}
+			}
+		}
+	}
+
+	return 0, ErrRateUnavailable
+}
+
+// direct returns the configured rate for a pair or the inverse of its reverse
+func (p *StaticRateProvider) direct(from, to models.Currency) (models.Rate, bool) {
+	if rate, ok := p.rates[pair{from, to}]; ok {
+		return rate, true
+	}
+	if rate, ok := p.rates[pair{to, from}]; ok {
+		if inverse := rate.Invert(); inverse.IsPositive() {
+			return inverse, true
+		}
+	}
+	return 0, false
+}
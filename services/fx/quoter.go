@@ -0,0 +1,79 @@
+package fx
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+// basisPointsPerUnit is the number of basis points in 100%
+const basisPointsPerUnit = 10000
+
+// Quoter prices conversions: it takes the provider's mid rate and keeps
+// SpreadBPS basis points of the amount sold as the platform's spread
+type Quoter struct {
+	provider  RateProvider
+	spreadBPS int64
+	ttl       time.Duration
+}
+
+// NewQuoter builds a quoter whose quotes can be executed for ttl
+func NewQuoter(provider RateProvider, spreadBPS int64, ttl time.Duration) (*Quoter, error) {
+	if spreadBPS < 0 || spreadBPS >= basisPointsPerUnit {
+		return nil, fmt.Errorf("FX spread must be between 0 and %d basis points", basisPointsPerUnit-1)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("FX quote TTL must be positive")
+	}
+	return &Quoter{provider: provider, spreadBPS: spreadBPS, ttl: ttl}, nil
+}
+
+// Quote prices selling amount for to. The quote is not saved.
+func (q *Quoter) Quote(userID uuid.UUID, amount models.Money, to models.Currency, now time.Time) (*models.FXQuote, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if !amount.Currency.IsSupported() || !to.IsSupported() {
+		return nil, fmt.Errorf("unsupported currency")
+	}
+	if amount.Currency == to {
+		return nil, fmt.Errorf("cannot convert %s to itself", to)
+	}
+
+	midRate, err := q.provider.Rate(amount.Currency, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// The spread rounds half up to the nearest minor unit; what the user
+	// receives rounds down
+	if q.spreadBPS > 0 && amount.Amount > (math.MaxInt64-basisPointsPerUnit/2)/q.spreadBPS {
+		return nil, fmt.Errorf("amount is too large to convert")
+	}
+	spread := models.NewMoney((amount.Amount*q.spreadBPS+basisPointsPerUnit/2)/basisPointsPerUnit, amount.Currency)
+	buyAmount, err := midRate.Convert(amount.Sub(spread), to)
+	if err != nil {
+		return nil, err
+	}
+	if !buyAmount.IsPositive() {
+		return nil, fmt.Errorf("amount is too small to convert")
+	}
+
+	return &models.FXQuote{
+		UserID:       userID,
+		FromCurrency: amount.Currency,
+		ToCurrency:   to,
+		SellAmount:   amount,
+		BuyAmount:    buyAmount,
+		MidRate:      midRate,
+		Rate:         models.RateOf(amount, buyAmount),
+		SpreadBPS:    q.spreadBPS,
+		Spread:       spread,
+		Provider:     q.provider.Name(),
+		Status:       models.FXQuoteStatusOpen,
+		ExpiresAt:    now.Add(q.ttl),
+	}, nil
+}
@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+func newTestQuoter(t *testing.T, spreadBPS int64) *Quoter {
+	t.Helper()
+	rate, err := models.ParseRate("1500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewStaticRateProvider(map[string]models.Rate{"USD/NGN": rate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoter, err := NewQuoter(provider, spreadBPS, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return quoter
+}
+
+func TestQuoterRounding(t *testing.T) {
+	quoter := newTestQuoter(t, 100)
+
+	tests := []struct {
+		name       string
+		sell       models.Money
+		to         models.Currency
+		wantSpread int64
+		wantBuy    int64
+	}{
+		// 1% of 50 cents is half a cent, which the spread rounds up
+		{name: "spread rounds half up", sell: models.NewMoney(50, models.CurrencyUSD), to: models.CurrencyNGN, wantSpread: 1, wantBuy: 73500},
+		// 1% of 49 cents is under half a cent, which rounds down
+		{name: "spread rounds down below half", sell: models.NewMoney(49, models.CurrencyUSD), to: models.CurrencyNGN, wantSpread: 0, wantBuy: 73500},
+		// 990.00 NGN at 1/1500 is 0.66000330 USD; the user gets 0.66
+		{name: "buy amount rounds down", sell: models.NewMoney(100000, models.CurrencyNGN), to: models.CurrencyUSD, wantSpread: 1000, wantBuy: 66},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := quoter.Quote(uuid.New(), tt.sell, tt.to, time.Now())
+			if err != nil {
+				t.Fatalf("Quote failed: %v", err)
+			}
+			if quote.Spread.Amount != tt.wantSpread || quote.Spread.Currency != tt.sell.Currency {
+				t.Errorf("spread = %d %s, want %d %s", quote.Spread.Amount, quote.Spread.Currency, tt.wantSpread, tt.sell.Currency)
+			}
+			if quote.BuyAmount.Amount != tt.wantBuy || quote.BuyAmount.Currency != tt.to {
+				t.Errorf("buy amount = %d %s, want %d %s", quote.BuyAmount.Amount, quote.BuyAmount.Currency, tt.wantBuy, tt.to)
+			}
+			if quote.Rate > quote.MidRate {
+				t.Errorf("effective rate %s is better than the mid rate %s", quote.Rate, quote.MidRate)
+			}
+		})
+	}
+}
+
+func TestQuoterRejectsAmountsTooSmallToConvert(t *testing.T) {
+	quoter := newTestQuoter(t, 100)
+
+	// 0.50 NGN less its spread buys less than a cent
+	if _, err := quoter.Quote(uuid.New(), models.NewMoney(50, models.CurrencyNGN), models.CurrencyUSD, time.Now()); err == nil {
+		t.Error("Quote of 0.50 NGN succeeded, want an error")
+	}
+}
+
+func TestQuoterRejectsOverflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		spreadBPS int64
+		sell      models.Money
+	}{
+		// amount x spread does not fit in an int64
+		{name: "spread", spreadBPS: 100, sell: models.NewMoney(math.MaxInt64/50, models.CurrencyUSD)},
+		// the converted amount does not fit in an int64
+		{name: "conversion", spreadBPS: 0, sell: models.NewMoney(math.MaxInt64/1000, models.CurrencyUSD)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoter := newTestQuoter(t, tt.spreadBPS)
+			if quote, err := quoter.Quote(uuid.New(), tt.sell, models.CurrencyNGN, time.Now()); err == nil {
+				t.Errorf("Quote succeeded with buy amount %d, want an error", quote.BuyAmount.Amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+)
+
+const MaxKeyLength = 255
+
+var (
+	// ErrInvalidKey is returned for an empty or overlong key
+	ErrInvalidKey = fmt.Errorf("idempotency key must be between 1 and %d characters", MaxKeyLength)
+	// ErrKeyReused is returned when a key is replayed with a different request
+	ErrKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrRequestInProgress is returned when the original request has not finished yet
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+	ttl  time.Duration
+	// How long a request holds its key before a retry can take it over
+	lease time.Duration
+}
+
+func NewIdempotencyService(repo *repository.IdempotencyRepository, ttl, lease time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		repo:  repo,
+		ttl:   ttl,
+		lease: lease,
+	}
+}
+
+// Fingerprint identifies a request by its method, path and body
+func Fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Begin reserves a key for a request. When the key has already completed
+// for the same request, the stored record is returned with replay set so the
+// original response can be sent again. A key whose request has held it for
+// longer than the lease without storing a response, e.g. because the server
+// crashed, is taken over by this request.
+func (s *IdempotencyService) Begin(userID uuid.UUID, key, method, path string, body []byte) (record *models.IdempotencyKey, replay bool, err error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, false, ErrInvalidKey
+	}
+
+	lockedUntil := time.Now().Add(s.lease)
+	record = &models.IdempotencyKey{
+		UserID:        userID,
+		Key:           key,
+		RequestMethod: method,
+		RequestPath:   path,
+		RequestHash:   Fingerprint(method, path, body),
+		LockToken:     uuid.New(),
+		LockedUntil:   &lockedUntil,
+		ExpiresAt:     time.Now().Add(s.ttl),
+	}
+
+	created, err := s.repo.Create(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	if created {
+		return record, false, nil
+	}
+
+	existing, err := s.repo.GetByUserAndKey(userID, key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	// An expired key that has not been purged yet can be used again
+	if existing.IsExpired() {
+		if err := s.repo.Delete(existing.ID); err != nil {
+			return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+		}
+		return s.Begin(userID, key, method, path, body)
+	}
+
+	if existing.RequestHash != record.RequestHash {
+		return nil, false, ErrKeyReused
+	}
+
+	if existing.IsCompleted() {
+		return existing, true, nil
+	}
+
+	if existing.IsLocked(time.Now()) {
+		return nil, false, ErrRequestInProgress
+	}
+	tookOver, err := s.repo.TakeOver(existing.ID, record.LockToken, lockedUntil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to take over idempotency key: %w", err)
+	}
+	if !tookOver {
+		// Another retry took it over first, or the original request finished
+		return nil, false, ErrRequestInProgress
+	}
+	existing.LockToken = record.LockToken
+	existing.LockedUntil = &lockedUntil
+	return existing, false, nil
+}
+
+// Complete stores the response for a key. Server errors release the key so
+// that the client can retry the request. Nothing is stored if another request
+// has taken the key over.
+func (s *IdempotencyService) Complete(record *models.IdempotencyKey, status int, contentType string, body []byte) error {
+	if status >= 500 {
+		return s.Release(record)
+	}
+	return s.repo.SaveResponse(record.ID, record.LockToken, status, contentType, body)
+}
+
+// Release discards a reserved key without storing a response
+func (s *IdempotencyService) Release(record *models.IdempotencyKey) error {
+	return s.repo.DeleteLocked(record.ID, record.LockToken)
+}
+
+// PurgeExpired deletes keys that are past their expiry
+func (s *IdempotencyService) PurgeExpired() (int64, error) {
+	return s.repo.DeleteExpired()
+}
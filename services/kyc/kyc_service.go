@@ -0,0 +1,219 @@
+package kyc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrVerifierUnavailable is returned when the verifier could not check a submission
+var ErrVerifierUnavailable = errors.New("identity verification is unavailable, please try again later")
+
+var (
+	elevenDigits = regexp.MustCompile(`^[0-9]{11}$`)
+	documentID   = regexp.MustCompile(`^[A-Za-z0-9]{5,20}$`)
+)
+
+type KYCService struct {
+	db       *sqlx.DB
+	kycRepo  *repository.KYCRepository
+	userRepo *repository.UserRepository
+	verifier Verifier
+	tiers    Tiers
+}
+
+func NewKYCService(
+	db *sqlx.DB,
+	kycRepo *repository.KYCRepository,
+	userRepo *repository.UserRepository,
+	verifier Verifier,
+	tiers Tiers,
+) *KYCService {
+	return &KYCService{
+		db:       db,
+		kycRepo:  kycRepo,
+		userRepo: userRepo,
+		verifier: verifier,
+		tiers:    tiers,
+	}
+}
+
+// TierInfo describes a KYC tier. Nil limits are not enforced.
+type TierInfo struct {
+	Tier       models.KYCTier     `json:"tier"`
+	MaxBalance *models.Money      `json:"max_balance"`
+	DailyLimit *models.Money      `json:"daily_limit"`
+	IDTypes    []models.KYCIDType `json:"id_types,omitempty"`
+}
+
+// Status is a user's KYC tier and what the next tier needs
+type Status struct {
+	Current TierInfo  `json:"current"`
+	Next    *TierInfo `json:"next,omitempty"`
+}
+
+// GetStatus returns a user's KYC tier, its limits and what is needed to
+// reach the next one
+func (s *KYCService) GetStatus(userID uuid.UUID) (*Status, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	status := &Status{Current: s.tierInfo(user.KYCTier)}
+	if user.KYCTier < models.MaxKYCTier {
+		next := s.tierInfo(user.KYCTier + 1)
+		next.IDTypes = idTypesForTier[next.Tier]
+		status.Next = &next
+	}
+	return status, nil
+}
+
+func (s *KYCService) tierInfo(tier models.KYCTier) TierInfo {
+	limits := s.tiers.For(tier)
+	info := TierInfo{Tier: tier}
+	if limits.MaxBalance.IsPositive() {
+		info.MaxBalance = &limits.MaxBalance
+	}
+	if limits.DailyOutflow.IsPositive() {
+		info.DailyLimit = &limits.DailyOutflow
+	}
+	return info
+}
+
+// Submit verifies a user's identity details for the next KYC tier and raises
+// their tier when the verifier approves them. Tiers must be reached in order.
+// The submission is returned whether it was approved or rejected. If the
+// verifier cannot be reached the submission is recorded as failed and
+// ErrVerifierUnavailable is returned.
+func (s *KYCService) Submit(userID uuid.UUID, req VerificationRequest) (*models.KYCSubmission, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	req.IDNumber = strings.TrimSpace(req.IDNumber)
+	req.FirstName = strings.TrimSpace(req.FirstName)
+	req.LastName = strings.TrimSpace(req.LastName)
+	if err := validateRequest(user, req); err != nil {
+		return nil, err
+	}
+
+	submission := &models.KYCSubmission{
+		UserID:         userID,
+		Tier:           req.Tier,
+		IDType:         req.IDType,
+		IDNumberMasked: maskIDNumber(req.IDNumber),
+		Status:         models.KYCSubmissionStatusPending,
+		Verifier:       s.verifier.Name(),
+	}
+	if err := s.kycRepo.Create(submission); err != nil {
+		return nil, fmt.Errorf("failed to create submission: %w", err)
+	}
+
+	result, verifyErr := s.verifier.Verify(user, req)
+	switch {
+	case verifyErr != nil:
+		submission.Status = models.KYCSubmissionStatusFailed
+		reason := verifyErr.Error()
+		submission.Reason = &reason
+	case result.Approved:
+		submission.Status = models.KYCSubmissionStatusApproved
+	default:
+		submission.Status = models.KYCSubmissionStatusRejected
+		submission.Reason = &result.Reason
+	}
+	if result != nil && result.Reference != "" {
+		submission.VerifierReference = &result.Reference
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.kycRepo.UpdateResult(tx, submission); err != nil {
+		return nil, fmt.Errorf("failed to update submission: %w", err)
+	}
+	if submission.Status == models.KYCSubmissionStatusApproved {
+		if err := s.userRepo.UpdateKYCTier(tx, userID, submission.Tier); err != nil {
+			return nil, fmt.Errorf("failed to update KYC tier: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if verifyErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerifierUnavailable, verifyErr)
+	}
+	return submission, nil
+}
+
+// ListSubmissions lists a user's KYC submissions newest first
+func (s *KYCService) ListSubmissions(userID uuid.UUID, limit, offset int) ([]models.KYCSubmission, error) {
+	return s.kycRepo.ListByUser(userID, limit, offset)
+}
+
+func validateRequest(user *models.User, req VerificationRequest) error {
+	if req.Tier <= user.KYCTier {
+		return fmt.Errorf("already verified for tier %d", user.KYCTier)
+	}
+	if req.Tier > models.MaxKYCTier {
+		return fmt.Errorf("tier must be between 1 and %d", models.MaxKYCTier)
+	}
+	if req.Tier != user.KYCTier+1 {
+		return fmt.Errorf("tier %d must be verified first", user.KYCTier+1)
+	}
+
+	accepted := false
+	for _, idType := range idTypesForTier[req.Tier] {
+		if req.IDType == idType {
+			accepted = true
+			break
+		}
+	}
+	if !accepted {
+		return fmt.Errorf("id_type %q cannot be used for tier %d", req.IDType, req.Tier)
+	}
+
+	switch req.IDType {
+	case models.KYCIDTypeBVN, models.KYCIDTypeNIN:
+		if !elevenDigits.MatchString(req.IDNumber) {
+			return fmt.Errorf("%s must be 11 digits", strings.ToUpper(string(req.IDType)))
+		}
+	default:
+		if !documentID.MatchString(req.IDNumber) {
+			return fmt.Errorf("id_number must be 5 to 20 letters or digits")
+		}
+	}
+
+	if req.FirstName == "" || req.LastName == "" {
+		return fmt.Errorf("first_name and last_name are required")
+	}
+	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		return fmt.Errorf("date_of_birth must be in YYYY-MM-DD format")
+	}
+	if dob.After(time.Now().AddDate(-18, 0, 0)) {
+		return fmt.Errorf("you must be at least 18 years old")
+	}
+	return nil
+}
+
+// maskIDNumber keeps only the last four characters of an ID number
+func maskIDNumber(idNumber string) string {
+	if len(idNumber) <= 4 {
+		return strings.Repeat("*", len(idNumber))
+	}
+	return strings.Repeat("*", len(idNumber)-4) + idNumber[len(idNumber)-4:]
+}
@@ -0,0 +1,82 @@
+package kyc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func TestValidateRequestTierGating(t *testing.T) {
+	adult := time.Now().AddDate(-30, 0, 0).Format("2006-01-02")
+	request := func(tier models.KYCTier, idType models.KYCIDType, idNumber string) VerificationRequest {
+		return VerificationRequest{
+			Tier:        tier,
+			IDType:      idType,
+			IDNumber:    idNumber,
+			FirstName:   "Ada",
+			LastName:    "Obi",
+			DateOfBirth: adult,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		current models.KYCTier
+		req     VerificationRequest
+		wantErr string
+	}{
+		{name: "BVN for tier 1", current: models.KYCTierEmail, req: request(models.KYCTierBVN, models.KYCIDTypeBVN, "22123456789")},
+		{name: "NIN for tier 2", current: models.KYCTierBVN, req: request(models.KYCTierDocument, models.KYCIDTypeNIN, "12345678901")},
+		{name: "passport for tier 2", current: models.KYCTierBVN, req: request(models.KYCTierDocument, models.KYCIDTypePassport, "A12345678")},
+		{name: "skipping tier 1", current: models.KYCTierEmail, req: request(models.KYCTierDocument, models.KYCIDTypeNIN, "12345678901"), wantErr: "tier 1 must be verified first"},
+		{name: "tier already reached", current: models.KYCTierBVN, req: request(models.KYCTierBVN, models.KYCIDTypeBVN, "22123456789"), wantErr: "already verified for tier 1"},
+		{name: "beyond the top tier", current: models.KYCTierDocument, req: request(models.KYCTierDocument+1, models.KYCIDTypeNIN, "12345678901"), wantErr: "tier must be between 1 and 2"},
+		{name: "document for tier 1", current: models.KYCTierEmail, req: request(models.KYCTierBVN, models.KYCIDTypeNIN, "12345678901"), wantErr: "cannot be used for tier 1"},
+		{name: "BVN for tier 2", current: models.KYCTierBVN, req: request(models.KYCTierDocument, models.KYCIDTypeBVN, "22123456789"), wantErr: "cannot be used for tier 2"},
+		{name: "short BVN", current: models.KYCTierEmail, req: request(models.KYCTierBVN, models.KYCIDTypeBVN, "2212345678"), wantErr: "BVN must be 11 digits"},
+		{name: "malformed document number", current: models.KYCTierBVN, req: request(models.KYCTierDocument, models.KYCIDTypePassport, "A1-2"), wantErr: "id_number must be"},
+	}
+
+	for _, tt := range tests {
+		err := validateRequest(&models.User{KYCTier: tt.current}, tt.req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: validateRequest() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: validateRequest() = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRequestRejectsMinors(t *testing.T) {
+	req := VerificationRequest{
+		Tier:        models.KYCTierBVN,
+		IDType:      models.KYCIDTypeBVN,
+		IDNumber:    "22123456789",
+		FirstName:   "Ada",
+		LastName:    "Obi",
+		DateOfBirth: time.Now().AddDate(-17, 0, 0).Format("2006-01-02"),
+	}
+	err := validateRequest(&models.User{}, req)
+	if err == nil || !strings.Contains(err.Error(), "at least 18") {
+		t.Errorf("validateRequest() = %v, want an error about age", err)
+	}
+}
+
+func TestMaskIDNumber(t *testing.T) {
+	tests := map[string]string{
+		"22123456789": "*******6789",
+		"1234":        "****",
+		"12":          "**",
+	}
+	for idNumber, want := range tests {
+		if got := maskIDNumber(idNumber); got != want {
+			t.Errorf("maskIDNumber(%q) = %q, want %q", idNumber, got, want)
+		}
+	}
+}
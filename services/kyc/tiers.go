@@ -0,0 +1,30 @@
+package kyc
+
+import "github.com/brainox/paystack_wallet_service/internal/models"
+
+// TierLimits caps what a wallet at a KYC tier can hold and send in a day.
+// Zero values are not enforced.
+type TierLimits struct {
+	MaxBalance   models.Money
+	DailyOutflow models.Money
+}
+
+// Tiers holds the limits for each KYC tier. Tiers without an entry are not
+// limited.
+type Tiers map[models.KYCTier]TierLimits
+
+// For returns the limits for a tier
+func (t Tiers) For(tier models.KYCTier) TierLimits {
+	return t[tier]
+}
+
+// idTypesForTier lists the identity numbers accepted to reach a tier
+var idTypesForTier = map[models.KYCTier][]models.KYCIDType{
+	models.KYCTierBVN: {models.KYCIDTypeBVN},
+	models.KYCTierDocument: {
+		models.KYCIDTypeNIN,
+		models.KYCIDTypeDriversLicense,
+		models.KYCIDTypePassport,
+		models.KYCIDTypeVotersCard,
+	},
+}
@@ -0,0 +1,261 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// LedgerService records balanced journal entries and keeps the stored wallet
+// balances in step with the postings made against wallet accounts.
+type LedgerService struct {
+	db         *sqlx.DB
+	ledgerRepo *repository.LedgerRepository
+	walletRepo *repository.WalletRepository
+}
+
+func NewLedgerService(
+	db *sqlx.DB,
+	ledgerRepo *repository.LedgerRepository,
+	walletRepo *repository.WalletRepository,
+) *LedgerService {
+	return &LedgerService{
+		db:         db,
+		ledgerRepo: ledgerRepo,
+		walletRepo: walletRepo,
+	}
+}
+
+// WalletAccount returns the ledger account for a wallet
+func (s *LedgerService) WalletAccount(tx *sqlx.Tx, walletID uuid.UUID) (*models.LedgerAccount, error) {
+	account, err := s.ledgerRepo.GetOrCreateWalletAccount(tx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet ledger account: %w", err)
+	}
+	return account, nil
+}
+
+// SystemAccount returns a system ledger account by code. Each currency has
+// its own account, created on first use for currencies other than the default.
+func (s *LedgerService) SystemAccount(tx *sqlx.Tx, code string, currency models.Currency) (*models.LedgerAccount, error) {
+	var account *models.LedgerAccount
+	var err error
+	if currency == models.DefaultCurrency {
+		account, err = s.ledgerRepo.GetAccountByCode(tx, code)
+	} else {
+		account, err = s.ledgerRepo.GetOrCreateCurrencyAccount(tx, code, currency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system ledger account: %w", err)
+	}
+	return account, nil
+}
+
+// Move posts a two-legged entry that debits from and credits to
+func (s *LedgerService) Move(
+	tx *sqlx.Tx,
+	reference string,
+	description string,
+	from *models.LedgerAccount,
+	to *models.LedgerAccount,
+	amount models.Money,
+) (*models.JournalEntry, error) {
+	entry := newMove(reference, description, from, to, amount)
+	if err := s.Post(tx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// MoveWithFee posts an entry that debits amount plus fee from from, credits
+// amount to to and credits the fee to feeAccount. A zero fee posts a plain
+// two-legged move.
+func (s *LedgerService) MoveWithFee(
+	tx *sqlx.Tx,
+	reference string,
+	description string,
+	from *models.LedgerAccount,
+	to *models.LedgerAccount,
+	amount models.Money,
+	feeAccount *models.LedgerAccount,
+	fee models.Money,
+) (*models.JournalEntry, error) {
+	entry := newMove(reference, description, from, to, amount)
+	if fee.IsPositive() {
+		entry.Postings[0].Amount = amount.Add(fee)
+		entry.Postings = append(entry.Postings, models.Posting{AccountID: feeAccount.ID, Direction: models.LedgerCredit, Amount: fee})
+	}
+	if err := s.Post(tx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ForceMove is Move for admin-forced corrections and for reversals the
+// payment provider has already made, such as chargebacks: the debited wallet
+// may be left with a negative balance
+func (s *LedgerService) ForceMove(
+	tx *sqlx.Tx,
+	reference string,
+	description string,
+	from *models.LedgerAccount,
+	to *models.LedgerAccount,
+	amount models.Money,
+) (*models.JournalEntry, error) {
+	entry := newMove(reference, description, from, to, amount)
+	if err := s.post(tx, entry, true); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func newMove(reference, description string, from, to *models.LedgerAccount, amount models.Money) *models.JournalEntry {
+	return &models.JournalEntry{
+		Reference:   reference,
+		Description: &description,
+		Postings: []models.Posting{
+			{AccountID: from.ID, Direction: models.LedgerDebit, Amount: amount},
+			{AccountID: to.ID, Direction: models.LedgerCredit, Amount: amount},
+		},
+	}
+}
+
+// Post records a journal entry and applies its wallet postings to wallet balances.
+// The entry is rejected unless its debits and credits balance.
+func (s *LedgerService) Post(tx *sqlx.Tx, entry *models.JournalEntry) error {
+	return s.post(tx, entry, false)
+}
+
+// post records an entry; allowNegative lets wallet balances drop below zero
+func (s *LedgerService) post(tx *sqlx.Tx, entry *models.JournalEntry, allowNegative bool) error {
+	accounts := make(map[uuid.UUID]*models.LedgerAccount, len(entry.Postings))
+	for _, posting := range entry.Postings {
+		if _, ok := accounts[posting.AccountID]; ok {
+			continue
+		}
+		account, err := s.ledgerRepo.GetAccountByID(tx, posting.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to get ledger account: %w", err)
+		}
+		accounts[account.ID] = account
+	}
+
+	if err := validateEntry(entry, accounts); err != nil {
+		return err
+	}
+
+	if err := s.ledgerRepo.CreateJournalEntry(tx, entry); err != nil {
+		return fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	for _, posting := range entry.Postings {
+		account := accounts[posting.AccountID]
+		if account.Type != models.LedgerAccountTypeWallet {
+			continue
+		}
+
+		delta := posting.Amount
+		if posting.Direction != account.NormalBalance {
+			delta = delta.Neg()
+		}
+		adjust := s.walletRepo.AdjustBalance
+		if allowNegative {
+			adjust = s.walletRepo.ForceAdjustBalance
+		}
+		if err := adjust(tx, *account.WalletID, delta); err != nil {
+			return fmt.Errorf("failed to update wallet balance: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateEntry checks that an entry balances in a single currency and that
+// every posting is in the currency of the account it is made to
+func validateEntry(entry *models.JournalEntry, accounts map[uuid.UUID]*models.LedgerAccount) error {
+	if entry.Reference == "" {
+		return fmt.Errorf("journal entry reference is required")
+	}
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("journal entry must have at least two postings")
+	}
+
+	var debits, credits int64
+	currency := entry.Postings[0].Amount.Currency
+	for _, posting := range entry.Postings {
+		if !posting.Amount.IsPositive() {
+			return fmt.Errorf("posting amount must be greater than zero")
+		}
+		if posting.Amount.Currency != currency {
+			return fmt.Errorf("journal entry mixes currencies %s and %s", currency, posting.Amount.Currency)
+		}
+		account, ok := accounts[posting.AccountID]
+		if !ok {
+			return fmt.Errorf("ledger account %s not found", posting.AccountID)
+		}
+		if account.Currency != posting.Amount.Currency {
+			return fmt.Errorf("posting in %s cannot be made to %s account %s", posting.Amount.Currency, account.Currency, account.Code)
+		}
+		switch posting.Direction {
+		case models.LedgerDebit:
+			debits += posting.Amount.Amount
+		case models.LedgerCredit:
+			credits += posting.Amount.Amount
+		default:
+			return fmt.Errorf("invalid posting direction: %s", posting.Direction)
+		}
+	}
+
+	if debits != credits {
+		return fmt.Errorf("journal entry is not balanced: debits %d, credits %d", debits, credits)
+	}
+	return nil
+}
+
+// HasEntry reports whether a journal entry with the reference has been posted
+func (s *LedgerService) HasEntry(tx *sqlx.Tx, reference string) (bool, error) {
+	exists, err := s.ledgerRepo.JournalEntryExists(tx, reference)
+	if err != nil {
+		return false, fmt.Errorf("failed to check journal entry: %w", err)
+	}
+	return exists, nil
+}
+
+// GetJournalEntry returns a journal entry and its postings
+func (s *LedgerService) GetJournalEntry(reference string) (*models.JournalEntry, error) {
+	return s.ledgerRepo.GetJournalEntryByReference(reference)
+}
+
+// GetSystemAccountBalance returns the balance of a system account in a
+// currency, e.g. the total collected through Paystack that is still held by
+// the platform
+func (s *LedgerService) GetSystemAccountBalance(code string, currency models.Currency) (models.Money, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	account, err := s.SystemAccount(tx, code, currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+	balance, err := s.ledgerRepo.GetAccountBalance(account.ID)
+	balance.Currency = currency
+	return balance, err
+}
+
+// VerifyWalletBalances returns every wallet whose stored balance does not
+// match the sum of its postings. An empty result means the wallets agree
+// with the ledger.
+func (s *LedgerService) VerifyWalletBalances() ([]models.BalanceMismatch, error) {
+	return s.ledgerRepo.GetBalanceMismatches()
+}
+
+// TrialBalance returns total debits and credits across the whole ledger
+func (s *LedgerService) TrialBalance() (*models.TrialBalance, error) {
+	return s.ledgerRepo.GetTrialBalance()
+}
@@ -0,0 +1,258 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/internal/testdb"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+)
+
+func ngn(amount int64) models.Money {
+	return models.NewMoney(amount, models.CurrencyNGN)
+}
+
+func usd(amount int64) models.Money {
+	return models.NewMoney(amount, models.CurrencyUSD)
+}
+
+func TestValidateEntry(t *testing.T) {
+	walletNGN := &models.LedgerAccount{ID: uuid.New(), Code: "wallet_ngn", Type: models.LedgerAccountTypeWallet, Currency: models.CurrencyNGN}
+	clearingNGN := &models.LedgerAccount{ID: uuid.New(), Code: models.LedgerAccountPaystackClearing, Type: models.LedgerAccountTypeSystem, Currency: models.CurrencyNGN}
+	feesNGN := &models.LedgerAccount{ID: uuid.New(), Code: models.LedgerAccountFees, Type: models.LedgerAccountTypeSystem, Currency: models.CurrencyNGN}
+	walletUSD := &models.LedgerAccount{ID: uuid.New(), Code: "wallet_usd", Type: models.LedgerAccountTypeWallet, Currency: models.CurrencyUSD}
+	accounts := map[uuid.UUID]*models.LedgerAccount{
+		walletNGN.ID:   walletNGN,
+		clearingNGN.ID: clearingNGN,
+		feesNGN.ID:     feesNGN,
+		walletUSD.ID:   walletUSD,
+	}
+
+	debit := func(account *models.LedgerAccount, amount models.Money) models.Posting {
+		return models.Posting{AccountID: account.ID, Direction: models.LedgerDebit, Amount: amount}
+	}
+	credit := func(account *models.LedgerAccount, amount models.Money) models.Posting {
+		return models.Posting{AccountID: account.ID, Direction: models.LedgerCredit, Amount: amount}
+	}
+
+	tests := []struct {
+		name        string
+		noReference bool
+		postings    []models.Posting
+		wantErr     string
+	}{
+		{
+			name:     "balanced two legs",
+			postings: []models.Posting{debit(clearingNGN, ngn(5000)), credit(walletNGN, ngn(5000))},
+		},
+		{
+			name:     "balanced with fee leg",
+			postings: []models.Posting{debit(walletNGN, ngn(5100)), credit(clearingNGN, ngn(5000)), credit(feesNGN, ngn(100))},
+		},
+		{
+			name:        "missing reference",
+			noReference: true,
+			postings:    []models.Posting{debit(clearingNGN, ngn(5000)), credit(walletNGN, ngn(5000))},
+			wantErr:     "reference is required",
+		},
+		{
+			name:    "no postings",
+			wantErr: "at least two postings",
+		},
+		{
+			name:     "single posting",
+			postings: []models.Posting{debit(clearingNGN, ngn(5000))},
+			wantErr:  "at least two postings",
+		},
+		{
+			name:     "unbalanced",
+			postings: []models.Posting{debit(clearingNGN, ngn(5000)), credit(walletNGN, ngn(4999))},
+			wantErr:  "not balanced",
+		},
+		{
+			name:     "only debits",
+			postings: []models.Posting{debit(clearingNGN, ngn(5000)), debit(walletNGN, ngn(5000))},
+			wantErr:  "not balanced",
+		},
+		{
+			name:     "mixed currencies",
+			postings: []models.Posting{debit(walletNGN, ngn(5000)), credit(walletUSD, usd(5000))},
+			wantErr:  "mixes currencies",
+		},
+		{
+			name:     "posting in another currency than its account",
+			postings: []models.Posting{debit(walletUSD, ngn(5000)), credit(walletNGN, ngn(5000))},
+			wantErr:  "cannot be made to USD account",
+		},
+		{
+			name:     "zero amount",
+			postings: []models.Posting{debit(clearingNGN, ngn(0)), credit(walletNGN, ngn(0))},
+			wantErr:  "greater than zero",
+		},
+		{
+			name:     "negative amount",
+			postings: []models.Posting{debit(clearingNGN, ngn(-500)), credit(walletNGN, ngn(-500))},
+			wantErr:  "greater than zero",
+		},
+		{
+			name:     "unknown account",
+			postings: []models.Posting{debit(clearingNGN, ngn(5000)), {AccountID: uuid.New(), Direction: models.LedgerCredit, Amount: ngn(5000)}},
+			wantErr:  "not found",
+		},
+		{
+			name:     "invalid direction",
+			postings: []models.Posting{debit(clearingNGN, ngn(5000)), {AccountID: walletNGN.ID, Direction: "sideways", Amount: ngn(5000)}},
+			wantErr:  "invalid posting direction",
+		},
+	}
+	for _, tt := range tests {
+		reference := "test-" + uuid.NewString()
+		if tt.noReference {
+			reference = ""
+		}
+		entry := &models.JournalEntry{Reference: reference, Postings: tt.postings}
+		err := validateEntry(entry, accounts)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: validateEntry failed: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: validateEntry = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// newTestService returns a ledger service on the test database and a new
+// wallet in NGN with a zero balance
+func newTestService(t *testing.T) (*LedgerService, *repository.WalletRepository, func() *models.Wallet) {
+	db := testdb.Open(t)
+	userRepo := repository.NewUserRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
+	service := NewLedgerService(db, repository.NewLedgerRepository(db), walletRepo)
+
+	newWallet := func() *models.Wallet {
+		user := &models.User{Email: "ledger-" + uuid.NewString() + "@example.com", Name: "Ledger Test"}
+		if err := userRepo.Create(user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		wallet := &models.Wallet{UserID: user.ID, Balance: ngn(0)}
+		if err := walletRepo.Create(wallet); err != nil {
+			t.Fatalf("failed to create wallet: %v", err)
+		}
+		return wallet
+	}
+	return service, walletRepo, newWallet
+}
+
+func walletBalance(t *testing.T, walletRepo *repository.WalletRepository, id uuid.UUID) models.Money {
+	t.Helper()
+	wallet, err := walletRepo.GetByID(id)
+	if err != nil {
+		t.Fatalf("failed to get wallet: %v", err)
+	}
+	return wallet.Balance
+}
+
+func TestPostUpdatesWalletBalances(t *testing.T) {
+	service, walletRepo, newWallet := newTestService(t)
+	wallet := newWallet()
+
+	tx, err := service.db.Beginx()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	clearing, err := service.SystemAccount(tx, models.LedgerAccountPaystackClearing, models.CurrencyNGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := service.WalletAccount(tx, wallet.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := "test-" + uuid.NewString()
+	if _, err := service.Move(tx, reference, "deposit", clearing, account, ngn(5000)); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if got := walletBalance(t, walletRepo, wallet.ID); got.Amount != 5000 {
+		t.Errorf("wallet balance = %d, want 5000", got.Amount)
+	}
+	entry, err := service.GetJournalEntry(reference)
+	if err != nil {
+		t.Fatalf("GetJournalEntry failed: %v", err)
+	}
+	if len(entry.Postings) != 2 {
+		t.Errorf("entry has %d postings, want 2", len(entry.Postings))
+	}
+}
+
+func TestPostIsAtomic(t *testing.T) {
+	service, walletRepo, newWallet := newTestService(t)
+	funded := newWallet()
+	empty := newWallet()
+
+	tx, err := service.db.Beginx()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	clearing, err := service.SystemAccount(tx, models.LedgerAccountPaystackClearing, models.CurrencyNGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fundedAccount, err := service.WalletAccount(tx, funded.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptyAccount, err := service.WalletAccount(tx, empty.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry balances and credits the first wallet before debiting the
+	// second, which has nothing to debit, so Post fails part-way through
+	reference := "test-" + uuid.NewString()
+	entry := &models.JournalEntry{
+		Reference: reference,
+		Postings: []models.Posting{
+			{AccountID: clearing.ID, Direction: models.LedgerDebit, Amount: ngn(5000)},
+			{AccountID: fundedAccount.ID, Direction: models.LedgerCredit, Amount: ngn(5000)},
+			{AccountID: emptyAccount.ID, Direction: models.LedgerDebit, Amount: ngn(5000)},
+			{AccountID: clearing.ID, Direction: models.LedgerCredit, Amount: ngn(5000)},
+		},
+	}
+	if err := service.Post(tx, entry); err == nil {
+		t.Fatal("Post succeeded, want an insufficient balance error")
+	}
+	tx.Rollback()
+
+	if got := walletBalance(t, walletRepo, funded.ID); got.Amount != 0 {
+		t.Errorf("funded wallet balance = %d, want 0", got.Amount)
+	}
+	if got := walletBalance(t, walletRepo, empty.ID); got.Amount != 0 {
+		t.Errorf("empty wallet balance = %d, want 0", got.Amount)
+	}
+
+	check, err := service.db.Beginx()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer check.Rollback()
+	exists, err := service.HasEntry(check, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Errorf("journal entry %s was recorded after Post failed", reference)
+	}
+}
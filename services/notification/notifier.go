@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"log"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// Notifier tells a user about something that happened to their wallet
+type Notifier interface {
+	Notify(user *models.User, subject, message string) error
+}
+
+// LogNotifier writes notifications to the service log. It is used until an
+// email or push provider is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(user *models.User, subject, message string) error {
+	log.Printf("Notification to %s (%s): %s: %s", user.Email, user.ID, subject, message)
+	return nil
+}
@@ -0,0 +1,172 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// PaymentProvider is a payment gateway that collects deposits
+type PaymentProvider interface {
+	// Name identifies the provider on deposits and in webhook URLs
+	Name() models.PaymentProvider
+
+	// InitializePayment starts a checkout for the deposit reference
+	InitializePayment(email string, amount models.Money, reference string) (*Checkout, error)
+
+	// VerifyPayment asks the provider what happened to a payment
+	VerifyPayment(reference string) (*Verification, error)
+
+	// WebhookSignatureHeader is the request header carrying the webhook signature
+	WebhookSignatureHeader() string
+
+	// ValidateWebhook checks that a webhook body was sent by the provider
+	ValidateWebhook(body []byte, signature string) bool
+
+	// ParseWebhook normalizes a webhook body into an Event
+	ParseWebhook(body []byte) (*Event, error)
+}
+
+// APIError is a provider answering a request with an error. Errors that are
+// not APIErrors, such as timeouts and dropped connections, leave it unknown
+// whether the provider acted on the request.
+type APIError struct {
+	Provider   models.PaymentProvider
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s returned error (HTTP %d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// IsRejected reports whether err is the provider clearly refusing a request,
+// so that the request had no effect. Server errors, timeouts, conflicts and
+// rate limiting do not count: the provider may have acted on the request, or
+// may still act on it.
+func IsRejected(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return apiErr.StatusCode < http.StatusInternalServerError
+}
+
+// Checkout is where the customer is sent to pay
+type Checkout struct {
+	AuthorizationURL string
+	Reference        string
+}
+
+// PaymentStatus is a provider's payment status mapped onto a common set
+type PaymentStatus string
+
+const (
+	PaymentStatusSuccess PaymentStatus = "success"
+	PaymentStatusFailed  PaymentStatus = "failed"
+	// Started but not paid yet; the customer may still complete it
+	PaymentStatusPending PaymentStatus = "pending"
+	// The customer left the checkout without paying
+	PaymentStatusAbandoned PaymentStatus = "abandoned"
+)
+
+// Verification is what the provider reports was collected for a payment
+type Verification struct {
+	Reference string
+	Status    PaymentStatus
+	// Amount in the currency's minor unit
+	Amount   int64
+	Currency string
+}
+
+// EventType is a webhook event mapped onto the events the wallet acts on
+type EventType string
+
+const (
+	EventChargeSuccess    EventType = "charge.success"
+	EventChargeFailed     EventType = "charge.failed"
+	EventRefundProcessed  EventType = "refund.processed"
+	EventRefundFailed     EventType = "refund.failed"
+	EventDisputeCreated   EventType = "dispute.created"
+	EventDisputeResolved  EventType = "dispute.resolved"
+	EventTransferSuccess  EventType = "transfer.success"
+	EventTransferFailed   EventType = "transfer.failed"
+	EventTransferReversed EventType = "transfer.reversed"
+	// An event the wallet does not act on
+	EventUnhandled EventType = ""
+)
+
+// DisputeResolution is how a chargeback dispute ended
+type DisputeResolution string
+
+const (
+	// The merchant accepted the chargeback, so the customer keeps the money
+	DisputeMerchantAccepted DisputeResolution = "merchant_accepted"
+	// The dispute was declined in the merchant's favour
+	DisputeDeclined DisputeResolution = "declined"
+)
+
+// Event is a webhook event normalized across providers
+type Event struct {
+	Provider models.PaymentProvider
+	Type     EventType
+	// Name is the event name as the provider sent it
+	Name string
+	// ID is the provider's ID for the object the event is about, e.g. a refund
+	ID string
+	// Reference is our reference for the charge or transfer
+	Reference string
+	// Amount in the currency's minor unit
+	Amount     int64
+	Currency   string
+	Resolution DisputeResolution
+}
+
+// Registry holds the configured providers and the one used by default
+type Registry struct {
+	providers       map[models.PaymentProvider]PaymentProvider
+	defaultProvider models.PaymentProvider
+}
+
+func NewRegistry(providers ...PaymentProvider) *Registry {
+	registry := &Registry{providers: make(map[models.PaymentProvider]PaymentProvider)}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+	return registry
+}
+
+// Register adds a provider. The first provider registered is the default.
+func (r *Registry) Register(provider PaymentProvider) {
+	r.providers[provider.Name()] = provider
+	if r.defaultProvider == "" {
+		r.defaultProvider = provider.Name()
+	}
+}
+
+// SetDefault chooses the provider used when a deposit does not name one
+func (r *Registry) SetDefault(name models.PaymentProvider) error {
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("payment provider %q is not configured", name)
+	}
+	r.defaultProvider = name
+	return nil
+}
+
+// Get returns a provider by name, or the default provider for an empty name
+func (r *Registry) Get(name models.PaymentProvider) (PaymentProvider, error) {
+	if name == "" {
+		name = r.defaultProvider
+	}
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider %q is not configured", name)
+	}
+	return provider, nil
+}
@@ -0,0 +1,260 @@
+package paymentrequest
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/notification"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/google/uuid"
+)
+
+// MaxMemoLength is the longest a payment request memo can be
+const MaxMemoLength = 140
+
+// Policy controls how long payment requests stay open
+type Policy struct {
+	// Lifetime of a request created without an expiry
+	DefaultTTL time.Duration
+	// Longest lifetime a requester can ask for
+	MaxTTL time.Duration
+}
+
+// PaymentRequestService lets users ask each other for money and pays the
+// requests that are accepted
+type PaymentRequestService struct {
+	paymentRequestRepo *repository.PaymentRequestRepository
+	walletRepo         *repository.WalletRepository
+	userRepo           *repository.UserRepository
+	walletService      *wallet.WalletService
+	notifier           notification.Notifier
+	policy             Policy
+}
+
+func NewPaymentRequestService(
+	paymentRequestRepo *repository.PaymentRequestRepository,
+	walletRepo *repository.WalletRepository,
+	userRepo *repository.UserRepository,
+	walletService *wallet.WalletService,
+	notifier notification.Notifier,
+	policy Policy,
+) *PaymentRequestService {
+	return &PaymentRequestService{
+		paymentRequestRepo: paymentRequestRepo,
+		walletRepo:         walletRepo,
+		userRepo:           userRepo,
+		walletService:      walletService,
+		notifier:           notifier,
+		policy:             policy,
+	}
+}
+
+// Create asks the owner of payerWalletNumber to pay amount into the
+// requester's wallet in the same currency. A zero amount currency means the
+// payer wallet's currency. expiresAt is optional.
+func (s *PaymentRequestService) Create(
+	requesterUserID uuid.UUID,
+	payerWalletNumber string,
+	amount models.Money,
+	memo string,
+	expiresAt *time.Time,
+) (*models.PaymentRequest, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	memo = strings.TrimSpace(memo)
+	if len(memo) > MaxMemoLength {
+		return nil, fmt.Errorf("memo must be at most %d characters", MaxMemoLength)
+	}
+
+	now := time.Now()
+	expiry := now.Add(s.policy.DefaultTTL)
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("expires_at must be in the future")
+		}
+		if expiresAt.After(now.Add(s.policy.MaxTTL)) {
+			return nil, fmt.Errorf("expires_at must be within %s", s.policy.MaxTTL)
+		}
+		expiry = *expiresAt
+	}
+
+	payerWallet, err := s.walletRepo.GetByWalletNumber(payerWalletNumber)
+	if err != nil {
+		return nil, fmt.Errorf("payer wallet not found: %w", err)
+	}
+	if payerWallet.UserID == requesterUserID {
+		return nil, fmt.Errorf("cannot request a payment from yourself")
+	}
+	if amount.Currency == "" {
+		amount.Currency = payerWallet.Currency
+	}
+	if amount.Currency != payerWallet.Currency {
+		return nil, fmt.Errorf("request currency %s does not match payer wallet currency %s", amount.Currency, payerWallet.Currency)
+	}
+	requesterWallet, err := s.walletRepo.GetByUserIDAndCurrency(requesterUserID, amount.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("you do not have a %s wallet: %w", amount.Currency, err)
+	}
+
+	pr := &models.PaymentRequest{
+		RequesterUserID:       requesterUserID,
+		RequesterWalletID:     requesterWallet.ID,
+		RequesterWalletNumber: requesterWallet.WalletNumber,
+		PayerUserID:           payerWallet.UserID,
+		PayerWalletID:         payerWallet.ID,
+		PayerWalletNumber:     payerWallet.WalletNumber,
+		Amount:                amount,
+		Status:                models.PaymentRequestStatusPending,
+		ExpiresAt:             expiry,
+	}
+	if memo != "" {
+		pr.Memo = &memo
+	}
+
+	if err := s.paymentRequestRepo.Create(pr); err != nil {
+		return nil, fmt.Errorf("failed to create payment request: %w", err)
+	}
+
+	message := fmt.Sprintf("Wallet %s has requested %s %s from you.", pr.RequesterWalletNumber, pr.Amount, pr.Amount.Currency)
+	if pr.Memo != nil {
+		message += fmt.Sprintf(" Memo: %q.", *pr.Memo)
+	}
+	message += fmt.Sprintf(" The request expires at %s.", pr.ExpiresAt.UTC().Format(time.RFC1123))
+	s.notify(pr.PayerUserID, pr, "Payment requested", message)
+
+	return pr, nil
+}
+
+// Get gets a payment request the user sent or received
+func (s *PaymentRequestService) Get(userID, id uuid.UUID) (*models.PaymentRequest, error) {
+	pr, err := s.paymentRequestRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.RequesterUserID != userID && pr.PayerUserID != userID {
+		return nil, fmt.Errorf("payment request not found")
+	}
+	return pr, nil
+}
+
+// ListSent lists the payment requests a user has sent, optionally filtered by status
+func (s *PaymentRequestService) ListSent(userID uuid.UUID, status string, limit, offset int) ([]models.PaymentRequest, error) {
+	return s.paymentRequestRepo.ListByRequester(userID, status, limit, offset)
+}
+
+// ListReceived lists the payment requests a user has been sent, optionally filtered by status
+func (s *PaymentRequestService) ListReceived(userID uuid.UUID, status string, limit, offset int) ([]models.PaymentRequest, error) {
+	return s.paymentRequestRepo.ListByPayer(userID, status, limit, offset)
+}
+
+// Accept pays a pending request the user received with WalletService.Transfer,
+// so the usual fees and limits apply. The request is marked accepted before
+// the transfer is made so that it cannot be paid twice; if the transfer fails
+// it goes back to pending.
+func (s *PaymentRequestService) Accept(userID uuid.UUID, apiKeyID *uuid.UUID, id uuid.UUID) (*models.PaymentRequest, error) {
+	pr, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.PayerUserID != userID {
+		return nil, fmt.Errorf("only the payer can accept a payment request")
+	}
+	if !pr.IsPayable(time.Now()) {
+		return nil, fmt.Errorf("payment request is %s", displayStatus(pr))
+	}
+
+	pr, err = s.paymentRequestRepo.UpdateStatus(id, models.PaymentRequestStatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	debit, err := s.walletService.Transfer(userID, apiKeyID, pr.RequesterWalletNumber, pr.Amount)
+	if err != nil {
+		if reopenErr := s.paymentRequestRepo.Reopen(id); reopenErr != nil {
+			log.Printf("Failed to reopen payment request %s after failed transfer: %v", id, reopenErr)
+		}
+		return nil, err
+	}
+
+	pr.TransactionID = &debit.ID
+	if err := s.paymentRequestRepo.SetTransaction(id, debit.ID); err != nil {
+		// The money has moved; only the link to the transaction is missing
+		log.Printf("Failed to record transaction %s on payment request %s: %v", debit.ID, id, err)
+	}
+
+	s.notify(pr.RequesterUserID, pr, "Payment request paid", fmt.Sprintf(
+		"Wallet %s has paid your request for %s %s.",
+		pr.PayerWalletNumber, pr.Amount, pr.Amount.Currency,
+	))
+	return pr, nil
+}
+
+// Decline refuses a pending request the user received
+func (s *PaymentRequestService) Decline(userID, id uuid.UUID) (*models.PaymentRequest, error) {
+	pr, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.PayerUserID != userID {
+		return nil, fmt.Errorf("only the payer can decline a payment request")
+	}
+
+	pr, err = s.paymentRequestRepo.UpdateStatus(id, models.PaymentRequestStatusDeclined)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(pr.RequesterUserID, pr, "Payment request declined", fmt.Sprintf(
+		"Wallet %s has declined your request for %s %s.",
+		pr.PayerWalletNumber, pr.Amount, pr.Amount.Currency,
+	))
+	return pr, nil
+}
+
+// Cancel withdraws a pending request the user sent
+func (s *PaymentRequestService) Cancel(userID, id uuid.UUID) (*models.PaymentRequest, error) {
+	pr, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.RequesterUserID != userID {
+		return nil, fmt.Errorf("only the requester can cancel a payment request")
+	}
+	return s.paymentRequestRepo.UpdateStatus(id, models.PaymentRequestStatusCancelled)
+}
+
+// ExpireRequests marks pending requests past their expiry as expired and
+// returns how many were expired
+func (s *PaymentRequestService) ExpireRequests() (int64, error) {
+	expired, err := s.paymentRequestRepo.ExpireDue()
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire payment requests: %w", err)
+	}
+	return expired, nil
+}
+
+// displayStatus is the status of a request as the user sees it: a pending
+// request past its expiry is expired even before the expiry job has run
+func displayStatus(pr *models.PaymentRequest) models.PaymentRequestStatus {
+	if pr.Status == models.PaymentRequestStatusPending && !time.Now().Before(pr.ExpiresAt) {
+		return models.PaymentRequestStatusExpired
+	}
+	return pr.Status
+}
+
+// notify tells one party about a payment request. Delivery failures are only logged.
+func (s *PaymentRequestService) notify(userID uuid.UUID, pr *models.PaymentRequest, subject, message string) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		log.Printf("Failed to notify user %s about payment request %s: %v", userID, pr.ID, err)
+		return
+	}
+	if err := s.notifier.Notify(user, subject, message); err != nil {
+		log.Printf("Failed to notify user %s about payment request %s: %v", userID, pr.ID, err)
+	}
+}
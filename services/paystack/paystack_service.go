@@ -9,8 +9,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/brainox/paystack_wallet_service/external/external_models"
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
 )
 
 const (
@@ -19,100 +23,166 @@ const (
 
 type PaystackService struct {
 	secretKey string
+	baseURL   string
 	client    *http.Client
 }
 
-func NewPaystackService(secretKey string) *PaystackService {
+// NewPaystackService creates a Paystack client. An empty baseURL means the
+// live Paystack API; pass another URL to use a fake server such as fakepaystack.
+func NewPaystackService(secretKey, baseURL string) *PaystackService {
+	if baseURL == "" {
+		baseURL = PaystackBaseURL
+	}
 	return &PaystackService{
 		secretKey: secretKey,
+		baseURL:   strings.TrimRight(baseURL, "/"),
 		client:    &http.Client{},
 	}
 }
 
 // InitializeTransaction initializes a Paystack transaction
-func (s *PaystackService) InitializeTransaction(email string, amount int, reference string) (*external_models.InitializeTransactionResponse, error) {
-	url := fmt.Sprintf("%s/transaction/initialize", PaystackBaseURL)
+func (s *PaystackService) InitializeTransaction(email string, amount models.Money, reference string) (*external_models.InitializeTransactionResponse, error) {
+	url := fmt.Sprintf("%s/transaction/initialize", s.baseURL)
 
 	payload := external_models.InitializeTransactionRequest{
-		Amount:    amount, // Amount in kobo (smallest currency unit)
+		Amount:    amount.Amount, // Amount in kobo (smallest currency unit)
 		Email:     email,
 		Reference: reference,
-		Currency:  "NGN",
+		Currency:  string(amount.Currency),
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	var result external_models.InitializeTransactionResponse
+	if err := s.do(http.MethodPost, url, payload, &result); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	return &result, nil
+}
 
-	req.Header.Set("Authorization", "Bearer "+s.secretKey)
-	req.Header.Set("Content-Type", "application/json")
+// VerifyTransaction verifies a Paystack transaction
+func (s *PaystackService) VerifyTransaction(reference string) (*external_models.VerifyTransactionResponse, error) {
+	url := fmt.Sprintf("%s/transaction/verify/%s", s.baseURL, reference)
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	var result external_models.VerifyTransactionResponse
+	if err := s.do(http.MethodGet, url, nil, &result); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	return &result, nil
+}
+
+// ListBanks lists the banks that can receive transfers in the given currency
+func (s *PaystackService) ListBanks(currency models.Currency) (*external_models.ListBanksResponse, error) {
+	query := url.Values{}
+	query.Set("currency", string(currency))
+	endpoint := fmt.Sprintf("%s/bank?%s", s.baseURL, query.Encode())
+
+	var result external_models.ListBanksResponse
+	if err := s.do(http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("paystack error: %s", string(body))
+	return &result, nil
+}
+
+// ResolveAccountNumber looks up the account name for a bank account
+func (s *PaystackService) ResolveAccountNumber(accountNumber, bankCode string) (*external_models.ResolveAccountResponse, error) {
+	query := url.Values{}
+	query.Set("account_number", accountNumber)
+	query.Set("bank_code", bankCode)
+	endpoint := fmt.Sprintf("%s/bank/resolve?%s", s.baseURL, query.Encode())
+
+	var result external_models.ResolveAccountResponse
+	if err := s.do(http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
 	}
 
-	var result external_models.InitializeTransactionResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	return &result, nil
+}
+
+// CreateTransferRecipient registers a Nigerian bank account as a transfer recipient
+func (s *PaystackService) CreateTransferRecipient(name, accountNumber, bankCode string, currency models.Currency) (*external_models.CreateTransferRecipientResponse, error) {
+	url := fmt.Sprintf("%s/transferrecipient", s.baseURL)
+
+	payload := external_models.CreateTransferRecipientRequest{
+		Type:          "nuban",
+		Name:          name,
+		AccountNumber: accountNumber,
+		BankCode:      bankCode,
+		Currency:      string(currency),
 	}
 
-	if !result.Status {
-		return nil, fmt.Errorf("paystack returned error: %s", result.Message)
+	var result external_models.CreateTransferRecipientResponse
+	if err := s.do(http.MethodPost, url, payload, &result); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
 }
 
-// VerifyTransaction verifies a Paystack transaction
-func (s *PaystackService) VerifyTransaction(reference string) (*external_models.VerifyTransactionResponse, error) {
-	url := fmt.Sprintf("%s/transaction/verify/%s", PaystackBaseURL, reference)
+// InitiateTransfer sends money from the Paystack balance to a transfer recipient
+func (s *PaystackService) InitiateTransfer(amount models.Money, recipientCode, reference, reason string) (*external_models.InitiateTransferResponse, error) {
+	url := fmt.Sprintf("%s/transfer", s.baseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	payload := external_models.InitiateTransferRequest{
+		Source:    "balance",
+		Amount:    amount.Amount, // Amount in kobo (smallest currency unit)
+		Recipient: recipientCode,
+		Reference: reference,
+		Reason:    reason,
+		Currency:  string(amount.Currency),
 	}
 
-	req.Header.Set("Authorization", "Bearer "+s.secretKey)
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	var result external_models.InitiateTransferResponse
+	if err := s.do(http.MethodPost, url, payload, &result); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	return &result, nil
+}
+
+// VerifyTransfer asks Paystack what happened to a transfer
+func (s *PaystackService) VerifyTransfer(reference string) (*external_models.InitiateTransferResponse, error) {
+	url := fmt.Sprintf("%s/transfer/verify/%s", s.baseURL, reference)
+
+	var result external_models.InitiateTransferResponse
+	if err := s.do(http.MethodGet, url, nil, &result); err != nil {
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("paystack error: %s", string(body))
+	return &result, nil
+}
+
+// CreateRefund refunds all or part of a successful transaction to the payer.
+// Paystack processes refunds asynchronously and reports the outcome with a
+// refund.processed or refund.failed webhook.
+func (s *PaystackService) CreateRefund(transactionReference string, amount models.Money, note string) (*external_models.CreateRefundResponse, error) {
+	url := fmt.Sprintf("%s/refund", s.baseURL)
+
+	payload := external_models.CreateRefundRequest{
+		Transaction:  transactionReference,
+		Amount:       amount.Amount, // Amount in kobo (smallest currency unit)
+		Currency:     string(amount.Currency),
+		MerchantNote: note,
 	}
 
-	var result external_models.VerifyTransactionResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	var result external_models.CreateRefundResponse
+	if err := s.do(http.MethodPost, url, payload, &result); err != nil {
+		return nil, err
 	}
 
-	if !result.Status {
-		return nil, fmt.Errorf("paystack returned error: %s", result.Message)
+	return &result, nil
+}
+
+// ListRefunds lists the refunds made against a transaction
+func (s *PaystackService) ListRefunds(transactionReference string) (*external_models.ListRefundsResponse, error) {
+	query := url.Values{}
+	query.Set("transaction", transactionReference)
+	endpoint := fmt.Sprintf("%s/refund?%s", s.baseURL, query.Encode())
+
+	var result external_models.ListRefundsResponse
+	if err := s.do(http.MethodGet, endpoint, nil, &result); err != nil {
+		return nil, err
 	}
 
 	return &result, nil
@@ -125,3 +195,66 @@ func (s *PaystackService) ValidateWebhookSignature(body []byte, signature string
 	expectedSignature := hex.EncodeToString(hash.Sum(nil))
 	return hmac.Equal([]byte(expectedSignature), []byte(signature))
 }
+
+// do sends an authenticated request to Paystack and decodes the response into result
+func (s *PaystackService) do(method, url string, payload interface{}, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Every Paystack response shares the same status/message envelope
+	var envelope struct {
+		Status  bool   `json:"status"`
+		Message string `json:"message"`
+	}
+	decodeErr := json.Unmarshal(respBody, &envelope)
+
+	// Creation endpoints such as /transferrecipient answer with 201. An error
+	// without Paystack's envelope, e.g. from a proxy, is not a clear answer.
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		if decodeErr != nil || envelope.Status {
+			return fmt.Errorf("paystack error (HTTP %d): %s", resp.StatusCode, string(respBody))
+		}
+		return &payment.APIError{Provider: models.PaymentProviderPaystack, StatusCode: resp.StatusCode, Message: envelope.Message}
+	}
+
+	if decodeErr != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", decodeErr)
+	}
+
+	if !envelope.Status {
+		return &payment.APIError{Provider: models.PaymentProviderPaystack, StatusCode: resp.StatusCode, Message: envelope.Message}
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package paystack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/brainox/paystack_wallet_service/external/external_models"
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+)
+
+// PaystackService implements payment.PaymentProvider
+var _ payment.PaymentProvider = (*PaystackService)(nil)
+
+const webhookSignatureHeader = "x-paystack-signature"
+
+func (s *PaystackService) Name() models.PaymentProvider {
+	return models.PaymentProviderPaystack
+}
+
+// InitializePayment starts a Paystack checkout
+func (s *PaystackService) InitializePayment(email string, amount models.Money, reference string) (*payment.Checkout, error) {
+	resp, err := s.InitializeTransaction(email, amount, reference)
+	if err != nil {
+		return nil, err
+	}
+	return &payment.Checkout{
+		AuthorizationURL: resp.Data.AuthorizationURL,
+		Reference:        resp.Data.Reference,
+	}, nil
+}
+
+// VerifyPayment verifies a Paystack transaction
+func (s *PaystackService) VerifyPayment(reference string) (*payment.Verification, error) {
+	resp, err := s.VerifyTransaction(reference)
+	if err != nil {
+		return nil, err
+	}
+	return &payment.Verification{
+		Reference: resp.Data.Reference,
+		Status:    paymentStatus(resp.Data.Status),
+		Amount:    resp.Data.Amount,
+		Currency:  resp.Data.Currency,
+	}, nil
+}
+
+func (s *PaystackService) WebhookSignatureHeader() string {
+	return webhookSignatureHeader
+}
+
+func (s *PaystackService) ValidateWebhook(body []byte, signature string) bool {
+	return s.ValidateWebhookSignature(body, signature)
+}
+
+// ParseWebhook normalizes a Paystack webhook event
+func (s *PaystackService) ParseWebhook(body []byte) (*payment.Event, error) {
+	var webhook external_models.WebhookEvent
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+	if webhook.Event == "" {
+		return nil, fmt.Errorf("webhook has no event name")
+	}
+
+	event := &payment.Event{
+		Provider:  models.PaymentProviderPaystack,
+		Name:      webhook.Event,
+		ID:        strconv.FormatInt(webhook.Data.ID, 10),
+		Reference: webhook.Data.ChargeReference(),
+		Amount:    webhook.Data.Amount,
+		Currency:  webhook.Data.Currency,
+	}
+
+	switch webhook.Event {
+	case "charge.success":
+		event.Type = payment.EventChargeSuccess
+	case "charge.failed":
+		event.Type = payment.EventChargeFailed
+	case "refund.processed":
+		event.Type = payment.EventRefundProcessed
+	case "refund.failed":
+		event.Type = payment.EventRefundFailed
+	case "charge.dispute.create":
+		event.Type = payment.EventDisputeCreated
+	case "charge.dispute.resolve":
+		event.Type = payment.EventDisputeResolved
+		event.Resolution = disputeResolution(webhook.Data.Resolution)
+	case "transfer.success":
+		event.Type = payment.EventTransferSuccess
+	case "transfer.failed":
+		event.Type = payment.EventTransferFailed
+	case "transfer.reversed":
+		event.Type = payment.EventTransferReversed
+	default:
+		event.Type = payment.EventUnhandled
+	}
+
+	return event, nil
+}
+
+// paymentStatus maps a Paystack transaction status onto the common statuses
+func paymentStatus(status string) payment.PaymentStatus {
+	switch status {
+	case "success":
+		return payment.PaymentStatusSuccess
+	case "failed", "reversed":
+		return payment.PaymentStatusFailed
+	case "abandoned":
+		return payment.PaymentStatusAbandoned
+	default:
+		// ongoing, pending, processing, queued
+		return payment.PaymentStatusPending
+	}
+}
+
+// disputeResolution maps Paystack's dispute resolutions. Unknown values are
+// passed through so that they are reported rather than silently handled.
+func disputeResolution(resolution string) payment.DisputeResolution {
+	switch resolution {
+	case "merchant-accepted":
+		return payment.DisputeMerchantAccepted
+	case "declined":
+		return payment.DisputeDeclined
+	default:
+		return payment.DisputeResolution(resolution)
+	}
+}
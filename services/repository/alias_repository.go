@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AliasRepository struct {
+	db *sqlx.DB
+}
+
+func NewAliasRepository(db *sqlx.DB) *AliasRepository {
+	return &AliasRepository{db: db}
+}
+
+// Create stores a newly claimed alias
+func (r *AliasRepository) Create(tx *sqlx.Tx, alias *models.Alias) error {
+	query := `
+		INSERT INTO aliases (id, user_id, type, value, status, claimed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	alias.ID = uuid.New()
+	alias.Status = models.AliasStatusActive
+	alias.ClaimedAt = time.Now()
+	alias.CreatedAt = time.Now()
+	alias.UpdatedAt = time.Now()
+
+	return tx.QueryRow(
+		query,
+		alias.ID,
+		alias.UserID,
+		alias.Type,
+		alias.Value,
+		alias.Status,
+		alias.ClaimedAt,
+		alias.CreatedAt,
+		alias.UpdatedAt,
+	).Scan(&alias.ID, &alias.CreatedAt, &alias.UpdatedAt)
+}
+
+// GetActive gets the alias currently held under value. It returns nil if
+// nobody holds value.
+func (r *AliasRepository) GetActive(value string) (*models.Alias, error) {
+	var alias models.Alias
+	query := `SELECT * FROM aliases WHERE value = $1 AND status = 'active'`
+	err := r.db.Get(&alias, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// GetLastReleased gets the most recent release of value. It returns nil if
+// value has never been released.
+func (r *AliasRepository) GetLastReleased(value string) (*models.Alias, error) {
+	var alias models.Alias
+	query := `
+		SELECT * FROM aliases
+		WHERE value = $1 AND status = 'released'
+		ORDER BY released_at DESC
+		LIMIT 1
+	`
+	err := r.db.Get(&alias, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// ListByUser returns every alias a user has held, newest first
+func (r *AliasRepository) ListByUser(userID uuid.UUID) ([]models.Alias, error) {
+	var aliases []models.Alias
+	query := `SELECT * FROM aliases WHERE user_id = $1 ORDER BY created_at DESC`
+	err := r.db.Select(&aliases, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// Release gives up a user's active alias of the given type, if they have one
+func (r *AliasRepository) Release(tx *sqlx.Tx, userID uuid.UUID, aliasType models.AliasType) error {
+	query := `
+		UPDATE aliases
+		SET status = 'released', released_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND type = $2 AND status = 'active'
+	`
+	_, err := tx.Exec(query, userID, aliasType)
+	return err
+}
+
+// CreateVerification stores a one-time code sent to a phone number
+func (r *AliasRepository) CreateVerification(verification *models.AliasVerification) error {
+	query := `
+		INSERT INTO alias_verifications (id, user_id, phone, code_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	verification.ID = uuid.New()
+	verification.CreatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		verification.ID,
+		verification.UserID,
+		verification.Phone,
+		verification.CodeHash,
+		verification.ExpiresAt,
+		verification.CreatedAt,
+	).Scan(&verification.ID, &verification.CreatedAt)
+}
+
+// GetLatestVerification gets the last code sent for a user. It returns nil
+// if none has been sent.
+func (r *AliasRepository) GetLatestVerification(userID uuid.UUID) (*models.AliasVerification, error) {
+	var verification models.AliasVerification
+	query := `
+		SELECT * FROM alias_verifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := r.db.Get(&verification, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// GetVerificationForUpdate gets a code and locks it until tx ends
+func (r *AliasRepository) GetVerificationForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.AliasVerification, error) {
+	var verification models.AliasVerification
+	query := `SELECT * FROM alias_verifications WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&verification, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("verification not found")
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// RecordAttempt counts a wrong code against a verification
+func (r *AliasRepository) RecordAttempt(tx *sqlx.Tx, id uuid.UUID) error {
+	query := `UPDATE alias_verifications SET attempts = attempts + 1 WHERE id = $1`
+	_, err := tx.Exec(query, id)
+	return err
+}
+
+// MarkVerified records that the right code was sent back, so it cannot be used again
+func (r *AliasRepository) MarkVerified(tx *sqlx.Tx, id uuid.UUID) error {
+	query := `UPDATE alias_verifications SET verified_at = NOW() WHERE id = $1`
+	_, err := tx.Exec(query, id)
+	return err
+}
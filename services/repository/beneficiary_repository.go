@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrBeneficiaryNotFound is returned when no beneficiary matches a lookup
+var ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+
+type BeneficiaryRepository struct {
+	db *sqlx.DB
+}
+
+func NewBeneficiaryRepository(db *sqlx.DB) *BeneficiaryRepository {
+	return &BeneficiaryRepository{db: db}
+}
+
+func (r *BeneficiaryRepository) Create(beneficiary *models.Beneficiary) error {
+	query := `
+		INSERT INTO beneficiaries (
+			id, user_id, type, nickname, wallet_number, bank_code, account_number,
+			account_name, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	beneficiary.ID = uuid.New()
+	beneficiary.CreatedAt = time.Now()
+	beneficiary.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		beneficiary.ID,
+		beneficiary.UserID,
+		beneficiary.Type,
+		beneficiary.Nickname,
+		beneficiary.WalletNumber,
+		beneficiary.BankCode,
+		beneficiary.AccountNumber,
+		beneficiary.AccountName,
+		beneficiary.CreatedAt,
+		beneficiary.UpdatedAt,
+	).Scan(&beneficiary.ID, &beneficiary.CreatedAt, &beneficiary.UpdatedAt)
+}
+
+func (r *BeneficiaryRepository) GetByID(id uuid.UUID) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	query := `SELECT * FROM beneficiaries WHERE id = $1`
+	err := r.db.Get(&beneficiary, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+// ListByUser returns a user's beneficiaries by nickname, optionally only
+// those of one type
+func (r *BeneficiaryRepository) ListByUser(userID uuid.UUID, beneficiaryType string) ([]models.Beneficiary, error) {
+	var beneficiaries []models.Beneficiary
+	query := `
+		SELECT * FROM beneficiaries
+		WHERE user_id = $1 AND ($2 = '' OR type::TEXT = $2)
+		ORDER BY LOWER(nickname)
+	`
+	if err := r.db.Select(&beneficiaries, query, userID, beneficiaryType); err != nil {
+		return nil, err
+	}
+	return beneficiaries, nil
+}
+
+// UpdateNickname renames a beneficiary
+func (r *BeneficiaryRepository) UpdateNickname(id uuid.UUID, nickname string) error {
+	query := `UPDATE beneficiaries SET nickname = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, nickname, time.Now(), id)
+	return err
+}
+
+func (r *BeneficiaryRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM beneficiaries WHERE id = $1`, id)
+	return err
+}
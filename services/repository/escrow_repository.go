@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type EscrowRepository struct {
+	db *sqlx.DB
+}
+
+func NewEscrowRepository(db *sqlx.DB) *EscrowRepository {
+	return &EscrowRepository{db: db}
+}
+
+func (r *EscrowRepository) Create(tx *sqlx.Tx, escrow *models.Escrow) error {
+	query := `
+		INSERT INTO escrows (
+			id, buyer_user_id, buyer_wallet_id, buyer_wallet_number,
+			seller_user_id, seller_wallet_id, seller_wallet_number, amount, currency,
+			status, reference, description, auto_release_at, api_key_id,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id, created_at, updated_at
+	`
+	escrow.Currency = escrow.Amount.Currency
+	escrow.ID = uuid.New()
+	escrow.CreatedAt = time.Now()
+	escrow.UpdatedAt = time.Now()
+
+	return tx.QueryRow(
+		query,
+		escrow.ID,
+		escrow.BuyerUserID,
+		escrow.BuyerWalletID,
+		escrow.BuyerWalletNumber,
+		escrow.SellerUserID,
+		escrow.SellerWalletID,
+		escrow.SellerWalletNumber,
+		escrow.Amount,
+		escrow.Currency,
+		escrow.Status,
+		escrow.Reference,
+		escrow.Description,
+		escrow.AutoReleaseAt,
+		escrow.APIKeyID,
+		escrow.CreatedAt,
+		escrow.UpdatedAt,
+	).Scan(&escrow.ID, &escrow.CreatedAt, &escrow.UpdatedAt)
+}
+
+func (r *EscrowRepository) GetByID(id uuid.UUID) (*models.Escrow, error) {
+	var escrow models.Escrow
+	query := `SELECT * FROM escrows WHERE id = $1`
+	err := r.db.Get(&escrow, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("escrow not found")
+		}
+		return nil, err
+	}
+	escrow.ApplyCurrency()
+	return &escrow, nil
+}
+
+// GetByIDForUpdate gets an escrow by ID and locks it until tx ends
+func (r *EscrowRepository) GetByIDForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.Escrow, error) {
+	var escrow models.Escrow
+	query := `SELECT * FROM escrows WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&escrow, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("escrow not found")
+		}
+		return nil, err
+	}
+	escrow.ApplyCurrency()
+	return &escrow, nil
+}
+
+// ListByUser returns the escrows a user is the buyer or seller in, newest
+// first, optionally filtered by status
+func (r *EscrowRepository) ListByUser(userID uuid.UUID, status string, limit, offset int) ([]models.Escrow, error) {
+	var escrows []models.Escrow
+	query := `
+		SELECT * FROM escrows
+		WHERE (buyer_user_id = $1 OR seller_user_id = $1) AND ($2 = '' OR status::TEXT = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.Select(&escrows, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range escrows {
+		escrows[i].ApplyCurrency()
+	}
+	return escrows, nil
+}
+
+// ListDueForRelease returns the IDs of funded escrows past their auto-release
+// time, oldest first
+func (r *EscrowRepository) ListDueForRelease(limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	query := `
+		SELECT id FROM escrows
+		WHERE status = $1 AND auto_release_at <= NOW()
+		ORDER BY auto_release_at
+		LIMIT $2
+	`
+	err := r.db.Select(&ids, query, models.EscrowStatusFunded, limit)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Update saves an escrow's status, dispute and settlement
+func (r *EscrowRepository) Update(tx *sqlx.Tx, escrow *models.Escrow) error {
+	query := `
+		UPDATE escrows
+		SET status = $1, disputed_by = $2, dispute_reason = $3, disputed_at = $4,
+			settled_at = $5, updated_at = $6
+		WHERE id = $7
+	`
+	escrow.UpdatedAt = time.Now()
+	_, err := tx.Exec(
+		query,
+		escrow.Status,
+		escrow.DisputedBy,
+		escrow.DisputeReason,
+		escrow.DisputedAt,
+		escrow.SettledAt,
+		escrow.UpdatedAt,
+		escrow.ID,
+	)
+	return err
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type FXQuoteRepository struct {
+	db *sqlx.DB
+}
+
+func NewFXQuoteRepository(db *sqlx.DB) *FXQuoteRepository {
+	return &FXQuoteRepository{db: db}
+}
+
+func (r *FXQuoteRepository) Create(quote *models.FXQuote) error {
+	query := `
+		INSERT INTO fx_quotes (
+			id, user_id, from_currency, to_currency, sell_amount, buy_amount,
+			mid_rate, rate, spread_bps, spread, provider, status, expires_at,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, created_at, updated_at
+	`
+	quote.ID = uuid.New()
+	quote.CreatedAt = time.Now()
+	quote.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		quote.ID,
+		quote.UserID,
+		quote.FromCurrency,
+		quote.ToCurrency,
+		quote.SellAmount,
+		quote.BuyAmount,
+		quote.MidRate,
+		quote.Rate,
+		quote.SpreadBPS,
+		quote.Spread,
+		quote.Provider,
+		quote.Status,
+		quote.ExpiresAt,
+		quote.CreatedAt,
+		quote.UpdatedAt,
+	).Scan(&quote.ID, &quote.CreatedAt, &quote.UpdatedAt)
+}
+
+func (r *FXQuoteRepository) GetByID(id uuid.UUID) (*models.FXQuote, error) {
+	var quote models.FXQuote
+	query := `SELECT * FROM fx_quotes WHERE id = $1`
+	err := r.db.Get(&quote, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("quote not found")
+		}
+		return nil, err
+	}
+	quote.ApplyCurrency()
+	return &quote, nil
+}
+
+// GetByIDForUpdate gets a quote by ID and locks it until tx ends
+func (r *FXQuoteRepository) GetByIDForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.FXQuote, error) {
+	var quote models.FXQuote
+	query := `SELECT * FROM fx_quotes WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&quote, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("quote not found")
+		}
+		return nil, err
+	}
+	quote.ApplyCurrency()
+	return &quote, nil
+}
+
+// MarkExecuted records that a quote has been used
+func (r *FXQuoteRepository) MarkExecuted(tx *sqlx.Tx, id uuid.UUID, executedAt time.Time) error {
+	query := `
+		UPDATE fx_quotes
+		SET status = $1, executed_at = $2, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+	result, err := tx.Exec(query, models.FXQuoteStatusExecuted, executedAt, id, models.FXQuoteStatusOpen)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("quote has already been executed")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type HoldRepository struct {
+	db *sqlx.DB
+}
+
+func NewHoldRepository(db *sqlx.DB) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+func (r *HoldRepository) Create(tx *sqlx.Tx, hold *models.Hold) error {
+	query := `
+		INSERT INTO wallet_holds (
+			id, wallet_id, user_id, amount, captured_amount, currency, status,
+			reference, description, expires_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at
+	`
+	hold.ID = uuid.New()
+	hold.Currency = hold.Amount.Currency
+	hold.CreatedAt = time.Now()
+	hold.UpdatedAt = time.Now()
+
+	return tx.QueryRow(
+		query,
+		hold.ID,
+		hold.WalletID,
+		hold.UserID,
+		hold.Amount,
+		hold.CapturedAmount,
+		hold.Currency,
+		hold.Status,
+		hold.Reference,
+		hold.Description,
+		hold.ExpiresAt,
+		hold.CreatedAt,
+		hold.UpdatedAt,
+	).Scan(&hold.ID, &hold.CreatedAt, &hold.UpdatedAt)
+}
+
+func (r *HoldRepository) GetByID(id uuid.UUID) (*models.Hold, error) {
+	var hold models.Hold
+	query := `SELECT * FROM wallet_holds WHERE id = $1`
+	err := r.db.Get(&hold, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("hold not found")
+		}
+		return nil, err
+	}
+	hold.ApplyCurrency()
+	return &hold, nil
+}
+
+// GetByIDForUpdate gets a hold by ID and locks it until tx ends
+func (r *HoldRepository) GetByIDForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.Hold, error) {
+	var hold models.Hold
+	query := `SELECT * FROM wallet_holds WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&hold, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("hold not found")
+		}
+		return nil, err
+	}
+	hold.ApplyCurrency()
+	return &hold, nil
+}
+
+// ListByWallet returns a wallet's holds newest first, optionally filtered by status
+func (r *HoldRepository) ListByWallet(walletID uuid.UUID, status string, limit, offset int) ([]models.Hold, error) {
+	var holds []models.Hold
+	query := `
+		SELECT * FROM wallet_holds
+		WHERE wallet_id = $1 AND ($2 = '' OR status::TEXT = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.Select(&holds, query, walletID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holds {
+		holds[i].ApplyCurrency()
+	}
+	return holds, nil
+}
+
+// GetHeldAmount sums the uncaptured part of a wallet's unexpired active holds
+func (r *HoldRepository) GetHeldAmount(q sqlx.Queryer, walletID uuid.UUID) (int64, error) {
+	var held int64
+	query := `
+		SELECT COALESCE(SUM(amount - captured_amount), 0)
+		FROM wallet_holds
+		WHERE wallet_id = $1 AND status = $2 AND expires_at > NOW()
+	`
+	err := sqlx.Get(q, &held, query, walletID, models.HoldStatusActive)
+	return held, err
+}
+
+// UpdateCapture records the total captured so far and the resulting status
+func (r *HoldRepository) UpdateCapture(tx *sqlx.Tx, id uuid.UUID, captured models.Money, status models.HoldStatus) error {
+	query := `
+		UPDATE wallet_holds
+		SET captured_amount = $1, status = $2, updated_at = $3
+		WHERE id = $4
+	`
+	_, err := tx.Exec(query, captured, status, time.Now(), id)
+	return err
+}
+
+func (r *HoldRepository) UpdateStatus(tx *sqlx.Tx, id uuid.UUID, status models.HoldStatus) error {
+	query := `
+		UPDATE wallet_holds
+		SET status = $1, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := tx.Exec(query, status, time.Now(), id)
+	return err
+}
+
+// ExpireDue marks active holds past their expiry as expired, which releases
+// their uncaptured remainder, and returns how many were expired
+func (r *HoldRepository) ExpireDue() (int64, error) {
+	query := `
+		UPDATE wallet_holds
+		SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND expires_at <= NOW()
+	`
+	result, err := r.db.Exec(query, models.HoldStatusExpired, models.HoldStatusActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
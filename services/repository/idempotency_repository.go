@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Create reserves a key for a user and locks it for the request with
+// key.LockToken until key.LockedUntil. It returns false without error when
+// the user has already used the key.
+func (r *IdempotencyRepository) Create(key *models.IdempotencyKey) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (
+			id, user_id, key, request_method, request_path, request_hash,
+			lock_token, locked_until, expires_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, key) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	key.UpdatedAt = time.Now()
+
+	err := r.db.QueryRow(
+		query,
+		key.ID,
+		key.UserID,
+		key.Key,
+		key.RequestMethod,
+		key.RequestPath,
+		key.RequestHash,
+		key.LockToken,
+		key.LockedUntil,
+		key.ExpiresAt,
+		key.CreatedAt,
+		key.UpdatedAt,
+	).Scan(&key.ID, &key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *IdempotencyRepository) GetByUserAndKey(userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+	query := `SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+	err := r.db.Get(&idempotencyKey, query, userID, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("idempotency key not found")
+		}
+		return nil, err
+	}
+	return &idempotencyKey, nil
+}
+
+// TakeOver locks a key with no response for a new request, if the lock of
+// the request that held it has run out. It reports whether it did.
+func (r *IdempotencyRepository) TakeOver(id uuid.UUID, lockToken uuid.UUID, lockedUntil time.Time) (bool, error) {
+	query := `
+		UPDATE idempotency_keys
+		SET lock_token = $1, locked_until = $2, updated_at = $3
+		WHERE id = $4 AND response_status IS NULL
+			AND (locked_until IS NULL OR locked_until <= NOW())
+	`
+	result, err := r.db.Exec(query, lockToken, lockedUntil, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// SaveResponse stores the response for a key, if the request with lockToken
+// still holds it
+func (r *IdempotencyRepository) SaveResponse(id, lockToken uuid.UUID, status int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response_status = $1, response_content_type = $2, response_body = $3,
+			locked_until = NULL, updated_at = $4
+		WHERE id = $5 AND lock_token = $6 AND response_status IS NULL
+	`
+	_, err := r.db.Exec(query, status, contentType, body, time.Now(), id, lockToken)
+	return err
+}
+
+func (r *IdempotencyRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM idempotency_keys WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// DeleteLocked deletes a key with no response, if the request with lockToken
+// still holds it
+func (r *IdempotencyRepository) DeleteLocked(id, lockToken uuid.UUID) error {
+	query := `DELETE FROM idempotency_keys WHERE id = $1 AND lock_token = $2 AND response_status IS NULL`
+	_, err := r.db.Exec(query, id, lockToken)
+	return err
+}
+
+func (r *IdempotencyRepository) DeleteExpired() (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at < NOW()`
+	result, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
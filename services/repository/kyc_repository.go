@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type KYCRepository struct {
+	db *sqlx.DB
+}
+
+func NewKYCRepository(db *sqlx.DB) *KYCRepository {
+	return &KYCRepository{db: db}
+}
+
+func (r *KYCRepository) Create(submission *models.KYCSubmission) error {
+	query := `
+		INSERT INTO kyc_submissions (
+			id, user_id, tier, id_type, id_number_masked, status, verifier,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	submission.ID = uuid.New()
+	submission.CreatedAt = time.Now()
+	submission.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		submission.ID,
+		submission.UserID,
+		submission.Tier,
+		submission.IDType,
+		submission.IDNumberMasked,
+		submission.Status,
+		submission.Verifier,
+		submission.CreatedAt,
+		submission.UpdatedAt,
+	).Scan(&submission.ID, &submission.CreatedAt, &submission.UpdatedAt)
+}
+
+// UpdateResult records the verifier's decision on a submission
+func (r *KYCRepository) UpdateResult(tx *sqlx.Tx, submission *models.KYCSubmission) error {
+	query := `
+		UPDATE kyc_submissions
+		SET status = $1, verifier_reference = $2, reason = $3, updated_at = $4
+		WHERE id = $5
+	`
+	submission.UpdatedAt = time.Now()
+	_, err := tx.Exec(
+		query,
+		submission.Status,
+		submission.VerifierReference,
+		submission.Reason,
+		submission.UpdatedAt,
+		submission.ID,
+	)
+	return err
+}
+
+// ListByUser returns a user's submissions newest first
+func (r *KYCRepository) ListByUser(userID uuid.UUID, limit, offset int) ([]models.KYCSubmission, error) {
+	var submissions []models.KYCSubmission
+	query := `
+		SELECT * FROM kyc_submissions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.Select(&submissions, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
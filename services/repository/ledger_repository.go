@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LedgerRepository struct {
+	db *sqlx.DB
+}
+
+func NewLedgerRepository(db *sqlx.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+func (r *LedgerRepository) GetAccountByID(tx *sqlx.Tx, id uuid.UUID) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	query := `SELECT * FROM ledger_accounts WHERE id = $1`
+	err := tx.Get(&account, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ledger account not found")
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *LedgerRepository) GetAccountByCode(tx *sqlx.Tx, code string) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	query := `SELECT * FROM ledger_accounts WHERE code = $1`
+	err := tx.Get(&account, query, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ledger account %s not found", code)
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetOrCreateCurrencyAccount returns the system account that holds code's
+// funds in a currency other than the default, creating it from the
+// default-currency account on first use. Its code is e.g. "fees:USD".
+func (r *LedgerRepository) GetOrCreateCurrencyAccount(tx *sqlx.Tx, code string, currency models.Currency) (*models.LedgerAccount, error) {
+	query := `
+		INSERT INTO ledger_accounts (id, code, name, type, normal_balance, currency, created_at, updated_at)
+		SELECT $1, code || ':' || $2::TEXT, name || ' (' || $2::TEXT || ')', type, normal_balance, $2::TEXT, $3, $3
+		FROM ledger_accounts
+		WHERE code = $4 AND type = $5
+		ON CONFLICT (code) DO NOTHING
+	`
+	if _, err := tx.Exec(
+		query,
+		uuid.New(),
+		string(currency),
+		time.Now(),
+		code,
+		models.LedgerAccountTypeSystem,
+	); err != nil {
+		return nil, err
+	}
+	return r.GetAccountByCode(tx, code+":"+string(currency))
+}
+
+// GetOrCreateWalletAccount returns the ledger account for a wallet, creating it on first use
+func (r *LedgerRepository) GetOrCreateWalletAccount(tx *sqlx.Tx, walletID uuid.UUID) (*models.LedgerAccount, error) {
+	query := `
+		INSERT INTO ledger_accounts (id, code, name, type, normal_balance, wallet_id, currency, created_at, updated_at)
+		SELECT $1, 'wallet:' || w.id, 'Wallet ' || w.wallet_number, $2, $3, w.id, w.currency, $4, $4
+		FROM wallets w
+		WHERE w.id = $5
+		ON CONFLICT (wallet_id) DO NOTHING
+	`
+	if _, err := tx.Exec(
+		query,
+		uuid.New(),
+		models.LedgerAccountTypeWallet,
+		models.LedgerCredit,
+		time.Now(),
+		walletID,
+	); err != nil {
+		return nil, err
+	}
+
+	var account models.LedgerAccount
+	err := tx.Get(&account, `SELECT * FROM ledger_accounts WHERE wallet_id = $1`, walletID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("wallet not found")
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *LedgerRepository) CreateJournalEntry(tx *sqlx.Tx, entry *models.JournalEntry) error {
+	query := `
+		INSERT INTO journal_entries (id, reference, description, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	entry.ID = uuid.New()
+	entry.CreatedAt = time.Now()
+
+	if err := tx.QueryRow(
+		query,
+		entry.ID,
+		entry.Reference,
+		entry.Description,
+		entry.CreatedAt,
+	).Scan(&entry.ID, &entry.CreatedAt); err != nil {
+		return err
+	}
+
+	postingQuery := `
+		INSERT INTO ledger_postings (id, journal_entry_id, account_id, direction, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		posting.ID = uuid.New()
+		posting.JournalEntryID = entry.ID
+		posting.CreatedAt = entry.CreatedAt
+
+		if _, err := tx.Exec(
+			postingQuery,
+			posting.ID,
+			posting.JournalEntryID,
+			posting.AccountID,
+			posting.Direction,
+			posting.Amount,
+			posting.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *LedgerRepository) JournalEntryExists(tx *sqlx.Tx, reference string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM journal_entries WHERE reference = $1)`
+	err := tx.Get(&exists, query, reference)
+	return exists, err
+}
+
+func (r *LedgerRepository) GetJournalEntryByReference(reference string) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	query := `SELECT * FROM journal_entries WHERE reference = $1`
+	err := r.db.Get(&entry, query, reference)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("journal entry not found")
+		}
+		return nil, err
+	}
+
+	query = `SELECT * FROM ledger_postings WHERE journal_entry_id = $1 ORDER BY direction DESC, created_at`
+	if err := r.db.Select(&entry.Postings, query, entry.ID); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetAccountBalance sums an account's postings relative to its normal balance
+func (r *LedgerRepository) GetAccountBalance(accountID uuid.UUID) (models.Money, error) {
+	var balance models.Money
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN p.direction = a.normal_balance THEN p.amount ELSE -p.amount END), 0)
+		FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		WHERE a.id = $1
+	`
+	err := r.db.Get(&balance, query, accountID)
+	return balance, err
+}
+
+// GetBalanceMismatches returns wallets whose stored balance differs from their postings
+func (r *LedgerRepository) GetBalanceMismatches() ([]models.BalanceMismatch, error) {
+	var mismatches []models.BalanceMismatch
+	query := `
+		SELECT w.id AS wallet_id, w.balance AS stored_balance, COALESCE(l.balance, 0) AS ledger_balance
+		FROM wallets w
+		LEFT JOIN (
+			SELECT a.wallet_id, SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END) AS balance
+			FROM ledger_accounts a
+			JOIN ledger_postings p ON p.account_id = a.id
+			WHERE a.type = 'wallet'
+			GROUP BY a.wallet_id
+		) l ON l.wallet_id = w.id
+		WHERE w.balance <> COALESCE(l.balance, 0)
+	`
+	err := r.db.Select(&mismatches, query)
+	if err != nil {
+		return nil, err
+	}
+	return mismatches, nil
+}
+
+func (r *LedgerRepository) GetTrialBalance() (*models.TrialBalance, error) {
+	var trialBalance models.TrialBalance
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE direction = 'debit'), 0) AS total_debits,
+			COALESCE(SUM(amount) FILTER (WHERE direction = 'credit'), 0) AS total_credits
+		FROM ledger_postings
+	`
+	err := r.db.Get(&trialBalance, query)
+	if err != nil {
+		return nil, err
+	}
+	return &trialBalance, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LimitOverrideRepository struct {
+	db *sqlx.DB
+}
+
+func NewLimitOverrideRepository(db *sqlx.DB) *LimitOverrideRepository {
+	return &LimitOverrideRepository{db: db}
+}
+
+// Upsert stores an override, replacing the one its user or API key already
+// has in the same currency
+func (r *LimitOverrideRepository) Upsert(override *models.LimitOverride) error {
+	conflict := `(user_id, currency) WHERE user_id IS NOT NULL`
+	if override.APIKeyID != nil {
+		conflict = `(api_key_id, currency) WHERE api_key_id IS NOT NULL`
+	}
+	query := `
+		INSERT INTO limit_overrides (
+			id, user_id, api_key_id, currency, max_transfer_amount, daily_outflow,
+			monthly_outflow, api_key_daily_spend, max_transfers_per_hour, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT ` + conflict + ` DO UPDATE SET
+			max_transfer_amount = EXCLUDED.max_transfer_amount,
+			daily_outflow = EXCLUDED.daily_outflow,
+			monthly_outflow = EXCLUDED.monthly_outflow,
+			api_key_daily_spend = EXCLUDED.api_key_daily_spend,
+			max_transfers_per_hour = EXCLUDED.max_transfers_per_hour,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at
+	`
+	override.ID = uuid.New()
+	override.CreatedAt = time.Now()
+	override.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		override.ID,
+		override.UserID,
+		override.APIKeyID,
+		override.Currency,
+		override.MaxTransferAmount,
+		override.DailyOutflow,
+		override.MonthlyOutflow,
+		override.APIKeyDailySpend,
+		override.MaxTransfersPerHour,
+		override.CreatedAt,
+		override.UpdatedAt,
+	).Scan(&override.ID, &override.CreatedAt, &override.UpdatedAt)
+}
+
+// GetForUser gets a user's override in currency. It returns nil if the user
+// has none.
+func (r *LimitOverrideRepository) GetForUser(q sqlx.Queryer, userID uuid.UUID, currency models.Currency) (*models.LimitOverride, error) {
+	return r.get(q, `SELECT * FROM limit_overrides WHERE user_id = $1 AND currency = $2`, userID, currency)
+}
+
+// GetForAPIKey gets an API key's override in currency. It returns nil if the
+// key has none.
+func (r *LimitOverrideRepository) GetForAPIKey(q sqlx.Queryer, apiKeyID uuid.UUID, currency models.Currency) (*models.LimitOverride, error) {
+	return r.get(q, `SELECT * FROM limit_overrides WHERE api_key_id = $1 AND currency = $2`, apiKeyID, currency)
+}
+
+func (r *LimitOverrideRepository) get(q sqlx.Queryer, query string, ownerID uuid.UUID, currency models.Currency) (*models.LimitOverride, error) {
+	var override models.LimitOverride
+	err := sqlx.Get(q, &override, query, ownerID, currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	override.ApplyCurrency()
+	return &override, nil
+}
+
+// ListForUser returns a user's overrides in every currency
+func (r *LimitOverrideRepository) ListForUser(userID uuid.UUID) ([]models.LimitOverride, error) {
+	return r.list(`SELECT * FROM limit_overrides WHERE user_id = $1 ORDER BY currency`, userID)
+}
+
+// ListForAPIKey returns an API key's overrides in every currency
+func (r *LimitOverrideRepository) ListForAPIKey(apiKeyID uuid.UUID) ([]models.LimitOverride, error) {
+	return r.list(`SELECT * FROM limit_overrides WHERE api_key_id = $1 ORDER BY currency`, apiKeyID)
+}
+
+func (r *LimitOverrideRepository) list(query string, ownerID uuid.UUID) ([]models.LimitOverride, error) {
+	overrides := []models.LimitOverride{}
+	if err := r.db.Select(&overrides, query, ownerID); err != nil {
+		return nil, err
+	}
+	for i := range overrides {
+		overrides[i].ApplyCurrency()
+	}
+	return overrides, nil
+}
+
+// DeleteForUser removes a user's override in currency and reports whether
+// there was one
+func (r *LimitOverrideRepository) DeleteForUser(userID uuid.UUID, currency models.Currency) (bool, error) {
+	return r.delete(`DELETE FROM limit_overrides WHERE user_id = $1 AND currency = $2`, userID, currency)
+}
+
+// DeleteForAPIKey removes an API key's override in currency and reports
+// whether there was one
+func (r *LimitOverrideRepository) DeleteForAPIKey(apiKeyID uuid.UUID, currency models.Currency) (bool, error) {
+	return r.delete(`DELETE FROM limit_overrides WHERE api_key_id = $1 AND currency = $2`, apiKeyID, currency)
+}
+
+func (r *LimitOverrideRepository) delete(query string, ownerID uuid.UUID, currency models.Currency) (bool, error) {
+	result, err := r.db.Exec(query, ownerID, currency)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PaymentRequestRepository struct {
+	db *sqlx.DB
+}
+
+func NewPaymentRequestRepository(db *sqlx.DB) *PaymentRequestRepository {
+	return &PaymentRequestRepository{db: db}
+}
+
+func (r *PaymentRequestRepository) Create(pr *models.PaymentRequest) error {
+	query := `
+		INSERT INTO payment_requests (
+			id, requester_user_id, requester_wallet_id, requester_wallet_number,
+			payer_user_id, payer_wallet_id, payer_wallet_number, amount, currency,
+			memo, status, expires_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`
+	pr.Currency = pr.Amount.Currency
+	pr.ID = uuid.New()
+	pr.CreatedAt = time.Now()
+	pr.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		pr.ID,
+		pr.RequesterUserID,
+		pr.RequesterWalletID,
+		pr.RequesterWalletNumber,
+		pr.PayerUserID,
+		pr.PayerWalletID,
+		pr.PayerWalletNumber,
+		pr.Amount,
+		pr.Currency,
+		pr.Memo,
+		pr.Status,
+		pr.ExpiresAt,
+		pr.CreatedAt,
+		pr.UpdatedAt,
+	).Scan(&pr.ID, &pr.CreatedAt, &pr.UpdatedAt)
+}
+
+func (r *PaymentRequestRepository) GetByID(id uuid.UUID) (*models.PaymentRequest, error) {
+	var pr models.PaymentRequest
+	query := `SELECT * FROM payment_requests WHERE id = $1`
+	err := r.db.Get(&pr, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment request not found")
+		}
+		return nil, err
+	}
+	pr.ApplyCurrency()
+	return &pr, nil
+}
+
+// ListByRequester returns the requests a user has sent newest first,
+// optionally filtered by status
+func (r *PaymentRequestRepository) ListByRequester(userID uuid.UUID, status string, limit, offset int) ([]models.PaymentRequest, error) {
+	return r.list(`requester_user_id`, userID, status, limit, offset)
+}
+
+// ListByPayer returns the requests a user has received newest first,
+// optionally filtered by status
+func (r *PaymentRequestRepository) ListByPayer(userID uuid.UUID, status string, limit, offset int) ([]models.PaymentRequest, error) {
+	return r.list(`payer_user_id`, userID, status, limit, offset)
+}
+
+func (r *PaymentRequestRepository) list(userColumn string, userID uuid.UUID, status string, limit, offset int) ([]models.PaymentRequest, error) {
+	var requests []models.PaymentRequest
+	query := `
+		SELECT * FROM payment_requests
+		WHERE ` + userColumn + ` = $1 AND ($2 = '' OR status::TEXT = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.Select(&requests, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range requests {
+		requests[i].ApplyCurrency()
+	}
+	return requests, nil
+}
+
+// UpdateStatus answers a pending payment request, provided it is still
+// pending and has not expired
+func (r *PaymentRequestRepository) UpdateStatus(id uuid.UUID, to models.PaymentRequestStatus) (*models.PaymentRequest, error) {
+	var pr models.PaymentRequest
+	query := `
+		UPDATE payment_requests
+		SET status = $1, responded_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status = $3 AND expires_at > NOW()
+		RETURNING *
+	`
+	err := r.db.Get(&pr, query, to, id, models.PaymentRequestStatusPending)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment request is no longer pending")
+		}
+		return nil, err
+	}
+	pr.ApplyCurrency()
+	return &pr, nil
+}
+
+// SetTransaction records the transfer that paid an accepted request
+func (r *PaymentRequestRepository) SetTransaction(id, transactionID uuid.UUID) error {
+	query := `
+		UPDATE payment_requests
+		SET transaction_id = $1, updated_at = NOW()
+		WHERE id = $2
+	`
+	_, err := r.db.Exec(query, transactionID, id)
+	return err
+}
+
+// Reopen puts an accepted request whose transfer failed back to pending
+func (r *PaymentRequestRepository) Reopen(id uuid.UUID) error {
+	query := `
+		UPDATE payment_requests
+		SET status = $1, responded_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND status = $3 AND transaction_id IS NULL
+	`
+	_, err := r.db.Exec(query, models.PaymentRequestStatusPending, id, models.PaymentRequestStatusAccepted)
+	return err
+}
+
+// ExpireDue marks pending requests past their expiry as expired and returns
+// how many were expired
+func (r *PaymentRequestRepository) ExpireDue() (int64, error) {
+	query := `
+		UPDATE payment_requests
+		SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND expires_at <= NOW()
+	`
+	result, err := r.db.Exec(query, models.PaymentRequestStatusExpired, models.PaymentRequestStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PayoutRepository struct {
+	db *sqlx.DB
+}
+
+func NewPayoutRepository(db *sqlx.DB) *PayoutRepository {
+	return &PayoutRepository{db: db}
+}
+
+// Create stores a batch and its rows
+func (r *PayoutRepository) Create(tx *sqlx.Tx, batch *models.PayoutBatch, items []models.PayoutItem) error {
+	query := `
+		INSERT INTO payout_batches (
+			id, user_id, wallet_id, wallet_number, currency, mode, status, reference,
+			item_count, total_amount, total_fee, api_key_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`
+	batch.Currency = batch.TotalAmount.Currency
+	batch.ID = uuid.New()
+	batch.CreatedAt = time.Now()
+	batch.UpdatedAt = time.Now()
+	batch.SucceededAmount = models.NewMoney(0, batch.Currency)
+
+	err := tx.QueryRow(
+		query,
+		batch.ID,
+		batch.UserID,
+		batch.WalletID,
+		batch.WalletNumber,
+		batch.Currency,
+		batch.Mode,
+		batch.Status,
+		batch.Reference,
+		batch.ItemCount,
+		batch.TotalAmount,
+		batch.TotalFee,
+		batch.APIKeyID,
+		batch.CreatedAt,
+		batch.UpdatedAt,
+	).Scan(&batch.ID, &batch.CreatedAt, &batch.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO payout_items (
+			id, batch_id, row_number, wallet_number, amount, fee, currency,
+			narration, status, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	for i := range items {
+		item := &items[i]
+		item.ID = uuid.New()
+		item.BatchID = batch.ID
+		item.Currency = item.Amount.Currency
+		item.Status = models.PayoutItemStatusPending
+		item.CreatedAt = batch.CreatedAt
+		item.UpdatedAt = batch.CreatedAt
+
+		if _, err := tx.Exec(
+			query,
+			item.ID,
+			item.BatchID,
+			item.RowNumber,
+			item.WalletNumber,
+			item.Amount,
+			item.Fee,
+			item.Currency,
+			item.Narration,
+			item.Status,
+			item.CreatedAt,
+			item.UpdatedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PayoutRepository) GetByID(id uuid.UUID) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	query := `SELECT * FROM payout_batches WHERE id = $1`
+	err := r.db.Get(&batch, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payout batch not found")
+		}
+		return nil, err
+	}
+	batch.ApplyCurrency()
+	return &batch, nil
+}
+
+// ListByUser returns a user's batches newest first, optionally filtered by status
+func (r *PayoutRepository) ListByUser(userID uuid.UUID, status string, limit, offset int) ([]models.PayoutBatch, error) {
+	var batches []models.PayoutBatch
+	query := `
+		SELECT * FROM payout_batches
+		WHERE user_id = $1 AND ($2 = '' OR status::TEXT = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.Select(&batches, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range batches {
+		batches[i].ApplyCurrency()
+	}
+	return batches, nil
+}
+
+// ListItems returns a batch's rows in row order, optionally filtered by status
+func (r *PayoutRepository) ListItems(batchID uuid.UUID, status string) ([]models.PayoutItem, error) {
+	var items []models.PayoutItem
+	query := `
+		SELECT * FROM payout_items
+		WHERE batch_id = $1 AND ($2 = '' OR status::TEXT = $2)
+		ORDER BY row_number
+	`
+	err := r.db.Select(&items, query, batchID, status)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].ApplyCurrency()
+	}
+	return items, nil
+}
+
+// ClaimRunnable marks pending batches, and processing batches whose worker
+// has let its lease lapse, as processing and hides them from other workers
+// for lease
+func (r *PayoutRepository) ClaimRunnable(limit int, lease time.Duration) ([]models.PayoutBatch, error) {
+	var batches []models.PayoutBatch
+	query := `
+		UPDATE payout_batches
+		SET status = 'processing', started_at = COALESCE(started_at, NOW()),
+			locked_until = NOW() + $1 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM payout_batches
+			WHERE status IN ('pending', 'processing')
+				AND (locked_until IS NULL OR locked_until <= NOW())
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.db.Select(&batches, query, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range batches {
+		batches[i].ApplyCurrency()
+	}
+	return batches, nil
+}
+
+// GetItemForUpdate gets a row of a batch and locks it until tx ends
+func (r *PayoutRepository) GetItemForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.PayoutItem, error) {
+	var item models.PayoutItem
+	query := `SELECT * FROM payout_items WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&item, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payout item not found")
+		}
+		return nil, err
+	}
+	item.ApplyCurrency()
+	return &item, nil
+}
+
+// RecordItem stores the outcome of a row, adds it to its batch's totals and
+// extends the batch's lease so that a long batch is not claimed twice
+func (r *PayoutRepository) RecordItem(tx *sqlx.Tx, item *models.PayoutItem, lease time.Duration) error {
+	query := `
+		UPDATE payout_items
+		SET status = $1, transaction_id = $2, error = $3, processed_at = $4, updated_at = NOW()
+		WHERE id = $5
+	`
+	if _, err := tx.Exec(query, item.Status, item.TransactionID, item.Error, item.ProcessedAt, item.ID); err != nil {
+		return err
+	}
+
+	succeeded, failed, amount := 0, 0, int64(0)
+	if item.Status == models.PayoutItemStatusSuccess {
+		succeeded, amount = 1, item.Amount.Amount
+	} else {
+		failed = 1
+	}
+	query = `
+		UPDATE payout_batches
+		SET succeeded_count = succeeded_count + $1, failed_count = failed_count + $2,
+			succeeded_amount = succeeded_amount + $3,
+			locked_until = NOW() + $4 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id = $5
+	`
+	_, err := tx.Exec(query, succeeded, failed, amount, lease.Seconds(), item.BatchID)
+	return err
+}
+
+// Finish closes a batch whose rows all have an outcome: completed if every
+// row was paid, failed if none was and partially completed otherwise
+func (r *PayoutRepository) Finish(e sqlx.Execer, batchID uuid.UUID) error {
+	query := `
+		UPDATE payout_batches
+		SET status = CASE
+				WHEN succeeded_count = item_count THEN 'completed'
+				WHEN succeeded_count = 0 THEN 'failed'
+				ELSE 'partially_completed'
+			END::payout_batch_status,
+			locked_until = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := e.Exec(query, batchID)
+	return err
+}
+
+// Fail closes a batch as failed with message and skips its unpaid rows
+func (r *PayoutRepository) Fail(tx *sqlx.Tx, batchID uuid.UUID, message string) error {
+	query := `
+		UPDATE payout_items
+		SET status = 'skipped', processed_at = NOW(), updated_at = NOW()
+		WHERE batch_id = $1 AND status = 'pending'
+	`
+	if _, err := tx.Exec(query, batchID); err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE payout_batches
+		SET status = 'failed', error = $1, locked_until = NULL,
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`
+	_, err := tx.Exec(query, message, batchID)
+	return err
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PocketRepository struct {
+	db *sqlx.DB
+}
+
+func NewPocketRepository(db *sqlx.DB) *PocketRepository {
+	return &PocketRepository{db: db}
+}
+
+func (r *PocketRepository) Create(pocket *models.Pocket) error {
+	query := `
+		INSERT INTO wallet_pockets (
+			id, wallet_id, user_id, name, balance, currency,
+			target_amount, target_date, locked_until, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+	pocket.Currency = pocket.Balance.Currency
+	pocket.ID = uuid.New()
+	pocket.CreatedAt = time.Now()
+	pocket.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		pocket.ID,
+		pocket.WalletID,
+		pocket.UserID,
+		pocket.Name,
+		pocket.Balance,
+		pocket.Currency,
+		pocket.TargetAmount,
+		pocket.TargetDate,
+		pocket.LockedUntil,
+		pocket.CreatedAt,
+		pocket.UpdatedAt,
+	).Scan(&pocket.ID, &pocket.CreatedAt, &pocket.UpdatedAt)
+}
+
+func (r *PocketRepository) GetByID(id uuid.UUID) (*models.Pocket, error) {
+	var pocket models.Pocket
+	query := `SELECT * FROM wallet_pockets WHERE id = $1`
+	err := r.db.Get(&pocket, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("pocket not found")
+		}
+		return nil, err
+	}
+	pocket.ApplyCurrency()
+	return &pocket, nil
+}
+
+// GetByIDForUpdate gets a pocket by ID and locks it until tx ends
+func (r *PocketRepository) GetByIDForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.Pocket, error) {
+	var pocket models.Pocket
+	query := `SELECT * FROM wallet_pockets WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&pocket, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("pocket not found")
+		}
+		return nil, err
+	}
+	pocket.ApplyCurrency()
+	return &pocket, nil
+}
+
+// ListByWallet returns a wallet's pockets oldest first
+func (r *PocketRepository) ListByWallet(walletID uuid.UUID) ([]models.Pocket, error) {
+	var pockets []models.Pocket
+	query := `SELECT * FROM wallet_pockets WHERE wallet_id = $1 ORDER BY created_at`
+	if err := r.db.Select(&pockets, query, walletID); err != nil {
+		return nil, err
+	}
+	for i := range pockets {
+		pockets[i].ApplyCurrency()
+	}
+	return pockets, nil
+}
+
+// GetPocketedAmount sums the balances of a wallet's pockets
+func (r *PocketRepository) GetPocketedAmount(q sqlx.Queryer, walletID uuid.UUID) (int64, error) {
+	var pocketed int64
+	query := `SELECT COALESCE(SUM(balance), 0) FROM wallet_pockets WHERE wallet_id = $1`
+	err := sqlx.Get(q, &pocketed, query, walletID)
+	return pocketed, err
+}
+
+// Update saves a pocket's name, target and lock
+func (r *PocketRepository) Update(tx *sqlx.Tx, pocket *models.Pocket) error {
+	query := `
+		UPDATE wallet_pockets
+		SET name = $1, target_amount = $2, target_date = $3, locked_until = $4, updated_at = $5
+		WHERE id = $6
+	`
+	pocket.UpdatedAt = time.Now()
+	_, err := tx.Exec(query, pocket.Name, pocket.TargetAmount, pocket.TargetDate, pocket.LockedUntil, pocket.UpdatedAt, pocket.ID)
+	return err
+}
+
+// UpdateBalance sets a pocket's balance
+func (r *PocketRepository) UpdateBalance(tx *sqlx.Tx, id uuid.UUID, balance models.Money) error {
+	query := `UPDATE wallet_pockets SET balance = $1, updated_at = $2 WHERE id = $3`
+	_, err := tx.Exec(query, balance, time.Now(), id)
+	return err
+}
+
+func (r *PocketRepository) Delete(tx *sqlx.Tx, id uuid.UUID) error {
+	_, err := tx.Exec(`DELETE FROM wallet_pockets WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ScheduledTransferRepository struct {
+	db *sqlx.DB
+}
+
+func NewScheduledTransferRepository(db *sqlx.DB) *ScheduledTransferRepository {
+	return &ScheduledTransferRepository{db: db}
+}
+
+func (r *ScheduledTransferRepository) Create(st *models.ScheduledTransfer) error {
+	query := `
+		INSERT INTO scheduled_transfers (
+			id, user_id, wallet_id, recipient_wallet_number, amount, currency, description,
+			schedule_type, cron_expression, day_of_month, start_at, end_at,
+			status, next_run_at, api_key_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id, created_at, updated_at
+	`
+	st.ID = uuid.New()
+	st.Currency = st.Amount.Currency
+	st.CreatedAt = time.Now()
+	st.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		st.ID,
+		st.UserID,
+		st.WalletID,
+		st.RecipientWalletNumber,
+		st.Amount,
+		st.Amount.Currency,
+		st.Description,
+		st.ScheduleType,
+		st.CronExpression,
+		st.DayOfMonth,
+		st.StartAt,
+		st.EndAt,
+		st.Status,
+		st.NextRunAt,
+		st.APIKeyID,
+		st.CreatedAt,
+		st.UpdatedAt,
+	).Scan(&st.ID, &st.CreatedAt, &st.UpdatedAt)
+}
+
+func (r *ScheduledTransferRepository) GetByID(id uuid.UUID) (*models.ScheduledTransfer, error) {
+	var st models.ScheduledTransfer
+	query := `SELECT * FROM scheduled_transfers WHERE id = $1`
+	err := r.db.Get(&st, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scheduled transfer not found")
+		}
+		return nil, err
+	}
+	st.ApplyCurrency()
+	return &st, nil
+}
+
+// ListByUser returns a user's scheduled transfers newest first, optionally filtered by status
+func (r *ScheduledTransferRepository) ListByUser(userID uuid.UUID, status string, limit, offset int) ([]models.ScheduledTransfer, error) {
+	var transfers []models.ScheduledTransfer
+	query := `
+		SELECT * FROM scheduled_transfers
+		WHERE user_id = $1 AND ($2 = '' OR status::TEXT = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	err := r.db.Select(&transfers, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transfers {
+		transfers[i].ApplyCurrency()
+	}
+	return transfers, nil
+}
+
+// UpdateStatus changes the status and next run of a scheduled transfer,
+// provided it is still in the expected status
+func (r *ScheduledTransferRepository) UpdateStatus(
+	id uuid.UUID,
+	from, to models.ScheduledTransferStatus,
+	nextRunAt *time.Time,
+) (*models.ScheduledTransfer, error) {
+	var st models.ScheduledTransfer
+	query := `
+		UPDATE scheduled_transfers
+		SET status = $1, next_run_at = $2, attempts = 0, updated_at = NOW()
+		WHERE id = $3 AND status = $4
+		RETURNING *
+	`
+	err := r.db.Get(&st, query, to, nextRunAt, id, from)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scheduled transfer is not %s", from)
+		}
+		return nil, err
+	}
+	st.ApplyCurrency()
+	return &st, nil
+}
+
+// ClaimDue returns active scheduled transfers whose next run is due. Claimed
+// transfers are locked for lease so that other workers skip them while they
+// run.
+func (r *ScheduledTransferRepository) ClaimDue(limit int, lease time.Duration) ([]models.ScheduledTransfer, error) {
+	var transfers []models.ScheduledTransfer
+	query := `
+		UPDATE scheduled_transfers
+		SET locked_until = NOW() + $1 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM scheduled_transfers
+			WHERE status = 'active' AND next_run_at <= NOW()
+				AND (locked_until IS NULL OR locked_until <= NOW())
+			ORDER BY next_run_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.db.Select(&transfers, query, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transfers {
+		transfers[i].ApplyCurrency()
+	}
+	return transfers, nil
+}
+
+// RecordRun stores a run and moves its scheduled transfer on to the next run.
+// A transfer paused or cancelled while it was running keeps that status.
+func (r *ScheduledTransferRepository) RecordRun(tx *sqlx.Tx, run *models.ScheduledTransferRun, st *models.ScheduledTransfer) error {
+	query := `
+		INSERT INTO scheduled_transfer_runs (
+			id, scheduled_transfer_id, attempt, status, transaction_id,
+			error, will_retry, scheduled_for, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	run.ID = uuid.New()
+	run.CreatedAt = time.Now()
+
+	if _, err := tx.Exec(
+		query,
+		run.ID,
+		run.ScheduledTransferID,
+		run.Attempt,
+		run.Status,
+		run.TransactionID,
+		run.Error,
+		run.WillRetry,
+		run.ScheduledFor,
+		run.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE scheduled_transfers
+		SET status = CASE WHEN status = 'active' THEN $1::scheduled_transfer_status ELSE status END,
+			next_run_at = $2, last_run_at = $3, attempts = $4,
+			locked_until = NULL, updated_at = NOW()
+		WHERE id = $5
+	`
+	_, err := tx.Exec(query, st.Status, st.NextRunAt, st.LastRunAt, st.Attempts, st.ID)
+	return err
+}
+
+// ListRuns returns a scheduled transfer's runs newest first
+func (r *ScheduledTransferRepository) ListRuns(scheduledTransferID uuid.UUID, limit, offset int) ([]models.ScheduledTransferRun, error) {
+	var runs []models.ScheduledTransferRun
+	query := `
+		SELECT * FROM scheduled_transfer_runs
+		WHERE scheduled_transfer_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.Select(&runs, query, scheduledTransferID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -21,13 +21,16 @@ func NewTransactionRepository(db *sqlx.DB) *TransactionRepository {
 func (r *TransactionRepository) Create(tx *sqlx.Tx, transaction *models.Transaction) error {
 	query := `
 		INSERT INTO transactions (
-			id, user_id, wallet_id, type, amount, status, reference, 
-			paystack_reference, recipient_wallet_id, recipient_user_id, 
-			description, metadata, created_at, updated_at
+			id, user_id, wallet_id, type, amount, fee, status, reference, 
+			paystack_reference, provider, recipient_wallet_id, recipient_user_id, 
+			description, metadata, journal_entry_id, api_key_id, related_transaction_id,
+			currency, fx_rate, fx_quote_id, escrow_id, created_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING id, created_at, updated_at
 	`
+	// Transactions are in the currency of their amount
+	transaction.Currency = transaction.Amount.Currency
 	transaction.ID = uuid.New()
 	transaction.CreatedAt = time.Now()
 	transaction.UpdatedAt = time.Now()
@@ -39,13 +42,22 @@ func (r *TransactionRepository) Create(tx *sqlx.Tx, transaction *models.Transact
 		transaction.WalletID,
 		transaction.Type,
 		transaction.Amount,
+		transaction.Fee,
 		transaction.Status,
 		transaction.Reference,
 		transaction.PaystackReference,
+		transaction.Provider,
 		transaction.RecipientWalletID,
 		transaction.RecipientUserID,
 		transaction.Description,
 		transaction.Metadata,
+		transaction.JournalEntryID,
+		transaction.APIKeyID,
+		transaction.RelatedTransactionID,
+		transaction.Currency,
+		transaction.FXRate,
+		transaction.FXQuoteID,
+		transaction.EscrowID,
 		transaction.CreatedAt,
 		transaction.UpdatedAt,
 	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
@@ -61,6 +73,7 @@ func (r *TransactionRepository) GetByID(id uuid.UUID) (*models.Transaction, erro
 		}
 		return nil, err
 	}
+	transaction.ApplyCurrency()
 	return &transaction, nil
 }
 
@@ -74,6 +87,37 @@ func (r *TransactionRepository) GetByReference(reference string) (*models.Transa
 		}
 		return nil, err
 	}
+	transaction.ApplyCurrency()
+	return &transaction, nil
+}
+
+// GetByReferenceForUpdate gets a transaction by reference and locks it until tx ends
+func (r *TransactionRepository) GetByReferenceForUpdate(tx *sqlx.Tx, reference string) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `SELECT * FROM transactions WHERE reference = $1 FOR UPDATE`
+	err := tx.Get(&transaction, query, reference)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("transaction not found")
+		}
+		return nil, err
+	}
+	transaction.ApplyCurrency()
+	return &transaction, nil
+}
+
+// GetByPaystackReferenceForUpdate gets a transaction by Paystack reference and locks it until tx ends
+func (r *TransactionRepository) GetByPaystackReferenceForUpdate(tx *sqlx.Tx, paystackReference string) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `SELECT * FROM transactions WHERE paystack_reference = $1 FOR UPDATE`
+	err := tx.Get(&transaction, query, paystackReference)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("transaction not found")
+		}
+		return nil, err
+	}
+	transaction.ApplyCurrency()
 	return &transaction, nil
 }
 
@@ -87,6 +131,7 @@ func (r *TransactionRepository) GetByPaystackReference(paystackReference string)
 		}
 		return nil, err
 	}
+	transaction.ApplyCurrency()
 	return &transaction, nil
 }
 
@@ -102,6 +147,45 @@ func (r *TransactionRepository) GetByUserID(userID uuid.UUID, limit, offset int)
 	if err != nil {
 		return nil, err
 	}
+	for i := range transactions {
+		transactions[i].ApplyCurrency()
+	}
+	return transactions, nil
+}
+
+// GetPendingDeposits returns the oldest deposits still pending that were
+// created before the cutoff
+func (r *TransactionRepository) GetPendingDeposits(createdBefore time.Time, limit int) ([]models.Transaction, error) {
+	return r.getPending(models.TransactionTypeDeposit, createdBefore, limit)
+}
+
+// GetPendingWithdrawals returns the oldest withdrawals still pending that
+// were created before the cutoff
+func (r *TransactionRepository) GetPendingWithdrawals(createdBefore time.Time, limit int) ([]models.Transaction, error) {
+	return r.getPending(models.TransactionTypeWithdrawal, createdBefore, limit)
+}
+
+// GetPendingRefunds returns the oldest deposit refunds still pending that
+// were created before the cutoff
+func (r *TransactionRepository) GetPendingRefunds(createdBefore time.Time, limit int) ([]models.Transaction, error) {
+	return r.getPending(models.TransactionTypeRefund, createdBefore, limit)
+}
+
+func (r *TransactionRepository) getPending(transactionType models.TransactionType, createdBefore time.Time, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	query := `
+		SELECT * FROM transactions
+		WHERE type = $1 AND status = $2 AND created_at < $3
+		ORDER BY created_at
+		LIMIT $4
+	`
+	err := r.db.Select(&transactions, query, transactionType, models.TransactionStatusPending, createdBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		transactions[i].ApplyCurrency()
+	}
 	return transactions, nil
 }
 
@@ -115,6 +199,144 @@ func (r *TransactionRepository) UpdateStatus(tx *sqlx.Tx, id uuid.UUID, status m
 	return err
 }
 
+// MarkForReview moves a transaction to under_review and merges the review
+// details into its metadata
+func (r *TransactionRepository) MarkForReview(tx *sqlx.Tx, id uuid.UUID, details string) error {
+	query := `
+		UPDATE transactions
+		SET status = $1, metadata = COALESCE(metadata, '{}'::jsonb) || $2::jsonb, updated_at = $3
+		WHERE id = $4
+	`
+	_, err := tx.Exec(query, models.TransactionStatusUnderReview, details, time.Now(), id)
+	return err
+}
+
+func (r *TransactionRepository) SetJournalEntry(tx *sqlx.Tx, id uuid.UUID, journalEntryID uuid.UUID) error {
+	query := `
+		UPDATE transactions
+		SET journal_entry_id = $1, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := tx.Exec(query, journalEntryID, time.Now(), id)
+	return err
+}
+
+// GetByIDForUpdate gets a transaction by ID and locks it until tx ends
+func (r *TransactionRepository) GetByIDForUpdate(tx *sqlx.Tx, id uuid.UUID) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `SELECT * FROM transactions WHERE id = $1 FOR UPDATE`
+	err := tx.Get(&transaction, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("transaction not found")
+		}
+		return nil, err
+	}
+	transaction.ApplyCurrency()
+	return &transaction, nil
+}
+
+// GetEscrowFundingForUpdate gets the buyer's debit that funded an escrow and
+// locks it until tx ends
+func (r *TransactionRepository) GetEscrowFundingForUpdate(tx *sqlx.Tx, escrowID uuid.UUID) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `SELECT * FROM transactions WHERE escrow_id = $1 AND type = $2 FOR UPDATE`
+	err := tx.Get(&transaction, query, escrowID, models.TransactionTypeEscrowDebit)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("escrow funding transaction not found")
+		}
+		return nil, err
+	}
+	transaction.ApplyCurrency()
+	return &transaction, nil
+}
+
+// GetByEscrowID returns the transactions of an escrow oldest first
+func (r *TransactionRepository) GetByEscrowID(escrowID uuid.UUID) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	query := `SELECT * FROM transactions WHERE escrow_id = $1 ORDER BY created_at, id`
+	err := r.db.Select(&transactions, query, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		transactions[i].ApplyCurrency()
+	}
+	return transactions, nil
+}
+
+// GetRefundByPaystackID gets a refund made through the Refund API by the
+// refund ID Paystack assigned. It returns nil if there is no such refund,
+// e.g. because the refund was issued from the Paystack dashboard.
+func (r *TransactionRepository) GetRefundByPaystackID(refundID string) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `SELECT * FROM transactions WHERE type = $1 AND paystack_reference = $2`
+	err := r.db.Get(&transaction, query, models.TransactionTypeRefund, refundID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	transaction.ApplyCurrency()
+	return &transaction, nil
+}
+
+// GetRefundTotals sums the refunds made against a deposit, both through the
+// Refund API and from the dashboard, split into settled and still pending
+func (r *TransactionRepository) GetRefundTotals(tx *sqlx.Tx, depositID uuid.UUID) (settled, pending int64, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE status = $1), 0),
+			COALESCE(SUM(amount) FILTER (WHERE status = $2), 0)
+		FROM transactions
+		WHERE related_transaction_id = $3 AND type IN ($4, $5)
+	`
+	err = tx.QueryRow(
+		query,
+		models.TransactionStatusSuccess,
+		models.TransactionStatusPending,
+		depositID,
+		models.TransactionTypeRefund,
+		models.TransactionTypeDebit,
+	).Scan(&settled, &pending)
+	return settled, pending, err
+}
+
+// FindRefundByAmount gets the refund of the given amount made against a
+// deposit through the Refund API, preferring one still pending. It returns
+// nil if there is no such refund.
+func (r *TransactionRepository) FindRefundByAmount(depositID uuid.UUID, amount int64) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `
+		SELECT * FROM transactions
+		WHERE related_transaction_id = $1 AND type = $2 AND amount = $3
+		ORDER BY status = $4 DESC, created_at
+		LIMIT 1
+	`
+	err := r.db.Get(&transaction, query, depositID, models.TransactionTypeRefund, amount, models.TransactionStatusPending)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	transaction.ApplyCurrency()
+	return &transaction, nil
+}
+
+// SetPaystackReference records the reference a payment provider assigned to a transaction
+func (r *TransactionRepository) SetPaystackReference(id uuid.UUID, paystackReference string) error {
+	query := `
+		UPDATE transactions
+		SET paystack_reference = $1, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, paystackReference, time.Now(), id)
+	return err
+}
+
 func (r *TransactionRepository) UpdateStatusByReference(reference string, status models.TransactionStatus) error {
 	query := `
 		UPDATE transactions
@@ -124,3 +346,70 @@ func (r *TransactionRepository) UpdateStatusByReference(reference string, status
 	_, err := r.db.Exec(query, status, time.Now(), reference)
 	return err
 }
+
+// GetOutflows sums a wallet's transfers, withdrawals and escrow payments,
+// fees included, made since dayStart and since monthStart, and counts its
+// transfers since hourStart. Failed and reversed outflows and reversal debits
+// are left out.
+func (r *TransactionRepository) GetOutflows(
+	q sqlx.Queryer,
+	walletID uuid.UUID,
+	dayStart, monthStart, hourStart time.Time,
+) (daily, monthly int64, transfersLastHour int, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(amount + fee) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(amount + fee) FILTER (WHERE created_at >= $3), 0),
+			COUNT(*) FILTER (WHERE type = $4 AND created_at >= $5)
+		FROM transactions
+		WHERE wallet_id = $1
+			AND type IN ($4, $6, $9)
+			AND status IN ($7, $8, $10)
+			AND related_transaction_id IS NULL
+			AND created_at >= LEAST($2::TIMESTAMPTZ, $3::TIMESTAMPTZ, $5::TIMESTAMPTZ)
+	`
+	err = q.QueryRowx(
+		query,
+		walletID,
+		dayStart,
+		monthStart,
+		models.TransactionTypeDebit,
+		hourStart,
+		models.TransactionTypeWithdrawal,
+		models.TransactionStatusPending,
+		models.TransactionStatusSuccess,
+		models.TransactionTypeEscrowDebit,
+		models.TransactionStatusDisputed,
+	).Scan(&daily, &monthly, &transfersLastHour)
+	return daily, monthly, transfersLastHour, err
+}
+
+// GetAPIKeyOutflow sums the transfers, withdrawals and escrow payments in a
+// currency, fees included, that an API key has made since since
+func (r *TransactionRepository) GetAPIKeyOutflow(q sqlx.Queryer, apiKeyID uuid.UUID, currency models.Currency, since time.Time) (int64, error) {
+	var total int64
+	query := `
+		SELECT COALESCE(SUM(amount + fee), 0)
+		FROM transactions
+		WHERE api_key_id = $1
+			AND type IN ($2, $3, $8)
+			AND status IN ($4, $5, $9)
+			AND created_at >= $6
+			AND currency = $7
+	`
+	err := sqlx.Get(
+		q,
+		&total,
+		query,
+		apiKeyID,
+		models.TransactionTypeDebit,
+		models.TransactionTypeWithdrawal,
+		models.TransactionStatusPending,
+		models.TransactionStatusSuccess,
+		since,
+		currency,
+		models.TransactionTypeEscrowDebit,
+		models.TransactionStatusDisputed,
+	)
+	return total, err
+}
@@ -88,3 +88,15 @@ func (r *UserRepository) Update(user *models.User) error {
 	_, err := r.db.Exec(query, user.Email, user.GoogleID, user.Name, user.UpdatedAt, user.ID)
 	return err
 }
+
+// UpdateKYCTier raises a user's KYC tier. A tier lower than the current one
+// is ignored so that an older approval cannot downgrade the user.
+func (r *UserRepository) UpdateKYCTier(tx *sqlx.Tx, id uuid.UUID, tier models.KYCTier) error {
+	query := `
+		UPDATE users
+		SET kyc_tier = $1, updated_at = $2
+		WHERE id = $3 AND kyc_tier < $1
+	`
+	_, err := tx.Exec(query, tier, time.Now(), id)
+	return err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrWalletNotFound is returned when no wallet matches a lookup
+var ErrWalletNotFound = errors.New("wallet not found")
+
 type WalletRepository struct {
 	db *sqlx.DB
 }
@@ -20,10 +24,11 @@ func NewWalletRepository(db *sqlx.DB) *WalletRepository {
 
 func (r *WalletRepository) Create(wallet *models.Wallet) error {
 	query := `
-		INSERT INTO wallets (id, user_id, wallet_number, balance, created_at, updated_at)
-		VALUES ($1, $2, generate_wallet_number(), $3, $4, $5)
+		INSERT INTO wallets (id, user_id, wallet_number, balance, currency, created_at, updated_at)
+		VALUES ($1, $2, generate_wallet_number(), $3, $4, $5, $6)
 		RETURNING id, wallet_number, created_at, updated_at
 	`
+	wallet.Currency = wallet.Balance.Currency
 	wallet.ID = uuid.New()
 	wallet.CreatedAt = time.Now()
 	wallet.UpdatedAt = time.Now()
@@ -33,6 +38,7 @@ func (r *WalletRepository) Create(wallet *models.Wallet) error {
 		wallet.ID,
 		wallet.UserID,
 		wallet.Balance,
+		wallet.Currency,
 		wallet.CreatedAt,
 		wallet.UpdatedAt,
 	).Scan(&wallet.ID, &wallet.WalletNumber, &wallet.CreatedAt, &wallet.UpdatedAt)
@@ -44,46 +50,71 @@ func (r *WalletRepository) GetByID(id uuid.UUID) (*models.Wallet, error) {
 	err := r.db.Get(&wallet, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("wallet not found")
+			return nil, ErrWalletNotFound
 		}
 		return nil, err
 	}
+	wallet.ApplyCurrency()
 	return &wallet, nil
 }
 
+// GetByUserID returns the user's wallet in the default currency
 func (r *WalletRepository) GetByUserID(userID uuid.UUID) (*models.Wallet, error) {
+	return r.GetByUserIDAndCurrency(userID, models.DefaultCurrency)
+}
+
+// GetByUserIDAndCurrency returns the user's wallet in a currency
+func (r *WalletRepository) GetByUserIDAndCurrency(userID uuid.UUID, currency models.Currency) (*models.Wallet, error) {
 	var wallet models.Wallet
-	query := `SELECT * FROM wallets WHERE user_id = $1`
-	err := r.db.Get(&wallet, query, userID)
+	query := `SELECT * FROM wallets WHERE user_id = $1 AND currency = $2`
+	err := r.db.Get(&wallet, query, userID, currency)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("wallet not found")
+			return nil, ErrWalletNotFound
 		}
 		return nil, err
 	}
+	wallet.ApplyCurrency()
 	return &wallet, nil
 }
 
+// ListByUserID returns all of a user's wallets, oldest first
+func (r *WalletRepository) ListByUserID(userID uuid.UUID) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	query := `SELECT * FROM wallets WHERE user_id = $1 ORDER BY created_at`
+	err := r.db.Select(&wallets, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range wallets {
+		wallets[i].ApplyCurrency()
+	}
+	return wallets, nil
+}
+
 func (r *WalletRepository) GetByWalletNumber(walletNumber string) (*models.Wallet, error) {
 	var wallet models.Wallet
 	query := `SELECT * FROM wallets WHERE wallet_number = $1`
 	err := r.db.Get(&wallet, query, walletNumber)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("wallet not found")
+			return nil, ErrWalletNotFound
 		}
 		return nil, err
 	}
+	wallet.ApplyCurrency()
 	return &wallet, nil
 }
 
-func (r *WalletRepository) UpdateBalance(tx *sqlx.Tx, walletID uuid.UUID, newBalance float64) error {
+// AdjustBalance applies a signed change to a wallet's stored balance. Only the
+// ledger should call this so that balances always match their postings.
+func (r *WalletRepository) AdjustBalance(tx *sqlx.Tx, walletID uuid.UUID, delta models.Money) error {
 	query := `
 		UPDATE wallets
-		SET balance = $1, updated_at = $2
-		WHERE id = $3 AND balance >= 0
+		SET balance = balance + $1, updated_at = $2
+		WHERE id = $3 AND balance + $1 >= 0
 	`
-	result, err := tx.Exec(query, newBalance, time.Now(), walletID)
+	result, err := tx.Exec(query, delta, time.Now(), walletID)
 	if err != nil {
 		return err
 	}
@@ -100,15 +131,40 @@ func (r *WalletRepository) UpdateBalance(tx *sqlx.Tx, walletID uuid.UUID, newBal
 	return nil
 }
 
-func (r *WalletRepository) GetBalanceForUpdate(tx *sqlx.Tx, walletID uuid.UUID) (float64, error) {
-	var balance float64
-	query := `SELECT balance FROM wallets WHERE id = $1 FOR UPDATE`
-	err := tx.Get(&balance, query, walletID)
+// ForceAdjustBalance applies a signed change to a wallet's stored balance even
+// if that leaves it below zero. It is only used for admin-forced reversals.
+func (r *WalletRepository) ForceAdjustBalance(tx *sqlx.Tx, walletID uuid.UUID, delta models.Money) error {
+	query := `
+		UPDATE wallets
+		SET balance = balance + $1, updated_at = $2
+		WHERE id = $3
+	`
+	result, err := tx.Exec(query, delta, time.Now(), walletID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("wallet not found")
+	}
+
+	return nil
+}
+
+func (r *WalletRepository) GetBalanceForUpdate(tx *sqlx.Tx, walletID uuid.UUID) (models.Money, error) {
+	var balance models.Money
+	query := `SELECT balance, currency FROM wallets WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRow(query, walletID).Scan(&balance, &balance.Currency)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, fmt.Errorf("wallet not found")
+			return models.Money{}, fmt.Errorf("wallet not found")
 		}
-		return 0, err
+		return models.Money{}, err
 	}
 	return balance, nil
 }
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookEventRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookEventRepository(db *sqlx.DB) *WebhookEventRepository {
+	return &WebhookEventRepository{db: db}
+}
+
+func (r *WebhookEventRepository) Create(event *models.WebhookEvent) error {
+	query := `
+		INSERT INTO webhook_events (
+			id, provider, event, reference, raw_body, signature, signature_valid,
+			status, attempts, created_at, updated_at
+		)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+	event.ID = uuid.New()
+	event.CreatedAt = time.Now()
+	event.UpdatedAt = time.Now()
+
+	return r.db.QueryRow(
+		query,
+		event.ID,
+		event.Provider,
+		stringValue(event.Event),
+		stringValue(event.Reference),
+		event.RawBody,
+		stringValue(event.Signature),
+		event.SignatureValid,
+		event.Status,
+		event.Attempts,
+		event.CreatedAt,
+		event.UpdatedAt,
+	).Scan(&event.ID, &event.CreatedAt, &event.UpdatedAt)
+}
+
+func (r *WebhookEventRepository) GetByID(id uuid.UUID) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	query := `SELECT * FROM webhook_events WHERE id = $1`
+	err := r.db.Get(&event, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook event not found")
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// List returns stored events newest first, optionally filtered by status
+func (r *WebhookEventRepository) List(status string, limit, offset int) ([]models.WebhookEvent, error) {
+	var events []models.WebhookEvent
+	query := `
+		SELECT * FROM webhook_events
+		WHERE $1 = '' OR status::TEXT = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	err := r.db.Select(&events, query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RecordAttempt stores the outcome of one processing attempt. A nil
+// nextAttemptAt means the event will not be retried automatically.
+func (r *WebhookEventRepository) RecordAttempt(
+	id uuid.UUID,
+	status models.WebhookEventStatus,
+	lastError *string,
+	nextAttemptAt *time.Time,
+) error {
+	query := `
+		UPDATE webhook_events
+		SET status = $1,
+			attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = $3,
+			processed_at = CASE WHEN $1 = 'processed' THEN NOW() ELSE processed_at END,
+			updated_at = NOW()
+		WHERE id = $4
+	`
+	_, err := r.db.Exec(query, status, lastError, nextAttemptAt, id)
+	return err
+}
+
+// ClaimDue returns failed events whose retry is due. Claimed events have
+// their next attempt pushed out by lease so that other workers skip them
+// while they are being processed.
+func (r *WebhookEventRepository) ClaimDue(limit int, lease time.Duration) ([]models.WebhookEvent, error) {
+	var events []models.WebhookEvent
+	query := `
+		UPDATE webhook_events
+		SET next_attempt_at = NOW() + $1 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_events
+			WHERE status = 'failed' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	err := r.db.Select(&events, query, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
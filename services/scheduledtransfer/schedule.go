@@ -0,0 +1,103 @@
+package scheduledtransfer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// validateSchedule checks that a scheduled transfer's schedule fields fit its type
+func validateSchedule(st *models.ScheduledTransfer) error {
+	switch st.ScheduleType {
+	case models.ScheduleTypeOnce:
+		st.CronExpression = nil
+		st.DayOfMonth = nil
+		st.EndAt = nil
+	case models.ScheduleTypeCron:
+		if st.CronExpression == nil {
+			return fmt.Errorf("cron_expression is required for cron schedules")
+		}
+		if _, err := parseCron(*st.CronExpression); err != nil {
+			return err
+		}
+		st.DayOfMonth = nil
+	case models.ScheduleTypeMonthly:
+		if st.DayOfMonth == nil || *st.DayOfMonth < 1 || *st.DayOfMonth > 31 {
+			return fmt.Errorf("day_of_month between 1 and 31 is required for monthly schedules")
+		}
+		st.CronExpression = nil
+	default:
+		return fmt.Errorf("schedule_type must be once, cron or monthly")
+	}
+
+	if st.EndAt != nil && !st.EndAt.After(st.StartAt) {
+		return fmt.Errorf("end_at must be after start_at")
+	}
+	return nil
+}
+
+// firstRun returns when a new or resumed scheduled transfer should next run,
+// or nil if it never will. Recurring occurrences before now are skipped.
+func firstRun(st *models.ScheduledTransfer, now time.Time) *time.Time {
+	if st.ScheduleType == models.ScheduleTypeOnce {
+		start := st.StartAt
+		return &start
+	}
+
+	// start_at itself may be the first occurrence
+	from := st.StartAt.Add(-time.Nanosecond)
+	if now.After(from) {
+		from = now
+	}
+	return nextRun(st, from)
+}
+
+// nextRun returns the first occurrence of a recurring schedule after t, or
+// nil for one-off transfers and schedules that have ended
+func nextRun(st *models.ScheduledTransfer, t time.Time) *time.Time {
+	var next time.Time
+	switch st.ScheduleType {
+	case models.ScheduleTypeCron:
+		cron, err := parseCron(*st.CronExpression)
+		if err != nil {
+			return nil
+		}
+		var ok bool
+		if next, ok = cron.next(t); !ok {
+			return nil
+		}
+	case models.ScheduleTypeMonthly:
+		next = nextMonthly(*st.DayOfMonth, st.StartAt, t)
+	default:
+		return nil
+	}
+
+	if st.EndAt != nil && next.After(*st.EndAt) {
+		return nil
+	}
+	return &next
+}
+
+// nextMonthly returns the first time after t that falls on day of the month
+// at the time of day of anchor, in UTC. In months shorter than day it falls
+// on the last day of the month.
+func nextMonthly(day int, anchor, t time.Time) time.Time {
+	anchor = anchor.UTC()
+	year, month, _ := t.UTC().Date()
+
+	for {
+		firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+		d := day
+		if d > lastDay {
+			d = lastDay
+		}
+
+		candidate := time.Date(year, month, d, anchor.Hour(), anchor.Minute(), anchor.Second(), 0, time.UTC)
+		if candidate.After(t) {
+			return candidate
+		}
+		month++
+	}
+}
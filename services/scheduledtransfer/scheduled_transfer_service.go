@@ -0,0 +1,318 @@
+package scheduledtransfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/notification"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// Due scheduled transfers claimed per worker run
+	runBatchSize = 50
+	// How long a claimed scheduled transfer is hidden from other workers
+	runLease = 5 * time.Minute
+)
+
+// RetryPolicy controls how failed runs of a scheduled transfer are retried
+type RetryPolicy struct {
+	// Attempts per run, including the first
+	MaxAttempts int
+	// Wait between attempts
+	RetryDelay time.Duration
+}
+
+// ScheduledTransferService manages transfers that users schedule ahead of
+// time and makes them when they fall due
+type ScheduledTransferService struct {
+	db                    *sqlx.DB
+	scheduledTransferRepo *repository.ScheduledTransferRepository
+	walletRepo            *repository.WalletRepository
+	userRepo              *repository.UserRepository
+	walletService         *wallet.WalletService
+	notifier              notification.Notifier
+	retryPolicy           RetryPolicy
+}
+
+func NewScheduledTransferService(
+	db *sqlx.DB,
+	scheduledTransferRepo *repository.ScheduledTransferRepository,
+	walletRepo *repository.WalletRepository,
+	userRepo *repository.UserRepository,
+	walletService *wallet.WalletService,
+	notifier notification.Notifier,
+	retryPolicy RetryPolicy,
+) *ScheduledTransferService {
+	return &ScheduledTransferService{
+		db:                    db,
+		scheduledTransferRepo: scheduledTransferRepo,
+		walletRepo:            walletRepo,
+		userRepo:              userRepo,
+		walletService:         walletService,
+		notifier:              notifier,
+		retryPolicy:           retryPolicy,
+	}
+}
+
+// Create schedules a transfer from the user's wallet. The recipient, amount,
+// description and schedule fields are taken from st. A zero StartAt means now.
+// apiKeyID is the key scheduling the transfer, if any; each run counts
+// towards its limits.
+func (s *ScheduledTransferService) Create(userID uuid.UUID, apiKeyID *uuid.UUID, st *models.ScheduledTransfer) (*models.ScheduledTransfer, error) {
+	if !st.Amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	now := time.Now()
+	if st.StartAt.IsZero() {
+		st.StartAt = now
+	}
+	if err := validateSchedule(st); err != nil {
+		return nil, err
+	}
+	if st.ScheduleType == models.ScheduleTypeOnce && !st.StartAt.After(now) {
+		return nil, fmt.Errorf("start_at must be in the future for one-off transfers")
+	}
+
+	senderWallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, st.Amount.Currency)
+	if errors.Is(err, repository.ErrWalletNotFound) {
+		return nil, fmt.Errorf("you have no %s wallet", st.Amount.Currency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet: %w", st.Amount.Currency, err)
+	}
+	recipientWallet, err := s.walletRepo.GetByWalletNumber(st.RecipientWalletNumber)
+	if err != nil {
+		return nil, fmt.Errorf("recipient wallet not found: %w", err)
+	}
+	if senderWallet.ID == recipientWallet.ID {
+		return nil, fmt.Errorf("cannot transfer to your own wallet")
+	}
+	if recipientWallet.Currency != senderWallet.Currency {
+		return nil, fmt.Errorf("%w: recipient wallet is in %s, not %s", wallet.ErrCrossCurrencyTransfer, recipientWallet.Currency, senderWallet.Currency)
+	}
+
+	st.UserID = userID
+	st.WalletID = senderWallet.ID
+	st.APIKeyID = apiKeyID
+	st.Status = models.ScheduledTransferStatusActive
+	st.NextRunAt = firstRun(st, now)
+	if st.NextRunAt == nil {
+		return nil, fmt.Errorf("schedule has no runs between start_at and end_at")
+	}
+
+	if err := s.scheduledTransferRepo.Create(st); err != nil {
+		return nil, fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+	return st, nil
+}
+
+// Get gets one of a user's scheduled transfers
+func (s *ScheduledTransferService) Get(userID, id uuid.UUID) (*models.ScheduledTransfer, error) {
+	st, err := s.scheduledTransferRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if st.UserID != userID {
+		return nil, fmt.Errorf("scheduled transfer not found")
+	}
+	return st, nil
+}
+
+// List lists a user's scheduled transfers, optionally filtered by status
+func (s *ScheduledTransferService) List(userID uuid.UUID, status string, limit, offset int) ([]models.ScheduledTransfer, error) {
+	return s.scheduledTransferRepo.ListByUser(userID, status, limit, offset)
+}
+
+// ListRuns lists the runs of one of a user's scheduled transfers
+func (s *ScheduledTransferService) ListRuns(userID, id uuid.UUID, limit, offset int) ([]models.ScheduledTransferRun, error) {
+	if _, err := s.Get(userID, id); err != nil {
+		return nil, err
+	}
+	return s.scheduledTransferRepo.ListRuns(id, limit, offset)
+}
+
+// Pause stops an active scheduled transfer from running until it is resumed
+func (s *ScheduledTransferService) Pause(userID, id uuid.UUID) (*models.ScheduledTransfer, error) {
+	if _, err := s.Get(userID, id); err != nil {
+		return nil, err
+	}
+	return s.scheduledTransferRepo.UpdateStatus(id, models.ScheduledTransferStatusActive, models.ScheduledTransferStatusPaused, nil)
+}
+
+// Resume reactivates a paused scheduled transfer. Recurring occurrences missed
+// while it was paused are skipped; a one-off transfer whose time has passed
+// runs straight away.
+func (s *ScheduledTransferService) Resume(userID, id uuid.UUID) (*models.ScheduledTransfer, error) {
+	st, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	next := firstRun(st, time.Now())
+	if next == nil {
+		return nil, fmt.Errorf("schedule has no runs left before end_at")
+	}
+	return s.scheduledTransferRepo.UpdateStatus(id, models.ScheduledTransferStatusPaused, models.ScheduledTransferStatusActive, next)
+}
+
+// Cancel stops an active or paused scheduled transfer for good
+func (s *ScheduledTransferService) Cancel(userID, id uuid.UUID) (*models.ScheduledTransfer, error) {
+	st, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch st.Status {
+	case models.ScheduledTransferStatusActive, models.ScheduledTransferStatusPaused:
+	default:
+		return nil, fmt.Errorf("scheduled transfer is %s", st.Status)
+	}
+	return s.scheduledTransferRepo.UpdateStatus(id, st.Status, models.ScheduledTransferStatusCancelled, nil)
+}
+
+// RunDue makes the scheduled transfers that have fallen due
+func (s *ScheduledTransferService) RunDue(ctx context.Context) error {
+	transfers, err := s.scheduledTransferRepo.ClaimDue(runBatchSize, runLease)
+	if err != nil {
+		return fmt.Errorf("failed to claim scheduled transfers: %w", err)
+	}
+
+	for i := range transfers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.run(&transfers[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// run makes one attempt at a scheduled transfer through WalletService and
+// records the outcome. A failed attempt is retried after the policy's delay
+// until its attempts are used up; after that a recurring transfer moves on to
+// its next occurrence and a one-off transfer is marked failed.
+//
+// A successful transfer commits in the same database transaction as its run
+// and the move to the next occurrence, so a crash cannot leave an occurrence
+// paid but still due. Successful runs are also unique per occurrence, so a
+// worker whose lease ran out cannot pay an occurrence another worker has paid.
+func (s *ScheduledTransferService) run(st *models.ScheduledTransfer) error {
+	now := time.Now()
+	run := &models.ScheduledTransferRun{
+		ScheduledTransferID: st.ID,
+		Attempt:             st.Attempts + 1,
+		ScheduledFor:        *st.NextRunAt,
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	debit, transferErr := s.walletService.TransferInTx(tx, st.UserID, st.APIKeyID, st.RecipientWalletNumber, st.Amount)
+	st.LastRunAt = &now
+
+	if transferErr == nil {
+		run.Status = models.ScheduledTransferRunStatusSuccess
+		run.TransactionID = &debit.ID
+		st.Attempts = 0
+		st.NextRunAt = nextRun(st, now)
+		if st.NextRunAt == nil {
+			st.Status = models.ScheduledTransferStatusCompleted
+		}
+	} else {
+		// Nothing from the failed transfer is kept; the run is recorded on its own
+		tx.Rollback()
+		if tx, err = s.db.Beginx(); err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		errMessage := transferErr.Error()
+		run.Error = &errMessage
+		s.recordFailure(st, run, now)
+	}
+
+	if err := s.scheduledTransferRepo.RecordRun(tx, run, st); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			log.Printf("Scheduled transfer %s was already paid for %s; skipping", st.ID, run.ScheduledFor.UTC().Format(time.RFC3339))
+			return nil
+		}
+		return fmt.Errorf("failed to record scheduled transfer run: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if transferErr != nil {
+		log.Printf("Scheduled transfer %s failed on attempt %d: %v", st.ID, run.Attempt, transferErr)
+	}
+	if errors.Is(transferErr, wallet.ErrInsufficientBalance) {
+		s.notifyInsufficientBalance(st, run)
+	}
+	return nil
+}
+
+// recordFailure marks run as failed and moves st on: to a retry while it
+// has attempts left, otherwise to its next occurrence, failing a one-off
+// transfer that has none
+func (s *ScheduledTransferService) recordFailure(st *models.ScheduledTransfer, run *models.ScheduledTransferRun, now time.Time) {
+	run.Status = models.ScheduledTransferRunStatusFailed
+
+	if run.Attempt < s.retryPolicy.MaxAttempts {
+		run.WillRetry = true
+		st.Attempts = run.Attempt
+		retryAt := now.Add(s.retryPolicy.RetryDelay)
+		st.NextRunAt = &retryAt
+		return
+	}
+
+	st.Attempts = 0
+	st.NextRunAt = nextRun(st, now)
+	if st.NextRunAt == nil {
+		if st.ScheduleType == models.ScheduleTypeOnce {
+			st.Status = models.ScheduledTransferStatusFailed
+		} else {
+			st.Status = models.ScheduledTransferStatusCompleted
+		}
+	}
+}
+
+// notifyInsufficientBalance tells the user a scheduled transfer could not be
+// made and what happens next. Delivery failures are only logged.
+func (s *ScheduledTransferService) notifyInsufficientBalance(st *models.ScheduledTransfer, run *models.ScheduledTransferRun) {
+	user, err := s.userRepo.GetByID(st.UserID)
+	if err != nil {
+		log.Printf("Failed to notify user %s about scheduled transfer %s: %v", st.UserID, st.ID, err)
+		return
+	}
+
+	message := fmt.Sprintf(
+		"Your scheduled transfer of %s %s to wallet %s could not be made because your wallet balance is too low.",
+		st.Amount, st.Amount.Currency, st.RecipientWalletNumber,
+	)
+	switch {
+	case run.WillRetry:
+		message += fmt.Sprintf(" We will try again at %s.", st.NextRunAt.UTC().Format(time.RFC1123))
+	case st.NextRunAt != nil:
+		message += fmt.Sprintf(" This payment has been skipped; the next one is due at %s.", st.NextRunAt.UTC().Format(time.RFC1123))
+	default:
+		message += " No further attempts will be made."
+	}
+
+	if err := s.notifier.Notify(user, "Scheduled transfer failed", message); err != nil {
+		log.Printf("Failed to notify user %s about scheduled transfer %s: %v", st.UserID, st.ID, err)
+	}
+}
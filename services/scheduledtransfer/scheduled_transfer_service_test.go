@@ -0,0 +1,192 @@
+package scheduledtransfer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/internal/testdb"
+	"github.com/brainox/paystack_wallet_service/services/kyc"
+	"github.com/brainox/paystack_wallet_service/services/ledger"
+	"github.com/brainox/paystack_wallet_service/services/notification"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/google/uuid"
+)
+
+// scheduledTest is a scheduled transfer service on the test database with
+// helpers to set up users and wallets
+type scheduledTest struct {
+	t          *testing.T
+	service    *ScheduledTransferService
+	userRepo   *repository.UserRepository
+	walletRepo *repository.WalletRepository
+}
+
+func newScheduledTest(t *testing.T, retryPolicy RetryPolicy) *scheduledTest {
+	db := testdb.Open(t)
+	userRepo := repository.NewUserRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
+	ledgerService := ledger.NewLedgerService(db, repository.NewLedgerRepository(db), walletRepo)
+	walletService := wallet.NewWalletService(
+		db,
+		walletRepo,
+		repository.NewTransactionRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewPocketRepository(db),
+		repository.NewFXQuoteRepository(db),
+		repository.NewEscrowRepository(db),
+		repository.NewPayoutRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		userRepo,
+		repository.NewLimitOverrideRepository(db),
+		ledgerService,
+		nil,
+		nil,
+		nil,
+		wallet.Limits{},
+		kyc.Tiers{},
+		nil,
+		wallet.ReversalPolicy{},
+	)
+	service := NewScheduledTransferService(
+		db,
+		repository.NewScheduledTransferRepository(db),
+		walletRepo,
+		userRepo,
+		walletService,
+		notification.NewLogNotifier(),
+		retryPolicy,
+	)
+	return &scheduledTest{t: t, service: service, userRepo: userRepo, walletRepo: walletRepo}
+}
+
+func (s *scheduledTest) newUser() *models.User {
+	s.t.Helper()
+	user := &models.User{Email: "scheduled-" + uuid.NewString() + "@example.com", Name: "Scheduled Test"}
+	if err := s.userRepo.Create(user); err != nil {
+		s.t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+func (s *scheduledTest) newWallet(user *models.User, currency models.Currency) *models.Wallet {
+	s.t.Helper()
+	w := &models.Wallet{UserID: user.ID, Balance: models.NewMoney(0, currency)}
+	if err := s.walletRepo.Create(w); err != nil {
+		s.t.Fatalf("failed to create %s wallet: %v", currency, err)
+	}
+	return w
+}
+
+func TestCreatePaysFromTheWalletInTheTransferCurrency(t *testing.T) {
+	s := newScheduledTest(t, RetryPolicy{MaxAttempts: 1})
+	sender := s.newUser()
+	s.newWallet(sender, models.CurrencyNGN)
+	senderUSD := s.newWallet(sender, models.CurrencyUSD)
+	recipient := s.newUser()
+	recipientUSD := s.newWallet(recipient, models.CurrencyUSD)
+	recipientNGN := s.newWallet(recipient, models.CurrencyNGN)
+	startAt := time.Now().Add(time.Hour)
+
+	st, err := s.service.Create(sender.ID, nil, &models.ScheduledTransfer{
+		RecipientWalletNumber: recipientUSD.WalletNumber,
+		Amount:                models.NewMoney(5000, models.CurrencyUSD),
+		ScheduleType:          models.ScheduleTypeOnce,
+		StartAt:               startAt,
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if st.WalletID != senderUSD.ID {
+		t.Errorf("wallet_id = %s, want the USD wallet %s", st.WalletID, senderUSD.ID)
+	}
+	got, err := s.service.Get(sender.ID, st.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Amount.Currency != models.CurrencyUSD {
+		t.Errorf("stored amount currency = %s, want USD", got.Amount.Currency)
+	}
+
+	_, err = s.service.Create(sender.ID, nil, &models.ScheduledTransfer{
+		RecipientWalletNumber: recipientNGN.WalletNumber,
+		Amount:                models.NewMoney(5000, models.CurrencyUSD),
+		ScheduleType:          models.ScheduleTypeOnce,
+		StartAt:               startAt,
+	})
+	if !errors.Is(err, wallet.ErrCrossCurrencyTransfer) {
+		t.Errorf("USD transfer to an NGN wallet = %v, want ErrCrossCurrencyTransfer", err)
+	}
+
+	_, err = s.service.Create(sender.ID, nil, &models.ScheduledTransfer{
+		RecipientWalletNumber: recipientUSD.WalletNumber,
+		Amount:                models.NewMoney(5000, models.CurrencyGHS),
+		ScheduleType:          models.ScheduleTypeOnce,
+		StartAt:               startAt,
+	})
+	if err == nil || err.Error() != "you have no GHS wallet" {
+		t.Errorf("transfer from a missing GHS wallet = %v, want %q", err, "you have no GHS wallet")
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	day := 15
+	endOfMarch := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	nextMonthly := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	retryAt := now.Add(10 * time.Minute)
+
+	tests := []struct {
+		name         string
+		scheduleType models.ScheduleType
+		endAt        *time.Time
+		attempt      int
+		wantRetry    bool
+		wantAttempts int
+		wantNext     *time.Time
+		wantStatus   models.ScheduledTransferStatus
+	}{
+		{name: "first attempt of a one-off", scheduleType: models.ScheduleTypeOnce, attempt: 1, wantRetry: true, wantAttempts: 1, wantNext: &retryAt, wantStatus: models.ScheduledTransferStatusActive},
+		{name: "last attempt of a one-off", scheduleType: models.ScheduleTypeOnce, attempt: 3, wantStatus: models.ScheduledTransferStatusFailed},
+		{name: "second attempt of a monthly", scheduleType: models.ScheduleTypeMonthly, attempt: 2, wantRetry: true, wantAttempts: 2, wantNext: &retryAt, wantStatus: models.ScheduledTransferStatusActive},
+		{name: "last attempt of a monthly", scheduleType: models.ScheduleTypeMonthly, attempt: 3, wantNext: &nextMonthly, wantStatus: models.ScheduledTransferStatusActive},
+		{name: "last attempt of the last monthly", scheduleType: models.ScheduleTypeMonthly, endAt: &now, attempt: 3, wantStatus: models.ScheduledTransferStatusCompleted},
+		{name: "last attempt before the end date", scheduleType: models.ScheduleTypeMonthly, endAt: &endOfMarch, attempt: 3, wantNext: &nextMonthly, wantStatus: models.ScheduledTransferStatusActive},
+	}
+
+	s := &ScheduledTransferService{retryPolicy: RetryPolicy{MaxAttempts: 3, RetryDelay: 10 * time.Minute}}
+	for _, tt := range tests {
+		st := &models.ScheduledTransfer{
+			ScheduleType: tt.scheduleType,
+			DayOfMonth:   &day,
+			StartAt:      start,
+			EndAt:        tt.endAt,
+			Status:       models.ScheduledTransferStatusActive,
+			Attempts:     tt.attempt - 1,
+		}
+		run := &models.ScheduledTransferRun{Attempt: tt.attempt}
+		s.recordFailure(st, run, now)
+
+		if run.Status != models.ScheduledTransferRunStatusFailed {
+			t.Errorf("%s: run status = %s, want %s", tt.name, run.Status, models.ScheduledTransferRunStatusFailed)
+		}
+		if run.WillRetry != tt.wantRetry {
+			t.Errorf("%s: will_retry = %v, want %v", tt.name, run.WillRetry, tt.wantRetry)
+		}
+		if st.Attempts != tt.wantAttempts {
+			t.Errorf("%s: attempts = %d, want %d", tt.name, st.Attempts, tt.wantAttempts)
+		}
+		switch {
+		case tt.wantNext == nil && st.NextRunAt != nil:
+			t.Errorf("%s: next run = %s, want none", tt.name, st.NextRunAt)
+		case tt.wantNext != nil && (st.NextRunAt == nil || !st.NextRunAt.Equal(*tt.wantNext)):
+			t.Errorf("%s: next run = %v, want %s", tt.name, st.NextRunAt, tt.wantNext)
+		}
+		if st.Status != tt.wantStatus {
+			t.Errorf("%s: status = %s, want %s", tt.name, st.Status, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Exclusive wraps a job so that only one instance of the service runs it at a
+// time. Each run takes a Postgres advisory lock named after the job; if
+// another instance holds the lock the run is skipped.
+func Exclusive(db *sqlx.DB, name string, run JobFunc) JobFunc {
+	key := advisoryLockKey(name)
+
+	return func(ctx context.Context) error {
+		// Advisory locks belong to a session, so lock and unlock on one connection
+		conn, err := db.Connx(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+		defer conn.Close()
+
+		var locked bool
+		if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, key); err != nil {
+			return fmt.Errorf("failed to take advisory lock: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+
+		return run(ctx)
+	}
+}
+
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
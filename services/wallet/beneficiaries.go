@@ -0,0 +1,233 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxBeneficiaries is the most beneficiaries a user can save
+	MaxBeneficiaries = 100
+	// MaxNicknameLength is the longest a beneficiary nickname can be
+	MaxNicknameLength = 50
+)
+
+// WalletOwner is who a wallet number belongs to, with the name masked so a
+// sender can recognise the recipient without learning their full name
+type WalletOwner struct {
+	WalletNumber string          `json:"wallet_number"`
+	Currency     models.Currency `json:"currency"`
+	AccountName  string          `json:"account_name"`
+}
+
+// ResolveWalletNumber returns the masked name of a wallet's owner so that a
+// sender can check it before paying
+func (s *WalletService) ResolveWalletNumber(walletNumber string) (*WalletOwner, error) {
+	wallet, err := s.walletRepo.GetByWalletNumber(strings.TrimSpace(walletNumber))
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.userRepo.GetByID(wallet.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet owner: %w", err)
+	}
+	return &WalletOwner{
+		WalletNumber: wallet.WalletNumber,
+		Currency:     wallet.Currency,
+		AccountName:  maskName(owner.Name),
+	}, nil
+}
+
+// maskName keeps the first letter of each word of a name and hides the
+// rest, e.g. "Ada Obi" becomes "A** O**"
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+	}
+	return strings.Join(words, " ")
+}
+
+// CreateBeneficiary saves a recipient for the user under a nickname. The
+// type and destination are taken from b: a wallet number for wallet
+// beneficiaries, or a bank code and account number for bank accounts, whose
+// name is looked up with the bank.
+func (s *WalletService) CreateBeneficiary(userID uuid.UUID, b *models.Beneficiary) (*models.Beneficiary, error) {
+	existing, err := s.beneficiaryRepo.ListByUser(userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list beneficiaries: %w", err)
+	}
+	if len(existing) >= MaxBeneficiaries {
+		return nil, fmt.Errorf("you can save at most %d beneficiaries", MaxBeneficiaries)
+	}
+
+	nickname, err := validateNickname(b.Nickname, uuid.Nil, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiary := &models.Beneficiary{
+		UserID:   userID,
+		Type:     b.Type,
+		Nickname: nickname,
+	}
+	switch b.Type {
+	case models.BeneficiaryTypeWallet:
+		if b.WalletNumber == nil || strings.TrimSpace(*b.WalletNumber) == "" {
+			return nil, fmt.Errorf("wallet_number is required for wallet beneficiaries")
+		}
+		walletNumber := strings.TrimSpace(*b.WalletNumber)
+		wallet, err := s.walletRepo.GetByWalletNumber(walletNumber)
+		if err != nil {
+			return nil, fmt.Errorf("wallet not found: %w", err)
+		}
+		if wallet.UserID == userID {
+			return nil, fmt.Errorf("cannot save your own wallet as a beneficiary")
+		}
+		for _, other := range existing {
+			if other.WalletNumber != nil && *other.WalletNumber == walletNumber {
+				return nil, fmt.Errorf("wallet %s is already saved as %q", walletNumber, other.Nickname)
+			}
+		}
+		owner, err := s.userRepo.GetByID(wallet.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wallet owner: %w", err)
+		}
+		beneficiary.WalletNumber = &walletNumber
+		beneficiary.AccountName = maskName(owner.Name)
+
+	case models.BeneficiaryTypeBankAccount:
+		if b.BankCode == nil || b.AccountNumber == nil || *b.BankCode == "" || *b.AccountNumber == "" {
+			return nil, fmt.Errorf("bank_code and account_number are required for bank account beneficiaries")
+		}
+		bankCode, accountNumber := *b.BankCode, *b.AccountNumber
+		if len(accountNumber) != 10 || strings.Trim(accountNumber, "0123456789") != "" {
+			return nil, fmt.Errorf("account_number must be 10 digits")
+		}
+		for _, other := range existing {
+			if other.BankCode != nil && *other.BankCode == bankCode && *other.AccountNumber == accountNumber {
+				return nil, fmt.Errorf("account %s is already saved as %q", accountNumber, other.Nickname)
+			}
+		}
+		accountName, err := s.ResolveBankAccount(accountNumber, bankCode)
+		if err != nil {
+			return nil, err
+		}
+		beneficiary.BankCode = &bankCode
+		beneficiary.AccountNumber = &accountNumber
+		beneficiary.AccountName = accountName
+
+	default:
+		return nil, fmt.Errorf("type must be %q or %q", models.BeneficiaryTypeWallet, models.BeneficiaryTypeBankAccount)
+	}
+
+	if err := s.beneficiaryRepo.Create(beneficiary); err != nil {
+		return nil, fmt.Errorf("failed to create beneficiary: %w", err)
+	}
+	return beneficiary, nil
+}
+
+// validateNickname trims a nickname and checks it is not taken by another of
+// the user's beneficiaries. id is the beneficiary being renamed, if any.
+func validateNickname(nickname string, id uuid.UUID, existing []models.Beneficiary) (string, error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return "", fmt.Errorf("nickname is required")
+	}
+	if len(nickname) > MaxNicknameLength {
+		return "", fmt.Errorf("nickname must be at most %d characters", MaxNicknameLength)
+	}
+	for _, other := range existing {
+		if other.ID != id && strings.EqualFold(other.Nickname, nickname) {
+			return "", fmt.Errorf("you already have a beneficiary named %q", other.Nickname)
+		}
+	}
+	return nickname, nil
+}
+
+// ListBeneficiaries lists the user's beneficiaries, optionally only those of one type
+func (s *WalletService) ListBeneficiaries(userID uuid.UUID, beneficiaryType string) ([]models.Beneficiary, error) {
+	return s.beneficiaryRepo.ListByUser(userID, beneficiaryType)
+}
+
+// GetBeneficiary gets one of the user's beneficiaries
+func (s *WalletService) GetBeneficiary(userID, beneficiaryID uuid.UUID) (*models.Beneficiary, error) {
+	beneficiary, err := s.beneficiaryRepo.GetByID(beneficiaryID)
+	if err != nil {
+		return nil, err
+	}
+	if beneficiary.UserID != userID {
+		return nil, repository.ErrBeneficiaryNotFound
+	}
+	return beneficiary, nil
+}
+
+// RenameBeneficiary changes a beneficiary's nickname. To pay somewhere else,
+// save a new beneficiary.
+func (s *WalletService) RenameBeneficiary(userID, beneficiaryID uuid.UUID, nickname string) (*models.Beneficiary, error) {
+	beneficiary, err := s.GetBeneficiary(userID, beneficiaryID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.beneficiaryRepo.ListByUser(userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list beneficiaries: %w", err)
+	}
+	nickname, err = validateNickname(nickname, beneficiary.ID, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.beneficiaryRepo.UpdateNickname(beneficiary.ID, nickname); err != nil {
+		return nil, fmt.Errorf("failed to update beneficiary: %w", err)
+	}
+	beneficiary.Nickname = nickname
+	return beneficiary, nil
+}
+
+// DeleteBeneficiary removes one of the user's beneficiaries
+func (s *WalletService) DeleteBeneficiary(userID, beneficiaryID uuid.UUID) error {
+	if _, err := s.GetBeneficiary(userID, beneficiaryID); err != nil {
+		return err
+	}
+	if err := s.beneficiaryRepo.Delete(beneficiaryID); err != nil {
+		return fmt.Errorf("failed to delete beneficiary: %w", err)
+	}
+	return nil
+}
+
+// BeneficiaryWalletNumber returns the wallet number of one of the user's
+// wallet beneficiaries, for paying it by transfer
+func (s *WalletService) BeneficiaryWalletNumber(userID, beneficiaryID uuid.UUID) (string, error) {
+	beneficiary, err := s.GetBeneficiary(userID, beneficiaryID)
+	if errors.Is(err, repository.ErrBeneficiaryNotFound) {
+		return "", rejectTransfer("beneficiary not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	if beneficiary.Type != models.BeneficiaryTypeWallet {
+		return "", rejectTransfer("beneficiary %q is a bank account; withdraw to it instead", beneficiary.Nickname)
+	}
+	return *beneficiary.WalletNumber, nil
+}
+
+// BeneficiaryBankAccount returns the bank code and account number of one of
+// the user's bank account beneficiaries, for paying it by withdrawal
+func (s *WalletService) BeneficiaryBankAccount(userID, beneficiaryID uuid.UUID) (string, string, error) {
+	beneficiary, err := s.GetBeneficiary(userID, beneficiaryID)
+	if err != nil {
+		return "", "", err
+	}
+	if beneficiary.Type != models.BeneficiaryTypeBankAccount {
+		return "", "", fmt.Errorf("beneficiary %q is a wallet; transfer to it instead", beneficiary.Nickname)
+	}
+	return *beneficiary.BankCode, *beneficiary.AccountNumber, nil
+}
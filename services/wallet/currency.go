@@ -0,0 +1,33 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreateWallet opens a wallet for a user in another currency. Each user can
+// hold one wallet per supported currency.
+func (s *WalletService) CreateWallet(userID uuid.UUID, currency models.Currency) (*models.Wallet, error) {
+	if !currency.IsSupported() {
+		return nil, fmt.Errorf("unsupported currency %q", currency)
+	}
+	if _, err := s.walletRepo.GetByUserIDAndCurrency(userID, currency); err == nil {
+		return nil, fmt.Errorf("you already have a %s wallet", currency)
+	}
+
+	wallet := &models.Wallet{
+		UserID:  userID,
+		Balance: models.NewMoney(0, currency),
+	}
+	if err := s.walletRepo.Create(wallet); err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+	return wallet, nil
+}
+
+// ListWallets returns all of a user's wallets
+func (s *WalletService) ListWallets(userID uuid.UUID) ([]models.Wallet, error) {
+	return s.walletRepo.ListByUserID(userID)
+}
@@ -0,0 +1,423 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+	"github.com/jmoiron/sqlx"
+)
+
+// depositProvider returns the provider that handled a deposit. Deposits made
+// before providers were recorded all went through Paystack.
+func depositProvider(deposit *models.Transaction) models.PaymentProvider {
+	if deposit.Provider == nil {
+		return models.PaymentProviderPaystack
+	}
+	return *deposit.Provider
+}
+
+// processChargeSuccess credits the wallet for a successful deposit charge
+func (s *WalletService) processChargeSuccess(event *payment.Event) error {
+	// Get transaction by provider reference
+	transaction, err := s.transactionRepo.GetByPaystackReference(event.Reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	// Check if already processed (idempotency)
+	if !isCreditable(transaction) {
+		return nil
+	}
+
+	// Only the provider that handled the deposit can settle it
+	name := depositProvider(transaction)
+	if event.Provider != name {
+		return fmt.Errorf("%s event for a deposit made with %s", event.Provider, name)
+	}
+
+	// Verify the transaction status with the provider
+	provider, err := s.providers.Get(name)
+	if err != nil {
+		return err
+	}
+	verification, err := provider.VerifyPayment(event.Reference)
+	if err != nil {
+		return fmt.Errorf("failed to verify transaction: %w", err)
+	}
+
+	if verification.Status != payment.PaymentStatusSuccess {
+		return fmt.Errorf("transaction not successful")
+	}
+
+	return s.creditDeposit(event.Reference, verification)
+}
+
+// creditDeposit credits the wallet for a deposit that its provider has
+// verified as successful. It is shared by the webhook and the reconciliation job.
+func (s *WalletService) creditDeposit(providerReference string, verification *payment.Verification) error {
+	// Begin database transaction
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Re-read the deposit under lock in case another delivery credited it meanwhile
+	transaction, err := s.transactionRepo.GetByPaystackReferenceForUpdate(tx, providerReference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+	if !isCreditable(transaction) {
+		return nil
+	}
+
+	// Hold the deposit for review if the provider collected something other
+	// than what was initialized, e.g. a partial payment or a different currency
+	if reason := paymentMismatch(verification, transaction); reason != "" {
+		return s.holdDepositForReview(tx, transaction, verification, reason)
+	}
+
+	// Lock the wallet while it is credited
+	balance, err := s.walletRepo.GetBalanceForUpdate(tx, transaction.WalletID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	// The money has been collected, so a deposit the wallet cannot hold at
+	// the user's KYC tier is held for review rather than refused
+	user, err := s.userRepo.GetByID(transaction.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := s.checkMaxBalance(user, balance, transaction.Amount, LimitCodeMaxBalance); err != nil {
+		return s.holdDepositForReview(tx, transaction, verification, err.Error())
+	}
+
+	// Post the deposit from the provider's clearing account into the wallet
+	provider := depositProvider(transaction)
+	clearingAccount, err := s.ledgerService.SystemAccount(tx, provider.ClearingAccount(), transaction.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	walletAccount, err := s.ledgerService.WalletAccount(tx, transaction.WalletID)
+	if err != nil {
+		return err
+	}
+	feeAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFees, transaction.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	entry, err := s.ledgerService.MoveWithFee(tx, *transaction.Reference, "Wallet deposit via "+provider.DisplayName(), clearingAccount, walletAccount, transaction.Amount, feeAccount, transaction.Fee)
+	if err != nil {
+		return fmt.Errorf("failed to post deposit to ledger: %w", err)
+	}
+	if err := s.transactionRepo.SetJournalEntry(tx, transaction.ID, entry.ID); err != nil {
+		return fmt.Errorf("failed to link journal entry: %w", err)
+	}
+	transaction.JournalEntryID = &entry.ID
+	if err := s.recordFee(tx, transaction, *transaction.Reference+"_FEE", "Deposit fee"); err != nil {
+		return err
+	}
+
+	// Update transaction status
+	if err := s.transactionRepo.UpdateStatus(tx, transaction.ID, models.TransactionStatusSuccess); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// holdDepositForReview flags a deposit for manual review instead of crediting
+// it and commits tx
+func (s *WalletService) holdDepositForReview(tx *sqlx.Tx, transaction *models.Transaction, verification *payment.Verification, reason string) error {
+	details, err := json.Marshal(newDepositReview(verification, reason))
+	if err != nil {
+		return fmt.Errorf("failed to encode review details: %w", err)
+	}
+	if err := s.transactionRepo.MarkForReview(tx, transaction.ID, string(details)); err != nil {
+		return fmt.Errorf("failed to flag transaction for review: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("Deposit %s held for review: %s", *transaction.Reference, reason)
+	return nil
+}
+
+// depositReview is merged into the metadata of a deposit held for review
+type depositReview struct {
+	ReviewReason  string `json:"review_reason"`
+	PaidAmount    string `json:"paid_amount"`
+	PaidCurrency  string `json:"paid_currency"`
+	PaidReference string `json:"paid_reference"`
+	FlaggedAt     string `json:"flagged_at"`
+}
+
+// paymentMismatch describes how a verified payment differs from the deposit,
+// or returns an empty string when they agree
+func paymentMismatch(verification *payment.Verification, deposit *models.Transaction) string {
+	if verification.Reference != "" && deposit.PaystackReference != nil && verification.Reference != *deposit.PaystackReference {
+		return fmt.Sprintf("reference %s does not match %s", verification.Reference, *deposit.PaystackReference)
+	}
+	if !strings.EqualFold(verification.Currency, string(deposit.Amount.Currency)) {
+		return fmt.Sprintf("paid in %s, expected %s", verification.Currency, deposit.Amount.Currency)
+	}
+	// The payer was charged the deposit fee on top of the amount
+	if expected := deposit.Amount.Add(deposit.Fee); verification.Amount != expected.Amount {
+		return fmt.Sprintf("paid %s, expected %s", models.NewMoney(verification.Amount, deposit.Amount.Currency), expected)
+	}
+	return ""
+}
+
+func newDepositReview(verification *payment.Verification, reason string) depositReview {
+	return depositReview{
+		ReviewReason:  reason,
+		PaidAmount:    models.NewMoney(verification.Amount, models.Currency(verification.Currency)).String(),
+		PaidCurrency:  verification.Currency,
+		PaidReference: verification.Reference,
+		FlaggedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// isCreditable reports whether a deposit has not been credited yet. A failed
+// or expired charge can still succeed if the customer retries on the same
+// checkout.
+func isCreditable(transaction *models.Transaction) bool {
+	if transaction.Type != models.TransactionTypeDeposit {
+		return false
+	}
+	return transaction.Status == models.TransactionStatusPending ||
+		transaction.Status == models.TransactionStatusFailed ||
+		transaction.Status == models.TransactionStatusExpired
+}
+
+// processChargeFailed marks a pending deposit as failed
+func (s *WalletService) processChargeFailed(event *payment.Event) error {
+	return s.transitionDeposit(event.Reference, models.TransactionStatusPending, models.TransactionStatusFailed)
+}
+
+// processRefundProcessed settles a refund made through RefundDeposit, or
+// handles a refund issued from the provider's dashboard. A dashboard refund
+// has left the Paystack balance without touching the wallet, so it is taken
+// back out of the wallet and a full refund marks the deposit reversed.
+func (s *WalletService) processRefundProcessed(event *payment.Event) error {
+	refund, err := s.findRefund(event)
+	if err != nil {
+		return err
+	}
+	if refund != nil {
+		return s.completeRefund(*refund.Reference)
+	}
+
+	reference := event.Reference
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.transactionRepo.GetByPaystackReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	// Already reversed (idempotency) or never credited
+	if deposit.Type != models.TransactionTypeDeposit ||
+		(deposit.Status != models.TransactionStatusSuccess && deposit.Status != models.TransactionStatusDisputed) {
+		return nil
+	}
+
+	// Each refund is posted once, keyed by the provider's refund ID
+	refundReference := fmt.Sprintf("%s_REFUND_%s", *deposit.Reference, event.ID)
+	posted, err := s.ledgerService.HasEntry(tx, refundReference)
+	if err != nil {
+		return err
+	}
+	if posted {
+		return nil
+	}
+
+	// Refunds against a deposit never add up to more than the deposit
+	settled, pending, err := s.transactionRepo.GetRefundTotals(tx, deposit.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get refund totals: %w", err)
+	}
+	refundable := refundableAmount(deposit.Amount, settled, pending)
+	if !refundable.IsPositive() {
+		log.Printf("Ignoring refund %s of deposit %s: the deposit has already been fully refunded", event.ID, *deposit.Reference)
+		return nil
+	}
+	amount := dashboardRefundAmount(refundable, event.Amount)
+
+	if err := s.reverseDeposit(tx, deposit, amount, refundReference, "Deposit refunded to card"); err != nil {
+		return err
+	}
+
+	// A partial refund leaves the rest of the deposit in place
+	if settled+amount.Amount >= deposit.Amount.Amount {
+		if err := s.transactionRepo.UpdateStatus(tx, deposit.ID, models.TransactionStatusReversed); err != nil {
+			return fmt.Errorf("failed to update transaction status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// processRefundFailed handles a refund that Paystack could not complete. The
+// funds held for a refund made through RefundDeposit go back to the wallet; a
+// dashboard refund never touched the wallet, so the deposit stays as it is.
+func (s *WalletService) processRefundFailed(event *payment.Event) error {
+	refund, err := s.findRefund(event)
+	if err != nil {
+		return err
+	}
+	if refund != nil {
+		return s.releaseRefund(*refund.Reference)
+	}
+
+	if _, err := s.transactionRepo.GetByPaystackReference(event.Reference); err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+	return nil
+}
+
+// processDisputeCreated flags a credited deposit whose charge is being disputed
+func (s *WalletService) processDisputeCreated(event *payment.Event) error {
+	return s.transitionDeposit(event.Reference, models.TransactionStatusSuccess, models.TransactionStatusDisputed)
+}
+
+// processDisputeResolved settles a disputed deposit. If the chargeback stands
+// the money is taken back out of the wallet, less any part of the deposit
+// already refunded or being refunded; otherwise the deposit is restored.
+func (s *WalletService) processDisputeResolved(event *payment.Event) error {
+	reference := event.Reference
+
+	if event.Resolution != payment.DisputeMerchantAccepted {
+		if event.Resolution != payment.DisputeDeclined {
+			return fmt.Errorf("unknown dispute resolution: %q", event.Resolution)
+		}
+		return s.transitionDeposit(reference, models.TransactionStatusDisputed, models.TransactionStatusSuccess)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.transactionRepo.GetByPaystackReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	// Already reversed (idempotency) or not a deposit
+	if deposit.Type != models.TransactionTypeDeposit || deposit.Status != models.TransactionStatusDisputed {
+		return nil
+	}
+
+	// Refunds already made or in flight have taken their part of the
+	// deposit out of the wallet, so only the rest is charged back
+	settled, pending, err := s.transactionRepo.GetRefundTotals(tx, deposit.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get refund totals: %w", err)
+	}
+	if amount := refundableAmount(deposit.Amount, settled, pending); amount.IsPositive() {
+		if err := s.reverseDeposit(tx, deposit, amount, chargebackReference(deposit), "Deposit charged back after dispute"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.transactionRepo.UpdateStatus(tx, deposit.ID, models.TransactionStatusReversed); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// transitionDeposit moves a deposit between statuses without touching balances
+func (s *WalletService) transitionDeposit(reference string, from, to models.TransactionStatus) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.transactionRepo.GetByPaystackReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	// Only deposits in the expected state move (idempotency)
+	if deposit.Type != models.TransactionTypeDeposit || deposit.Status != from {
+		return nil
+	}
+
+	if err := s.transactionRepo.UpdateStatus(tx, deposit.ID, to); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// reverseDeposit takes money for a credited deposit back out of the wallet
+// and records it as a debit in the wallet's history
+func (s *WalletService) reverseDeposit(tx *sqlx.Tx, deposit *models.Transaction, amount models.Money, reference, description string) error {
+	if _, err := s.walletRepo.GetBalanceForUpdate(tx, deposit.WalletID); err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	walletAccount, err := s.ledgerService.WalletAccount(tx, deposit.WalletID)
+	if err != nil {
+		return err
+	}
+	clearingAccount, err := s.ledgerService.SystemAccount(tx, depositProvider(deposit).ClearingAccount(), amount.Currency)
+	if err != nil {
+		return err
+	}
+	// The provider has already taken the money back, so the reversal is
+	// posted even if the wallet has spent it, leaving the balance negative
+	entry, err := s.ledgerService.ForceMove(tx, reference, description, walletAccount, clearingAccount, amount)
+	if err != nil {
+		return fmt.Errorf("failed to reverse deposit %s: %w", *deposit.Reference, err)
+	}
+
+	debitTransaction := &models.Transaction{
+		UserID:               deposit.UserID,
+		WalletID:             deposit.WalletID,
+		Type:                 models.TransactionTypeDebit,
+		Amount:               amount,
+		Status:               models.TransactionStatusSuccess,
+		Reference:            &reference,
+		Description:          stringPtr(fmt.Sprintf("%s (%s)", description, *deposit.Reference)),
+		JournalEntryID:       &entry.ID,
+		RelatedTransactionID: &deposit.ID,
+	}
+	if err := s.transactionRepo.Create(tx, debitTransaction); err != nil {
+		return fmt.Errorf("failed to create debit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+)
+
+func TestPaymentMismatch(t *testing.T) {
+	providerReference := "PSK_123"
+	deposit := &models.Transaction{
+		Type:              models.TransactionTypeDeposit,
+		Amount:            models.NewMoney(500000, models.CurrencyNGN),
+		Fee:               models.NewMoney(7500, models.CurrencyNGN),
+		PaystackReference: &providerReference,
+	}
+
+	tests := []struct {
+		name         string
+		verification payment.Verification
+		want         string
+	}{
+		{name: "amount and fee paid", verification: payment.Verification{Reference: "PSK_123", Amount: 507500, Currency: "NGN"}},
+		{name: "lower case currency", verification: payment.Verification{Reference: "PSK_123", Amount: 507500, Currency: "ngn"}},
+		{name: "no reference reported", verification: payment.Verification{Amount: 507500, Currency: "NGN"}},
+		{name: "fee not paid", verification: payment.Verification{Reference: "PSK_123", Amount: 500000, Currency: "NGN"}, want: "expected"},
+		{name: "partial payment", verification: payment.Verification{Reference: "PSK_123", Amount: 100000, Currency: "NGN"}, want: "expected"},
+		{name: "other currency", verification: payment.Verification{Reference: "PSK_123", Amount: 507500, Currency: "USD"}, want: "paid in USD"},
+		{name: "other reference", verification: payment.Verification{Reference: "PSK_999", Amount: 507500, Currency: "NGN"}, want: "does not match"},
+	}
+
+	for _, tt := range tests {
+		got := paymentMismatch(&tt.verification, deposit)
+		if tt.want == "" && got != "" {
+			t.Errorf("%s: mismatch = %q, want none", tt.name, got)
+		}
+		if tt.want != "" && !strings.Contains(got, tt.want) {
+			t.Errorf("%s: mismatch = %q, want one containing %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsCreditable(t *testing.T) {
+	tests := []struct {
+		txType models.TransactionType
+		status models.TransactionStatus
+		want   bool
+	}{
+		{models.TransactionTypeDeposit, models.TransactionStatusPending, true},
+		{models.TransactionTypeDeposit, models.TransactionStatusFailed, true},
+		{models.TransactionTypeDeposit, models.TransactionStatusExpired, true},
+		{models.TransactionTypeDeposit, models.TransactionStatusSuccess, false},
+		{models.TransactionTypeDeposit, models.TransactionStatusUnderReview, false},
+		{models.TransactionTypeWithdrawal, models.TransactionStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		transaction := &models.Transaction{Type: tt.txType, Status: tt.status}
+		if got := isCreditable(transaction); got != tt.want {
+			t.Errorf("isCreditable(%s %s) = %v, want %v", tt.status, tt.txType, got, tt.want)
+		}
+	}
+}
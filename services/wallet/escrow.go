@@ -0,0 +1,445 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/fees"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// DefaultEscrowReleaseAfter is how long an escrow is held before it is
+	// released automatically when no release time is given
+	DefaultEscrowReleaseAfter = 14 * 24 * time.Hour
+	// MaxEscrowReleaseAfter is the longest an escrow can be held
+	MaxEscrowReleaseAfter = 90 * 24 * time.Hour
+	// Due escrows released per run of the auto-release job
+	escrowReleaseBatchSize = 100
+)
+
+// Outcomes an admin can resolve a dispute with
+const (
+	EscrowOutcomeRelease = "release"
+	EscrowOutcomeRefund  = "refund"
+)
+
+// ErrEscrowDisputed is returned when a disputed escrow is released or cancelled
+var ErrEscrowDisputed = errors.New("escrow is under dispute and can only be resolved by an admin")
+
+// EscrowDetails is an escrow and the transactions recorded against it
+type EscrowDetails struct {
+	*models.Escrow
+	Transactions []models.Transaction `json:"transactions"`
+}
+
+// CreateEscrow pays amount from the buyer's wallet into escrow for the seller
+// at sellerWalletNumber. The buyer pays the transfer fee and the usual limits
+// apply. The escrow is released to the seller automatically at autoReleaseAt;
+// a zero autoReleaseAt means DefaultEscrowReleaseAfter from now. apiKeyID is
+// the API key funding the escrow, if any.
+func (s *WalletService) CreateEscrow(
+	buyerUserID uuid.UUID,
+	apiKeyID *uuid.UUID,
+	sellerWalletNumber string,
+	amount models.Money,
+	reference, description string,
+	autoReleaseAt time.Time,
+) (*models.Escrow, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	now := time.Now()
+	if autoReleaseAt.IsZero() {
+		autoReleaseAt = now.Add(DefaultEscrowReleaseAfter)
+	}
+	if !autoReleaseAt.After(now) {
+		return nil, fmt.Errorf("auto_release_at must be in the future")
+	}
+	if autoReleaseAt.After(now.Add(MaxEscrowReleaseAfter)) {
+		return nil, fmt.Errorf("escrows cannot be held longer than %s", MaxEscrowReleaseAfter)
+	}
+
+	buyerWallet, err := s.walletRepo.GetByUserIDAndCurrency(buyerUserID, amount.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buyer %s wallet: %w", amount.Currency, err)
+	}
+	sellerWallet, err := s.walletRepo.GetByWalletNumber(sellerWalletNumber)
+	if err != nil {
+		return nil, fmt.Errorf("seller wallet not found: %w", err)
+	}
+	if sellerWallet.Currency != buyerWallet.Currency {
+		return nil, fmt.Errorf("%w: seller wallet is in %s, not %s", ErrCrossCurrencyTransfer, sellerWallet.Currency, buyerWallet.Currency)
+	}
+	if sellerWallet.UserID == buyerUserID {
+		return nil, fmt.Errorf("cannot pay yourself into escrow")
+	}
+
+	buyer, err := s.userRepo.GetByID(buyerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buyer: %w", err)
+	}
+	seller, err := s.userRepo.GetByID(sellerWallet.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller: %w", err)
+	}
+
+	quote := s.QuoteFee(fees.KindTransfer, amount)
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	available, err := s.lockAvailableBalance(tx, buyerWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buyer balance: %w", err)
+	}
+	if available.LessThan(quote.Total) {
+		return nil, ErrInsufficientBalance
+	}
+	if err := s.checkLimits(tx, buyerWallet.ID, buyer, apiKeyID, amount, quote.Total, false); err != nil {
+		return nil, err
+	}
+
+	// The seller must be able to receive the money when it is released
+	sellerBalance, err := s.walletRepo.GetBalanceForUpdate(tx, sellerWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller balance: %w", err)
+	}
+	if err := s.checkMaxBalance(seller, sellerBalance, amount, LimitCodeRecipientMaxBalance); err != nil {
+		return nil, err
+	}
+
+	escrow := &models.Escrow{
+		BuyerUserID:        buyerUserID,
+		BuyerWalletID:      buyerWallet.ID,
+		BuyerWalletNumber:  buyerWallet.WalletNumber,
+		SellerUserID:       sellerWallet.UserID,
+		SellerWalletID:     sellerWallet.ID,
+		SellerWalletNumber: sellerWallet.WalletNumber,
+		Amount:             amount,
+		Status:             models.EscrowStatusFunded,
+		AutoReleaseAt:      autoReleaseAt,
+		APIKeyID:           apiKeyID,
+	}
+	if reference != "" {
+		escrow.Reference = &reference
+	}
+	if description != "" {
+		escrow.Description = &description
+	}
+	if err := s.escrowRepo.Create(tx, escrow); err != nil {
+		return nil, fmt.Errorf("failed to create escrow: %w", err)
+	}
+
+	if err := s.postEscrowFunding(tx, escrow, buyerWallet, quote.Fee); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return escrow, nil
+}
+
+// postEscrowFunding moves the escrow amount and fee out of the locked buyer
+// wallet into the escrow and fees accounts and records the buyer's debit
+func (s *WalletService) postEscrowFunding(tx *sqlx.Tx, escrow *models.Escrow, buyerWallet *models.Wallet, fee models.Money) error {
+	buyerAccount, err := s.ledgerService.WalletAccount(tx, buyerWallet.ID)
+	if err != nil {
+		return err
+	}
+	escrowAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountEscrow, escrow.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	feeAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFees, escrow.Amount.Currency)
+	if err != nil {
+		return err
+	}
+
+	baseReference := fmt.Sprintf("ESC_%s_%d", escrow.ID.String()[:8], time.Now().Unix())
+	entry, err := s.ledgerService.MoveWithFee(
+		tx,
+		baseReference,
+		fmt.Sprintf("Escrow %s from wallet %s for wallet %s", escrow.ID, buyerWallet.WalletNumber, escrow.SellerWalletNumber),
+		buyerAccount,
+		escrowAccount,
+		escrow.Amount,
+		feeAccount,
+		fee,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to post escrow to ledger: %w", err)
+	}
+
+	debitReference := baseReference + "_DEBIT"
+	debit := &models.Transaction{
+		UserID:            escrow.BuyerUserID,
+		WalletID:          buyerWallet.ID,
+		Type:              models.TransactionTypeEscrowDebit,
+		Amount:            escrow.Amount,
+		Fee:               fee,
+		Status:            models.TransactionStatusSuccess,
+		Reference:         &debitReference,
+		RecipientWalletID: &escrow.SellerWalletID,
+		RecipientUserID:   &escrow.SellerUserID,
+		Description:       stringPtr(fmt.Sprintf("Escrow payment to wallet %s", escrow.SellerWalletNumber)),
+		JournalEntryID:    &entry.ID,
+		APIKeyID:          escrow.APIKeyID,
+		EscrowID:          &escrow.ID,
+	}
+	if err := s.transactionRepo.Create(tx, debit); err != nil {
+		return fmt.Errorf("failed to create escrow transaction: %w", err)
+	}
+	return s.recordFee(tx, debit, baseReference+"_FEE", "Escrow fee")
+}
+
+// GetEscrow gets an escrow the user is the buyer or seller in, with its transactions
+func (s *WalletService) GetEscrow(userID, escrowID uuid.UUID) (*EscrowDetails, error) {
+	escrow, err := s.getEscrowForParty(userID, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.transactionRepo.GetByEscrowID(escrowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escrow transactions: %w", err)
+	}
+	return &EscrowDetails{Escrow: escrow, Transactions: transactions}, nil
+}
+
+// ListEscrows lists the escrows a user is the buyer or seller in, optionally filtered by status
+func (s *WalletService) ListEscrows(userID uuid.UUID, status string, limit, offset int) ([]models.Escrow, error) {
+	return s.escrowRepo.ListByUser(userID, status, limit, offset)
+}
+
+func (s *WalletService) getEscrowForParty(userID, escrowID uuid.UUID) (*models.Escrow, error) {
+	escrow, err := s.escrowRepo.GetByID(escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.BuyerUserID != userID && escrow.SellerUserID != userID {
+		return nil, fmt.Errorf("escrow not found")
+	}
+	return escrow, nil
+}
+
+// ReleaseEscrow pays a funded escrow out to the seller. Only the buyer can
+// release it, either directly or through one of their API keys with the
+// escrow_release permission.
+func (s *WalletService) ReleaseEscrow(userID uuid.UUID, apiKeyID *uuid.UUID, escrowID uuid.UUID) (*models.Escrow, error) {
+	escrow, err := s.getEscrowForParty(userID, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.BuyerUserID != userID {
+		return nil, fmt.Errorf("only the buyer can release an escrow")
+	}
+	return s.settleEscrow(escrowID, EscrowOutcomeRelease, apiKeyID, false)
+}
+
+// CancelEscrow refunds a funded escrow to the buyer. Only the seller can
+// cancel it; a buyer who wants their money back opens a dispute. The fee paid
+// when the escrow was funded is not refunded.
+func (s *WalletService) CancelEscrow(userID, escrowID uuid.UUID) (*models.Escrow, error) {
+	escrow, err := s.getEscrowForParty(userID, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.SellerUserID != userID {
+		return nil, fmt.Errorf("only the seller can cancel an escrow")
+	}
+	return s.settleEscrow(escrowID, EscrowOutcomeRefund, nil, false)
+}
+
+// DisputeEscrow freezes a funded escrow until an admin resolves it. Either
+// party can open a dispute. The buyer's funding transaction is marked
+// disputed while it lasts.
+func (s *WalletService) DisputeEscrow(userID, escrowID uuid.UUID, reason string) (*models.Escrow, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if _, err := s.getEscrowForParty(userID, escrowID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	escrow, err := s.escrowRepo.GetByIDForUpdate(tx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.Status != models.EscrowStatusFunded {
+		return nil, fmt.Errorf("escrow is %s", escrow.Status)
+	}
+
+	funding, err := s.transactionRepo.GetEscrowFundingForUpdate(tx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transactionRepo.UpdateStatus(tx, funding.ID, models.TransactionStatusDisputed); err != nil {
+		return nil, fmt.Errorf("failed to update escrow transaction: %w", err)
+	}
+
+	now := time.Now()
+	escrow.Status = models.EscrowStatusDisputed
+	escrow.DisputedBy = &userID
+	escrow.DisputeReason = &reason
+	escrow.DisputedAt = &now
+	if err := s.escrowRepo.Update(tx, escrow); err != nil {
+		return nil, fmt.Errorf("failed to update escrow: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return escrow, nil
+}
+
+// ResolveEscrowDispute settles a disputed escrow for an admin, either
+// releasing it to the seller or refunding it to the buyer
+func (s *WalletService) ResolveEscrowDispute(escrowID uuid.UUID, outcome string) (*models.Escrow, error) {
+	if outcome != EscrowOutcomeRelease && outcome != EscrowOutcomeRefund {
+		return nil, fmt.Errorf("outcome must be %q or %q", EscrowOutcomeRelease, EscrowOutcomeRefund)
+	}
+	return s.settleEscrow(escrowID, outcome, nil, true)
+}
+
+// ReleaseDueEscrows releases funded escrows past their auto-release time and
+// returns how many were released. Disputed escrows are left alone. A failure
+// to release one escrow is logged and does not stop the others.
+func (s *WalletService) ReleaseDueEscrows(ctx context.Context) (int, error) {
+	ids, err := s.escrowRepo.ListDueForRelease(escrowReleaseBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list escrows due for release: %w", err)
+	}
+
+	released := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return released, ctx.Err()
+		}
+		if _, err := s.settleEscrow(id, EscrowOutcomeRelease, nil, false); err != nil {
+			log.Printf("Failed to auto-release escrow %s: %v", id, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// settleEscrow pays an escrow out of the escrow account, to the seller on
+// release or back to the buyer on refund, and records the credit against the
+// escrow. Only funded escrows can be settled unless resolvingDispute is set,
+// in which case only disputed ones can, and their funding transaction is
+// restored to success first.
+func (s *WalletService) settleEscrow(escrowID uuid.UUID, outcome string, apiKeyID *uuid.UUID, resolvingDispute bool) (*models.Escrow, error) {
+	escrow, err := s.escrowRepo.GetByID(escrowID)
+	if err != nil {
+		return nil, err
+	}
+
+	walletID, walletNumber, userID := escrow.SellerWalletID, escrow.SellerWalletNumber, escrow.SellerUserID
+	txType, status, label := models.TransactionTypeEscrowCredit, models.EscrowStatusReleased, "Escrow release"
+	if outcome == EscrowOutcomeRefund {
+		walletID, walletNumber, userID = escrow.BuyerWalletID, escrow.BuyerWalletNumber, escrow.BuyerUserID
+		txType, status, label = models.TransactionTypeEscrowRefund, models.EscrowStatusRefunded, "Escrow refund"
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The wallet is locked before the escrow, as everywhere else
+	if _, err := s.walletRepo.GetBalanceForUpdate(tx, walletID); err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	escrow, err = s.escrowRepo.GetByIDForUpdate(tx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+
+	funding, err := s.transactionRepo.GetEscrowFundingForUpdate(tx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resolvingDispute && escrow.Status == models.EscrowStatusDisputed:
+		if err := s.transactionRepo.UpdateStatus(tx, funding.ID, models.TransactionStatusSuccess); err != nil {
+			return nil, fmt.Errorf("failed to update escrow transaction: %w", err)
+		}
+	case resolvingDispute:
+		return nil, fmt.Errorf("escrow is %s, not disputed", escrow.Status)
+	case escrow.Status == models.EscrowStatusDisputed:
+		return nil, ErrEscrowDisputed
+	case escrow.Status != models.EscrowStatusFunded:
+		return nil, fmt.Errorf("escrow is already %s", escrow.Status)
+	}
+
+	escrowAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountEscrow, escrow.Amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	walletAccount, err := s.ledgerService.WalletAccount(tx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	reference := fmt.Sprintf("ESC_%s_%d_%s", escrow.ID.String()[:8], time.Now().Unix(), strings.ToUpper(outcome))
+	entry, err := s.ledgerService.Move(
+		tx,
+		reference,
+		fmt.Sprintf("%s of escrow %s to wallet %s", label, escrow.ID, walletNumber),
+		escrowAccount,
+		walletAccount,
+		escrow.Amount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post escrow to ledger: %w", err)
+	}
+
+	credit := &models.Transaction{
+		UserID:               userID,
+		WalletID:             walletID,
+		Type:                 txType,
+		Amount:               escrow.Amount,
+		Status:               models.TransactionStatusSuccess,
+		Reference:            &reference,
+		Description:          stringPtr(fmt.Sprintf("%s for escrow %s", label, escrow.ID)),
+		JournalEntryID:       &entry.ID,
+		APIKeyID:             apiKeyID,
+		RelatedTransactionID: &funding.ID,
+		EscrowID:             &escrow.ID,
+	}
+	if err := s.transactionRepo.Create(tx, credit); err != nil {
+		return nil, fmt.Errorf("failed to create escrow transaction: %w", err)
+	}
+
+	now := time.Now()
+	escrow.Status = status
+	escrow.SettledAt = &now
+	if err := s.escrowRepo.Update(tx, escrow); err != nil {
+		return nil, fmt.Errorf("failed to update escrow: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return escrow, nil
+}
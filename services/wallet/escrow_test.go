@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// newEscrow pays amount from a new buyer into escrow for a new seller
+func (p *payoutTest) newEscrow(amount int64) (buyer *models.User, buyerWallet *models.Wallet, seller *models.User, sellerWallet *models.Wallet, escrow *models.Escrow) {
+	p.t.Helper()
+	buyer, buyerWallet = p.newWallet(50000)
+	seller, sellerWallet = p.newWallet(0)
+	escrow, err := p.service.CreateEscrow(buyer.ID, nil, sellerWallet.WalletNumber, models.NewMoney(amount, models.CurrencyNGN), "", "", time.Time{})
+	if err != nil {
+		p.t.Fatalf("CreateEscrow failed: %v", err)
+	}
+	return buyer, buyerWallet, seller, sellerWallet, escrow
+}
+
+func TestReleaseEscrowPaysTheSellerOnce(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	buyer, buyerWallet, seller, sellerWallet, escrow := p.newEscrow(20000)
+
+	if got := p.balance(buyerWallet); got != 30000 {
+		t.Errorf("buyer balance after funding = %d, want 30000", got)
+	}
+	if _, err := p.service.ReleaseEscrow(seller.ID, nil, escrow.ID); err == nil {
+		t.Error("seller released the escrow")
+	}
+
+	released, err := p.service.ReleaseEscrow(buyer.ID, nil, escrow.ID)
+	if err != nil {
+		t.Fatalf("ReleaseEscrow failed: %v", err)
+	}
+	if released.Status != models.EscrowStatusReleased {
+		t.Errorf("status = %s, want %s", released.Status, models.EscrowStatusReleased)
+	}
+	if got := p.balance(sellerWallet); got != 20000 {
+		t.Errorf("seller balance = %d, want 20000", got)
+	}
+
+	if _, err := p.service.ReleaseEscrow(buyer.ID, nil, escrow.ID); err == nil || !strings.Contains(err.Error(), "already released") {
+		t.Errorf("second release = %v, want an already released error", err)
+	}
+	if _, err := p.service.CancelEscrow(seller.ID, escrow.ID); err == nil {
+		t.Error("released escrow was cancelled")
+	}
+	if got := p.balance(sellerWallet); got != 20000 {
+		t.Errorf("seller balance after repeated settlement = %d, want 20000", got)
+	}
+	if got := p.balance(buyerWallet); got != 30000 {
+		t.Errorf("buyer balance after repeated settlement = %d, want 30000", got)
+	}
+}
+
+func TestCancelEscrowRefundsTheBuyer(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	buyer, buyerWallet, seller, sellerWallet, escrow := p.newEscrow(20000)
+
+	if _, err := p.service.CancelEscrow(buyer.ID, escrow.ID); err == nil {
+		t.Error("buyer cancelled the escrow")
+	}
+
+	refunded, err := p.service.CancelEscrow(seller.ID, escrow.ID)
+	if err != nil {
+		t.Fatalf("CancelEscrow failed: %v", err)
+	}
+	if refunded.Status != models.EscrowStatusRefunded {
+		t.Errorf("status = %s, want %s", refunded.Status, models.EscrowStatusRefunded)
+	}
+	if got := p.balance(buyerWallet); got != 50000 {
+		t.Errorf("buyer balance = %d, want 50000", got)
+	}
+	if got := p.balance(sellerWallet); got != 0 {
+		t.Errorf("seller balance = %d, want 0", got)
+	}
+	if _, err := p.service.ReleaseEscrow(buyer.ID, nil, escrow.ID); err == nil {
+		t.Error("refunded escrow was released")
+	}
+}
+
+func TestDisputedEscrowIsSettledOnlyByAnAdmin(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	buyer, buyerWallet, seller, sellerWallet, escrow := p.newEscrow(20000)
+
+	if _, err := p.service.ResolveEscrowDispute(escrow.ID, EscrowOutcomeRefund); err == nil {
+		t.Error("undisputed escrow was resolved")
+	}
+	if _, err := p.service.DisputeEscrow(buyer.ID, escrow.ID, "Goods never arrived"); err != nil {
+		t.Fatalf("DisputeEscrow failed: %v", err)
+	}
+
+	if _, err := p.service.ReleaseEscrow(buyer.ID, nil, escrow.ID); !errors.Is(err, ErrEscrowDisputed) {
+		t.Errorf("release of disputed escrow = %v, want ErrEscrowDisputed", err)
+	}
+	if _, err := p.service.CancelEscrow(seller.ID, escrow.ID); !errors.Is(err, ErrEscrowDisputed) {
+		t.Errorf("cancel of disputed escrow = %v, want ErrEscrowDisputed", err)
+	}
+	if _, err := p.service.ResolveEscrowDispute(escrow.ID, "split"); err == nil {
+		t.Error("dispute resolved with an unknown outcome")
+	}
+
+	resolved, err := p.service.ResolveEscrowDispute(escrow.ID, EscrowOutcomeRefund)
+	if err != nil {
+		t.Fatalf("ResolveEscrowDispute failed: %v", err)
+	}
+	if resolved.Status != models.EscrowStatusRefunded {
+		t.Errorf("status = %s, want %s", resolved.Status, models.EscrowStatusRefunded)
+	}
+	if got := p.balance(buyerWallet); got != 50000 {
+		t.Errorf("buyer balance = %d, want 50000", got)
+	}
+	if got := p.balance(sellerWallet); got != 0 {
+		t.Errorf("seller balance = %d, want 0", got)
+	}
+	if _, err := p.service.ResolveEscrowDispute(escrow.ID, EscrowOutcomeRelease); err == nil {
+		t.Error("resolved dispute was resolved again")
+	}
+}
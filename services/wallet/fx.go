@@ -0,0 +1,221 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrQuoteExpired is returned when a quote is executed after its TTL
+var ErrQuoteExpired = errors.New("quote has expired; request a new one")
+
+// Conversion is an executed quote and the transactions it made
+type Conversion struct {
+	Quote  *models.FXQuote     `json:"quote"`
+	Debit  *models.Transaction `json:"debit"`
+	Credit *models.Transaction `json:"credit"`
+}
+
+// QuoteConversion prices converting amount from the user's wallet in its
+// currency to their wallet in to. The quote can be executed until it expires.
+func (s *WalletService) QuoteConversion(userID uuid.UUID, amount models.Money, to models.Currency) (*models.FXQuote, error) {
+	if _, err := s.walletRepo.GetByUserIDAndCurrency(userID, amount.Currency); err != nil {
+		return nil, fmt.Errorf("you do not have a %s wallet: %w", amount.Currency, err)
+	}
+	if _, err := s.walletRepo.GetByUserIDAndCurrency(userID, to); err != nil {
+		return nil, fmt.Errorf("you do not have a %s wallet: %w", to, err)
+	}
+
+	quote, err := s.fxQuoter.Quote(userID, amount, to, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fxQuoteRepo.Create(quote); err != nil {
+		return nil, fmt.Errorf("failed to create quote: %w", err)
+	}
+	return quote, nil
+}
+
+// GetQuote gets one of a user's quotes
+func (s *WalletService) GetQuote(userID, quoteID uuid.UUID) (*models.FXQuote, error) {
+	quote, err := s.fxQuoteRepo.GetByID(quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.UserID != userID {
+		return nil, fmt.Errorf("quote not found")
+	}
+	return quote, nil
+}
+
+// ExecuteQuote makes the conversion a quote priced. In one database
+// transaction it debits the sell amount from one wallet, credits the buy
+// amount to the other and keeps the spread in the FX spread account. Each
+// currency is posted as its own journal entry against the FX position
+// account, and both transactions record the quoted rate.
+func (s *WalletService) ExecuteQuote(userID, quoteID uuid.UUID) (*Conversion, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	quote, err := s.fxQuoteRepo.GetByIDForUpdate(tx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.UserID != userID {
+		return nil, fmt.Errorf("quote not found")
+	}
+	if !quote.IsExecutable(now) {
+		if quote.Status == models.FXQuoteStatusOpen {
+			return nil, ErrQuoteExpired
+		}
+		return nil, fmt.Errorf("quote has already been executed")
+	}
+
+	fromWallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, quote.FromCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet: %w", quote.FromCurrency, err)
+	}
+	toWallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, quote.ToCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet: %w", quote.ToCurrency, err)
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	available, err := s.lockAvailableBalance(tx, fromWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	if available.LessThan(quote.SellAmount) {
+		return nil, ErrInsufficientBalance
+	}
+	toBalance, err := s.walletRepo.GetBalanceForUpdate(tx, toWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	if err := s.checkMaxBalance(user, toBalance, quote.BuyAmount, LimitCodeMaxBalance); err != nil {
+		return nil, err
+	}
+
+	baseReference := fmt.Sprintf("FX_%s_%d", uuid.New().String()[:8], now.Unix())
+	debit, credit, err := s.postConversion(tx, quote, fromWallet, toWallet, baseReference)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fxQuoteRepo.MarkExecuted(tx, quote.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to update quote: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	quote.Status = models.FXQuoteStatusExecuted
+	quote.ExecutedAt = &now
+	return &Conversion{Quote: quote, Debit: debit, Credit: credit}, nil
+}
+
+// postConversion posts a quote's two journal entries between locked wallets
+// and records the debit and credit transactions
+func (s *WalletService) postConversion(tx *sqlx.Tx, quote *models.FXQuote, from, to *models.Wallet, baseReference string) (*models.Transaction, *models.Transaction, error) {
+	fromAccount, err := s.ledgerService.WalletAccount(tx, from.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	toAccount, err := s.ledgerService.WalletAccount(tx, to.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	sellPosition, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFXPosition, quote.FromCurrency)
+	if err != nil {
+		return nil, nil, err
+	}
+	buyPosition, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFXPosition, quote.ToCurrency)
+	if err != nil {
+		return nil, nil, err
+	}
+	spreadAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFXSpread, quote.FromCurrency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The sell side: the wallet pays the full amount, the spread is kept
+	debitReference := baseReference + "_DEBIT"
+	sellEntry, err := s.ledgerService.MoveWithFee(
+		tx,
+		debitReference,
+		fmt.Sprintf("Conversion of %s %s from wallet %s", quote.SellAmount, quote.FromCurrency, from.WalletNumber),
+		fromAccount,
+		sellPosition,
+		quote.SellAmount.Sub(quote.Spread),
+		spreadAccount,
+		quote.Spread,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to post conversion to ledger: %w", err)
+	}
+
+	// The buy side
+	creditReference := baseReference + "_CREDIT"
+	buyEntry, err := s.ledgerService.Move(
+		tx,
+		creditReference,
+		fmt.Sprintf("Conversion of %s %s to wallet %s", quote.BuyAmount, quote.ToCurrency, to.WalletNumber),
+		buyPosition,
+		toAccount,
+		quote.BuyAmount,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to post conversion to ledger: %w", err)
+	}
+
+	rate := quote.Rate
+	debit := &models.Transaction{
+		UserID:            from.UserID,
+		WalletID:          from.ID,
+		Type:              models.TransactionTypeFXDebit,
+		Amount:            quote.SellAmount,
+		Fee:               models.NewMoney(0, quote.FromCurrency),
+		Status:            models.TransactionStatusSuccess,
+		Reference:         &debitReference,
+		RecipientWalletID: &to.ID,
+		RecipientUserID:   &to.UserID,
+		Description:       stringPtr(fmt.Sprintf("Conversion to %s wallet %s", quote.ToCurrency, to.WalletNumber)),
+		JournalEntryID:    &sellEntry.ID,
+		FXRate:            &rate,
+		FXQuoteID:         &quote.ID,
+	}
+	if err := s.transactionRepo.Create(tx, debit); err != nil {
+		return nil, nil, fmt.Errorf("failed to create debit transaction: %w", err)
+	}
+
+	credit := &models.Transaction{
+		UserID:         to.UserID,
+		WalletID:       to.ID,
+		Type:           models.TransactionTypeFXCredit,
+		Amount:         quote.BuyAmount,
+		Fee:            models.NewMoney(0, quote.ToCurrency),
+		Status:         models.TransactionStatusSuccess,
+		Reference:      &creditReference,
+		Description:    stringPtr(fmt.Sprintf("Conversion from %s wallet %s", quote.FromCurrency, from.WalletNumber)),
+		JournalEntryID: &buyEntry.ID,
+		FXRate:         &rate,
+		FXQuoteID:      &quote.ID,
+	}
+	if err := s.transactionRepo.Create(tx, credit); err != nil {
+		return nil, nil, fmt.Errorf("failed to create credit transaction: %w", err)
+	}
+
+	return debit, credit, nil
+}
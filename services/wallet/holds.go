@@ -0,0 +1,249 @@
+package wallet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultHoldDuration is how long a hold lasts when no expiry is given
+	DefaultHoldDuration = 7 * 24 * time.Hour
+	// MaxHoldDuration is the longest a hold may last
+	MaxHoldDuration = 30 * 24 * time.Hour
+)
+
+// CreateHold reserves part of the available balance of a user's wallet in the
+// amount's currency until expiresAt. A zero expiresAt means
+// DefaultHoldDuration from now.
+func (s *WalletService) CreateHold(userID uuid.UUID, amount models.Money, expiresAt time.Time, reference, description string) (*models.Hold, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	now := time.Now()
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(DefaultHoldDuration)
+	}
+	if !expiresAt.After(now) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+	if expiresAt.After(now.Add(MaxHoldDuration)) {
+		return nil, fmt.Errorf("holds cannot last longer than %s", MaxHoldDuration)
+	}
+
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, amount.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet: %w", amount.Currency, err)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	available, err := s.lockAvailableBalance(tx, wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	if available.LessThan(amount) {
+		return nil, fmt.Errorf("insufficient balance")
+	}
+
+	hold := &models.Hold{
+		WalletID:       wallet.ID,
+		UserID:         userID,
+		Amount:         amount,
+		CapturedAmount: models.NewMoney(0, amount.Currency),
+		Status:         models.HoldStatusActive,
+		ExpiresAt:      expiresAt,
+	}
+	if reference != "" {
+		hold.Reference = &reference
+	}
+	if description != "" {
+		hold.Description = &description
+	}
+	if err := s.holdRepo.Create(tx, hold); err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return hold, nil
+}
+
+// GetHold gets one of a user's holds
+func (s *WalletService) GetHold(userID, holdID uuid.UUID) (*models.Hold, error) {
+	hold, err := s.holdRepo.GetByID(holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != userID {
+		return nil, fmt.Errorf("hold not found")
+	}
+	return hold, nil
+}
+
+// ListHolds lists the holds on a user's wallet in a currency, optionally
+// filtered by status
+func (s *WalletService) ListHolds(userID uuid.UUID, currency models.Currency, status string, limit, offset int) ([]models.Hold, error) {
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet: %w", currency, err)
+	}
+	return s.holdRepo.ListByWallet(wallet.ID, status, limit, offset)
+}
+
+// CaptureHold pays all or part of a hold to another wallet in the hold's
+// currency. A zero amount captures everything not captured yet. Whatever is
+// left stays held until it is captured, released or expires.
+func (s *WalletService) CaptureHold(userID uuid.UUID, apiKeyID *uuid.UUID, holdID uuid.UUID, recipientWalletNumber string, amount models.Money) (*models.Hold, error) {
+	recipientWallet, err := s.walletRepo.GetByWalletNumber(recipientWalletNumber)
+	if err != nil {
+		return nil, fmt.Errorf("recipient wallet not found: %w", err)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := s.holdRepo.GetByIDForUpdate(tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != userID {
+		return nil, fmt.Errorf("hold not found")
+	}
+	if !hold.IsCapturable(time.Now()) {
+		if hold.Status == models.HoldStatusActive {
+			return nil, fmt.Errorf("hold has expired")
+		}
+		return nil, fmt.Errorf("hold is %s", hold.Status)
+	}
+	if hold.WalletID == recipientWallet.ID {
+		return nil, fmt.Errorf("cannot capture a hold to the wallet it is on")
+	}
+	if recipientWallet.Currency != hold.Currency {
+		return nil, fmt.Errorf("recipient wallet currency %s does not match hold currency %s", recipientWallet.Currency, hold.Currency)
+	}
+
+	remaining := hold.Remaining()
+	if amount.IsZero() {
+		amount = remaining
+	}
+	if !amount.SameCurrency(hold.Amount) {
+		return nil, fmt.Errorf("capture currency %s does not match hold currency %s", amount.Currency, hold.Amount.Currency)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if remaining.LessThan(amount) {
+		return nil, fmt.Errorf("capture amount %s exceeds the %s left on the hold", amount, remaining)
+	}
+
+	senderWallet, err := s.walletRepo.GetByID(hold.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	// The held funds are still part of the ledger balance
+	balance, err := s.walletRepo.GetBalanceForUpdate(tx, senderWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender balance: %w", err)
+	}
+	if balance.LessThan(amount) {
+		return nil, fmt.Errorf("insufficient balance")
+	}
+
+	// A capture sends money out of the wallet like a transfer, so it counts
+	// towards the same limits, checked under the sender's lock
+	sender, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender: %w", err)
+	}
+	if err := s.checkLimits(tx, senderWallet.ID, sender, apiKeyID, amount, amount, true); err != nil {
+		return nil, err
+	}
+
+	recipientBalance, err := s.walletRepo.GetBalanceForUpdate(tx, recipientWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient balance: %w", err)
+	}
+	recipient, err := s.userRepo.GetByID(recipientWallet.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient: %w", err)
+	}
+	if err := s.checkMaxBalance(recipient, recipientBalance, amount, LimitCodeRecipientMaxBalance); err != nil {
+		return nil, err
+	}
+
+	baseReference := fmt.Sprintf("CAP_%s_%d", uuid.New().String()[:8], time.Now().Unix())
+	if _, _, err := s.postTransfer(tx, senderWallet, recipientWallet, amount, models.NewMoney(0, amount.Currency), apiKeyID, baseReference, "Hold capture", ""); err != nil {
+		return nil, err
+	}
+
+	hold.CapturedAmount = hold.CapturedAmount.Add(amount)
+	if !hold.Remaining().IsPositive() {
+		hold.Status = models.HoldStatusCaptured
+	}
+	if err := s.holdRepo.UpdateCapture(tx, hold.ID, hold.CapturedAmount, hold.Status); err != nil {
+		return nil, fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return hold, nil
+}
+
+// ReleaseHold makes the uncaptured remainder of a hold available again
+func (s *WalletService) ReleaseHold(userID, holdID uuid.UUID) (*models.Hold, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := s.holdRepo.GetByIDForUpdate(tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != userID {
+		return nil, fmt.Errorf("hold not found")
+	}
+	if hold.Status != models.HoldStatusActive {
+		return nil, fmt.Errorf("hold is %s", hold.Status)
+	}
+
+	hold.Status = models.HoldStatusReleased
+	if err := s.holdRepo.UpdateStatus(tx, hold.ID, hold.Status); err != nil {
+		return nil, fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return hold, nil
+}
+
+// ExpireHolds marks active holds past their expiry as expired and returns how
+// many were expired. Expired holds stop counting against the available
+// balance as soon as they expire; this only brings their status up to date.
+func (s *WalletService) ExpireHolds() (int64, error) {
+	expired, err := s.holdRepo.ExpireDue()
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return expired, nil
+}
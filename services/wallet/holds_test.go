@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func TestCaptureHoldChecksTransferLimits(t *testing.T) {
+	p := newPayoutTest(t, Limits{MaxTransferAmount: models.NewMoney(10000, models.CurrencyNGN)})
+	sender, senderWallet := p.newWallet(50000)
+	_, recipient := p.newWallet(0)
+
+	hold, err := p.service.CreateHold(sender.ID, models.NewMoney(30000, models.CurrencyNGN), time.Time{}, "", "")
+	if err != nil {
+		t.Fatalf("CreateHold failed: %v", err)
+	}
+
+	_, err = p.service.CaptureHold(sender.ID, nil, hold.ID, recipient.WalletNumber, models.NewMoney(15000, models.CurrencyNGN))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Code != LimitCodeMaxTransferAmount {
+		t.Fatalf("capture over the limit: err = %v, want %s", err, LimitCodeMaxTransferAmount)
+	}
+
+	captured, err := p.service.CaptureHold(sender.ID, nil, hold.ID, recipient.WalletNumber, models.NewMoney(10000, models.CurrencyNGN))
+	if err != nil {
+		t.Fatalf("capture within the limit failed: %v", err)
+	}
+	if captured.CapturedAmount.Amount != 10000 {
+		t.Errorf("captured_amount = %d, want 10000", captured.CapturedAmount.Amount)
+	}
+	if captured.Status != models.HoldStatusActive {
+		t.Errorf("hold status = %s, want %s", captured.Status, models.HoldStatusActive)
+	}
+	if got := p.balance(senderWallet); got != 40000 {
+		t.Errorf("sender balance = %d, want 40000", got)
+	}
+	if got := p.balance(recipient); got != 10000 {
+		t.Errorf("recipient balance = %d, want 10000", got)
+	}
+}
+
+func TestCaptureHoldCountsTowardsHourlyTransfers(t *testing.T) {
+	p := newPayoutTest(t, Limits{MaxTransfersPerHour: 1})
+	sender, _ := p.newWallet(50000)
+	_, recipient := p.newWallet(0)
+
+	hold, err := p.service.CreateHold(sender.ID, models.NewMoney(20000, models.CurrencyNGN), time.Time{}, "", "")
+	if err != nil {
+		t.Fatalf("CreateHold failed: %v", err)
+	}
+	if _, err := p.service.CaptureHold(sender.ID, nil, hold.ID, recipient.WalletNumber, models.NewMoney(5000, models.CurrencyNGN)); err != nil {
+		t.Fatalf("first capture failed: %v", err)
+	}
+
+	_, err = p.service.CaptureHold(sender.ID, nil, hold.ID, recipient.WalletNumber, models.NewMoney(5000, models.CurrencyNGN))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Code != LimitCodeHourlyTransfers {
+		t.Fatalf("second capture: err = %v, want %s", err, LimitCodeHourlyTransfers)
+	}
+}
@@ -0,0 +1,36 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/kyc"
+)
+
+func TestTransferChecksKYCTierLimits(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	p.service.kycTiers = kyc.Tiers{
+		models.KYCTierEmail: {
+			MaxBalance:   models.NewMoney(30000, models.CurrencyNGN),
+			DailyOutflow: models.NewMoney(10000, models.CurrencyNGN),
+		},
+	}
+	sender, _ := p.newWallet(25000)
+	_, recipient := p.newWallet(25000)
+
+	_, err := p.service.Transfer(sender.ID, nil, recipient.WalletNumber, models.NewMoney(12000, models.CurrencyNGN))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Code != LimitCodeKYCDailyOutflow {
+		t.Errorf("transfer over the tier's daily limit: err = %v, want %s", err, LimitCodeKYCDailyOutflow)
+	}
+
+	_, err = p.service.Transfer(sender.ID, nil, recipient.WalletNumber, models.NewMoney(8000, models.CurrencyNGN))
+	if !errors.As(err, &limitErr) || limitErr.Code != LimitCodeRecipientMaxBalance {
+		t.Errorf("transfer over the recipient's maximum balance: err = %v, want %s", err, LimitCodeRecipientMaxBalance)
+	}
+
+	if _, err := p.service.Transfer(sender.ID, nil, recipient.WalletNumber, models.NewMoney(5000, models.CurrencyNGN)); err != nil {
+		t.Errorf("transfer within the tier's limits failed: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrLimitOwnerNotFound is returned when a limit override is set for a user
+// or API key that does not exist
+var ErrLimitOwnerNotFound = errors.New("user or API key not found")
+
+// SetLimitOverride sets the limits for the user or API key named in override
+// in its currency, replacing any override it already has there. Limits left
+// nil fall back to the configured defaults.
+func (s *WalletService) SetLimitOverride(override *models.LimitOverride) (*models.LimitOverride, error) {
+	if (override.UserID == nil) == (override.APIKeyID == nil) {
+		return nil, fmt.Errorf("a limit override is for either a user or an API key")
+	}
+	if !override.Currency.IsSupported() {
+		return nil, fmt.Errorf("unsupported currency %s", override.Currency)
+	}
+	for _, amount := range []*models.Money{override.MaxTransferAmount, override.DailyOutflow, override.MonthlyOutflow, override.APIKeyDailySpend} {
+		if amount == nil {
+			continue
+		}
+		if amount.IsNegative() {
+			return nil, fmt.Errorf("limits cannot be negative")
+		}
+		amount.Currency = override.Currency
+	}
+	if override.MaxTransfersPerHour != nil && *override.MaxTransfersPerHour < 0 {
+		return nil, fmt.Errorf("limits cannot be negative")
+	}
+
+	if err := s.limitOverrideRepo.Upsert(override); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return nil, ErrLimitOwnerNotFound
+		}
+		return nil, fmt.Errorf("failed to save limit override: %w", err)
+	}
+	return override, nil
+}
+
+// ListUserLimitOverrides returns a user's overrides in every currency
+func (s *WalletService) ListUserLimitOverrides(userID uuid.UUID) ([]models.LimitOverride, error) {
+	return s.limitOverrideRepo.ListForUser(userID)
+}
+
+// ListAPIKeyLimitOverrides returns an API key's overrides in every currency
+func (s *WalletService) ListAPIKeyLimitOverrides(apiKeyID uuid.UUID) ([]models.LimitOverride, error) {
+	return s.limitOverrideRepo.ListForAPIKey(apiKeyID)
+}
+
+// DeleteUserLimitOverride puts a user back on the default limits in currency
+func (s *WalletService) DeleteUserLimitOverride(userID uuid.UUID, currency models.Currency) error {
+	deleted, err := s.limitOverrideRepo.DeleteForUser(userID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete limit override: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("limit override not found")
+	}
+	return nil
+}
+
+// DeleteAPIKeyLimitOverride puts an API key back on the default limits in
+// currency
+func (s *WalletService) DeleteAPIKeyLimitOverride(apiKeyID uuid.UUID, currency models.Currency) error {
+	deleted, err := s.limitOverrideRepo.DeleteForAPIKey(apiKeyID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete limit override: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("limit override not found")
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package wallet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Limit error codes returned to clients
+const (
+	LimitCodeMaxTransferAmount = "max_transfer_amount_exceeded"
+	LimitCodeDailyOutflow      = "daily_limit_exceeded"
+	LimitCodeMonthlyOutflow    = "monthly_limit_exceeded"
+	LimitCodeAPIKeyDailySpend  = "api_key_daily_limit_exceeded"
+	LimitCodeHourlyTransfers   = "hourly_transfer_count_exceeded"
+	// KYC tier limits
+	LimitCodeKYCDailyOutflow     = "kyc_daily_limit_exceeded"
+	LimitCodeMaxBalance          = "max_balance_exceeded"
+	LimitCodeRecipientMaxBalance = "recipient_max_balance_exceeded"
+)
+
+// LimitError is returned when a transfer or withdrawal would break one of the
+// wallet's limits
+type LimitError struct {
+	Code    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// Limits caps what can leave a wallet through transfers and withdrawals. Zero
+// values are not enforced, and amount limits only apply to wallets in the
+// limit's currency. Daily and monthly totals reset at midnight UTC and
+// include fees. These are the defaults; a user or API key can have its own
+// limits through a models.LimitOverride.
+type Limits struct {
+	// Largest single transfer or withdrawal
+	MaxTransferAmount models.Money
+	// Total a wallet can send per day and per calendar month
+	DailyOutflow   models.Money
+	MonthlyOutflow models.Money
+	// Total a single API key can send per day
+	APIKeyDailySpend models.Money
+	// Transfers a wallet can make in any 60 minutes
+	MaxTransfersPerHour int
+}
+
+// LimitUsage is how much of an amount limit has been used. Limit and
+// Remaining are nil when the limit is not enforced.
+type LimitUsage struct {
+	Limit     *models.Money `json:"limit"`
+	Used      models.Money  `json:"used"`
+	Remaining *models.Money `json:"remaining"`
+}
+
+// CountUsage is how much of a count limit has been used. Limit and Remaining
+// are nil when the limit is not enforced.
+type CountUsage struct {
+	Limit     *int `json:"limit"`
+	Used      int  `json:"used"`
+	Remaining *int `json:"remaining"`
+}
+
+// LimitsStatus is a wallet's limits and what is left of them
+type LimitsStatus struct {
+	Currency          models.Currency `json:"currency"`
+	WalletNumber      string          `json:"wallet_number"`
+	MaxTransferAmount *models.Money   `json:"max_transfer_amount"`
+	Daily             LimitUsage      `json:"daily"`
+	Monthly           LimitUsage      `json:"monthly"`
+	// Limits set by the user's KYC tier
+	KYCTier    models.KYCTier `json:"kyc_tier"`
+	KYCDaily   LimitUsage     `json:"kyc_daily"`
+	MaxBalance *models.Money  `json:"max_balance"`
+	// Only reported for requests made with an API key
+	APIKeyDaily     *LimitUsage `json:"api_key_daily,omitempty"`
+	HourlyTransfers CountUsage  `json:"hourly_transfers"`
+}
+
+// withOverride returns l with the limits set by o in place of its own
+func (l Limits) withOverride(o *models.LimitOverride) Limits {
+	if o == nil {
+		return l
+	}
+	if o.MaxTransferAmount != nil {
+		l.MaxTransferAmount = *o.MaxTransferAmount
+	}
+	if o.DailyOutflow != nil {
+		l.DailyOutflow = *o.DailyOutflow
+	}
+	if o.MonthlyOutflow != nil {
+		l.MonthlyOutflow = *o.MonthlyOutflow
+	}
+	if o.APIKeyDailySpend != nil {
+		l.APIKeyDailySpend = *o.APIKeyDailySpend
+	}
+	if o.MaxTransfersPerHour != nil {
+		l.MaxTransfersPerHour = *o.MaxTransfersPerHour
+	}
+	return l
+}
+
+// effectiveLimits returns the limits on a user's wallet in currency: the
+// configured defaults, then the user's override, then the override of the
+// API key making the request, each replacing the limits it sets
+func (s *WalletService) effectiveLimits(q sqlx.Queryer, userID uuid.UUID, apiKeyID *uuid.UUID, currency models.Currency) (Limits, error) {
+	limits := s.limits
+
+	userOverride, err := s.limitOverrideRepo.GetForUser(q, userID, currency)
+	if err != nil {
+		return Limits{}, fmt.Errorf("failed to get user limit override: %w", err)
+	}
+	limits = limits.withOverride(userOverride)
+
+	if apiKeyID != nil {
+		keyOverride, err := s.limitOverrideRepo.GetForAPIKey(q, *apiKeyID, currency)
+		if err != nil {
+			return Limits{}, fmt.Errorf("failed to get API key limit override: %w", err)
+		}
+		limits = limits.withOverride(keyOverride)
+	}
+
+	return limits, nil
+}
+
+// outflowUsage is what a wallet, and the API key acting on it, has sent so far
+type outflowUsage struct {
+	daily, monthly, apiKeyDaily int64
+	transfersLastHour           int
+}
+
+// getOutflowUsage reads a wallet's outflows for the current day, month and
+// hour. The API key's outflow is counted in the wallet's currency. Inside a
+// transaction the wallet must already be locked so that concurrent outflows
+// cannot both pass the limits.
+func (s *WalletService) getOutflowUsage(q sqlx.Queryer, walletID uuid.UUID, currency models.Currency, apiKeyID *uuid.UUID, now time.Time) (*outflowUsage, error) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var usage outflowUsage
+	var err error
+	usage.daily, usage.monthly, usage.transfersLastHour, err = s.transactionRepo.GetOutflows(q, walletID, dayStart, monthStart, now.Add(-time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outflow totals: %w", err)
+	}
+
+	if apiKeyID != nil {
+		usage.apiKeyDaily, err = s.transactionRepo.GetAPIKeyOutflow(q, *apiKeyID, currency, dayStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get API key outflow: %w", err)
+		}
+	}
+
+	return &usage, nil
+}
+
+// checkLimits returns a *LimitError if sending amount (fees included as
+// total) from a locked wallet would break its owner's limits, including the
+// daily limit of the owner's KYC tier. isTransfer counts the outflow towards
+// the hourly transfer count.
+func (s *WalletService) checkLimits(tx *sqlx.Tx, walletID uuid.UUID, owner *models.User, apiKeyID *uuid.UUID, amount, total models.Money, isTransfer bool) error {
+	limits, err := s.effectiveLimits(tx, owner.ID, apiKeyID, amount.Currency)
+	if err != nil {
+		return err
+	}
+	tier := owner.KYCTier
+	tierLimits := s.kycTiers.For(tier)
+
+	if limits.MaxTransferAmount.IsPositive() && limits.MaxTransferAmount.SameCurrency(amount) && limits.MaxTransferAmount.LessThan(amount) {
+		return &LimitError{
+			Code:    LimitCodeMaxTransferAmount,
+			Message: fmt.Sprintf("amount exceeds the maximum of %s per transaction", limits.MaxTransferAmount),
+		}
+	}
+
+	usage, err := s.getOutflowUsage(tx, walletID, amount.Currency, apiKeyID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	exceeds := func(limit models.Money, used int64) bool {
+		return limit.IsPositive() && limit.SameCurrency(total) && used+total.Amount > limit.Amount
+	}
+	switch {
+	case exceeds(limits.DailyOutflow, usage.daily):
+		return &LimitError{
+			Code:    LimitCodeDailyOutflow,
+			Message: fmt.Sprintf("this would exceed the daily limit of %s", limits.DailyOutflow),
+		}
+	case exceeds(tierLimits.DailyOutflow, usage.daily):
+		return &LimitError{
+			Code:    LimitCodeKYCDailyOutflow,
+			Message: fmt.Sprintf("this would exceed the daily limit of %s for KYC tier %d; verify your identity to raise it", tierLimits.DailyOutflow, tier),
+		}
+	case exceeds(limits.MonthlyOutflow, usage.monthly):
+		return &LimitError{
+			Code:    LimitCodeMonthlyOutflow,
+			Message: fmt.Sprintf("this would exceed the monthly limit of %s", limits.MonthlyOutflow),
+		}
+	case apiKeyID != nil && exceeds(limits.APIKeyDailySpend, usage.apiKeyDaily):
+		return &LimitError{
+			Code:    LimitCodeAPIKeyDailySpend,
+			Message: fmt.Sprintf("this would exceed the API key's daily limit of %s", limits.APIKeyDailySpend),
+		}
+	case isTransfer && limits.MaxTransfersPerHour > 0 && usage.transfersLastHour >= limits.MaxTransfersPerHour:
+		return &LimitError{
+			Code:    LimitCodeHourlyTransfers,
+			Message: fmt.Sprintf("no more than %d transfers can be made per hour", limits.MaxTransfersPerHour),
+		}
+	}
+
+	return nil
+}
+
+// checkMaxBalance returns a *LimitError with code if crediting amount to a
+// wallet holding balance would take it over the maximum balance of the
+// owner's KYC tier. Wallets in other currencies have no maximum.
+func (s *WalletService) checkMaxBalance(owner *models.User, balance, amount models.Money, code string) error {
+	maxBalance := s.kycTiers.For(owner.KYCTier).MaxBalance
+	if !maxBalance.IsPositive() || !maxBalance.SameCurrency(balance) || !maxBalance.LessThan(balance.Add(amount)) {
+		return nil
+	}
+
+	message := fmt.Sprintf("this would take the wallet balance above the maximum of %s for KYC tier %d; verify your identity to raise it", maxBalance, owner.KYCTier)
+	if code == LimitCodeRecipientMaxBalance {
+		message = "the recipient's wallet cannot receive this amount"
+	}
+	return &LimitError{Code: code, Message: message}
+}
+
+// GetLimits returns the limits on each of a user's wallets and what is left
+// of them. apiKeyID is the key making the request, if any.
+func (s *WalletService) GetLimits(userID uuid.UUID, apiKeyID *uuid.UUID) ([]LimitsStatus, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	wallets, err := s.walletRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	statuses := make([]LimitsStatus, 0, len(wallets))
+	for _, wallet := range wallets {
+		status, err := s.getWalletLimits(user, &wallet, apiKeyID)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (s *WalletService) getWalletLimits(user *models.User, wallet *models.Wallet, apiKeyID *uuid.UUID) (*LimitsStatus, error) {
+	currency := wallet.Currency
+	limits, err := s.effectiveLimits(s.db, user.ID, apiKeyID, currency)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.getOutflowUsage(s.db, wallet.ID, currency, apiKeyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Limits in another currency do not apply to the wallet
+	inCurrency := func(limit models.Money) models.Money {
+		if limit.Currency != currency {
+			return models.NewMoney(0, currency)
+		}
+		return limit
+	}
+	tierLimits := s.kycTiers.For(user.KYCTier)
+	status := &LimitsStatus{
+		Currency:     currency,
+		WalletNumber: wallet.WalletNumber,
+		Daily:        newLimitUsage(inCurrency(limits.DailyOutflow), models.NewMoney(usage.daily, currency)),
+		Monthly:      newLimitUsage(inCurrency(limits.MonthlyOutflow), models.NewMoney(usage.monthly, currency)),
+		KYCTier:      user.KYCTier,
+		KYCDaily:     newLimitUsage(inCurrency(tierLimits.DailyOutflow), models.NewMoney(usage.daily, currency)),
+		HourlyTransfers: CountUsage{
+			Used: usage.transfersLastHour,
+		},
+	}
+	if max := inCurrency(limits.MaxTransferAmount); max.IsPositive() {
+		status.MaxTransferAmount = &max
+	}
+	if maxBalance := inCurrency(tierLimits.MaxBalance); maxBalance.IsPositive() {
+		status.MaxBalance = &maxBalance
+	}
+	if apiKeyID != nil {
+		apiKeyDaily := newLimitUsage(inCurrency(limits.APIKeyDailySpend), models.NewMoney(usage.apiKeyDaily, currency))
+		status.APIKeyDaily = &apiKeyDaily
+	}
+	if limit := limits.MaxTransfersPerHour; limit > 0 {
+		remaining := limit - usage.transfersLastHour
+		if remaining < 0 {
+			remaining = 0
+		}
+		status.HourlyTransfers.Limit = &limit
+		status.HourlyTransfers.Remaining = &remaining
+	}
+
+	return status, nil
+}
+
+func newLimitUsage(limit, used models.Money) LimitUsage {
+	usage := LimitUsage{Used: used}
+	if limit.IsPositive() {
+		remaining := limit.Sub(used)
+		if remaining.IsNegative() {
+			remaining = models.NewMoney(0, used.Currency)
+		}
+		usage.Limit = &limit
+		usage.Remaining = &remaining
+	}
+	return usage
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func TestLimitsWithOverride(t *testing.T) {
+	ngn := func(amount int64) *models.Money {
+		m := models.NewMoney(amount, models.CurrencyNGN)
+		return &m
+	}
+	count := func(n int) *int { return &n }
+
+	defaults := Limits{
+		MaxTransferAmount:   *ngn(500000),
+		DailyOutflow:        *ngn(1000000),
+		MonthlyOutflow:      *ngn(5000000),
+		APIKeyDailySpend:    *ngn(200000),
+		MaxTransfersPerHour: 10,
+	}
+
+	tests := []struct {
+		name      string
+		overrides []*models.LimitOverride
+		want      Limits
+	}{
+		{
+			name: "no override",
+			want: defaults,
+		},
+		{
+			name:      "user override replaces only the limits it sets",
+			overrides: []*models.LimitOverride{{DailyOutflow: ngn(2000000), MaxTransfersPerHour: count(50)}},
+			want: Limits{
+				MaxTransferAmount:   *ngn(500000),
+				DailyOutflow:        *ngn(2000000),
+				MonthlyOutflow:      *ngn(5000000),
+				APIKeyDailySpend:    *ngn(200000),
+				MaxTransfersPerHour: 50,
+			},
+		},
+		{
+			name:      "zero lifts a limit",
+			overrides: []*models.LimitOverride{{MaxTransferAmount: ngn(0), MaxTransfersPerHour: count(0)}},
+			want: Limits{
+				MaxTransferAmount: *ngn(0),
+				DailyOutflow:      *ngn(1000000),
+				MonthlyOutflow:    *ngn(5000000),
+				APIKeyDailySpend:  *ngn(200000),
+			},
+		},
+		{
+			name: "API key override takes precedence over the user's",
+			overrides: []*models.LimitOverride{
+				{DailyOutflow: ngn(2000000), APIKeyDailySpend: ngn(300000)},
+				{DailyOutflow: ngn(100000)},
+			},
+			want: Limits{
+				MaxTransferAmount:   *ngn(500000),
+				DailyOutflow:        *ngn(100000),
+				MonthlyOutflow:      *ngn(5000000),
+				APIKeyDailySpend:    *ngn(300000),
+				MaxTransfersPerHour: 10,
+			},
+		},
+		{
+			name:      "missing override keeps the limits so far",
+			overrides: []*models.LimitOverride{{MonthlyOutflow: ngn(0)}, nil},
+			want: Limits{
+				MaxTransferAmount:   *ngn(500000),
+				DailyOutflow:        *ngn(1000000),
+				MonthlyOutflow:      *ngn(0),
+				APIKeyDailySpend:    *ngn(200000),
+				MaxTransfersPerHour: 10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := defaults
+		for _, o := range tt.overrides {
+			got = got.withOverride(o)
+		}
+		if got != tt.want {
+			t.Errorf("%s: limits = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
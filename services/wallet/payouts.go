@@ -0,0 +1,573 @@
+package wallet
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/fees"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// MaxPayoutRows is the most rows a bulk payout can have
+	MaxPayoutRows = 1000
+	// MaxNarrationLength is the longest a payout row's narration can be
+	MaxNarrationLength = 100
+	// Batches claimed per run of the payout job
+	payoutBatchSize = 5
+	// How long a claimed batch is hidden from other workers. Paying a row
+	// extends it, so only a stopped worker lets it lapse.
+	payoutLease = 5 * time.Minute
+)
+
+// ErrDuplicatePayoutReference is returned when a user submits a second batch
+// with a reference they have already used
+var ErrDuplicatePayoutReference = errors.New("a payout batch with this reference already exists")
+
+// PayoutRow is one transfer in a bulk payout
+type PayoutRow struct {
+	WalletNumber string       `json:"wallet_number"`
+	Amount       models.Money `json:"amount"`
+	Narration    string       `json:"narration"`
+}
+
+// PayoutRowError is why a row of a bulk payout was rejected. Rows are
+// numbered from 1 in the order they were submitted.
+type PayoutRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// PayoutValidationError is returned when rows of a bulk payout are invalid.
+// It lists every invalid row, and nothing is queued.
+type PayoutValidationError struct {
+	Rows []PayoutRowError
+}
+
+func (e *PayoutValidationError) Error() string {
+	return fmt.Sprintf("%d of the payout rows are invalid", len(e.Rows))
+}
+
+// PayoutBatchDetails is a batch and the outcome of each of its rows
+type PayoutBatchDetails struct {
+	*models.PayoutBatch
+	Items []models.PayoutItem `json:"items"`
+}
+
+// ParsePayoutCSV reads bulk payout rows from a CSV file. The first line is a
+// header naming the wallet_number, amount and, optionally, narration columns
+// in any order, each once. Amounts are decimals in currency, e.g. 1500.50. Rows whose
+// amount cannot be read are reported together in a *PayoutValidationError.
+func ParsePayoutCSV(r io.Reader, currency models.Currency) ([]PayoutRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save a byte order mark before the first column
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; ok && name != "" {
+			return nil, fmt.Errorf("CSV header has more than one %s column", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"wallet_number", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header must have a %s column", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []PayoutRow
+	var invalid []PayoutRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(rows) == MaxPayoutRows {
+			return nil, fmt.Errorf("a payout can have at most %d rows", MaxPayoutRows)
+		}
+
+		amount, err := models.ParseMoney(field(record, "amount"), currency)
+		if err != nil {
+			invalid = append(invalid, PayoutRowError{Row: len(rows) + 1, Error: err.Error()})
+		}
+		rows = append(rows, PayoutRow{
+			WalletNumber: field(record, "wallet_number"),
+			Amount:       amount,
+			Narration:    field(record, "narration"),
+		})
+	}
+
+	if len(invalid) > 0 {
+		return nil, &PayoutValidationError{Rows: invalid}
+	}
+	return rows, nil
+}
+
+// CreatePayoutBatch checks a bulk payout from the user's wallet in currency
+// and queues it to be paid in the background, each row as its own transfer
+// with the usual fee. Every row is checked before anything is queued and all
+// invalid rows are reported together in a *PayoutValidationError. The wallet
+// must be able to cover every row and its fee when the batch is submitted;
+// the balance and the usual limits are checked again as each row is paid.
+// A reference, if given, must not have been used on another of the user's
+// batches. apiKeyID is the API key submitting the batch, if any.
+func (s *WalletService) CreatePayoutBatch(
+	userID uuid.UUID,
+	apiKeyID *uuid.UUID,
+	currency models.Currency,
+	mode models.PayoutBatchMode,
+	reference string,
+	rows []PayoutRow,
+) (*PayoutBatchDetails, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("mode must be %q or %q", models.PayoutBatchModeAllOrNothing, models.PayoutBatchModeBestEffort)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("a payout needs at least one row")
+	}
+	if len(rows) > MaxPayoutRows {
+		return nil, fmt.Errorf("a payout can have at most %d rows", MaxPayoutRows)
+	}
+
+	senderWallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet: %w", currency, err)
+	}
+	limits, err := s.effectiveLimits(s.db, userID, apiKeyID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.PayoutItem, 0, len(rows))
+	total := models.NewMoney(0, currency)
+	totalFee := models.NewMoney(0, currency)
+	recipients := make(map[string]*models.Wallet)
+	var invalid []PayoutRowError
+	for i, row := range rows {
+		row.WalletNumber = strings.TrimSpace(row.WalletNumber)
+		row.Narration = strings.TrimSpace(row.Narration)
+		if err := s.validatePayoutRow(senderWallet, limits.MaxTransferAmount, row, recipients); err != nil {
+			invalid = append(invalid, PayoutRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+
+		quote := s.QuoteFee(fees.KindTransfer, row.Amount)
+		item := models.PayoutItem{
+			RowNumber:    i + 1,
+			WalletNumber: row.WalletNumber,
+			Amount:       row.Amount,
+			Fee:          quote.Fee,
+		}
+		if narration := row.Narration; narration != "" {
+			item.Narration = &narration
+		}
+		items = append(items, item)
+		total = total.Add(row.Amount)
+		totalFee = totalFee.Add(quote.Fee)
+	}
+	if len(invalid) > 0 {
+		return nil, &PayoutValidationError{Rows: invalid}
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	available, err := s.lockAvailableBalance(tx, senderWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	if available.LessThan(total.Add(totalFee)) {
+		return nil, fmt.Errorf("%w: the payout needs %s including fees", ErrInsufficientBalance, total.Add(totalFee))
+	}
+
+	batch := &models.PayoutBatch{
+		UserID:       userID,
+		WalletID:     senderWallet.ID,
+		WalletNumber: senderWallet.WalletNumber,
+		Mode:         mode,
+		Status:       models.PayoutBatchStatusPending,
+		ItemCount:    len(items),
+		TotalAmount:  total,
+		TotalFee:     totalFee,
+		APIKeyID:     apiKeyID,
+	}
+	if reference != "" {
+		batch.Reference = &reference
+	}
+	if err := s.payoutRepo.Create(tx, batch, items); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrDuplicatePayoutReference
+		}
+		return nil, fmt.Errorf("failed to create payout batch: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &PayoutBatchDetails{PayoutBatch: batch, Items: items}, nil
+}
+
+// validatePayoutRow checks a trimmed row against the sender's wallet and the
+// largest transfer the sender may make. recipients caches wallets already
+// looked up for earlier rows.
+func (s *WalletService) validatePayoutRow(sender *models.Wallet, max models.Money, row PayoutRow, recipients map[string]*models.Wallet) error {
+	switch {
+	case row.WalletNumber == "":
+		return fmt.Errorf("wallet_number is required")
+	case !row.Amount.IsPositive():
+		return fmt.Errorf("amount must be greater than zero")
+	case row.Amount.Currency != sender.Currency:
+		return fmt.Errorf("amount is in %s, not %s", row.Amount.Currency, sender.Currency)
+	case len(row.Narration) > MaxNarrationLength:
+		return fmt.Errorf("narration must be at most %d characters", MaxNarrationLength)
+	}
+
+	if max.IsPositive() && max.SameCurrency(row.Amount) && max.LessThan(row.Amount) {
+		return fmt.Errorf("amount exceeds the maximum of %s per transaction", max)
+	}
+
+	recipient, ok := recipients[row.WalletNumber]
+	if !ok {
+		var err error
+		recipient, err = s.walletRepo.GetByWalletNumber(row.WalletNumber)
+		if err != nil {
+			return fmt.Errorf("recipient wallet not found: %w", err)
+		}
+		recipients[row.WalletNumber] = recipient
+	}
+	if recipient.Currency != sender.Currency {
+		return fmt.Errorf("%w: recipient wallet is in %s, not %s", ErrCrossCurrencyTransfer, recipient.Currency, sender.Currency)
+	}
+	if recipient.ID == sender.ID {
+		return fmt.Errorf("cannot transfer to your own wallet")
+	}
+	return nil
+}
+
+// GetPayoutBatch gets one of a user's batches with its rows, optionally only
+// the rows in itemStatus
+func (s *WalletService) GetPayoutBatch(userID, batchID uuid.UUID, itemStatus string) (*PayoutBatchDetails, error) {
+	batch, err := s.payoutRepo.GetByID(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.UserID != userID {
+		return nil, fmt.Errorf("payout batch not found")
+	}
+	items, err := s.payoutRepo.ListItems(batchID, itemStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout items: %w", err)
+	}
+	return &PayoutBatchDetails{PayoutBatch: batch, Items: items}, nil
+}
+
+// ListPayoutBatches lists a user's batches, optionally filtered by status
+func (s *WalletService) ListPayoutBatches(userID uuid.UUID, status string, limit, offset int) ([]models.PayoutBatch, error) {
+	return s.payoutRepo.ListByUser(userID, status, limit, offset)
+}
+
+// ProcessPayouts pays the rows of queued batches and returns how many
+// batches it finished. A batch left half done by a stopped worker is picked
+// up again once its lease lapses; rows already paid are not paid again. A
+// failure in one batch is logged and does not stop the others.
+func (s *WalletService) ProcessPayouts(ctx context.Context) (int, error) {
+	batches, err := s.payoutRepo.ClaimRunnable(payoutBatchSize, payoutLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim payout batches: %w", err)
+	}
+
+	finished := 0
+	for i := range batches {
+		if ctx.Err() != nil {
+			return finished, ctx.Err()
+		}
+		batch := &batches[i]
+		if batch.Mode == models.PayoutBatchModeAllOrNothing {
+			err = s.payAllOrNothing(batch)
+		} else {
+			err = s.payBestEffort(ctx, batch)
+		}
+		if err != nil {
+			log.Printf("Failed to process payout batch %s: %v", batch.ID, err)
+			continue
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+// payAllOrNothing pays every row of a batch in one database transaction. If
+// any row cannot be paid none is, and the batch fails naming that row.
+func (s *WalletService) payAllOrNothing(batch *models.PayoutBatch) error {
+	items, err := s.payoutRepo.ListItems(batch.ID, string(models.PayoutItemStatusPending))
+	if err != nil {
+		return fmt.Errorf("failed to get payout items: %w", err)
+	}
+	senderWallet, sender, err := s.payoutSender(batch)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	available, err := s.lockAvailableBalance(tx, senderWallet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	for i := range items {
+		item := &items[i]
+		debit, err := s.payPayoutItem(tx, batch, senderWallet, sender, item, available)
+		if err != nil {
+			tx.Rollback()
+			return s.failPayoutBatch(batch, item, err)
+		}
+		available = available.Sub(item.Amount.Add(item.Fee))
+
+		now := time.Now()
+		item.Status = models.PayoutItemStatusSuccess
+		item.TransactionID = &debit.ID
+		item.ProcessedAt = &now
+		if err := s.payoutRepo.RecordItem(tx, item, payoutLease); err != nil {
+			return fmt.Errorf("failed to record payout item: %w", err)
+		}
+	}
+
+	if err := s.payoutRepo.Finish(tx, batch.ID); err != nil {
+		return fmt.Errorf("failed to finish payout batch: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// failPayoutBatch records why a row of an all-or-nothing batch could not be
+// paid and fails the batch, skipping its other rows
+func (s *WalletService) failPayoutBatch(batch *models.PayoutBatch, item *models.PayoutItem, cause error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	message := cause.Error()
+	item.Status = models.PayoutItemStatusFailed
+	item.Error = &message
+	item.ProcessedAt = &now
+	if err := s.payoutRepo.RecordItem(tx, item, payoutLease); err != nil {
+		return fmt.Errorf("failed to record payout item: %w", err)
+	}
+	if err := s.payoutRepo.Fail(tx, batch.ID, fmt.Sprintf("row %d: %s", item.RowNumber, message)); err != nil {
+		return fmt.Errorf("failed to fail payout batch: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// payBestEffort pays the unpaid rows of a batch one at a time, each in its
+// own database transaction, recording a failed row and moving on
+func (s *WalletService) payBestEffort(ctx context.Context, batch *models.PayoutBatch) error {
+	items, err := s.payoutRepo.ListItems(batch.ID, string(models.PayoutItemStatusPending))
+	if err != nil {
+		return fmt.Errorf("failed to get payout items: %w", err)
+	}
+	senderWallet, sender, err := s.payoutSender(batch)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		item := &items[i]
+		if err := s.payBestEffortItem(batch, senderWallet, sender, item); err != nil {
+			if err := s.recordPayoutItemFailure(item, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.payoutRepo.Finish(s.db, batch.ID); err != nil {
+		return fmt.Errorf("failed to finish payout batch: %w", err)
+	}
+	return nil
+}
+
+// payBestEffortItem pays one row of a best-effort batch and records it. A
+// row another worker has already recorded is left alone.
+func (s *WalletService) payBestEffortItem(batch *models.PayoutBatch, senderWallet *models.Wallet, sender *models.User, item *models.PayoutItem) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The wallet is locked before the row, as everywhere else
+	available, err := s.lockAvailableBalance(tx, senderWallet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	locked, err := s.payoutRepo.GetItemForUpdate(tx, item.ID)
+	if err != nil {
+		return err
+	}
+	if locked.Status != models.PayoutItemStatusPending {
+		return nil
+	}
+
+	debit, err := s.payPayoutItem(tx, batch, senderWallet, sender, item, available)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	item.Status = models.PayoutItemStatusSuccess
+	item.TransactionID = &debit.ID
+	item.ProcessedAt = &now
+	if err := s.payoutRepo.RecordItem(tx, item, payoutLease); err != nil {
+		return fmt.Errorf("failed to record payout item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// recordPayoutItemFailure records why a row of a best-effort batch was not
+// paid, unless it has been recorded already
+func (s *WalletService) recordPayoutItemFailure(item *models.PayoutItem, cause error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	locked, err := s.payoutRepo.GetItemForUpdate(tx, item.ID)
+	if err != nil {
+		return err
+	}
+	if locked.Status != models.PayoutItemStatusPending {
+		return nil
+	}
+
+	now := time.Now()
+	message := cause.Error()
+	item.Status = models.PayoutItemStatusFailed
+	item.Error = &message
+	item.ProcessedAt = &now
+	if err := s.payoutRepo.RecordItem(tx, item, payoutLease); err != nil {
+		return fmt.Errorf("failed to record payout item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// payoutSender gets the wallet a batch is paid from and its owner
+func (s *WalletService) payoutSender(batch *models.PayoutBatch) (*models.Wallet, *models.User, error) {
+	senderWallet, err := s.walletRepo.GetByID(batch.WalletID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	sender, err := s.userRepo.GetByID(batch.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return senderWallet, sender, nil
+}
+
+// payPayoutItem pays one row from the locked sender wallet, which has
+// available to spend, as a transfer with the fee quoted when the batch was
+// submitted. The balance, the sender's limits and the recipient's maximum
+// balance are checked as for any transfer, except the hourly transfer limit:
+// a batch is one request, so its rows are not held to a per-transfer rate.
+// Paid rows still count towards the hourly total of later transfers.
+func (s *WalletService) payPayoutItem(
+	tx *sqlx.Tx,
+	batch *models.PayoutBatch,
+	senderWallet *models.Wallet,
+	sender *models.User,
+	item *models.PayoutItem,
+	available models.Money,
+) (*models.Transaction, error) {
+	total := item.Amount.Add(item.Fee)
+	if available.LessThan(total) {
+		return nil, ErrInsufficientBalance
+	}
+	if err := s.checkLimits(tx, senderWallet.ID, sender, batch.APIKeyID, item.Amount, total, false); err != nil {
+		return nil, err
+	}
+
+	recipientWallet, err := s.walletRepo.GetByWalletNumber(item.WalletNumber)
+	if err != nil {
+		return nil, fmt.Errorf("recipient wallet not found: %w", err)
+	}
+	recipient, err := s.userRepo.GetByID(recipientWallet.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient: %w", err)
+	}
+	recipientBalance, err := s.walletRepo.GetBalanceForUpdate(tx, recipientWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient balance: %w", err)
+	}
+	if err := s.checkMaxBalance(recipient, recipientBalance, item.Amount, LimitCodeRecipientMaxBalance); err != nil {
+		return nil, err
+	}
+
+	narration := ""
+	if item.Narration != nil {
+		narration = *item.Narration
+	}
+	baseReference := fmt.Sprintf("PYT_%s_%d", uuid.New().String()[:8], time.Now().Unix())
+	debit, _, err := s.postTransfer(tx, senderWallet, recipientWallet, item.Amount, item.Fee, batch.APIKeyID, baseReference, "Payout", narration)
+	if err != nil {
+		return nil, err
+	}
+	return debit, nil
+}
@@ -0,0 +1,381 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/internal/testdb"
+	"github.com/brainox/paystack_wallet_service/services/ledger"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/google/uuid"
+)
+
+func TestParsePayoutCSV(t *testing.T) {
+	tests := []struct {
+		name        string
+		csv         string
+		want        []PayoutRow
+		wantErr     string
+		invalidRows []int
+	}{
+		{
+			name: "header in order",
+			csv:  "wallet_number,amount,narration\n4566678954356,250000.00,January salary\n4566678954357,1500.5,\n",
+			want: []PayoutRow{
+				{WalletNumber: "4566678954356", Amount: models.NewMoney(25000000, models.CurrencyNGN), Narration: "January salary"},
+				{WalletNumber: "4566678954357", Amount: models.NewMoney(150050, models.CurrencyNGN)},
+			},
+		},
+		{
+			name: "columns in another order without narration",
+			csv:  "Amount , WALLET_NUMBER\n10,4566678954356\n",
+			want: []PayoutRow{{WalletNumber: "4566678954356", Amount: models.NewMoney(1000, models.CurrencyNGN)}},
+		},
+		{
+			name: "byte order mark, extra columns and short rows",
+			csv:  "\ufeffwallet_number,amount,narration,department\n4566678954356,10,Bonus,Sales\n4566678954357,20\n",
+			want: []PayoutRow{
+				{WalletNumber: "4566678954356", Amount: models.NewMoney(1000, models.CurrencyNGN), Narration: "Bonus"},
+				{WalletNumber: "4566678954357", Amount: models.NewMoney(2000, models.CurrencyNGN)},
+			},
+		},
+		{
+			name: "quoted narration with a comma",
+			csv:  "wallet_number,amount,narration\n4566678954356,10,\"Salary, January\"\n",
+			want: []PayoutRow{{WalletNumber: "4566678954356", Amount: models.NewMoney(1000, models.CurrencyNGN), Narration: "Salary, January"}},
+		},
+		{
+			name: "header only",
+			csv:  "wallet_number,amount\n",
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantErr: "CSV file is empty",
+		},
+		{
+			name:    "missing amount column",
+			csv:     "wallet_number,narration\n4566678954356,Bonus\n",
+			wantErr: "must have a amount column",
+		},
+		{
+			name:    "missing wallet_number column",
+			csv:     "account,amount\n4566678954356,10\n",
+			wantErr: "must have a wallet_number column",
+		},
+		{
+			name:    "no header",
+			csv:     "4566678954356,10,Bonus\n",
+			wantErr: "must have a wallet_number column",
+		},
+		{
+			name:    "duplicate column",
+			csv:     "wallet_number,amount,Amount\n4566678954356,10,20\n",
+			wantErr: "more than one amount column",
+		},
+		{
+			name:    "unterminated quote",
+			csv:     "wallet_number,amount\n4566678954356,\"10\n",
+			wantErr: "failed to read CSV",
+		},
+		{
+			name:        "bad amounts are reported together",
+			csv:         "wallet_number,amount\n4566678954356,10\n4566678954357,ten\n4566678954358,1.001\n4566678954359,\n4566678954360,5\n",
+			wantErr:     "3 of the payout rows are invalid",
+			invalidRows: []int{2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		rows, err := ParsePayoutCSV(strings.NewReader(tt.csv), models.CurrencyNGN)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: ParsePayoutCSV = %v, want an error containing %q", tt.name, err, tt.wantErr)
+				continue
+			}
+			var validationErr *PayoutValidationError
+			if errors.As(err, &validationErr) != (tt.invalidRows != nil) {
+				t.Errorf("%s: error %v is not a *PayoutValidationError", tt.name, err)
+				continue
+			}
+			if validationErr != nil {
+				var got []int
+				for _, row := range validationErr.Rows {
+					got = append(got, row.Row)
+				}
+				if !equalInts(got, tt.invalidRows) {
+					t.Errorf("%s: invalid rows = %v, want %v", tt.name, got, tt.invalidRows)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParsePayoutCSV failed: %v", tt.name, err)
+			continue
+		}
+		if len(rows) != len(tt.want) {
+			t.Errorf("%s: got %d rows, want %d", tt.name, len(rows), len(tt.want))
+			continue
+		}
+		for i := range rows {
+			if rows[i] != tt.want[i] {
+				t.Errorf("%s: row %d = %+v, want %+v", tt.name, i+1, rows[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParsePayoutCSVRowLimit(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("wallet_number,amount\n")
+	for i := 0; i < MaxPayoutRows+1; i++ {
+		b.WriteString("4566678954356,10\n")
+	}
+	if _, err := ParsePayoutCSV(strings.NewReader(b.String()), models.CurrencyNGN); err == nil {
+		t.Errorf("ParsePayoutCSV accepted %d rows", MaxPayoutRows+1)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// payoutTest is a wallet service on the test database with helpers to set
+// up wallets
+type payoutTest struct {
+	t          *testing.T
+	service    *WalletService
+	userRepo   *repository.UserRepository
+	walletRepo *repository.WalletRepository
+}
+
+func newPayoutTest(t *testing.T, limits Limits) *payoutTest {
+	db := testdb.Open(t)
+	userRepo := repository.NewUserRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
+	ledgerService := ledger.NewLedgerService(db, repository.NewLedgerRepository(db), walletRepo)
+	service := NewWalletService(
+		db,
+		walletRepo,
+		repository.NewTransactionRepository(db),
+		repository.NewHoldRepository(db),
+		repository.NewPocketRepository(db),
+		repository.NewFXQuoteRepository(db),
+		repository.NewEscrowRepository(db),
+		repository.NewPayoutRepository(db),
+		repository.NewBeneficiaryRepository(db),
+		userRepo,
+		repository.NewLimitOverrideRepository(db),
+		ledgerService,
+		nil,
+		nil,
+		nil,
+		limits,
+		nil,
+		nil,
+		ReversalPolicy{},
+	)
+	return &payoutTest{t: t, service: service, userRepo: userRepo, walletRepo: walletRepo}
+}
+
+// newWallet creates a user with an NGN wallet holding balance, credited
+// from Paystack clearing as a deposit would be
+func (p *payoutTest) newWallet(balance int64) (*models.User, *models.Wallet) {
+	p.t.Helper()
+	user := &models.User{Email: "payout-" + uuid.NewString() + "@example.com", Name: "Payout Test"}
+	if err := p.userRepo.Create(user); err != nil {
+		p.t.Fatalf("failed to create user: %v", err)
+	}
+	wallet := &models.Wallet{UserID: user.ID, Balance: models.NewMoney(0, models.CurrencyNGN)}
+	if err := p.walletRepo.Create(wallet); err != nil {
+		p.t.Fatalf("failed to create wallet: %v", err)
+	}
+	if balance == 0 {
+		return user, wallet
+	}
+
+	tx, err := p.service.db.Beginx()
+	if err != nil {
+		p.t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	clearing, err := p.service.ledgerService.SystemAccount(tx, models.LedgerAccountPaystackClearing, models.CurrencyNGN)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	account, err := p.service.ledgerService.WalletAccount(tx, wallet.ID)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if _, err := p.service.ledgerService.Move(tx, "test-"+uuid.NewString(), "test funding", clearing, account, models.NewMoney(balance, models.CurrencyNGN)); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		p.t.Fatal(err)
+	}
+	return user, wallet
+}
+
+func (p *payoutTest) balance(wallet *models.Wallet) int64 {
+	p.t.Helper()
+	got, err := p.walletRepo.GetByID(wallet.ID)
+	if err != nil {
+		p.t.Fatalf("failed to get wallet: %v", err)
+	}
+	return got.Balance.Amount
+}
+
+// run processes queued batches until batchID has finished
+func (p *payoutTest) run(userID, batchID uuid.UUID) *PayoutBatchDetails {
+	p.t.Helper()
+	for i := 0; i < 20; i++ {
+		if _, err := p.service.ProcessPayouts(context.Background()); err != nil {
+			p.t.Fatalf("ProcessPayouts failed: %v", err)
+		}
+		details, err := p.service.GetPayoutBatch(userID, batchID, "")
+		if err != nil {
+			p.t.Fatalf("GetPayoutBatch failed: %v", err)
+		}
+		switch details.Status {
+		case models.PayoutBatchStatusPending, models.PayoutBatchStatusProcessing:
+			continue
+		}
+		return details
+	}
+	p.t.Fatalf("payout batch %s did not finish", batchID)
+	return nil
+}
+
+// The daily limit lets the first row through and stops the second, which
+// is only found out when the batch is paid
+var payoutTestLimits = Limits{DailyOutflow: models.NewMoney(15000, models.CurrencyNGN)}
+
+func TestPayoutAllOrNothingPaysNothingWhenARowFails(t *testing.T) {
+	p := newPayoutTest(t, payoutTestLimits)
+	sender, senderWallet := p.newWallet(50000)
+	_, first := p.newWallet(0)
+	_, second := p.newWallet(0)
+
+	batch, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeAllOrNothing, "", []PayoutRow{
+		{WalletNumber: first.WalletNumber, Amount: models.NewMoney(10000, models.CurrencyNGN)},
+		{WalletNumber: second.WalletNumber, Amount: models.NewMoney(10000, models.CurrencyNGN)},
+	})
+	if err != nil {
+		t.Fatalf("CreatePayoutBatch failed: %v", err)
+	}
+
+	details := p.run(sender.ID, batch.ID)
+	if details.Status != models.PayoutBatchStatusFailed {
+		t.Errorf("batch status = %s, want %s", details.Status, models.PayoutBatchStatusFailed)
+	}
+	if details.SucceededCount != 0 {
+		t.Errorf("succeeded_count = %d, want 0", details.SucceededCount)
+	}
+	wantStatuses := []models.PayoutItemStatus{models.PayoutItemStatusSkipped, models.PayoutItemStatusFailed}
+	for i, item := range details.Items {
+		if item.Status != wantStatuses[i] {
+			t.Errorf("row %d status = %s, want %s", item.RowNumber, item.Status, wantStatuses[i])
+		}
+	}
+	if got := p.balance(senderWallet); got != 50000 {
+		t.Errorf("sender balance = %d, want 50000", got)
+	}
+	if got := p.balance(first); got != 0 {
+		t.Errorf("first recipient balance = %d, want 0", got)
+	}
+}
+
+func TestPayoutBestEffortPaysTheRowsItCan(t *testing.T) {
+	p := newPayoutTest(t, payoutTestLimits)
+	sender, senderWallet := p.newWallet(50000)
+	_, first := p.newWallet(0)
+	_, second := p.newWallet(0)
+
+	batch, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, "", []PayoutRow{
+		{WalletNumber: first.WalletNumber, Amount: models.NewMoney(10000, models.CurrencyNGN)},
+		{WalletNumber: second.WalletNumber, Amount: models.NewMoney(10000, models.CurrencyNGN)},
+	})
+	if err != nil {
+		t.Fatalf("CreatePayoutBatch failed: %v", err)
+	}
+
+	details := p.run(sender.ID, batch.ID)
+	if details.Status != models.PayoutBatchStatusPartiallyCompleted {
+		t.Errorf("batch status = %s, want %s", details.Status, models.PayoutBatchStatusPartiallyCompleted)
+	}
+	if details.SucceededCount != 1 || details.FailedCount != 1 {
+		t.Errorf("succeeded_count = %d, failed_count = %d, want 1 and 1", details.SucceededCount, details.FailedCount)
+	}
+	wantStatuses := []models.PayoutItemStatus{models.PayoutItemStatusSuccess, models.PayoutItemStatusFailed}
+	for i, item := range details.Items {
+		if item.Status != wantStatuses[i] {
+			t.Errorf("row %d status = %s, want %s", item.RowNumber, item.Status, wantStatuses[i])
+		}
+	}
+	if got := p.balance(senderWallet); got != 40000 {
+		t.Errorf("sender balance = %d, want 40000", got)
+	}
+	if got := p.balance(first); got != 10000 {
+		t.Errorf("first recipient balance = %d, want 10000", got)
+	}
+	if got := p.balance(second); got != 0 {
+		t.Errorf("second recipient balance = %d, want 0", got)
+	}
+}
+
+func TestCreatePayoutBatchRejectsDuplicateReference(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	sender, _ := p.newWallet(50000)
+	other, _ := p.newWallet(50000)
+	_, recipient := p.newWallet(0)
+	rows := []PayoutRow{{WalletNumber: recipient.WalletNumber, Amount: models.NewMoney(1000, models.CurrencyNGN)}}
+	reference := "PAYROLL-" + uuid.NewString()
+
+	if _, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, reference, rows); err != nil {
+		t.Fatalf("CreatePayoutBatch failed: %v", err)
+	}
+	if _, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, reference, rows); !errors.Is(err, ErrDuplicatePayoutReference) {
+		t.Errorf("second batch with reference %s = %v, want ErrDuplicatePayoutReference", reference, err)
+	}
+	if _, err := p.service.CreatePayoutBatch(other.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, reference, rows); err != nil {
+		t.Errorf("another user's batch with reference %s failed: %v", reference, err)
+	}
+	if _, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, "", rows); err != nil {
+		t.Errorf("batch without a reference failed: %v", err)
+	}
+	if _, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, "", rows); err != nil {
+		t.Errorf("second batch without a reference failed: %v", err)
+	}
+}
+
+func TestCreatePayoutBatchUsesTheSendersLimitOverride(t *testing.T) {
+	p := newPayoutTest(t, Limits{MaxTransferAmount: models.NewMoney(5000, models.CurrencyNGN)})
+	sender, _ := p.newWallet(50000)
+	_, recipient := p.newWallet(0)
+	rows := []PayoutRow{{WalletNumber: recipient.WalletNumber, Amount: models.NewMoney(10000, models.CurrencyNGN)}}
+
+	_, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, "", rows)
+	var validationErr *PayoutValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("batch over the default limit = %v, want a PayoutValidationError", err)
+	}
+
+	max := models.NewMoney(20000, models.CurrencyNGN)
+	override := &models.LimitOverride{UserID: &sender.ID, Currency: models.CurrencyNGN, MaxTransferAmount: &max}
+	if err := p.service.limitOverrideRepo.Upsert(override); err != nil {
+		t.Fatalf("failed to create limit override: %v", err)
+	}
+	if _, err := p.service.CreatePayoutBatch(sender.ID, nil, models.CurrencyNGN, models.PayoutBatchModeBestEffort, "", rows); err != nil {
+		t.Errorf("batch within the overridden limit failed: %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxPocketsPerWallet is the most pockets a wallet can have
+	MaxPocketsPerWallet = 10
+	// MaxPocketNameLength is the longest a pocket name can be
+	MaxPocketNameLength = 50
+)
+
+// ErrPocketLocked is returned when money is taken out of a locked pocket
+var ErrPocketLocked = errors.New("pocket is locked")
+
+// PocketUpdate changes a pocket's settings. Nil fields are left as they are.
+// A zero TargetAmount or TargetDate removes the target. A lock can be added
+// or extended but not shortened.
+type PocketUpdate struct {
+	Name         *string
+	TargetAmount *models.Money
+	TargetDate   *time.Time
+	LockedUntil  *time.Time
+}
+
+// CreatePocket adds a named pocket to the user's wallet in a currency.
+// targetAmount, targetDate and lockedUntil are optional.
+func (s *WalletService) CreatePocket(userID uuid.UUID, currency models.Currency, name string, targetAmount *models.Money, targetDate, lockedUntil *time.Time) (*models.Pocket, error) {
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("you do not have a %s wallet: %w", currency, err)
+	}
+
+	pockets, err := s.pocketRepo.ListByWallet(wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pockets: %w", err)
+	}
+	if len(pockets) >= MaxPocketsPerWallet {
+		return nil, fmt.Errorf("a wallet can have at most %d pockets", MaxPocketsPerWallet)
+	}
+
+	pocket := &models.Pocket{
+		WalletID: wallet.ID,
+		UserID:   userID,
+		Balance:  models.NewMoney(0, wallet.Currency),
+	}
+	if err := s.applyPocketUpdate(pocket, pockets, PocketUpdate{
+		Name:         &name,
+		TargetAmount: targetAmount,
+		TargetDate:   targetDate,
+		LockedUntil:  lockedUntil,
+	}, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.pocketRepo.Create(pocket); err != nil {
+		return nil, fmt.Errorf("failed to create pocket: %w", err)
+	}
+	return pocket, nil
+}
+
+// ListPockets lists the pockets on the user's wallet in a currency
+func (s *WalletService) ListPockets(userID uuid.UUID, currency models.Currency) ([]models.Pocket, error) {
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return s.pocketRepo.ListByWallet(wallet.ID)
+}
+
+// GetPocket gets one of a user's pockets
+func (s *WalletService) GetPocket(userID, pocketID uuid.UUID) (*models.Pocket, error) {
+	pocket, err := s.pocketRepo.GetByID(pocketID)
+	if err != nil {
+		return nil, err
+	}
+	if pocket.UserID != userID {
+		return nil, fmt.Errorf("pocket not found")
+	}
+	return pocket, nil
+}
+
+// UpdatePocket renames a pocket or changes its target or lock
+func (s *WalletService) UpdatePocket(userID, pocketID uuid.UUID, update PocketUpdate) (*models.Pocket, error) {
+	pocket, err := s.GetPocket(userID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	others, err := s.pocketRepo.ListByWallet(pocket.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pockets: %w", err)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	pocket, err = s.pocketRepo.GetByIDForUpdate(tx, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPocketUpdate(pocket, others, update, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.pocketRepo.Update(tx, pocket); err != nil {
+		return nil, fmt.Errorf("failed to update pocket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pocket, nil
+}
+
+// applyPocketUpdate validates an update against the wallet's other pockets
+// and applies it to pocket
+func (s *WalletService) applyPocketUpdate(pocket *models.Pocket, others []models.Pocket, update PocketUpdate, now time.Time) error {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return fmt.Errorf("name is required")
+		}
+		if len(name) > MaxPocketNameLength {
+			return fmt.Errorf("name must be at most %d characters", MaxPocketNameLength)
+		}
+		for _, other := range others {
+			if other.ID != pocket.ID && strings.EqualFold(other.Name, name) {
+				return fmt.Errorf("you already have a pocket named %q", other.Name)
+			}
+		}
+		pocket.Name = name
+	}
+
+	if update.TargetAmount != nil {
+		target := *update.TargetAmount
+		target.Currency = pocket.Balance.Currency
+		switch {
+		case target.IsZero():
+			pocket.TargetAmount = nil
+		case !target.IsPositive():
+			return fmt.Errorf("target_amount must be greater than zero")
+		default:
+			pocket.TargetAmount = &target
+		}
+	}
+
+	if update.TargetDate != nil {
+		switch {
+		case update.TargetDate.IsZero():
+			pocket.TargetDate = nil
+		case !update.TargetDate.After(now):
+			return fmt.Errorf("target_date must be in the future")
+		default:
+			targetDate := *update.TargetDate
+			pocket.TargetDate = &targetDate
+		}
+	}
+
+	if update.LockedUntil != nil {
+		lockedUntil := *update.LockedUntil
+		if !lockedUntil.After(now) {
+			return fmt.Errorf("locked_until must be in the future")
+		}
+		if pocket.IsLocked(now) && lockedUntil.Before(*pocket.LockedUntil) {
+			return fmt.Errorf("%w until %s; the lock can be extended but not shortened", ErrPocketLocked, pocket.LockedUntil.Format(time.RFC3339))
+		}
+		pocket.LockedUntil = &lockedUntil
+	}
+
+	return nil
+}
+
+// MoveToPocket sets aside amount of the wallet's available balance in a pocket
+func (s *WalletService) MoveToPocket(userID, pocketID uuid.UUID, amount models.Money) (*models.Pocket, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	pocket, err := s.GetPocket(userID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	amount.Currency = pocket.Balance.Currency
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The wallet is locked before the pocket, as everywhere else
+	available, err := s.lockAvailableBalance(tx, pocket.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	if available.LessThan(amount) {
+		return nil, ErrInsufficientBalance
+	}
+
+	pocket, err = s.pocketRepo.GetByIDForUpdate(tx, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	pocket.Balance = pocket.Balance.Add(amount)
+	if err := s.pocketRepo.UpdateBalance(tx, pocket.ID, pocket.Balance); err != nil {
+		return nil, fmt.Errorf("failed to update pocket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pocket, nil
+}
+
+// MoveFromPocket returns amount from a pocket to the wallet's available
+// balance. A zero amount empties the pocket. Locked pockets cannot be
+// emptied before their lock ends.
+func (s *WalletService) MoveFromPocket(userID, pocketID uuid.UUID, amount models.Money) (*models.Pocket, error) {
+	pocket, err := s.GetPocket(userID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.walletRepo.GetBalanceForUpdate(tx, pocket.WalletID); err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	pocket, err = s.pocketRepo.GetByIDForUpdate(tx, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	if pocket.IsLocked(time.Now()) {
+		return nil, fmt.Errorf("%w until %s", ErrPocketLocked, pocket.LockedUntil.Format(time.RFC3339))
+	}
+
+	if amount.IsZero() {
+		amount = pocket.Balance
+	}
+	amount.Currency = pocket.Balance.Currency
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if pocket.Balance.LessThan(amount) {
+		return nil, fmt.Errorf("amount %s exceeds the %s in the pocket", amount, pocket.Balance)
+	}
+
+	pocket.Balance = pocket.Balance.Sub(amount)
+	if err := s.pocketRepo.UpdateBalance(tx, pocket.ID, pocket.Balance); err != nil {
+		return nil, fmt.Errorf("failed to update pocket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pocket, nil
+}
+
+// DeletePocket removes a pocket. Anything left in it goes back to the
+// wallet's available balance, so locked pockets cannot be deleted.
+func (s *WalletService) DeletePocket(userID, pocketID uuid.UUID) error {
+	pocket, err := s.GetPocket(userID, pocketID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.walletRepo.GetBalanceForUpdate(tx, pocket.WalletID); err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	pocket, err = s.pocketRepo.GetByIDForUpdate(tx, pocketID)
+	if err != nil {
+		return err
+	}
+	if pocket.IsLocked(time.Now()) {
+		return fmt.Errorf("%w until %s", ErrPocketLocked, pocket.LockedUntil.Format(time.RFC3339))
+	}
+	if err := s.pocketRepo.Delete(tx, pocket.ID); err != nil {
+		return fmt.Errorf("failed to delete pocket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+)
+
+// Pending deposits checked per reconciliation run
+const reconciliationBatchSize = 100
+
+// ReconcilePendingDeposits asks the payment provider about deposits that have
+// been pending for longer than minAge, for when the webhook never arrived.
+// Successful charges are credited through the same path as the webhook,
+// failed charges are marked failed, and checkouts the provider reports as
+// abandoned, or does not know, after ttl are expired. It returns the number
+// of deposits checked.
+func (s *WalletService) ReconcilePendingDeposits(ctx context.Context, minAge, ttl time.Duration) (int, error) {
+	now := time.Now()
+	deposits, err := s.transactionRepo.GetPendingDeposits(now.Add(-minAge), reconciliationBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending deposits: %w", err)
+	}
+
+	checked := 0
+	for i := range deposits {
+		if ctx.Err() != nil {
+			return checked, ctx.Err()
+		}
+
+		deposit := &deposits[i]
+		if err := s.reconcileDeposit(deposit, now.Add(-ttl)); err != nil {
+			log.Printf("Failed to reconcile deposit %s: %v", *deposit.Reference, err)
+		}
+		checked++
+	}
+
+	return checked, nil
+}
+
+// reconcileDeposit settles one pending deposit from its status at the provider
+func (s *WalletService) reconcileDeposit(deposit *models.Transaction, expireBefore time.Time) error {
+	if deposit.PaystackReference == nil {
+		return fmt.Errorf("deposit has no provider reference")
+	}
+	reference := *deposit.PaystackReference
+	abandoned := deposit.CreatedAt.Before(expireBefore)
+
+	provider, err := s.providers.Get(depositProvider(deposit))
+	if err != nil {
+		return err
+	}
+
+	verification, err := provider.VerifyPayment(reference)
+	if err != nil {
+		// The provider may not know a checkout that was never opened. An
+		// expired deposit is still credited if a successful charge arrives later.
+		if abandoned && payment.IsRejected(err) {
+			return s.transitionDeposit(reference, models.TransactionStatusPending, models.TransactionStatusExpired)
+		}
+		// Timeouts and server errors say nothing about the charge; the next
+		// run tries again
+		return fmt.Errorf("failed to verify transaction: %w", err)
+	}
+
+	switch verification.Status {
+	case payment.PaymentStatusSuccess:
+		return s.creditDeposit(reference, verification)
+	case payment.PaymentStatusFailed:
+		return s.transitionDeposit(reference, models.TransactionStatusPending, models.TransactionStatusFailed)
+	case payment.PaymentStatusAbandoned:
+		// The customer may still pay until the deposit is old enough to expire
+		if abandoned {
+			return s.transitionDeposit(reference, models.TransactionStatusPending, models.TransactionStatusExpired)
+		}
+		return nil
+	default:
+		// The provider is still processing the charge, e.g. a bank transfer
+		// awaiting confirmation, so it is left for the webhook or a later run
+		return nil
+	}
+}
@@ -0,0 +1,444 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/external/external_models"
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+	"github.com/google/uuid"
+)
+
+// refundMetadata is stored on the refund transaction for support and auditing
+type refundMetadata struct {
+	DepositReference string `json:"deposit_reference"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// refundableAmount is what is left of a deposit once the refunds settled
+// and in flight against it are taken off. It is never negative.
+func refundableAmount(deposit models.Money, settled, pending int64) models.Money {
+	left := deposit.Sub(models.NewMoney(settled+pending, deposit.Currency))
+	if left.IsNegative() {
+		return models.NewMoney(0, deposit.Currency)
+	}
+	return left
+}
+
+// dashboardRefundAmount is how much of a refund issued from the provider's
+// dashboard is taken back out of the wallet: the amount the provider
+// reports, or all that is left to refund if it reports none or more
+func dashboardRefundAmount(refundable models.Money, reported int64) models.Money {
+	if reported > 0 && reported < refundable.Amount {
+		return models.NewMoney(reported, refundable.Currency)
+	}
+	return refundable
+}
+
+// refundRequestAmount checks a requested refund against what is left to
+// refund of the deposit. A zero amount asks for everything that is left.
+func refundRequestAmount(amount, refundable models.Money) (models.Money, error) {
+	if amount.IsZero() {
+		amount = refundable
+	}
+	if !amount.SameCurrency(refundable) {
+		return models.Money{}, fmt.Errorf("refund currency %s does not match deposit currency %s", amount.Currency, refundable.Currency)
+	}
+	if !refundable.IsPositive() {
+		return models.Money{}, fmt.Errorf("deposit has already been fully refunded")
+	}
+	if !amount.IsPositive() {
+		return models.Money{}, fmt.Errorf("amount must be greater than zero")
+	}
+	if refundable.LessThan(amount) {
+		return models.Money{}, fmt.Errorf("refund amount %s exceeds the %s left to refund", amount, refundable)
+	}
+	return amount, nil
+}
+
+// chargebackReference is the journal entry reference of a deposit's chargeback
+func chargebackReference(deposit *models.Transaction) string {
+	return *deposit.Reference + "_CHARGEBACK"
+}
+
+// RefundDeposit returns all or part of a Paystack deposit to the payer's card.
+// A zero amount refunds whatever has not been refunded yet. The amount is moved
+// out of the wallet into pending refunds before Paystack is asked to refund it,
+// and is settled or returned to the wallet when the refund webhook arrives. If
+// Paystack's answer is unclear the refund is returned pending.
+func (s *WalletService) RefundDeposit(depositReference string, amount models.Money, reason string) (*models.Transaction, error) {
+	reference := fmt.Sprintf("RFD_%s_%d", uuid.New().String()[:8], time.Now().Unix())
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.transactionRepo.GetByReferenceForUpdate(tx, depositReference)
+	if err != nil {
+		return nil, fmt.Errorf("deposit not found: %w", err)
+	}
+
+	if deposit.Type != models.TransactionTypeDeposit {
+		return nil, fmt.Errorf("transaction %s is not a deposit", depositReference)
+	}
+	if depositProvider(deposit) != models.PaymentProviderPaystack {
+		return nil, fmt.Errorf("refunds are only supported for Paystack deposits")
+	}
+	if deposit.Status != models.TransactionStatusSuccess {
+		return nil, fmt.Errorf("only successful deposits can be refunded, deposit is %s", deposit.Status)
+	}
+
+	// Refunds already settled or in flight reduce what is left to refund
+	settled, pending, err := s.transactionRepo.GetRefundTotals(tx, deposit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund totals: %w", err)
+	}
+	amount, err = refundRequestAmount(amount, refundableAmount(deposit.Amount, settled, pending))
+	if err != nil {
+		return nil, err
+	}
+
+	// The wallet must still hold the money being sent back, outside any holds
+	balance, err := s.lockAvailableBalance(tx, deposit.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	if balance.LessThan(amount) {
+		return nil, fmt.Errorf("insufficient balance: the wallet no longer holds the funds to refund")
+	}
+
+	walletAccount, err := s.ledgerService.WalletAccount(tx, deposit.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	pendingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPendingRefunds, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.ledgerService.Move(tx, reference, "Deposit refund to card", walletAccount, pendingAccount, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post refund to ledger: %w", err)
+	}
+
+	metadata, err := json.Marshal(refundMetadata{DepositReference: depositReference, Reason: reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refund metadata: %w", err)
+	}
+
+	provider := models.PaymentProviderPaystack
+	transaction := &models.Transaction{
+		UserID:               deposit.UserID,
+		WalletID:             deposit.WalletID,
+		Type:                 models.TransactionTypeRefund,
+		Amount:               amount,
+		Status:               models.TransactionStatusPending,
+		Reference:            &reference,
+		Provider:             &provider,
+		Description:          stringPtr(fmt.Sprintf("Refund of deposit %s to card", depositReference)),
+		Metadata:             stringPtr(string(metadata)),
+		JournalEntryID:       &entry.ID,
+		RelatedTransactionID: &deposit.ID,
+	}
+	if err := s.transactionRepo.Create(tx, transaction); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	resp, err := s.paystackService.CreateRefund(*deposit.PaystackReference, amount, reason)
+	if err != nil {
+		if payment.IsRejected(err) {
+			// Paystack turned the refund down, so the held funds go straight back
+			if releaseErr := s.releaseRefund(reference); releaseErr != nil {
+				return nil, fmt.Errorf("failed to create refund: %v (release failed: %w)", err, releaseErr)
+			}
+			return nil, fmt.Errorf("failed to create refund: %w", err)
+		}
+
+		// Paystack may have created the refund before the error. It stays
+		// pending until a refund webhook or reconciliation settles it.
+		log.Printf("Refund %s left pending: failed to create refund: %v", reference, err)
+		return s.transactionRepo.GetByReference(reference)
+	}
+
+	// Refund webhooks identify the refund by Paystack's ID
+	refundID := strconv.FormatInt(resp.Data.ID, 10)
+	if err := s.transactionRepo.SetPaystackReference(transaction.ID, refundID); err != nil {
+		return nil, fmt.Errorf("failed to record refund ID %s for %s: %w", refundID, reference, err)
+	}
+
+	switch resp.Data.Status {
+	case "processed":
+		if err := s.completeRefund(reference); err != nil {
+			return nil, err
+		}
+	case "failed":
+		if err := s.releaseRefund(reference); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.transactionRepo.GetByReference(reference)
+}
+
+// findRefund matches a refund webhook to a refund made through RefundDeposit.
+// Paystack does not always include the refund ID in the event, in which case
+// a refund of the same amount against the charge is used. It returns nil for
+// refunds issued from the Paystack dashboard.
+func (s *WalletService) findRefund(event *payment.Event) (*models.Transaction, error) {
+	if event.ID != "" && event.ID != "0" {
+		refund, err := s.transactionRepo.GetRefundByPaystackID(event.ID)
+		if err != nil || refund != nil {
+			return refund, err
+		}
+	}
+
+	deposit, err := s.transactionRepo.GetByPaystackReference(event.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found: %w", err)
+	}
+	return s.transactionRepo.FindRefundByAmount(deposit.ID, event.Amount)
+}
+
+// completeRefund settles a refund once Paystack confirms it. The deposit is
+// marked reversed when its refunds add up to the full amount.
+func (s *WalletService) completeRefund(reference string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	refund, err := s.transactionRepo.GetByReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	if refund.Type != models.TransactionTypeRefund {
+		return fmt.Errorf("transaction %s is not a refund", reference)
+	}
+
+	// Already settled or released (idempotency)
+	if refund.Status != models.TransactionStatusPending {
+		return nil
+	}
+
+	// The refund has left the Paystack balance
+	pendingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPendingRefunds, refund.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	clearingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPaystackClearing, refund.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ledgerService.Move(tx, reference+"_SETTLE", "Deposit refund paid out by Paystack", pendingAccount, clearingAccount, refund.Amount); err != nil {
+		return fmt.Errorf("failed to post refund settlement to ledger: %w", err)
+	}
+
+	if err := s.transactionRepo.UpdateStatus(tx, refund.ID, models.TransactionStatusSuccess); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if refund.RelatedTransactionID != nil {
+		deposit, err := s.transactionRepo.GetByIDForUpdate(tx, *refund.RelatedTransactionID)
+		if err != nil {
+			return fmt.Errorf("deposit not found: %w", err)
+		}
+		settled, _, err := s.transactionRepo.GetRefundTotals(tx, deposit.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get refund totals: %w", err)
+		}
+		if deposit.Status == models.TransactionStatusSuccess && settled >= deposit.Amount.Amount {
+			if err := s.transactionRepo.UpdateStatus(tx, deposit.ID, models.TransactionStatusReversed); err != nil {
+				return fmt.Errorf("failed to update deposit status: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// releaseRefund returns a refund's held funds to the wallet when Paystack
+// rejects or fails the refund. If the deposit was charged back while the
+// refund was in flight, the chargeback only took what was not being
+// refunded out of the wallet, so the held funds go towards the chargeback.
+func (s *WalletService) releaseRefund(reference string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	refund, err := s.transactionRepo.GetByReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	if refund.Type != models.TransactionTypeRefund {
+		return fmt.Errorf("transaction %s is not a refund", reference)
+	}
+
+	// Already settled or released (idempotency)
+	if refund.Status != models.TransactionStatusPending {
+		return nil
+	}
+
+	if _, err := s.walletRepo.GetBalanceForUpdate(tx, refund.WalletID); err != nil {
+		return fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	pendingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPendingRefunds, refund.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	releaseTo, err := s.ledgerService.WalletAccount(tx, refund.WalletID)
+	if err != nil {
+		return err
+	}
+	description := "Release of unsuccessful deposit refund"
+
+	if refund.RelatedTransactionID != nil {
+		deposit, err := s.transactionRepo.GetByIDForUpdate(tx, *refund.RelatedTransactionID)
+		if err != nil {
+			return fmt.Errorf("deposit not found: %w", err)
+		}
+		chargedBack, err := s.ledgerService.HasEntry(tx, chargebackReference(deposit))
+		if err != nil {
+			return err
+		}
+		if chargedBack {
+			releaseTo, err = s.ledgerService.SystemAccount(tx, depositProvider(deposit).ClearingAccount(), refund.Amount.Currency)
+			if err != nil {
+				return err
+			}
+			description = "Unsuccessful deposit refund applied to chargeback"
+		}
+	}
+
+	if _, err := s.ledgerService.Move(tx, reference+"_RELEASE", description, pendingAccount, releaseTo, refund.Amount); err != nil {
+		return fmt.Errorf("failed to post refund release to ledger: %w", err)
+	}
+
+	if err := s.transactionRepo.UpdateStatus(tx, refund.ID, models.TransactionStatusFailed); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReconcilePendingRefunds asks Paystack about refunds that have been pending
+// for longer than minAge, for when the refund could not be confirmed when it
+// was made and no webhook has arrived since. It returns the number of refunds
+// checked.
+func (s *WalletService) ReconcilePendingRefunds(ctx context.Context, minAge time.Duration) (int, error) {
+	refunds, err := s.transactionRepo.GetPendingRefunds(time.Now().Add(-minAge), reconciliationBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending refunds: %w", err)
+	}
+
+	checked := 0
+	for i := range refunds {
+		if ctx.Err() != nil {
+			return checked, ctx.Err()
+		}
+
+		refund := &refunds[i]
+		if err := s.reconcileRefund(refund); err != nil {
+			log.Printf("Failed to reconcile refund %s: %v", *refund.Reference, err)
+		}
+		checked++
+	}
+
+	return checked, nil
+}
+
+// reconcileRefund settles one pending refund from the refunds Paystack lists
+// against its deposit. A refund whose ID was never recorded is matched by
+// amount to a Paystack refund no other refund has claimed; if there is none,
+// Paystack never created it and the funds go back to the wallet.
+func (s *WalletService) reconcileRefund(refund *models.Transaction) error {
+	if refund.RelatedTransactionID == nil {
+		return fmt.Errorf("refund is not linked to a deposit")
+	}
+	deposit, err := s.transactionRepo.GetByID(*refund.RelatedTransactionID)
+	if err != nil {
+		return fmt.Errorf("deposit not found: %w", err)
+	}
+
+	resp, err := s.paystackService.ListRefunds(*deposit.PaystackReference)
+	if err != nil {
+		return fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	match, err := s.matchRefund(refund, resp.Data)
+	if err != nil {
+		return err
+	}
+	if match == nil {
+		if refund.PaystackReference != nil {
+			return fmt.Errorf("paystack does not list refund %s", *refund.PaystackReference)
+		}
+		return s.releaseRefund(*refund.Reference)
+	}
+
+	if refund.PaystackReference == nil {
+		refundID := strconv.FormatInt(match.ID, 10)
+		if err := s.transactionRepo.SetPaystackReference(refund.ID, refundID); err != nil {
+			return fmt.Errorf("failed to record refund ID %s: %w", refundID, err)
+		}
+	}
+
+	switch match.Status {
+	case "processed":
+		return s.completeRefund(*refund.Reference)
+	case "failed":
+		return s.releaseRefund(*refund.Reference)
+	default:
+		// Pending or processing: Paystack is still working on it
+		return nil
+	}
+}
+
+// matchRefund finds the Paystack refund a pending refund was created as
+func (s *WalletService) matchRefund(refund *models.Transaction, candidates []external_models.Refund) (*external_models.Refund, error) {
+	for i := range candidates {
+		candidate := &candidates[i]
+		refundID := strconv.FormatInt(candidate.ID, 10)
+		if refund.PaystackReference != nil {
+			if *refund.PaystackReference == refundID {
+				return candidate, nil
+			}
+			continue
+		}
+
+		if candidate.Amount != refund.Amount.Amount {
+			continue
+		}
+		claimed, err := s.transactionRepo.GetRefundByPaystackID(refundID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get refund %s: %w", refundID, err)
+		}
+		if claimed == nil {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func TestRefundableAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		deposit int64
+		settled int64
+		pending int64
+		want    int64
+	}{
+		{name: "nothing refunded", deposit: 500000, want: 500000},
+		{name: "partly refunded", deposit: 500000, settled: 100000, want: 400000},
+		{name: "refund in flight", deposit: 500000, pending: 200000, want: 300000},
+		{name: "settled and in flight", deposit: 500000, settled: 100000, pending: 150000, want: 250000},
+		{name: "fully refunded", deposit: 500000, settled: 300000, pending: 200000, want: 0},
+		{name: "over refunded", deposit: 500000, settled: 600000, want: 0},
+	}
+
+	for _, tt := range tests {
+		got := refundableAmount(models.NewMoney(tt.deposit, models.CurrencyUSD), tt.settled, tt.pending)
+		if got.Amount != tt.want {
+			t.Errorf("%s: refundable = %d, want %d", tt.name, got.Amount, tt.want)
+		}
+		if got.Currency != models.CurrencyUSD {
+			t.Errorf("%s: currency = %s, want %s", tt.name, got.Currency, models.CurrencyUSD)
+		}
+	}
+}
+
+func TestRefundRequestAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     models.Money
+		refundable int64
+		want       int64
+		wantErr    string
+	}{
+		{name: "everything left", amount: models.NewMoney(0, models.CurrencyNGN), refundable: 300000, want: 300000},
+		{name: "part of what is left", amount: models.NewMoney(100000, models.CurrencyNGN), refundable: 300000, want: 100000},
+		{name: "exactly what is left", amount: models.NewMoney(300000, models.CurrencyNGN), refundable: 300000, want: 300000},
+		{name: "more than is left", amount: models.NewMoney(300001, models.CurrencyNGN), refundable: 300000, wantErr: "exceeds"},
+		{name: "nothing left", amount: models.NewMoney(0, models.CurrencyNGN), refundable: 0, wantErr: "already been fully refunded"},
+		{name: "negative amount", amount: models.NewMoney(-100, models.CurrencyNGN), refundable: 300000, wantErr: "greater than zero"},
+		{name: "other currency", amount: models.NewMoney(100000, models.CurrencyUSD), refundable: 300000, wantErr: "does not match"},
+	}
+
+	for _, tt := range tests {
+		got, err := refundRequestAmount(tt.amount, models.NewMoney(tt.refundable, models.CurrencyNGN))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: refundRequestAmount = %v, want an error containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: refundRequestAmount failed: %v", tt.name, err)
+			continue
+		}
+		if got.Amount != tt.want || got.Currency != models.CurrencyNGN {
+			t.Errorf("%s: amount = %s, want %d NGN", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDashboardRefundAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		refundable int64
+		reported   int64
+		want       int64
+	}{
+		{name: "partial refund", refundable: 500000, reported: 200000, want: 200000},
+		{name: "amount not reported", refundable: 500000, reported: 0, want: 500000},
+		{name: "full refund", refundable: 500000, reported: 500000, want: 500000},
+		{name: "more than is left", refundable: 300000, reported: 500000, want: 300000},
+	}
+
+	for _, tt := range tests {
+		got := dashboardRefundAmount(models.NewMoney(tt.refundable, models.CurrencyNGN), tt.reported)
+		if got.Amount != tt.want {
+			t.Errorf("%s: amount = %d, want %d", tt.name, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestChargebackReference(t *testing.T) {
+	reference := "TXN_123"
+	deposit := &models.Transaction{Reference: &reference}
+	if got := chargebackReference(deposit); got != "TXN_123_CHARGEBACK" {
+		t.Errorf("chargebackReference = %q, want %q", got, "TXN_123_CHARGEBACK")
+	}
+}
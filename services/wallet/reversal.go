@@ -0,0 +1,205 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+// ErrRecipientSpentFunds is returned when reversing a transfer would take more
+// than the recipient has available
+var ErrRecipientSpentFunds = errors.New("recipient has already spent the transferred funds")
+
+// ReversalPolicy controls how transfer reversals treat recipients who no
+// longer hold the money
+type ReversalPolicy struct {
+	// AllowForceNegative lets an admin reverse a transfer even when that takes
+	// the recipient's balance below zero
+	AllowForceNegative bool
+}
+
+// TransferReversal is the pair of compensating transactions for a reversed transfer
+type TransferReversal struct {
+	Reference string `json:"reference"`
+	Reason    string `json:"reason"`
+	// Taken back from the recipient
+	Debit *models.Transaction `json:"debit"`
+	// Returned to the sender
+	Credit *models.Transaction `json:"credit"`
+}
+
+// reversalMetadata is stored on both compensating transactions
+type reversalMetadata struct {
+	TransferReference string `json:"transfer_reference"`
+	Reason            string `json:"reason"`
+	ForcedNegative    bool   `json:"forced_negative"`
+}
+
+// transferBaseReference returns the reference shared by both sides of a
+// transfer from the reference of either side
+func transferBaseReference(reference string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(reference, "_DEBIT"), "_CREDIT")
+}
+
+// ReverseTransfer undoes a completed wallet-to-wallet transfer. The reference
+// may be the transfer's debit or credit reference. The money is moved back
+// from the recipient to the sender with compensating debit and credit
+// transactions linked to the originals, which are marked reversed. If the
+// recipient no longer has the money available the reversal fails with
+// ErrRecipientSpentFunds, unless forceNegative is set and the policy allows
+// it, in which case the recipient's balance goes below zero.
+func (s *WalletService) ReverseTransfer(reference, reason string, forceNegative bool) (*TransferReversal, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to reverse a transfer")
+	}
+	if forceNegative && !s.reversalPolicy.AllowForceNegative {
+		return nil, fmt.Errorf("forcing a negative balance is not allowed by the reversal policy")
+	}
+
+	baseReference := transferBaseReference(reference)
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	debit, err := s.transactionRepo.GetByReferenceForUpdate(tx, baseReference+"_DEBIT")
+	if err != nil {
+		return nil, fmt.Errorf("transfer not found: %w", err)
+	}
+	credit, err := s.transactionRepo.GetByReferenceForUpdate(tx, baseReference+"_CREDIT")
+	if err != nil {
+		return nil, fmt.Errorf("transfer not found: %w", err)
+	}
+
+	if debit.Type != models.TransactionTypeDebit || credit.Type != models.TransactionTypeCredit || debit.RecipientWalletID == nil {
+		return nil, fmt.Errorf("transaction %s is not a wallet transfer", reference)
+	}
+	if debit.RelatedTransactionID != nil {
+		return nil, fmt.Errorf("a reversal cannot itself be reversed")
+	}
+	switch debit.Status {
+	case models.TransactionStatusSuccess:
+	case models.TransactionStatusReversed:
+		return nil, fmt.Errorf("transfer has already been reversed")
+	default:
+		return nil, fmt.Errorf("only completed transfers can be reversed, transfer is %s", debit.Status)
+	}
+
+	senderWallet, err := s.walletRepo.GetByID(debit.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender wallet: %w", err)
+	}
+	recipientWallet, err := s.walletRepo.GetByID(credit.WalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient wallet: %w", err)
+	}
+
+	// Lock both wallets in the same order as Transfer
+	if _, err := s.walletRepo.GetBalanceForUpdate(tx, senderWallet.ID); err != nil {
+		return nil, fmt.Errorf("failed to get sender balance: %w", err)
+	}
+	recipientBalance, err := s.lockAvailableBalance(tx, recipientWallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient balance: %w", err)
+	}
+
+	forced := recipientBalance.LessThan(credit.Amount)
+	if forced && !forceNegative {
+		return nil, ErrRecipientSpentFunds
+	}
+
+	senderAccount, err := s.ledgerService.WalletAccount(tx, senderWallet.ID)
+	if err != nil {
+		return nil, err
+	}
+	recipientAccount, err := s.ledgerService.WalletAccount(tx, recipientWallet.ID)
+	if err != nil {
+		return nil, err
+	}
+	move := s.ledgerService.Move
+	if forced {
+		move = s.ledgerService.ForceMove
+	}
+	reversalReference := baseReference + "_REVERSAL"
+	entry, err := move(
+		tx,
+		reversalReference,
+		fmt.Sprintf("Reversal of transfer %s: %s", baseReference, reason),
+		recipientAccount,
+		senderAccount,
+		credit.Amount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post reversal to ledger: %w", err)
+	}
+
+	metadata, err := json.Marshal(reversalMetadata{
+		TransferReference: baseReference,
+		Reason:            reason,
+		ForcedNegative:    forced,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reversal metadata: %w", err)
+	}
+
+	// Taken back from the recipient, linked to the credit it compensates
+	debitReference := reversalReference + "_DEBIT"
+	reversalDebit := &models.Transaction{
+		UserID:               recipientWallet.UserID,
+		WalletID:             recipientWallet.ID,
+		Type:                 models.TransactionTypeDebit,
+		Amount:               credit.Amount,
+		Status:               models.TransactionStatusSuccess,
+		Reference:            &debitReference,
+		RecipientWalletID:    &senderWallet.ID,
+		RecipientUserID:      &senderWallet.UserID,
+		Description:          stringPtr(fmt.Sprintf("Reversal of transfer from wallet %s", senderWallet.WalletNumber)),
+		Metadata:             stringPtr(string(metadata)),
+		JournalEntryID:       &entry.ID,
+		RelatedTransactionID: &credit.ID,
+	}
+	if err := s.transactionRepo.Create(tx, reversalDebit); err != nil {
+		return nil, fmt.Errorf("failed to create debit transaction: %w", err)
+	}
+
+	// Returned to the sender, linked to the debit it compensates
+	creditReference := reversalReference + "_CREDIT"
+	reversalCredit := &models.Transaction{
+		UserID:               senderWallet.UserID,
+		WalletID:             senderWallet.ID,
+		Type:                 models.TransactionTypeCredit,
+		Amount:               debit.Amount,
+		Status:               models.TransactionStatusSuccess,
+		Reference:            &creditReference,
+		Description:          stringPtr(fmt.Sprintf("Reversal of transfer to wallet %s", recipientWallet.WalletNumber)),
+		Metadata:             stringPtr(string(metadata)),
+		JournalEntryID:       &entry.ID,
+		RelatedTransactionID: &debit.ID,
+	}
+	if err := s.transactionRepo.Create(tx, reversalCredit); err != nil {
+		return nil, fmt.Errorf("failed to create credit transaction: %w", err)
+	}
+
+	for _, original := range []*models.Transaction{debit, credit} {
+		if err := s.transactionRepo.UpdateStatus(tx, original.ID, models.TransactionStatusReversed); err != nil {
+			return nil, fmt.Errorf("failed to update transaction status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &TransferReversal{
+		Reference: reversalReference,
+		Reason:    reason,
+		Debit:     reversalDebit,
+		Credit:    reversalCredit,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func TestTransferBaseReference(t *testing.T) {
+	tests := map[string]string{
+		"TXF_ab12cd34_1700000000_DEBIT":  "TXF_ab12cd34_1700000000",
+		"TXF_ab12cd34_1700000000_CREDIT": "TXF_ab12cd34_1700000000",
+		"TXF_ab12cd34_1700000000":        "TXF_ab12cd34_1700000000",
+	}
+	for reference, want := range tests {
+		if got := transferBaseReference(reference); got != want {
+			t.Errorf("transferBaseReference(%q) = %q, want %q", reference, got, want)
+		}
+	}
+}
+
+func TestReverseTransferOnlyOnce(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	sender, senderWallet := p.newWallet(50000)
+	_, recipient := p.newWallet(0)
+
+	debit, err := p.service.Transfer(sender.ID, nil, recipient.WalletNumber, models.NewMoney(20000, models.CurrencyNGN))
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	if _, err := p.service.ReverseTransfer(*debit.Reference, " ", false); err == nil {
+		t.Error("reversal without a reason succeeded")
+	}
+
+	// Either side of the transfer identifies it
+	creditReference := transferBaseReference(*debit.Reference) + "_CREDIT"
+	reversal, err := p.service.ReverseTransfer(creditReference, "Sent to the wrong wallet", false)
+	if err != nil {
+		t.Fatalf("ReverseTransfer failed: %v", err)
+	}
+	if got := p.balance(senderWallet); got != 50000 {
+		t.Errorf("sender balance = %d, want 50000", got)
+	}
+	if got := p.balance(recipient); got != 0 {
+		t.Errorf("recipient balance = %d, want 0", got)
+	}
+	if reversal.Credit.RelatedTransactionID == nil || *reversal.Credit.RelatedTransactionID != debit.ID {
+		t.Errorf("reversal credit related_transaction_id = %v, want %s", reversal.Credit.RelatedTransactionID, debit.ID)
+	}
+
+	if _, err := p.service.ReverseTransfer(*debit.Reference, "Sent to the wrong wallet", false); err == nil || !strings.Contains(err.Error(), "already been reversed") {
+		t.Errorf("second reversal = %v, want an already reversed error", err)
+	}
+	if _, err := p.service.ReverseTransfer(*reversal.Debit.Reference, "Undo the reversal", false); err == nil {
+		t.Error("reversing a reversal succeeded")
+	}
+	if got := p.balance(senderWallet); got != 50000 {
+		t.Errorf("sender balance after repeated reversals = %d, want 50000", got)
+	}
+}
+
+func TestReverseTransferRefusesSpentFunds(t *testing.T) {
+	p := newPayoutTest(t, Limits{})
+	sender, _ := p.newWallet(50000)
+	recipientUser, recipient := p.newWallet(0)
+	_, other := p.newWallet(0)
+
+	debit, err := p.service.Transfer(sender.ID, nil, recipient.WalletNumber, models.NewMoney(20000, models.CurrencyNGN))
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if _, err := p.service.Transfer(recipientUser.ID, nil, other.WalletNumber, models.NewMoney(15000, models.CurrencyNGN)); err != nil {
+		t.Fatalf("onward transfer failed: %v", err)
+	}
+
+	if _, err := p.service.ReverseTransfer(*debit.Reference, "Fraud", false); !errors.Is(err, ErrRecipientSpentFunds) {
+		t.Errorf("reversal of spent funds = %v, want ErrRecipientSpentFunds", err)
+	}
+	// The policy does not allow forcing a negative balance
+	if _, err := p.service.ReverseTransfer(*debit.Reference, "Fraud", true); err == nil {
+		t.Error("forced reversal succeeded without the policy allowing it")
+	}
+
+	p.service.reversalPolicy.AllowForceNegative = true
+	if _, err := p.service.ReverseTransfer(*debit.Reference, "Fraud", true); err != nil {
+		t.Fatalf("forced reversal failed: %v", err)
+	}
+	if got := p.balance(recipient); got != -15000 {
+		t.Errorf("recipient balance = %d, want -15000", got)
+	}
+}
@@ -1,273 +1,519 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/brainox/paystack_wallet_service/external/external_models"
 	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/fees"
+	"github.com/brainox/paystack_wallet_service/services/fx"
+	"github.com/brainox/paystack_wallet_service/services/kyc"
+	"github.com/brainox/paystack_wallet_service/services/ledger"
+	"github.com/brainox/paystack_wallet_service/services/payment"
 	"github.com/brainox/paystack_wallet_service/services/paystack"
 	"github.com/brainox/paystack_wallet_service/services/repository"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+var (
+	// ErrUnhandledEvent is returned by ProcessWebhook for event types the service does not act on
+	ErrUnhandledEvent = errors.New("unhandled webhook event")
+	// ErrInsufficientBalance is returned when a wallet cannot cover a transfer
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrCrossCurrencyTransfer is returned when a transfer's recipient wallet
+	// is in a different currency and no conversion was requested
+	ErrCrossCurrencyTransfer = errors.New("cross-currency transfers require an explicit conversion")
+)
+
+// TransferError is returned when a transfer cannot be made as requested, e.g.
+// to a wallet that does not exist. Together with ErrInsufficientBalance,
+// ErrCrossCurrencyTransfer and *LimitError it covers everything a caller can
+// fix; any other error from a transfer is an internal failure.
+type TransferError struct {
+	Reason string
+}
+
+func (e *TransferError) Error() string {
+	return e.Reason
+}
+
+func rejectTransfer(format string, args ...interface{}) error {
+	return &TransferError{Reason: fmt.Sprintf(format, args...)}
+}
+
 type WalletService struct {
-	db              *sqlx.DB
-	walletRepo      *repository.WalletRepository
-	transactionRepo *repository.TransactionRepository
-	userRepo        *repository.UserRepository
-	paystackService *paystack.PaystackService
+	db                *sqlx.DB
+	walletRepo        *repository.WalletRepository
+	transactionRepo   *repository.TransactionRepository
+	holdRepo          *repository.HoldRepository
+	pocketRepo        *repository.PocketRepository
+	fxQuoteRepo       *repository.FXQuoteRepository
+	escrowRepo        *repository.EscrowRepository
+	payoutRepo        *repository.PayoutRepository
+	beneficiaryRepo   *repository.BeneficiaryRepository
+	userRepo          *repository.UserRepository
+	limitOverrideRepo *repository.LimitOverrideRepository
+	ledgerService     *ledger.LedgerService
+	paystackService   *paystack.PaystackService
+	providers         *payment.Registry
+	feeSchedule       *fees.Schedule
+	limits            Limits
+	kycTiers          kyc.Tiers
+	fxQuoter          *fx.Quoter
+	reversalPolicy    ReversalPolicy
 }
 
 func NewWalletService(
 	db *sqlx.DB,
 	walletRepo *repository.WalletRepository,
 	transactionRepo *repository.TransactionRepository,
+	holdRepo *repository.HoldRepository,
+	pocketRepo *repository.PocketRepository,
+	fxQuoteRepo *repository.FXQuoteRepository,
+	escrowRepo *repository.EscrowRepository,
+	payoutRepo *repository.PayoutRepository,
+	beneficiaryRepo *repository.BeneficiaryRepository,
 	userRepo *repository.UserRepository,
+	limitOverrideRepo *repository.LimitOverrideRepository,
+	ledgerService *ledger.LedgerService,
 	paystackService *paystack.PaystackService,
+	providers *payment.Registry,
+	feeSchedule *fees.Schedule,
+	limits Limits,
+	kycTiers kyc.Tiers,
+	fxQuoter *fx.Quoter,
+	reversalPolicy ReversalPolicy,
 ) *WalletService {
 	return &WalletService{
-		db:              db,
-		walletRepo:      walletRepo,
-		transactionRepo: transactionRepo,
-		userRepo:        userRepo,
-		paystackService: paystackService,
+		db:                db,
+		walletRepo:        walletRepo,
+		transactionRepo:   transactionRepo,
+		holdRepo:          holdRepo,
+		pocketRepo:        pocketRepo,
+		fxQuoteRepo:       fxQuoteRepo,
+		escrowRepo:        escrowRepo,
+		payoutRepo:        payoutRepo,
+		beneficiaryRepo:   beneficiaryRepo,
+		userRepo:          userRepo,
+		limitOverrideRepo: limitOverrideRepo,
+		ledgerService:     ledgerService,
+		paystackService:   paystackService,
+		providers:         providers,
+		feeSchedule:       feeSchedule,
+		limits:            limits,
+		kycTiers:          kycTiers,
+		fxQuoter:          fxQuoter,
+		reversalPolicy:    reversalPolicy,
 	}
 }
 
-// InitiateDeposit initiates a deposit using Paystack
-func (s *WalletService) InitiateDeposit(userID uuid.UUID, amount float64) (string, string, error) {
+// InitiateDeposit starts a deposit with a payment provider into the user's
+// wallet in the amount's currency. An empty provider uses the configured
+// default.
+func (s *WalletService) InitiateDeposit(userID uuid.UUID, amount models.Money, providerName models.PaymentProvider) (*models.Transaction, string, error) {
+	if !amount.IsPositive() {
+		return nil, "", fmt.Errorf("amount must be greater than zero")
+	}
+
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Get user
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get user: %w", err)
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Get user's wallet
-	wallet, err := s.walletRepo.GetByUserID(userID)
+	// Get the user's wallet in the deposit currency
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, amount.Currency)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get wallet: %w", err)
+		return nil, "", fmt.Errorf("you do not have a %s wallet: %w", amount.Currency, err)
+	}
+
+	// Refuse deposits the wallet could not hold at the user's KYC tier
+	if err := s.checkMaxBalance(user, wallet.Balance, amount, LimitCodeMaxBalance); err != nil {
+		return nil, "", err
 	}
 
 	// Generate unique reference
 	reference := fmt.Sprintf("DEP_%s_%d", uuid.New().String()[:8], time.Now().Unix())
 
-	// Convert amount to kobo (Paystack uses smallest currency unit)
-	amountInKobo := int(amount * 100)
+	// The payer is charged the deposit fee on top of the amount
+	quote := s.QuoteFee(fees.KindDeposit, amount)
 
-	// Initialize Paystack transaction
-	paystackResp, err := s.paystackService.InitializeTransaction(user.Email, amountInKobo, reference)
+	// Start the provider checkout (amount is already in minor units)
+	checkout, err := provider.InitializePayment(user.Email, quote.Total, reference)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to initialize Paystack transaction: %w", err)
+		return nil, "", fmt.Errorf("failed to initialize %s payment: %w", provider.Name(), err)
 	}
 
 	// Create pending transaction record
 	tx, err := s.db.Beginx()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	name := provider.Name()
 	transaction := &models.Transaction{
 		UserID:            userID,
 		WalletID:          wallet.ID,
 		Type:              models.TransactionTypeDeposit,
 		Amount:            amount,
+		Fee:               quote.Fee,
 		Status:            models.TransactionStatusPending,
 		Reference:         &reference,
-		PaystackReference: &paystackResp.Data.Reference,
-		Description:       stringPtr("Wallet deposit via Paystack"),
+		PaystackReference: &checkout.Reference,
+		Provider:          &name,
+		Description:       stringPtr("Wallet deposit via " + name.DisplayName()),
 	}
 
 	if err := s.transactionRepo.Create(tx, transaction); err != nil {
-		return "", "", fmt.Errorf("failed to create transaction: %w", err)
+		return nil, "", fmt.Errorf("failed to create transaction: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return "", "", fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, "", fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return reference, paystackResp.Data.AuthorizationURL, nil
+	return transaction, checkout.AuthorizationURL, nil
 }
 
-// ProcessWebhook processes a Paystack webhook event
-func (s *WalletService) ProcessWebhook(event *external_models.WebhookEvent) error {
-	// Only process successful charge events
-	if event.Event != "charge.success" {
-		return nil
+// ProcessWebhook processes a webhook event from a payment provider
+func (s *WalletService) ProcessWebhook(event *payment.Event) error {
+	switch event.Type {
+	case payment.EventChargeSuccess:
+		return s.processChargeSuccess(event)
+	case payment.EventChargeFailed:
+		return s.processChargeFailed(event)
+	case payment.EventRefundProcessed:
+		return s.processRefundProcessed(event)
+	case payment.EventRefundFailed:
+		return s.processRefundFailed(event)
+	case payment.EventDisputeCreated:
+		return s.processDisputeCreated(event)
+	case payment.EventDisputeResolved:
+		return s.processDisputeResolved(event)
+	case payment.EventTransferSuccess:
+		return s.completeWithdrawal(event.Reference)
+	case payment.EventTransferFailed:
+		return s.refundWithdrawal(event.Reference, models.TransactionStatusFailed)
+	case payment.EventTransferReversed:
+		return s.refundWithdrawal(event.Reference, models.TransactionStatusReversed)
+	default:
+		return ErrUnhandledEvent
 	}
+}
 
-	// Get transaction by Paystack reference
-	transaction, err := s.transactionRepo.GetByPaystackReference(event.Data.Reference)
-	if err != nil {
-		return fmt.Errorf("transaction not found: %w", err)
-	}
+// GetDepositStatus gets the status of a deposit transaction
+func (s *WalletService) GetDepositStatus(reference string) (*models.Transaction, error) {
+	return s.transactionRepo.GetByReference(reference)
+}
 
-	// Check if already processed (idempotency)
-	if transaction.Status == models.TransactionStatusSuccess {
-		return nil // Already processed
-	}
+// Balance is a wallet's ledger balance and the part of it that can be spent.
+// The difference is held by active holds or set aside in pockets.
+type Balance struct {
+	Currency  models.Currency `json:"currency"`
+	Available models.Money    `json:"available"`
+	Ledger    models.Money    `json:"ledger"`
+	Pockets   models.Money    `json:"pockets"`
+}
 
-	// Verify the transaction status from Paystack
-	verifyResp, err := s.paystackService.VerifyTransaction(event.Data.Reference)
+// GetBalance gets the available and ledger balance of a user's wallet in a currency
+func (s *WalletService) GetBalance(userID uuid.UUID, currency models.Currency) (*Balance, error) {
+	wallet, err := s.walletRepo.GetByUserIDAndCurrency(userID, currency)
 	if err != nil {
-		return fmt.Errorf("failed to verify transaction: %w", err)
+		return nil, err
 	}
+	return s.getBalance(wallet)
+}
 
-	if verifyResp.Data.Status != "success" {
-		return fmt.Errorf("transaction not successful")
+func (s *WalletService) getBalance(wallet *models.Wallet) (*Balance, error) {
+	held, err := s.holdRepo.GetHeldAmount(s.db, wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held amount: %w", err)
 	}
+	pocketed, err := s.pocketRepo.GetPocketedAmount(s.db, wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pocket balances: %w", err)
+	}
+	return &Balance{
+		Currency:  wallet.Currency,
+		Available: wallet.Balance.Sub(models.NewMoney(held+pocketed, wallet.Balance.Currency)),
+		Ledger:    wallet.Balance,
+		Pockets:   models.NewMoney(pocketed, wallet.Balance.Currency),
+	}, nil
+}
 
-	// Begin database transaction
+// WalletInfo is one of a user's wallets with its balances and pockets
+type WalletInfo struct {
+	Wallet  models.Wallet
+	Balance Balance
+	Pockets []models.Pocket
+}
+
+// GetWalletInfo returns each of a user's wallets, oldest first, with its
+// balances and pockets
+func (s *WalletService) GetWalletInfo(userID uuid.UUID) ([]WalletInfo, error) {
+	wallets, err := s.walletRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	infos := make([]WalletInfo, 0, len(wallets))
+	for i := range wallets {
+		balance, err := s.getBalance(&wallets[i])
+		if err != nil {
+			return nil, err
+		}
+		pockets, err := s.pocketRepo.ListByWallet(wallets[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pockets: %w", err)
+		}
+		infos = append(infos, WalletInfo{Wallet: wallets[i], Balance: *balance, Pockets: pockets})
+	}
+	return infos, nil
+}
+
+// Transfer transfers money from the sender's wallet in the amount's currency
+// to another wallet and returns the sender's debit transaction. The recipient
+// wallet must be in the same currency, otherwise ErrCrossCurrencyTransfer is
+// returned. apiKeyID is the API key making the transfer, if any, and is
+// counted against that key's spend limit. A transfer that would break the
+// sender's limits fails with a *LimitError, and one that cannot be made as
+// requested with a *TransferError.
+func (s *WalletService) Transfer(senderUserID uuid.UUID, apiKeyID *uuid.UUID, recipientWalletNumber string, amount models.Money) (*models.Transaction, error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Get wallet with lock
-	balance, err := s.walletRepo.GetBalanceForUpdate(tx, transaction.WalletID)
+	debitTransaction, err := s.TransferInTx(tx, senderUserID, apiKeyID, recipientWalletNumber, amount)
 	if err != nil {
-		return fmt.Errorf("failed to get wallet balance: %w", err)
+		return nil, err
 	}
 
-	// Calculate new balance
-	newBalance := balance + transaction.Amount
-
-	// Update wallet balance
-	if err := s.walletRepo.UpdateBalance(tx, transaction.WalletID, newBalance); err != nil {
-		return fmt.Errorf("failed to update wallet balance: %w", err)
-	}
-
-	// Update transaction status
-	if err := s.transactionRepo.UpdateStatus(tx, transaction.ID, models.TransactionStatusSuccess); err != nil {
-		return fmt.Errorf("failed to update transaction status: %w", err)
-	}
-
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return debitTransaction, nil
 }
 
-// GetDepositStatus gets the status of a deposit transaction
-func (s *WalletService) GetDepositStatus(reference string) (*models.Transaction, error) {
-	return s.transactionRepo.GetByReference(reference)
-}
-
-// GetBalance gets the wallet balance for a user
-func (s *WalletService) GetBalance(userID uuid.UUID) (float64, error) {
-	wallet, err := s.walletRepo.GetByUserID(userID)
-	if err != nil {
-		return 0, err
+// TransferInTx is Transfer inside the caller's database transaction, for
+// callers that record something alongside the transfer. Nothing is written
+// until the caller commits tx.
+func (s *WalletService) TransferInTx(tx *sqlx.Tx, senderUserID uuid.UUID, apiKeyID *uuid.UUID, recipientWalletNumber string, amount models.Money) (*models.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, rejectTransfer("amount must be greater than zero")
 	}
-	return wallet.Balance, nil
-}
-
-func (s *WalletService) GetWalletDetails(userID uuid.UUID) (*models.Wallet, error) {
-	return s.walletRepo.GetByUserID(userID)
-}
 
-// Transfer transfers money from one wallet to another
-func (s *WalletService) Transfer(senderUserID uuid.UUID, recipientWalletNumber string, amount float64) error {
-	if amount <= 0 {
-		return fmt.Errorf("amount must be greater than zero")
+	// Get sender's wallet in the transfer currency
+	senderWallet, err := s.walletRepo.GetByUserIDAndCurrency(senderUserID, amount.Currency)
+	if errors.Is(err, repository.ErrWalletNotFound) {
+		return nil, rejectTransfer("you have no %s wallet", amount.Currency)
 	}
-
-	// Get sender's wallet
-	senderWallet, err := s.walletRepo.GetByUserID(senderUserID)
 	if err != nil {
-		return fmt.Errorf("failed to get sender wallet: %w", err)
+		return nil, fmt.Errorf("failed to get sender %s wallet: %w", amount.Currency, err)
 	}
 
 	// Get recipient's wallet
 	recipientWallet, err := s.walletRepo.GetByWalletNumber(recipientWalletNumber)
+	if errors.Is(err, repository.ErrWalletNotFound) {
+		return nil, rejectTransfer("recipient wallet not found")
+	}
 	if err != nil {
-		return fmt.Errorf("recipient wallet not found: %w", err)
+		return nil, fmt.Errorf("failed to get recipient wallet: %w", err)
+	}
+
+	if recipientWallet.Currency != senderWallet.Currency {
+		return nil, fmt.Errorf("%w: recipient wallet is in %s, not %s", ErrCrossCurrencyTransfer, recipientWallet.Currency, senderWallet.Currency)
 	}
 
 	// Check if sender is trying to send to themselves
 	if senderWallet.ID == recipientWallet.ID {
-		return fmt.Errorf("cannot transfer to your own wallet")
+		return nil, rejectTransfer("cannot transfer to your own wallet")
 	}
 
-	// Begin database transaction
-	tx, err := s.db.Beginx()
+	// KYC tiers limit what the sender can send and the recipient can hold
+	sender, err := s.userRepo.GetByID(senderUserID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to get sender: %w", err)
 	}
-	defer tx.Rollback()
+	recipient, err := s.userRepo.GetByID(recipientWallet.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient: %w", err)
+	}
+
+	// The sender pays the transfer fee on top of the amount
+	quote := s.QuoteFee(fees.KindTransfer, amount)
 
-	// Get sender balance with lock
-	senderBalance, err := s.walletRepo.GetBalanceForUpdate(tx, senderWallet.ID)
+	// Get sender balance with lock; held funds cannot be spent
+	senderBalance, err := s.lockAvailableBalance(tx, senderWallet.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get sender balance: %w", err)
+		return nil, fmt.Errorf("failed to get sender balance: %w", err)
 	}
 
 	// Check sufficient balance
-	if senderBalance < amount {
-		return fmt.Errorf("insufficient balance")
+	if senderBalance.LessThan(quote.Total) {
+		return nil, ErrInsufficientBalance
 	}
 
-	// Get recipient balance with lock
+	// Checked under the sender's lock so concurrent transfers cannot both pass
+	if err := s.checkLimits(tx, senderWallet.ID, sender, apiKeyID, amount, quote.Total, true); err != nil {
+		return nil, err
+	}
+
+	// Lock recipient wallet
 	recipientBalance, err := s.walletRepo.GetBalanceForUpdate(tx, recipientWallet.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get recipient balance: %w", err)
+		return nil, fmt.Errorf("failed to get recipient balance: %w", err)
+	}
+	if err := s.checkMaxBalance(recipient, recipientBalance, amount, LimitCodeRecipientMaxBalance); err != nil {
+		return nil, err
 	}
 
-	// Update sender balance (debit)
-	newSenderBalance := senderBalance - amount
-	if err := s.walletRepo.UpdateBalance(tx, senderWallet.ID, newSenderBalance); err != nil {
-		return fmt.Errorf("failed to update sender balance: %w", err)
+	baseReference := fmt.Sprintf("TXF_%s_%d", uuid.New().String()[:8], time.Now().Unix())
+	debitTransaction, _, err := s.postTransfer(tx, senderWallet, recipientWallet, amount, quote.Fee, apiKeyID, baseReference, "Transfer", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return debitTransaction, nil
+}
+
+// lockAvailableBalance locks a wallet and returns what it can spend: its
+// ledger balance less the uncaptured part of its active holds and the money
+// in its pockets
+func (s *WalletService) lockAvailableBalance(tx *sqlx.Tx, walletID uuid.UUID) (models.Money, error) {
+	balance, err := s.walletRepo.GetBalanceForUpdate(tx, walletID)
+	if err != nil {
+		return models.Money{}, err
+	}
+	held, err := s.holdRepo.GetHeldAmount(tx, walletID)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to get held amount: %w", err)
+	}
+	pocketed, err := s.pocketRepo.GetPocketedAmount(tx, walletID)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to get pocket balances: %w", err)
 	}
+	return balance.Sub(models.NewMoney(held+pocketed, balance.Currency)), nil
+}
 
-	// Update recipient balance (credit)
-	newRecipientBalance := recipientBalance + amount
-	if err := s.walletRepo.UpdateBalance(tx, recipientWallet.ID, newRecipientBalance); err != nil {
-		return fmt.Errorf("failed to update recipient balance: %w", err)
+// postTransfer moves money between two locked wallets. It posts the ledger
+// entry, which debits the sender amount plus fee, credits the recipient and
+// credits the fee to the fees account, and records the debit, credit and fee
+// transactions. apiKeyID, if set, is recorded on the debit. label names the
+// operation in descriptions, e.g. "Transfer", and narration, if set, is the
+// sender's note and is added to the debit and credit descriptions.
+func (s *WalletService) postTransfer(
+	tx *sqlx.Tx,
+	sender, recipient *models.Wallet,
+	amount, fee models.Money,
+	apiKeyID *uuid.UUID,
+	baseReference, label, narration string,
+) (*models.Transaction, *models.Transaction, error) {
+	senderAccount, err := s.ledgerService.WalletAccount(tx, sender.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	recipientAccount, err := s.ledgerService.WalletAccount(tx, recipient.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	feeAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFees, amount.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := s.ledgerService.MoveWithFee(
+		tx,
+		baseReference,
+		fmt.Sprintf("%s from wallet %s to wallet %s", label, sender.WalletNumber, recipient.WalletNumber),
+		senderAccount,
+		recipientAccount,
+		amount,
+		feeAccount,
+		fee,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to post transfer to ledger: %w", err)
 	}
 
 	// Create debit transaction for sender
-	baseReference := fmt.Sprintf("TXF_%s_%d", uuid.New().String()[:8], time.Now().Unix())
 	debitReference := fmt.Sprintf("%s_DEBIT", baseReference)
 	debitTransaction := &models.Transaction{
-		UserID:            senderUserID,
-		WalletID:          senderWallet.ID,
+		UserID:            sender.UserID,
+		WalletID:          sender.ID,
 		Type:              models.TransactionTypeDebit,
 		Amount:            amount,
+		Fee:               fee,
 		Status:            models.TransactionStatusSuccess,
 		Reference:         &debitReference,
-		RecipientWalletID: &recipientWallet.ID,
-		RecipientUserID:   &recipientWallet.UserID,
-		Description:       stringPtr(fmt.Sprintf("Transfer to wallet %s", recipientWalletNumber)),
+		RecipientWalletID: &recipient.ID,
+		RecipientUserID:   &recipient.UserID,
+		Description:       stringPtr(withNarration(fmt.Sprintf("%s to wallet %s", label, recipient.WalletNumber), narration)),
+		JournalEntryID:    &entry.ID,
+		APIKeyID:          apiKeyID,
 	}
 	if err := s.transactionRepo.Create(tx, debitTransaction); err != nil {
-		return fmt.Errorf("failed to create debit transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to create debit transaction: %w", err)
+	}
+	if err := s.recordFee(tx, debitTransaction, baseReference+"_FEE", label+" fee"); err != nil {
+		return nil, nil, err
 	}
 
 	// Create credit transaction for recipient
 	creditReference := fmt.Sprintf("%s_CREDIT", baseReference)
 	creditTransaction := &models.Transaction{
-		UserID:      recipientWallet.UserID,
-		WalletID:    recipientWallet.ID,
-		Type:        models.TransactionTypeCredit,
-		Amount:      amount,
-		Status:      models.TransactionStatusSuccess,
-		Reference:   &creditReference,
-		Description: stringPtr(fmt.Sprintf("Transfer from wallet %s", senderWallet.WalletNumber)),
+		UserID:         recipient.UserID,
+		WalletID:       recipient.ID,
+		Type:           models.TransactionTypeCredit,
+		Amount:         amount,
+		Status:         models.TransactionStatusSuccess,
+		Reference:      &creditReference,
+		Description:    stringPtr(withNarration(fmt.Sprintf("%s from wallet %s", label, sender.WalletNumber), narration)),
+		JournalEntryID: &entry.ID,
 	}
 	if err := s.transactionRepo.Create(tx, creditTransaction); err != nil {
-		return fmt.Errorf("failed to create credit transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to create credit transaction: %w", err)
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return debitTransaction, creditTransaction, nil
+}
+
+// QuoteFee works out the fee for an operation before it is made
+func (s *WalletService) QuoteFee(kind fees.Kind, amount models.Money) fees.Quote {
+	return s.feeSchedule.Quote(kind, amount)
+}
+
+// recordFee records the fee charged on a transaction as its own line item,
+// posted under the same journal entry. It does nothing when there is no fee.
+func (s *WalletService) recordFee(tx *sqlx.Tx, charged *models.Transaction, reference, description string) error {
+	if !charged.Fee.IsPositive() {
+		return nil
 	}
 
+	feeTransaction := &models.Transaction{
+		UserID:               charged.UserID,
+		WalletID:             charged.WalletID,
+		Type:                 models.TransactionTypeFee,
+		Amount:               charged.Fee,
+		Status:               models.TransactionStatusSuccess,
+		Reference:            &reference,
+		Description:          &description,
+		JournalEntryID:       charged.JournalEntryID,
+		RelatedTransactionID: &charged.ID,
+		EscrowID:             charged.EscrowID,
+	}
+	if err := s.transactionRepo.Create(tx, feeTransaction); err != nil {
+		return fmt.Errorf("failed to create fee transaction: %w", err)
+	}
 	return nil
 }
 
@@ -286,3 +532,11 @@ func (s *WalletService) GetTransactionHistory(userID uuid.UUID, limit, offset in
 func stringPtr(s string) *string {
 	return &s
 }
+
+// withNarration appends the sender's note to a transaction description
+func withNarration(description, narration string) string {
+	if narration == "" {
+		return description
+	}
+	return description + ": " + narration
+}
@@ -0,0 +1,389 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/external/external_models"
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/fees"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+	"github.com/google/uuid"
+)
+
+// ListBanks lists the banks that withdrawals can be paid out to
+func (s *WalletService) ListBanks() ([]external_models.Bank, error) {
+	resp, err := s.paystackService.ListBanks(models.DefaultCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list banks: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// ResolveBankAccount returns the account name registered to a bank account
+func (s *WalletService) ResolveBankAccount(accountNumber, bankCode string) (string, error) {
+	resp, err := s.paystackService.ResolveAccountNumber(accountNumber, bankCode)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve bank account: %w", err)
+	}
+	return resp.Data.AccountName, nil
+}
+
+// withdrawalMetadata is stored on the withdrawal transaction for support and auditing
+type withdrawalMetadata struct {
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+	RecipientCode string `json:"recipient_code"`
+}
+
+// InitiateWithdrawal pays wallet funds out to a Nigerian bank account. The
+// amount is moved out of the wallet into pending payouts before Paystack is
+// asked to send it, and is returned to the wallet if the payout fails. If
+// Paystack cannot be reached or its answer is unclear, the withdrawal is
+// returned pending and settled later by webhook or reconciliation.
+// Withdrawals count towards the same outflow limits as transfers.
+func (s *WalletService) InitiateWithdrawal(userID uuid.UUID, apiKeyID *uuid.UUID, amount models.Money, bankCode, accountNumber, reason string) (*models.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	wallet, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	if !amount.SameCurrency(wallet.Balance) {
+		return nil, fmt.Errorf("withdrawal currency %s does not match wallet currency %s", amount.Currency, wallet.Balance.Currency)
+	}
+
+	// Confirm the account exists and register it with Paystack
+	accountName, err := s.ResolveBankAccount(accountNumber, bankCode)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.paystackService.CreateTransferRecipient(accountName, accountNumber, bankCode, amount.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer recipient: %w", err)
+	}
+
+	metadata, err := json.Marshal(withdrawalMetadata{
+		BankCode:      bankCode,
+		AccountNumber: accountNumber,
+		AccountName:   accountName,
+		RecipientCode: recipient.Data.RecipientCode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode withdrawal metadata: %w", err)
+	}
+
+	reference := fmt.Sprintf("WDR_%s_%d", uuid.New().String()[:8], time.Now().Unix())
+
+	// The withdrawal fee is charged on top of the amount paid out
+	quote := s.QuoteFee(fees.KindWithdrawal, amount)
+
+	// Hold the funds before asking Paystack to pay them out
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Held funds cannot be withdrawn
+	balance, err := s.lockAvailableBalance(tx, wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	if balance.LessThan(quote.Total) {
+		return nil, ErrInsufficientBalance
+	}
+
+	if err := s.checkLimits(tx, wallet.ID, user, apiKeyID, amount, quote.Total, false); err != nil {
+		return nil, err
+	}
+
+	walletAccount, err := s.ledgerService.WalletAccount(tx, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+	pendingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPendingPayouts, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	feeAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFees, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.ledgerService.MoveWithFee(tx, reference, "Withdrawal to bank account", walletAccount, pendingAccount, amount, feeAccount, quote.Fee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post withdrawal to ledger: %w", err)
+	}
+
+	// Payouts always go through Paystack Transfers
+	provider := models.PaymentProviderPaystack
+	transaction := &models.Transaction{
+		UserID:            userID,
+		WalletID:          wallet.ID,
+		Type:              models.TransactionTypeWithdrawal,
+		Amount:            amount,
+		Fee:               quote.Fee,
+		Status:            models.TransactionStatusPending,
+		Reference:         &reference,
+		PaystackReference: &reference,
+		Provider:          &provider,
+		Description:       stringPtr(fmt.Sprintf("Withdrawal to %s (%s)", accountName, accountNumber)),
+		Metadata:          stringPtr(string(metadata)),
+		JournalEntryID:    &entry.ID,
+		APIKeyID:          apiKeyID,
+	}
+	if err := s.transactionRepo.Create(tx, transaction); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	if err := s.recordFee(tx, transaction, reference+"_FEE", "Withdrawal fee"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if reason == "" {
+		reason = "Wallet withdrawal"
+	}
+
+	transferResp, err := s.paystackService.InitiateTransfer(amount, recipient.Data.RecipientCode, reference, reason)
+	if err != nil {
+		if payment.IsRejected(err) {
+			// Paystack turned the payout down, so the held funds go straight back
+			if refundErr := s.refundWithdrawal(reference, models.TransactionStatusFailed); refundErr != nil {
+				return nil, fmt.Errorf("failed to initiate transfer: %v (refund failed: %w)", err, refundErr)
+			}
+			return nil, fmt.Errorf("failed to initiate transfer: %w", err)
+		}
+
+		// Paystack may have started the payout before the error, e.g. on a
+		// timeout. The withdrawal stays pending until a transfer webhook or
+		// reconciliation tells us what happened.
+		log.Printf("Withdrawal %s left pending: failed to initiate transfer: %v", reference, err)
+		return s.transactionRepo.GetByReference(reference)
+	}
+
+	switch transferResp.Data.Status {
+	case "success":
+		if err := s.completeWithdrawal(reference); err != nil {
+			return nil, err
+		}
+	case "failed", "reversed":
+		if err := s.refundWithdrawal(reference, models.TransactionStatusFailed); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.transactionRepo.GetByReference(reference)
+}
+
+// completeWithdrawal settles a withdrawal once Paystack confirms the payout
+func (s *WalletService) completeWithdrawal(reference string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := s.transactionRepo.GetByReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	if transaction.Type != models.TransactionTypeWithdrawal {
+		return fmt.Errorf("transaction %s is not a withdrawal", reference)
+	}
+
+	// Already settled or refunded (idempotency)
+	if transaction.Status != models.TransactionStatusPending {
+		return nil
+	}
+
+	// The payout has left the Paystack balance
+	pendingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPendingPayouts, transaction.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	clearingAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountPaystackClearing, transaction.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ledgerService.Move(tx, reference+"_SETTLE", "Withdrawal paid out by Paystack", pendingAccount, clearingAccount, transaction.Amount); err != nil {
+		return fmt.Errorf("failed to post withdrawal settlement to ledger: %w", err)
+	}
+
+	if err := s.transactionRepo.UpdateStatus(tx, transaction.ID, models.TransactionStatusSuccess); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// withdrawalRefundSource returns the ledger account a withdrawal in current
+// status is refunded from when the payout ends in status, and whether it is
+// refunded at all. A pending payout is still held in pending payouts; a
+// completed one has already left clearing and only comes back through it if
+// Paystack reverses it. Withdrawals already refunded are not refunded again.
+func withdrawalRefundSource(current, status models.TransactionStatus) (string, bool) {
+	switch current {
+	case models.TransactionStatusPending:
+		return models.LedgerAccountPendingPayouts, true
+	case models.TransactionStatusSuccess:
+		if status != models.TransactionStatusReversed {
+			return "", false
+		}
+		return models.LedgerAccountPaystackClearing, true
+	default:
+		return "", false
+	}
+}
+
+// withdrawalStatusForTransfer maps the status of a Paystack transfer to the
+// status its withdrawal settles in. ok is false while Paystack is still
+// working on the transfer.
+func withdrawalStatusForTransfer(transferStatus string) (status models.TransactionStatus, ok bool) {
+	switch transferStatus {
+	case "success":
+		return models.TransactionStatusSuccess, true
+	case "failed", "abandoned", "blocked", "rejected":
+		return models.TransactionStatusFailed, true
+	case "reversed":
+		return models.TransactionStatusReversed, true
+	default:
+		// Pending, queued or awaiting OTP
+		return "", false
+	}
+}
+
+// refundWithdrawal returns a withdrawal's funds to the wallet when the payout
+// fails, or when Paystack reverses a payout that had already succeeded
+func (s *WalletService) refundWithdrawal(reference string, status models.TransactionStatus) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := s.transactionRepo.GetByReferenceForUpdate(tx, reference)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+
+	if transaction.Type != models.TransactionTypeWithdrawal {
+		return fmt.Errorf("transaction %s is not a withdrawal", reference)
+	}
+
+	source, ok := withdrawalRefundSource(transaction.Status, status)
+	if !ok {
+		return nil
+	}
+
+	sourceAccount, err := s.ledgerService.SystemAccount(tx, source, transaction.Amount.Currency)
+	if err != nil {
+		return err
+	}
+	walletAccount, err := s.ledgerService.WalletAccount(tx, transaction.WalletID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ledgerService.Move(tx, reference+"_REFUND", "Refund of unsuccessful withdrawal", sourceAccount, walletAccount, transaction.Amount); err != nil {
+		return fmt.Errorf("failed to post withdrawal refund to ledger: %w", err)
+	}
+
+	// The fee is returned with the amount when the payout does not happen
+	if transaction.Fee.IsPositive() {
+		feeAccount, err := s.ledgerService.SystemAccount(tx, models.LedgerAccountFees, transaction.Amount.Currency)
+		if err != nil {
+			return err
+		}
+		if _, err := s.ledgerService.Move(tx, reference+"_FEE_REFUND", "Refund of withdrawal fee", feeAccount, walletAccount, transaction.Fee); err != nil {
+			return fmt.Errorf("failed to post withdrawal fee refund to ledger: %w", err)
+		}
+		feeTransaction, err := s.transactionRepo.GetByReferenceForUpdate(tx, reference+"_FEE")
+		if err != nil {
+			return fmt.Errorf("fee transaction not found: %w", err)
+		}
+		if err := s.transactionRepo.UpdateStatus(tx, feeTransaction.ID, models.TransactionStatusReversed); err != nil {
+			return fmt.Errorf("failed to update fee transaction status: %w", err)
+		}
+	}
+
+	if err := s.transactionRepo.UpdateStatus(tx, transaction.ID, status); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReconcilePendingWithdrawals asks Paystack about withdrawals that have been
+// pending for longer than minAge, for when the transfer could not be confirmed
+// when it was made and no webhook has arrived since. It returns the number of
+// withdrawals checked.
+func (s *WalletService) ReconcilePendingWithdrawals(ctx context.Context, minAge time.Duration) (int, error) {
+	withdrawals, err := s.transactionRepo.GetPendingWithdrawals(time.Now().Add(-minAge), reconciliationBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending withdrawals: %w", err)
+	}
+
+	checked := 0
+	for i := range withdrawals {
+		if ctx.Err() != nil {
+			return checked, ctx.Err()
+		}
+
+		reference := *withdrawals[i].Reference
+		if err := s.reconcileWithdrawal(reference); err != nil {
+			log.Printf("Failed to reconcile withdrawal %s: %v", reference, err)
+		}
+		checked++
+	}
+
+	return checked, nil
+}
+
+// reconcileWithdrawal settles one pending withdrawal from its transfer status
+// at Paystack. Errors other than Paystack rejecting the lookup leave the
+// withdrawal pending for the next run.
+func (s *WalletService) reconcileWithdrawal(reference string) error {
+	transferResp, err := s.paystackService.VerifyTransfer(reference)
+	if err != nil {
+		// Paystack has no transfer under the reference, so the payout never started
+		if payment.IsRejected(err) {
+			return s.refundWithdrawal(reference, models.TransactionStatusFailed)
+		}
+		return fmt.Errorf("failed to verify transfer: %w", err)
+	}
+
+	status, ok := withdrawalStatusForTransfer(transferResp.Data.Status)
+	switch {
+	case !ok:
+		// Paystack is still working on it
+		return nil
+	case status == models.TransactionStatusSuccess:
+		return s.completeWithdrawal(reference)
+	default:
+		return s.refundWithdrawal(reference, status)
+	}
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+)
+
+func TestWithdrawalRefundSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    models.TransactionStatus
+		status     models.TransactionStatus
+		wantSource string
+		wantRefund bool
+	}{
+		{
+			name:       "pending payout fails",
+			current:    models.TransactionStatusPending,
+			status:     models.TransactionStatusFailed,
+			wantSource: models.LedgerAccountPendingPayouts,
+			wantRefund: true,
+		},
+		{
+			name:       "pending payout reversed",
+			current:    models.TransactionStatusPending,
+			status:     models.TransactionStatusReversed,
+			wantSource: models.LedgerAccountPendingPayouts,
+			wantRefund: true,
+		},
+		{
+			name:       "completed payout reversed",
+			current:    models.TransactionStatusSuccess,
+			status:     models.TransactionStatusReversed,
+			wantSource: models.LedgerAccountPaystackClearing,
+			wantRefund: true,
+		},
+		{
+			name:    "late failure after the payout completed",
+			current: models.TransactionStatusSuccess,
+			status:  models.TransactionStatusFailed,
+		},
+		{
+			name:    "already refunded after failing",
+			current: models.TransactionStatusFailed,
+			status:  models.TransactionStatusFailed,
+		},
+		{
+			name:    "already refunded after a reversal",
+			current: models.TransactionStatusReversed,
+			status:  models.TransactionStatusReversed,
+		},
+	}
+
+	for _, tt := range tests {
+		source, refund := withdrawalRefundSource(tt.current, tt.status)
+		if refund != tt.wantRefund || source != tt.wantSource {
+			t.Errorf("%s: withdrawalRefundSource = %q, %v, want %q, %v", tt.name, source, refund, tt.wantSource, tt.wantRefund)
+		}
+	}
+}
+
+func TestWithdrawalStatusForTransfer(t *testing.T) {
+	tests := []struct {
+		transferStatus string
+		want           models.TransactionStatus
+		wantOK         bool
+	}{
+		{transferStatus: "success", want: models.TransactionStatusSuccess, wantOK: true},
+		{transferStatus: "failed", want: models.TransactionStatusFailed, wantOK: true},
+		{transferStatus: "abandoned", want: models.TransactionStatusFailed, wantOK: true},
+		{transferStatus: "blocked", want: models.TransactionStatusFailed, wantOK: true},
+		{transferStatus: "rejected", want: models.TransactionStatusFailed, wantOK: true},
+		{transferStatus: "reversed", want: models.TransactionStatusReversed, wantOK: true},
+		{transferStatus: "pending"},
+		{transferStatus: "otp"},
+		{transferStatus: "received"},
+	}
+
+	for _, tt := range tests {
+		got, ok := withdrawalStatusForTransfer(tt.transferStatus)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("withdrawalStatusForTransfer(%q) = %q, %v, want %q, %v", tt.transferStatus, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/brainox/paystack_wallet_service/internal/models"
+	"github.com/brainox/paystack_wallet_service/services/payment"
+	"github.com/brainox/paystack_wallet_service/services/repository"
+	"github.com/brainox/paystack_wallet_service/services/wallet"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidPayload   = errors.New("invalid webhook payload")
+)
+
+const (
+	// Failed events claimed per retry run
+	retryBatchSize = 50
+	// How long a claimed event is hidden from other workers
+	retryLease = 5 * time.Minute
+)
+
+// RetryPolicy controls how failed webhook events are retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NextAttempt returns when to retry after the given number of attempts, or
+// nil once the attempts are used up. The delay doubles after every attempt.
+func (p RetryPolicy) NextAttempt(attempts int) *time.Time {
+	if attempts >= p.MaxAttempts {
+		return nil
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	next := time.Now().Add(delay)
+	return &next
+}
+
+// WebhookService stores every inbound webhook before handing it to the
+// wallet service, so that failed deliveries can be retried and replayed
+type WebhookService struct {
+	webhookEventRepo *repository.WebhookEventRepository
+	walletService    *wallet.WalletService
+	providers        *payment.Registry
+	retryPolicy      RetryPolicy
+}
+
+func NewWebhookService(
+	webhookEventRepo *repository.WebhookEventRepository,
+	walletService *wallet.WalletService,
+	providers *payment.Registry,
+	retryPolicy RetryPolicy,
+) *WebhookService {
+	return &WebhookService{
+		webhookEventRepo: webhookEventRepo,
+		walletService:    walletService,
+		providers:        providers,
+		retryPolicy:      retryPolicy,
+	}
+}
+
+// Receive stores a webhook delivery from a payment provider and processes it.
+// Deliveries with a missing or bad signature or an unreadable payload are
+// stored as rejected and an error is returned. A processing failure is not
+// returned as an error; it is recorded on the event, which is then retried
+// in the background.
+func (s *WebhookService) Receive(providerName models.PaymentProvider, body []byte, headers http.Header) (*models.WebhookEvent, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil || providerName == "" {
+		return nil, ErrUnknownProvider
+	}
+
+	signature := headers.Get(provider.WebhookSignatureHeader())
+	record := &models.WebhookEvent{
+		Provider:       provider.Name(),
+		RawBody:        string(body),
+		Signature:      &signature,
+		SignatureValid: signature != "" && provider.ValidateWebhook(body, signature),
+		Status:         models.WebhookEventStatusReceived,
+	}
+
+	event, parseErr := provider.ParseWebhook(body)
+	if parseErr == nil {
+		record.Event = &event.Name
+		record.Reference = &event.Reference
+	}
+
+	var rejection error
+	switch {
+	case signature == "":
+		rejection = ErrMissingSignature
+	case !record.SignatureValid:
+		rejection = ErrInvalidSignature
+	case parseErr != nil:
+		rejection = ErrInvalidPayload
+	}
+	if rejection != nil {
+		record.Status = models.WebhookEventStatusRejected
+		lastError := rejection.Error()
+		record.LastError = &lastError
+	}
+
+	if err := s.webhookEventRepo.Create(record); err != nil {
+		return nil, fmt.Errorf("failed to store webhook event: %w", err)
+	}
+	if rejection != nil {
+		return record, rejection
+	}
+
+	if err := s.process(record, event); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Replay processes a stored event again, whatever its current status.
+// Processing is idempotent, so replaying an event that already succeeded
+// has no effect on balances.
+func (s *WebhookService) Replay(id uuid.UUID) (*models.WebhookEvent, error) {
+	record, err := s.webhookEventRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !record.SignatureValid {
+		return nil, fmt.Errorf("webhook event failed signature validation and cannot be replayed")
+	}
+
+	event, err := s.parseStored(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.process(record, event); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RetryDue reprocesses failed events whose next attempt is due
+func (s *WebhookService) RetryDue(ctx context.Context) error {
+	records, err := s.webhookEventRepo.ClaimDue(retryBatchSize, retryLease)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook events: %w", err)
+	}
+
+	for i := range records {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		record := &records[i]
+		event, err := s.parseStored(record)
+		if err != nil {
+			// Retrying cannot fix a payload that does not parse
+			if err := s.deadLetter(record, err); err != nil {
+				return err
+			}
+			log.Printf("Webhook event %s will not be retried: %v", record.ID, err)
+			continue
+		}
+		if err := s.process(record, event); err != nil {
+			return err
+		}
+		if record.Status == models.WebhookEventStatusFailed {
+			log.Printf("Webhook event %s failed on attempt %d: %s", record.ID, record.Attempts, *record.LastError)
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookService) GetEvent(id uuid.UUID) (*models.WebhookEvent, error) {
+	return s.webhookEventRepo.GetByID(id)
+}
+
+func (s *WebhookService) ListEvents(status string, limit, offset int) ([]models.WebhookEvent, error) {
+	return s.webhookEventRepo.List(status, limit, offset)
+}
+
+// process runs one attempt and records its outcome on the event
+func (s *WebhookService) process(record *models.WebhookEvent, event *payment.Event) error {
+	record.Attempts++
+	record.LastError = nil
+	record.NextAttemptAt = nil
+
+	err := s.walletService.ProcessWebhook(event)
+	switch {
+	case err == nil:
+		record.Status = models.WebhookEventStatusProcessed
+	case errors.Is(err, wallet.ErrUnhandledEvent):
+		record.Status = models.WebhookEventStatusIgnored
+	default:
+		record.Status = models.WebhookEventStatusFailed
+		lastError := err.Error()
+		record.LastError = &lastError
+		record.NextAttemptAt = s.retryPolicy.NextAttempt(record.Attempts)
+	}
+
+	if err := s.webhookEventRepo.RecordAttempt(record.ID, record.Status, record.LastError, record.NextAttemptAt); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// deadLetter records a failed attempt with no retry scheduled, leaving the
+// event failed until it is replayed
+func (s *WebhookService) deadLetter(record *models.WebhookEvent, cause error) error {
+	record.Attempts++
+	record.Status = models.WebhookEventStatusFailed
+	lastError := cause.Error()
+	record.LastError = &lastError
+	record.NextAttemptAt = nil
+
+	if err := s.webhookEventRepo.RecordAttempt(record.ID, record.Status, record.LastError, record.NextAttemptAt); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// parseStored parses a stored event with the provider that sent it
+func (s *WebhookService) parseStored(record *models.WebhookEvent) (*payment.Event, error) {
+	provider, err := s.providers.Get(record.Provider)
+	if err != nil {
+		return nil, err
+	}
+	event, err := provider.ParseWebhook([]byte(record.RawBody))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return event, nil
+}
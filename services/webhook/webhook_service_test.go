@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyNextAttempt(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 8, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		// Doubling again would pass MaxDelay
+		{attempts: 5, want: 5 * time.Minute},
+		{attempts: 7, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		before := time.Now()
+		next := policy.NextAttempt(tt.attempts)
+		after := time.Now()
+
+		if next == nil {
+			t.Fatalf("NextAttempt(%d) = nil, want a retry", tt.attempts)
+		}
+		if next.Before(before.Add(tt.want)) || next.After(after.Add(tt.want)) {
+			t.Errorf("NextAttempt(%d) is %s from now, want %s", tt.attempts, next.Sub(before), tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyNextAttemptStopsAtMaxAttempts(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Hour}
+
+	for _, attempts := range []int{3, 4, 10} {
+		if next := policy.NextAttempt(attempts); next != nil {
+			t.Errorf("NextAttempt(%d) = %s, want nil", attempts, next)
+		}
+	}
+}
+
+func TestRetryPolicyNextAttemptCapsLargeAttemptCounts(t *testing.T) {
+	// Enough doublings to overflow time.Duration if the delay were not capped
+	policy := RetryPolicy{MaxAttempts: 1000, BaseDelay: time.Second, MaxDelay: 6 * time.Hour}
+
+	before := time.Now()
+	next := policy.NextAttempt(999)
+	if next == nil {
+		t.Fatal("NextAttempt(999) = nil, want a retry")
+	}
+	if delay := next.Sub(before); delay < 6*time.Hour || delay > 6*time.Hour+time.Second {
+		t.Errorf("NextAttempt(999) is %s from now, want 6h", delay)
+	}
+}